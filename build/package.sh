@@ -30,6 +30,14 @@ cat deploy/crds/enterprise.splunk.com_searchheadclusters_crd.yaml >> release-${V
 echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
 cat deploy/crds/enterprise.splunk.com_indexerclusters_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
 echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
+cat deploy/crds/enterprise.splunk.com_clustermasters_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
+echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
+cat deploy/crds/enterprise.splunk.com_monitoringconsoles_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
+echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
+cat deploy/crds/enterprise.splunk.com_splunkindexes_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
+echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
+cat deploy/crds/enterprise.splunk.com_splunkbackups_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
+echo "---" >> release-${VERSION}/splunk-operator-crds.yaml
 cat deploy/crds/enterprise.splunk.com_sparks_crd.yaml >> release-${VERSION}/splunk-operator-crds.yaml
 
 echo Generating release-${VERSION}/splunk-operator-noadmin.yaml
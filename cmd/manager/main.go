@@ -42,6 +42,7 @@ import (
 
 	"github.com/splunk/splunk-operator/pkg/apis"
 	"github.com/splunk/splunk-operator/pkg/controller"
+	"github.com/splunk/splunk-operator/pkg/webhook"
 	"github.com/splunk/splunk-operator/version"
 )
 
@@ -50,6 +51,7 @@ var (
 	metricsHost               = "0.0.0.0"
 	metricsPort         int32 = 8383
 	operatorMetricsPort int32 = 8686
+	webhookPort               = 9443
 )
 var log = logf.Log.WithName("cmd")
 
@@ -109,10 +111,15 @@ func main() {
 	log.Info("Creating new manager", "namespace", namespace)
 
 	// Create a new Cmd to provide shared dependencies and start components
-	mgr, err := manager.New(cfg, manager.Options{
+	enableWebhooks := os.Getenv("ENABLE_WEBHOOKS") == "true"
+	options := manager.Options{
 		Namespace:          namespace,
 		MetricsBindAddress: fmt.Sprintf("%s:%d", metricsHost, metricsPort),
-	})
+	}
+	if enableWebhooks {
+		options.Port = webhookPort
+	}
+	mgr, err := manager.New(cfg, options)
 	if err != nil {
 		log.Error(err, "")
 		os.Exit(1)
@@ -132,6 +139,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup admission webhooks
+	if enableWebhooks {
+		log.Info("Registering admission webhooks", "port", webhookPort)
+		if err := webhook.AddToManager(mgr); err != nil {
+			log.Error(err, "")
+			os.Exit(1)
+		}
+	}
+
 	// Add the Metrics Service
 	addMetrics(ctx, cfg, namespace)
 
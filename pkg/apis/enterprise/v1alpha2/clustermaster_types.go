@@ -0,0 +1,148 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// default all fields to being optional
+// +kubebuilder:validation:Optional
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
+// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+// see also https://book.kubebuilder.io/reference/markers/crd.html
+
+// ClusterMasterSpec defines the desired state of a Splunk Enterprise cluster master, which may be shared by
+// one or more IndexerCluster resources using clusterMasterRef
+type ClusterMasterSpec struct {
+	CommonSplunkSpec `json:",inline"`
+
+	// List of site names (site1 through site63) used to configure a multisite indexer cluster
+	Sites []string `json:"sites,omitempty"`
+
+	// Multisite replication factor, using the format of site_replication_factor in server.conf (defaults to "origin:1,total:<number of sites>")
+	SiteReplicationFactor string `json:"siteReplicationFactor,omitempty"`
+
+	// Multisite search factor, using the format of site_search_factor in server.conf (defaults to "origin:1,total:<number of sites>")
+	SiteSearchFactor string `json:"siteSearchFactor,omitempty"`
+
+	// SmartStore configuration used to store indexed data in remote object storage
+	SmartStore SmartStoreSpec `json:"smartstore,omitempty"`
+
+	// List of Splunk apps to install on the indexer cluster peers, using the cluster master's bundle.
+	// Apps are added, upgraded and removed without recreating pods
+	AppSources []AppSource `json:"appSources,omitempty"`
+}
+
+// ClusterBundleStatus describes a configuration bundle distributed by the cluster master
+type ClusterBundleStatus struct {
+	// Checksum used to verify bundle integrity
+	Checksum string `json:"checksum,omitempty"`
+
+	// Timestamp of the bundle
+	Timestamp int64 `json:"timestamp,omitempty"`
+}
+
+// ClusterMasterStatus defines the observed state of a Splunk Enterprise cluster master
+type ClusterMasterStatus struct {
+	// current phase of the cluster master
+	Phase ResourcePhase `json:"phase"`
+
+	// Indicates if the cluster is initialized.
+	Initialized bool `json:"initialized_flag"`
+
+	// Indicates if the cluster is ready for indexing.
+	IndexingReady bool `json:"indexing_ready_flag"`
+
+	// Indicates whether the master is ready to begin servicing, based on whether it is initialized.
+	ServiceReady bool `json:"service_ready_flag"`
+
+	// Indicates if the cluster is in maintenance mode.
+	MaintenanceMode bool `json:"maintenance_mode"`
+
+	// Indicates if the replication factor is met for all buckets.
+	ReplicationFactorMet bool `json:"replication_factor_met"`
+
+	// Indicates if the search factor is met for all buckets.
+	SearchFactorMet bool `json:"search_factor_met"`
+
+	// Configuration bundle currently being used by the peers
+	ActiveBundle ClusterBundleStatus `json:"activeBundle,omitempty"`
+
+	// Most recent configuration bundle; if this differs from activeBundle, it has not yet been pushed to all peers
+	LatestBundle ClusterBundleStatus `json:"latestBundle,omitempty"`
+
+	// Indicates if the peers must be restarted to apply the latest configuration bundle
+	RestartRequired bool `json:"restartRequired,omitempty"`
+
+	// Names of the peers that are not yet using the active configuration bundle
+	OutdatedPeers []string `json:"outdatedPeers,omitempty"`
+
+	// conditions describing the current state of the cluster master
+	Conditions []Condition `json:"conditions"`
+
+	// apps installed by the operator on the cluster master
+	Apps []AppTargetStatus `json:"apps,omitempty"`
+
+	// volumes of the cluster master that are being resized
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterMaster is the Schema for a Splunk Enterprise cluster master
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clustermasters,scope=Namespaced,shortName=cm;cmaster
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of cluster master"
+// +kubebuilder:printcolumn:name="RF Met",type="boolean",JSONPath=".status.replication_factor_met",description="Replication factor is met"
+// +kubebuilder:printcolumn:name="SF Met",type="boolean",JSONPath=".status.search_factor_met",description="Search factor is met"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of cluster master"
+type ClusterMaster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterMasterSpec   `json:"spec,omitempty"`
+	Status ClusterMasterStatus `json:"status,omitempty"`
+}
+
+// GetIdentifier is a convenience function to return unique identifier for the Splunk enterprise deployment
+func (cr *ClusterMaster) GetIdentifier() string {
+	return cr.ObjectMeta.Name
+}
+
+// GetNamespace is a convenience function to return namespace for a Splunk enterprise deployment
+func (cr *ClusterMaster) GetNamespace() string {
+	return cr.ObjectMeta.Namespace
+}
+
+// GetTypeMeta is a convenience function to return a TypeMeta object
+func (cr *ClusterMaster) GetTypeMeta() metav1.TypeMeta {
+	return cr.TypeMeta
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterMasterList contains a list of ClusterMaster
+type ClusterMasterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterMaster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterMaster{}, &ClusterMasterList{})
+}
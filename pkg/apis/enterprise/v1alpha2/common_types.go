@@ -19,6 +19,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // ResourcePhase is used to represent the current phase of a custom resource
@@ -48,6 +49,92 @@ const (
 	PhaseError ResourcePhase = "Error"
 )
 
+// VolumeReclaimPolicy determines what happens to the PersistentVolumeClaims of Splunk instances that are removed
+// +kubebuilder:validation:Enum=Delete;Retain;Snapshot
+type VolumeReclaimPolicy string
+
+const (
+	// VolumeReclaimDelete means PersistentVolumeClaims are deleted
+	VolumeReclaimDelete VolumeReclaimPolicy = "Delete"
+
+	// VolumeReclaimRetain means PersistentVolumeClaims are kept, and labeled as orphaned
+	VolumeReclaimRetain VolumeReclaimPolicy = "Retain"
+
+	// VolumeReclaimSnapshot means a VolumeSnapshot is taken of each PersistentVolumeClaim before it is deleted
+	VolumeReclaimSnapshot VolumeReclaimPolicy = "Snapshot"
+)
+
+// ConditionType is used to represent the type of a custom resource status condition
+type ConditionType string
+
+const (
+	// ConditionReady indicates whether or not a custom resource is ready and up to date
+	ConditionReady ConditionType = "Ready"
+
+	// ConditionSecretsApplied indicates whether or not the secrets used by a custom resource have been applied
+	ConditionSecretsApplied ConditionType = "SecretsApplied"
+
+	// ConditionClusterMasterReady indicates whether or not the cluster master of an indexer cluster is ready
+	ConditionClusterMasterReady ConditionType = "ClusterMasterReady"
+
+	// ConditionIndexingReady indicates whether or not an indexer cluster is ready for indexing
+	ConditionIndexingReady ConditionType = "IndexingReady"
+
+	// ConditionCaptainReady indicates whether or not a search head cluster has a ready captain
+	ConditionCaptainReady ConditionType = "CaptainReady"
+
+	// ConditionScalingBlocked indicates that a change in the number of replicas is waiting on the cluster
+	ConditionScalingBlocked ConditionType = "ScalingBlocked"
+
+	// ConditionSparkMasterReady indicates whether or not the master of a Spark cluster is ready
+	ConditionSparkMasterReady ConditionType = "SparkMasterReady"
+
+	// ConditionAppsInstalled indicates whether or not all of the apps declared by a custom resource have been installed
+	ConditionAppsInstalled ConditionType = "AppsInstalled"
+
+	// ConditionPeersConfigured indicates whether or not all discovered Splunk instances have been added as search peers of a monitoring console
+	ConditionPeersConfigured ConditionType = "PeersConfigured"
+
+	// ConditionSecretsRotated indicates whether or not the last requested rotation of Splunk secrets has completed
+	ConditionSecretsRotated ConditionType = "SecretsRotated"
+
+	// ConditionIndexPushed indicates whether or not the configuration of a SplunkIndex has been pushed to Splunk Enterprise
+	ConditionIndexPushed ConditionType = "IndexPushed"
+
+	// ConditionBundlePushed indicates whether or not the latest configuration bundle has been validated and is active on all peers
+	ConditionBundlePushed ConditionType = "BundlePushed"
+
+	// ConditionPaused indicates whether reconciliation has been paused using the enterprise.splunk.com/paused annotation
+	ConditionPaused ConditionType = "Paused"
+
+	// ConditionBackupCompleted indicates whether or not the most recent backup of a SplunkBackup is ready to use
+	ConditionBackupCompleted ConditionType = "BackupCompleted"
+
+	// ConditionDependenciesReady indicates whether or not all of the custom resources referenced by a custom resource are ready
+	ConditionDependenciesReady ConditionType = "DependenciesReady"
+)
+
+// Condition is used to represent a detailed aspect of the current state of a custom resource
+type Condition struct {
+	// type of condition
+	Type ConditionType `json:"type"`
+
+	// status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+
+	// generation of the custom resource that this condition was last evaluated against
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// last time the condition transitioned from one status to another
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// one-word CamelCase reason for the condition's last transition
+	Reason string `json:"reason,omitempty"`
+
+	// human-readable message with details about the last transition
+	Message string `json:"message,omitempty"`
+}
+
 // default all fields to being optional
 // +kubebuilder:validation:Optional
 
@@ -111,6 +198,166 @@ type CommonSplunkSpec struct {
 
 	// IndexerClusterRef refers to a Splunk Enterprise indexer cluster managed by the operator within Kubernetes
 	IndexerClusterRef corev1.ObjectReference `json:"indexerClusterRef"`
+
+	// ClusterMasterRef refers to a Splunk Enterprise cluster master managed by the operator within Kubernetes.
+	// When used by an indexer cluster, its peers join the referenced cluster master instead of creating their own
+	ClusterMasterRef corev1.ObjectReference `json:"clusterMasterRef,omitempty"`
+
+	// Name of a Kubernetes Secret containing a "ca.crt" key with the CA bundle used to verify the certificates of splunkd's
+	// management port, and optional "tls.crt" and "tls.key" keys with a client certificate that the operator presents to splunkd.
+	// When not provided, the operator verifies certificates using the CA that it generates for this resource
+	TLSSecretRef string `json:"tlsSecretRef,omitempty"`
+
+	// Name of a Kubernetes Secret containing user-provided values for "password" (required), "hec_token", "pass4SymmKey",
+	// "idxc_secret" and "shc_secret". These override any values that are generated by the operator when the resource's
+	// secrets are created; later changes are only applied by rotating them
+	SecretRef string `json:"secretRef,omitempty"`
+
+	// Name of a backup taken by a SplunkBackup resource, whose VolumeSnapshots are used to seed the etc and var volumes of
+	// new standalone, license master, cluster master or deployer instances. This has no effect on existing volumes, and
+	// requires secretRef to provide the secrets of the resource that was backed up
+	RestoreFrom string `json:"restoreFrom,omitempty"`
+
+	// What happens to the volumes of instances that are removed by scaling down, or when the resource is deleted with
+	// the "enterprise.splunk.com/delete-pvc" finalizer: "Delete" (the default), "Retain" or "Snapshot"
+	VolumeReclaimPolicy VolumeReclaimPolicy `json:"volumeReclaimPolicy,omitempty"`
+
+	// Number of hours during which volumes kept by the Retain volumeReclaimPolicy can be re-attached by scaling back up;
+	// older volumes are deleted before scaling up (defaults to 24)
+	RetainedVolumeGracePeriodHours int64 `json:"retainedVolumeGracePeriodHours,omitempty"`
+
+	// Maximum number of pods of each StatefulSet that may be unavailable during voluntary disruptions, such as node drains
+	// (defaults to one less than the replication factor for indexers, or 1 for each site of multisite indexer clusters,
+	// to a minority of search head cluster members, and to 1 for all other instances)
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
+}
+
+// SmartStoreSpec defines the desired state of Splunk SmartStore, which is used to store indexed data in remote object storage
+type SmartStoreSpec struct {
+	// List of remote storage volumes
+	Volumes []SmartStoreVolume `json:"volumes,omitempty"`
+
+	// List of indexes that store their data on a remote storage volume
+	Indexes []SmartStoreIndex `json:"indexes,omitempty"`
+
+	// Settings for the cache manager, which controls the local cache of data stored in remote volumes
+	CacheManager SmartStoreCacheManager `json:"cacheManager,omitempty"`
+}
+
+// SmartStoreVolume defines a remote storage volume for Splunk SmartStore
+type SmartStoreVolume struct {
+	// Name of the remote storage volume
+	Name string `json:"name"`
+
+	// URL of the S3 compatible endpoint for the remote storage volume (for example, https://s3.us-west-2.amazonaws.com)
+	Endpoint string `json:"endpoint"`
+
+	// Remote path for the volume, including the bucket name (for example, my-bucket/smartstore)
+	Path string `json:"path"`
+
+	// Name of a Kubernetes Secret containing "s3_access_key" and "s3_secret_key" keys used to access the remote storage volume.
+	// When not provided, credentials must be available from the environment (for example, using IAM roles)
+	SecretRef string `json:"secretRef,omitempty"`
+}
+
+// SmartStoreIndex defines an index that stores its data using Splunk SmartStore
+type SmartStoreIndex struct {
+	// Name of the index
+	Name string `json:"name"`
+
+	// Name of the remote storage volume used by the index (defaults to the first volume)
+	VolumeName string `json:"volumeName,omitempty"`
+
+	// Path for the index relative to the path of its remote storage volume (defaults to the name of the index)
+	RemotePath string `json:"remotePath,omitempty"`
+}
+
+// SmartStoreCacheManager defines settings for the Splunk SmartStore cache manager
+type SmartStoreCacheManager struct {
+	// Maximum amount of local disk space, in MB, used to cache data from remote storage (defaults to unlimited)
+	MaxCacheSizeMB int64 `json:"maxCacheSizeMB,omitempty"`
+
+	// Additional amount of disk space, in MB, that must remain free after the cache manager evicts data
+	EvictionPaddingMB int64 `json:"evictionPaddingMB,omitempty"`
+
+	// Time period, in seconds, during which recently created buckets are protected from eviction
+	HotlistRecencySecs int64 `json:"hotlistRecencySecs,omitempty"`
+
+	// Time period, in hours, during which bloom filters of recently created buckets are protected from eviction
+	HotlistBloomFilterRecencyHours int64 `json:"hotlistBloomFilterRecencyHours,omitempty"`
+}
+
+// AppSource defines the location of a Splunk app package that is installed by the operator.
+// Exactly one of configMap, persistentVolumeClaim or url must be provided.
+type AppSource struct {
+	// Name of the app, which must match the name of the top-level directory in its package
+	Name string `json:"name"`
+
+	// Version of the app; changing the version (or location) of an app upgrades it
+	Version string `json:"version,omitempty"`
+
+	// Key of a Kubernetes ConfigMap that contains the app package (use binaryData for packages)
+	ConfigMap *corev1.ConfigMapKeySelector `json:"configMap,omitempty"`
+
+	// Kubernetes PersistentVolumeClaim that contains the app package
+	PersistentVolumeClaim *AppSourceVolume `json:"persistentVolumeClaim,omitempty"`
+
+	// HTTP or HTTPS URL for the app package, including S3 object URLs (for example, pre-signed URLs)
+	URL string `json:"url,omitempty"`
+
+	// Expected SHA-256 checksum of the app package, which is verified before the app is installed
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// AppSourceVolume defines the location of an app package within a PersistentVolumeClaim
+type AppSourceVolume struct {
+	// Name of the PersistentVolumeClaim
+	ClaimName string `json:"claimName"`
+
+	// Path of the app package, relative to the root of the volume
+	Path string `json:"path"`
+}
+
+// AppStatus is used to track the status of an app installed by the operator
+type AppStatus struct {
+	// Name of the app
+	Name string `json:"name"`
+
+	// Version of the app
+	Version string `json:"version,omitempty"`
+
+	// SHA-256 checksum of the installed app package
+	Checksum string `json:"checksum,omitempty"`
+
+	// Error that occurred while installing the app, if any
+	Error string `json:"error,omitempty"`
+}
+
+// AppTargetStatus is used to track the apps installed on a target pod (standalone, deployer or cluster master)
+type AppTargetStatus struct {
+	// Name of the target pod
+	Target string `json:"target"`
+
+	// Status of each app installed on the target
+	Apps []AppStatus `json:"apps"`
+
+	// True if apps have changed and are waiting to be pushed to cluster members, or to be loaded by a restart
+	PendingPush bool `json:"pendingPush,omitempty"`
+}
+
+// VolumeStatus is used to track the progress of resizing a PersistentVolumeClaim
+type VolumeStatus struct {
+	// Name of the PersistentVolumeClaim
+	Name string `json:"name"`
+
+	// Storage capacity requested for the volume
+	Requested string `json:"requested"`
+
+	// Current storage capacity of the volume
+	Capacity string `json:"capacity,omitempty"`
+
+	// Resize state of the volume: Resizing, FileSystemResizePending or Resized
+	State string `json:"state"`
 }
 
 // MetaObject is used to represent common interfaces of custom resources
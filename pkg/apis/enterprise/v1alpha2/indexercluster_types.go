@@ -32,6 +32,38 @@ type IndexerClusterSpec struct {
 
 	// Number of search head pods; a search head cluster will be created if > 1
 	Replicas int32 `json:"replicas"`
+
+	// List of sites used to create a multisite indexer cluster. A separate StatefulSet of peers is created
+	// for each site, and replicas is set to the total number of peers across all sites
+	Sites []IndexerClusterSite `json:"sites,omitempty"`
+
+	// Multisite replication factor, using the format of site_replication_factor in server.conf (defaults to "origin:1,total:<number of sites>")
+	SiteReplicationFactor string `json:"siteReplicationFactor,omitempty"`
+
+	// Multisite search factor, using the format of site_search_factor in server.conf (defaults to "origin:1,total:<number of sites>")
+	SiteSearchFactor string `json:"siteSearchFactor,omitempty"`
+
+	// Node label used to pin the peers for each site to a zone (defaults to "failure-domain.beta.kubernetes.io/zone")
+	ZoneTopologyKey string `json:"zoneTopologyKey,omitempty"`
+
+	// SmartStore configuration used to store indexed data in remote object storage
+	SmartStore SmartStoreSpec `json:"smartstore,omitempty"`
+
+	// List of Splunk apps to install on the indexer cluster peers, using the cluster master's bundle.
+	// Apps are added, upgraded and removed without recreating pods
+	AppSources []AppSource `json:"appSources,omitempty"`
+}
+
+// IndexerClusterSite defines the desired state of a site within a multisite indexer cluster
+type IndexerClusterSite struct {
+	// Name of the site; must be one of site1 through site63
+	Name string `json:"name"`
+
+	// Number of indexer cluster peers for the site (defaults to 1)
+	Replicas int32 `json:"replicas"`
+
+	// Value of the zone topology key for nodes that peers for this site will be assigned to
+	Zone string `json:"zone,omitempty"`
 }
 
 // IndexerClusterMemberStatus is used to track the status of each indexer cluster peer.
@@ -53,6 +85,9 @@ type IndexerClusterMemberStatus struct {
 
 	// Flag indicating if this peer belongs to the current committed generation and is searchable.
 	Searchable bool `json:"is_searchable"`
+
+	// Site that the peer belongs to.
+	Site string `json:"site,omitempty"`
 }
 
 // IndexerClusterStatus defines the observed state of a Splunk Enterprise indexer cluster
@@ -84,8 +119,36 @@ type IndexerClusterStatus struct {
 	// Indicates if the cluster is in maintenance mode.
 	MaintenanceMode bool `json:"maintenance_mode"`
 
+	// Indicates if maintenance mode was enabled by the operator for a rolling upgrade of the peers, rather than manually.
+	// This remains set until maintenance mode is disabled and the replication and search factors are met again.
+	OperatorMaintenanceMode bool `json:"operatorMaintenanceMode,omitempty"`
+
+	// Indicates if the master is restarting the peers to apply changes to their configuration
+	RollingRestart bool `json:"rollingRestart,omitempty"`
+
+	// Configuration bundle currently being used by the peers
+	ActiveBundle ClusterBundleStatus `json:"activeBundle,omitempty"`
+
+	// Most recent configuration bundle; if this differs from activeBundle, it has not yet been pushed to all peers
+	LatestBundle ClusterBundleStatus `json:"latestBundle,omitempty"`
+
+	// Indicates if the peers must be restarted to apply the latest configuration bundle
+	RestartRequired bool `json:"restartRequired,omitempty"`
+
+	// Names of the peers that are not yet using the active configuration bundle
+	OutdatedPeers []string `json:"outdatedPeers,omitempty"`
+
 	// status of each indexer cluster peer
 	Peers []IndexerClusterMemberStatus `json:"peers"`
+
+	// conditions describing the current state of the indexer cluster
+	Conditions []Condition `json:"conditions"`
+
+	// apps installed by the operator on the cluster master
+	Apps []AppTargetStatus `json:"apps,omitempty"`
+
+	// volumes of the indexer cluster that are being resized
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -35,6 +35,12 @@ type LicenseMasterSpec struct {
 type LicenseMasterStatus struct {
 	// current phase of the license master
 	Phase ResourcePhase `json:"phase"`
+
+	// conditions describing the current state of the license master
+	Conditions []Condition `json:"conditions"`
+
+	// volumes of the license master that are being resized
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
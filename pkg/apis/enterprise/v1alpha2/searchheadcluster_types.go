@@ -40,6 +40,10 @@ type SearchHeadClusterSpec struct {
 
 	// Image to use for Spark pod containers (overrides RELATED_IMAGE_SPLUNK_SPARK environment variables)
 	SparkImage string `json:"sparkImage"`
+
+	// List of Splunk apps to install on the search head cluster members, using the deployer's bundle.
+	// Apps are added, upgraded and removed without recreating pods
+	AppSources []AppSource `json:"appSources,omitempty"`
 }
 
 // SearchHeadClusterMemberStatus is used to track the status of each search head cluster member
@@ -95,8 +99,20 @@ type SearchHeadClusterStatus struct {
 	// true if the search head cluster is in maintenance mode
 	MaintenanceMode bool `json:"maintenanceMode"`
 
+	// true if the captain is restarting the members to apply changes to their configuration
+	RollingRestart bool `json:"rollingRestart,omitempty"`
+
 	// status of each search head cluster member
 	Members []SearchHeadClusterMemberStatus `json:"members"`
+
+	// conditions describing the current state of the search head cluster
+	Conditions []Condition `json:"conditions"`
+
+	// apps installed by the operator on the deployer
+	Apps []AppTargetStatus `json:"apps,omitempty"`
+
+	// volumes of the search head cluster that are being resized
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
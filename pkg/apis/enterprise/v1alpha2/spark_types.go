@@ -15,6 +15,7 @@
 package v1alpha2
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,6 +33,52 @@ type SparkSpec struct {
 
 	// Number of spark worker pods
 	Replicas int32 `json:"replicas"`
+
+	// Settings for the spark master, which override the resources, affinity and tolerations shared with the workers
+	Master SparkInstanceSpec `json:"master,omitempty"`
+
+	// Settings for the spark workers, which override the resources, affinity and tolerations shared with the master
+	Worker SparkWorkerSpec `json:"worker,omitempty"`
+}
+
+// SparkInstanceSpec defines settings for either the spark master or the spark workers. Settings that are not
+// provided fall back to those shared by the master and workers.
+type SparkInstanceSpec struct {
+	// resource requirements for the pod containers
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Kubernetes Affinity rules that control how pods are assigned to particular nodes.
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// Pod's tolerations for Kubernetes node's taint
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Labels that a node must have for pods to be assigned to it
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Additional Spark configuration properties (for example, "spark.worker.cleanup.enabled"), which are passed
+	// using the SPARK_MASTER_OPTS or SPARK_WORKER_OPTS environment variables
+	SparkConf map[string]string `json:"sparkConf,omitempty"`
+}
+
+// SparkWorkerSpec defines settings for the spark workers
+type SparkWorkerSpec struct {
+	SparkInstanceSpec `json:",inline"`
+
+	// Number of cores each spark worker makes available to Spark applications (SPARK_WORKER_CORES; defaults to all
+	// cores of the node)
+	// +kubebuilder:validation:Minimum=0
+	Cores int32 `json:"cores,omitempty"`
+
+	// Amount of memory each spark worker makes available to Spark applications, for example "4g" (SPARK_WORKER_MEMORY;
+	// defaults to the memory of the node, minus 1g)
+	// +kubebuilder:validation:Pattern=`^[0-9]+[kmgtKMGT]?$`
+	Memory string `json:"memory,omitempty"`
+
+	// Maximum time, in seconds, to wait for the executors running on a spark worker to finish before it is removed by
+	// scaling down or recycled for updates; executors that are still running are then killed (defaults to 600)
+	// +kubebuilder:validation:Minimum=0
+	DrainTimeoutSeconds int32 `json:"drainTimeoutSeconds,omitempty"`
 }
 
 // SparkStatus defines the observed state of a Spark cluster
@@ -50,6 +97,30 @@ type SparkStatus struct {
 
 	// selector for pods, used by HorizontalPodAutoscaler
 	Selector string `json:"selector"`
+
+	// conditions describing the current state of the spark cluster
+	Conditions []Condition `json:"conditions"`
+
+	// number of spark workers that are registered with the spark master and alive
+	AliveWorkers int32 `json:"aliveWorkers,omitempty"`
+
+	// total number of cores provided by alive spark workers
+	Cores int32 `json:"cores,omitempty"`
+
+	// number of cores used by spark applications
+	CoresUsed int32 `json:"coresUsed,omitempty"`
+
+	// total memory provided by alive spark workers, in megabytes
+	Memory int64 `json:"memory,omitempty"`
+
+	// memory used by spark applications, in megabytes
+	MemoryUsed int64 `json:"memoryUsed,omitempty"`
+
+	// number of spark applications that are waiting or running
+	ActiveApps int32 `json:"activeApps,omitempty"`
+
+	// number of spark applications that have finished
+	CompletedApps int32 `json:"completedApps,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -0,0 +1,119 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// default all fields to being optional
+// +kubebuilder:validation:Optional
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
+// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+// see also https://book.kubebuilder.io/reference/markers/crd.html
+
+// SplunkBackupSpec defines the desired state of backups of a Splunk Enterprise instance's volumes.
+type SplunkBackupSpec struct {
+	// Reference to the Standalone, LicenseMaster, ClusterMaster, IndexerCluster (for its cluster master) or SearchHeadCluster
+	// (for its deployer) resource to back up. The resource must be in the same namespace as the SplunkBackup
+	TargetRef corev1.ObjectReference `json:"targetRef"`
+
+	// Name of the VolumeSnapshotClass used to create VolumeSnapshots (default is the cluster's default VolumeSnapshotClass)
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Time between backups, using a Go duration such as "24h" (default is to take a single backup)
+	Interval string `json:"interval,omitempty"`
+
+	// Number of backups to keep, after which the oldest are deleted (default is 7)
+	Retain int32 `json:"retain,omitempty"`
+}
+
+// SplunkBackupSnapshotStatus defines the observed state of a single backup, consisting of VolumeSnapshots of the etc and var volumes.
+type SplunkBackupSnapshotStatus struct {
+	// name of the backup, which may be used for restoreFrom
+	Name string `json:"name"`
+
+	// time the backup was started
+	Time metav1.Time `json:"time"`
+
+	// true when the VolumeSnapshots for all volumes are ready to be used for restoring
+	ReadyToUse bool `json:"readyToUse"`
+}
+
+// SplunkBackupStatus defines the observed state of backups of a Splunk Enterprise instance's volumes.
+type SplunkBackupStatus struct {
+	// current phase of the backups
+	Phase ResourcePhase `json:"phase"`
+
+	// name of the most recent backup that is ready to be used for restoring
+	LatestBackup string `json:"latestBackup,omitempty"`
+
+	// true while the target is quiesced for a backup in progress
+	Quiesced bool `json:"quiesced,omitempty"`
+
+	// status of each backup that is being kept, from oldest to newest
+	Backups []SplunkBackupSnapshotStatus `json:"backups"`
+
+	// conditions describing the current state of the backups
+	Conditions []Condition `json:"conditions"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SplunkBackup is the Schema for backups of a Splunk Enterprise instance's volumes.
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=splunkbackups,scope=Namespaced,shortName=backup
+// +kubebuilder:printcolumn:name="Target",type="string",JSONPath=".spec.targetRef.name",description="Name of the resource that is backed up"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of the backups"
+// +kubebuilder:printcolumn:name="Latest",type="string",JSONPath=".status.latestBackup",description="Most recent backup that is ready to use"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of the backups"
+type SplunkBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SplunkBackupSpec   `json:"spec,omitempty"`
+	Status SplunkBackupStatus `json:"status,omitempty"`
+}
+
+// GetIdentifier is a convenience function to return unique identifier for the Splunk enterprise deployment
+func (cr *SplunkBackup) GetIdentifier() string {
+	return cr.ObjectMeta.Name
+}
+
+// GetNamespace is a convenience function to return namespace for a Splunk enterprise deployment
+func (cr *SplunkBackup) GetNamespace() string {
+	return cr.ObjectMeta.Namespace
+}
+
+// GetTypeMeta is a convenience function to return a TypeMeta object
+func (cr *SplunkBackup) GetTypeMeta() metav1.TypeMeta {
+	return cr.TypeMeta
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SplunkBackupList contains a list of SplunkBackup
+type SplunkBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SplunkBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SplunkBackup{}, &SplunkBackupList{})
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha2
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// default all fields to being optional
+// +kubebuilder:validation:Optional
+
+// NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
+// Important: Run "operator-sdk generate k8s" to regenerate code after modifying this file
+// Add custom validation using kubebuilder tags: https://book-v1.book.kubebuilder.io/beyond_basics/generating_crd.html
+// see also https://book.kubebuilder.io/reference/markers/crd.html
+
+// SplunkIndexSpec defines the desired state of a Splunk Enterprise index.
+type SplunkIndexSpec struct {
+	// Name of the index in Splunk Enterprise (default is the name of the SplunkIndex resource)
+	IndexName string `json:"indexName,omitempty"`
+
+	// Reference to the IndexerCluster or Standalone resource that stores the index
+	TargetRef corev1.ObjectReference `json:"targetRef"`
+
+	// Type of data stored in the index: event or metric (default is event)
+	DataType string `json:"datatype,omitempty"`
+
+	// Retention period in seconds, after which data is frozen (frozenTimePeriodInSecs; default is the Splunk Enterprise default)
+	FrozenTimePeriodInSecs int64 `json:"frozenTimePeriodInSecs,omitempty"`
+
+	// Maximum size of the index in megabytes (maxTotalDataSizeMB; default is the Splunk Enterprise default)
+	MaxTotalDataSizeMB int64 `json:"maxTotalDataSizeMB,omitempty"`
+
+	// SmartStore remote storage location for the index, using a volume defined by the target (for example, "volume:s3/myindex")
+	RemotePath string `json:"remotePath,omitempty"`
+}
+
+// SplunkIndexStatus defines the observed state of a Splunk Enterprise index.
+type SplunkIndexStatus struct {
+	// current phase of the index
+	Phase ResourcePhase `json:"phase"`
+
+	// name of the index in Splunk Enterprise
+	IndexName string `json:"indexName,omitempty"`
+
+	// name of the ConfigMap that the index configuration is rendered into
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// total number of buckets for the index across all indexer cluster peers
+	BucketCount int64 `json:"bucketCount"`
+
+	// conditions describing the current state of the index
+	Conditions []Condition `json:"conditions"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SplunkIndex is the Schema for a Splunk Enterprise index.
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=splunkindexes,scope=Namespaced,shortName=idx
+// +kubebuilder:printcolumn:name="Index",type="string",JSONPath=".status.indexName",description="Name of the index"
+// +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase",description="Status of the index"
+// +kubebuilder:printcolumn:name="Buckets",type="integer",JSONPath=".status.bucketCount",description="Number of buckets in the index"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp",description="Age of the index"
+type SplunkIndex struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SplunkIndexSpec   `json:"spec,omitempty"`
+	Status SplunkIndexStatus `json:"status,omitempty"`
+}
+
+// GetIdentifier is a convenience function to return unique identifier for the Splunk enterprise deployment
+func (cr *SplunkIndex) GetIdentifier() string {
+	return cr.ObjectMeta.Name
+}
+
+// GetNamespace is a convenience function to return namespace for a Splunk enterprise deployment
+func (cr *SplunkIndex) GetNamespace() string {
+	return cr.ObjectMeta.Namespace
+}
+
+// GetTypeMeta is a convenience function to return a TypeMeta object
+func (cr *SplunkIndex) GetTypeMeta() metav1.TypeMeta {
+	return cr.TypeMeta
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// SplunkIndexList contains a list of SplunkIndex
+type SplunkIndexList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SplunkIndex `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SplunkIndex{}, &SplunkIndexList{})
+}
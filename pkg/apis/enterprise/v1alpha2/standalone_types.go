@@ -40,6 +40,12 @@ type StandaloneSpec struct {
 
 	// Image to use for Spark pod containers (overrides RELATED_IMAGE_SPLUNK_SPARK environment variables)
 	SparkImage string `json:"sparkImage"`
+
+	// SmartStore configuration used to store indexed data in remote object storage
+	SmartStore SmartStoreSpec `json:"smartstore,omitempty"`
+
+	// List of Splunk apps to install. Apps are added, upgraded and removed without recreating pods
+	AppSources []AppSource `json:"appSources,omitempty"`
 }
 
 // StandaloneStatus defines the observed state of a Splunk Enterprise standalone instances.
@@ -55,6 +61,15 @@ type StandaloneStatus struct {
 
 	// selector for pods, used by HorizontalPodAutoscaler
 	Selector string `json:"selector"`
+
+	// conditions describing the current state of the standalone instances
+	Conditions []Condition `json:"conditions"`
+
+	// apps installed by the operator on each standalone instance
+	Apps []AppTargetStatus `json:"apps,omitempty"`
+
+	// volumes of the standalone instances that are being resized
+	Volumes []VolumeStatus `json:"volumes,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
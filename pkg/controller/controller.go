@@ -2,6 +2,8 @@ package controller
 
 import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	splunkreconcile "github.com/splunk/splunk-operator/pkg/splunk/reconcile"
 )
 
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager
@@ -9,6 +11,9 @@ var AddToManagerFuncs []func(manager.Manager) error
 
 // AddToManager adds all Controllers to the Manager
 func AddToManager(m manager.Manager) error {
+	// record events about actions taken on custom resources
+	splunkreconcile.SetEventRecorder(m.GetEventRecorderFor("splunk-operator"))
+
 	for _, f := range AddToManagerFuncs {
 		if err := f(m); err != nil {
 			return err
@@ -81,6 +81,15 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to secondary resource StatefulSet and requeue the owner Spark
+	err = c.Watch(&source.Kind{Type: &appsv1.StatefulSet{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &enterprisev1.Spark{},
+	})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
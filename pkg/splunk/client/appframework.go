@@ -0,0 +1,92 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// AppFrameworkClient is a simple object used to query the app framework sidecar of a Splunk instance
+type AppFrameworkClient struct {
+	// http endpoint for the sidecar's status interface (e.g. "http://server:9090")
+	StatusURI string
+
+	// HTTP client used to process requests
+	Client SplunkHTTPClient
+}
+
+// NewAppFrameworkClient returns a new AppFrameworkClient object.
+func NewAppFrameworkClient(statusURI string) *AppFrameworkClient {
+	return &AppFrameworkClient{
+		StatusURI: statusURI,
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// AppFrameworkAppInfo represents an app installed by the app framework sidecar
+type AppFrameworkAppInfo struct {
+	// Name of the app
+	Name string `json:"name"`
+
+	// Version of the app
+	Version string `json:"version"`
+
+	// SHA-256 checksum of the installed app package
+	Checksum string `json:"checksum"`
+}
+
+// AppFrameworkStatus represents the status reported by the app framework sidecar
+type AppFrameworkStatus struct {
+	// Apps that are currently installed
+	Apps []AppFrameworkAppInfo `json:"apps"`
+
+	// Errors that occurred during the last sync, keyed by app name (an empty name is used for push errors)
+	Errors map[string]string `json:"errors"`
+
+	// True if apps have changed and are waiting to be pushed, or to be loaded by a restart
+	PendingPush bool `json:"pendingPush"`
+}
+
+// GetStatus queries the app framework sidecar for the status of installed apps.
+func (c *AppFrameworkClient) GetStatus() (*AppFrameworkStatus, error) {
+	endpoint := fmt.Sprintf("%s/status", c.StatusURI)
+	request, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Response code=%d from %s; want %d", response.StatusCode, request.URL, 200)
+	}
+	data, _ := ioutil.ReadAll(response.Body)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Received empty response body from %s", request.URL)
+	}
+	var status AppFrameworkStatus
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
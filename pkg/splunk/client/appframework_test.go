@@ -0,0 +1,56 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"net/http"
+	"testing"
+
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestAppFrameworkGetStatus(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "http://localhost:9090/status", nil)
+	test := func(status int, body string, wantErr bool) {
+		mockClient := &spltest.MockHTTPClient{}
+		mockClient.AddHandler(wantRequest, status, body, nil)
+		c := NewAppFrameworkClient("http://localhost:9090")
+		c.Client = mockClient
+		got, err := c.GetStatus()
+		if wantErr {
+			if err == nil {
+				t.Errorf("GetStatus() returned nil; want error")
+			}
+		} else if err != nil {
+			t.Errorf("GetStatus() err = %v", err)
+		} else {
+			if len(got.Apps) != 1 || got.Apps[0].Name != "app1" || got.Apps[0].Version != "1.0" {
+				t.Errorf("GetStatus() Apps = %v; want app1 1.0", got.Apps)
+			}
+			if !got.PendingPush {
+				t.Errorf("GetStatus() PendingPush = false; want true")
+			}
+			if got.Errors["app2"] != "download failed" {
+				t.Errorf("GetStatus() Errors = %v; want app2 error", got.Errors)
+			}
+		}
+		mockClient.CheckRequests(t, "TestAppFrameworkGetStatus")
+	}
+
+	test(200, `{"apps":[{"name":"app1","version":"1.0","checksum":"abc"}],"errors":{"app2":"download failed"},"pendingPush":true}`, false)
+	test(200, "", true)
+	test(200, "not json", true)
+	test(500, "", true)
+}
@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"regexp"
+	"strings"
 	"time"
 )
 
@@ -46,7 +48,15 @@ type SplunkClient struct {
 }
 
 // NewSplunkClient returns a new SplunkClient object initialized with a username and password.
-func NewSplunkClient(managementURI, username, password string) *SplunkClient {
+// The TLS configuration is used to verify splunkd's certificate; if nil, it defaults to NewTLSConfig(nil, nil, nil).
+func NewSplunkClient(managementURI, username, password string, tlsConfig *tls.Config) *SplunkClient {
+	if tlsConfig == nil {
+		var err error
+		if tlsConfig, err = NewTLSConfig(nil, nil, nil); err != nil {
+			// fall back to system root CAs if the operator-wide settings are invalid
+			tlsConfig = &tls.Config{}
+		}
+	}
 	return &SplunkClient{
 		ManagementURI: managementURI,
 		Username:      username,
@@ -54,7 +64,7 @@ func NewSplunkClient(managementURI, username, password string) *SplunkClient {
 		Client: &http.Client{
 			Timeout: 5 * time.Second,
 			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: true}, // don't verify ssl certs
+				TLSClientConfig: tlsConfig,
 			},
 		},
 	}
@@ -253,6 +263,18 @@ type SearchHeadClusterMemberInfo struct {
 	Status string `json:"status"`
 }
 
+// RollingRestartSearchHeadCluster restarts all of the members of a search head cluster, one at a time. You can only
+// use this on the captain. Progress is reported by GetSearchHeadCaptainInfo in RollingRestart.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#shcluster.2Fcaptain.2Fcontrol.2Fcontrol.2Frestart
+func (c *SplunkClient) RollingRestartSearchHeadCluster() error {
+	endpoint := fmt.Sprintf("%s/services/shcluster/captain/control/control/restart", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
 // GetSearchHeadClusterMemberInfo queries info from a search head cluster member.
 // You can use this on any member of a search head cluster.
 // See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#shcluster.2Fmember.2Finfo
@@ -352,6 +374,27 @@ type ClusterBundleInfo struct {
 
 	// Timestamp of the bundle
 	Timestamp int64 `json:"timestamp"`
+
+	// Indicates if the bundle passed validation (only used for last_validated_bundle)
+	IsValidBundle bool `json:"is_valid_bundle"`
+}
+
+// ClusterApplyBundleStatus represents the status of the most recent attempt to validate or apply a configuration bundle.
+type ClusterApplyBundleStatus struct {
+	// Most recent bundle that failed validation
+	InvalidBundle struct {
+		// Checksum used to verify bundle integrity
+		Checksum string `json:"checksum"`
+
+		// Errors reported by the master while validating the bundle
+		BundleValidationErrors []string `json:"bundle_validation_errors_on_master"`
+	} `json:"invalid_bundle"`
+
+	// Indicates if the peers have been asked to reload the bundle without restarting
+	ReloadBundleIssued bool `json:"reload_bundle_issued"`
+
+	// Status of the bundle push
+	Status string `json:"status"`
 }
 
 // ClusterMasterInfo represents the status of the indexer cluster master.
@@ -382,6 +425,18 @@ type ClusterMasterInfo struct {
 	// In steady state, this is equal to active_bundle. If it is not equal, then pushing the latest bundle to all peers is in process (or needs to be started).
 	LatestBundle ClusterBundleInfo `json:"latest_bundle"`
 
+	// The most recent bundle that was validated, and whether it is valid.
+	LastValidatedBundle ClusterBundleInfo `json:"last_validated_bundle"`
+
+	// Indicates if the peers must be restarted to apply the last validated bundle, when validated with check-restart.
+	LastCheckRestartBundleResult bool `json:"last_check_restart_bundle_result"`
+
+	// The bundle that was active before the current one, which is restored by a rollback.
+	PreviousActiveBundle ClusterBundleInfo `json:"previous_active_bundle"`
+
+	// Status of the most recent attempt to validate or apply a bundle.
+	ApplyBundleStatus ClusterApplyBundleStatus `json:"apply_bundle_status"`
+
 	// Timestamp corresponding to the creation of the master.
 	StartTime int64 `json:"start_time"`
 }
@@ -406,6 +461,63 @@ func (c *SplunkClient) GetClusterMasterInfo() (*ClusterMasterInfo, error) {
 	return &apiResponse.Entry[0].Content, nil
 }
 
+// ClusterMasterGenerationInfo represents the status of the current generation of an indexer cluster.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fgeneration
+type ClusterMasterGenerationInfo struct {
+	// Indicates if the replication factor is met.
+	ReplicationFactorMet bool `json:"replication_factor_met"`
+
+	// Indicates if the search factor is met.
+	SearchFactorMet bool `json:"search_factor_met"`
+}
+
+// UnmarshalJSON for ClusterMasterGenerationInfo handles flags that are reported as either booleans or "0" and "1" strings
+func (info *ClusterMasterGenerationInfo) UnmarshalJSON(data []byte) error {
+	content := struct {
+		ReplicationFactorMet interface{} `json:"replication_factor_met"`
+		SearchFactorMet      interface{} `json:"search_factor_met"`
+	}{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return err
+	}
+	info.ReplicationFactorMet = parseSplunkFlag(content.ReplicationFactorMet)
+	info.SearchFactorMet = parseSplunkFlag(content.SearchFactorMet)
+	return nil
+}
+
+// parseSplunkFlag returns the value of a flag that may be reported by Splunk as a boolean, number or string
+func parseSplunkFlag(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		return v == "1" || v == "true"
+	}
+	return false
+}
+
+// GetClusterMasterGeneration queries the cluster master for info about the current generation of the indexer cluster.
+// You can only use this on a cluster master.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fgeneration
+func (c *SplunkClient) GetClusterMasterGeneration() (*ClusterMasterGenerationInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Content ClusterMasterGenerationInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := "/services/cluster/master/generation"
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResponse.Entry) < 1 {
+		return nil, fmt.Errorf("Invalid response from %s%s", c.ManagementURI, path)
+	}
+	return &apiResponse.Entry[0].Content, nil
+}
+
 // IndexerClusterPeerInfo represents the status of a indexer cluster peer.
 // See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fslave.2Finfo
 type IndexerClusterPeerInfo struct {
@@ -608,3 +720,239 @@ func (c *SplunkClient) DecommissionIndexerClusterPeer(enforceCounts bool) error
 	}
 	return c.Do(request, 200, nil)
 }
+
+// SetClusterMaintenanceMode enables or disables maintenance mode for an indexer cluster, which halts most bucket fixup
+// activity while peers are restarted. You can only use this on a cluster master.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fcontrol.2Fdefault.2Fmaintenance
+func (c *SplunkClient) SetClusterMaintenanceMode(enable bool) error {
+	endpoint := fmt.Sprintf("%s/services/cluster/master/control/default/maintenance?mode=%t", c.ManagementURI, enable)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// RollingRestartIndexerCluster restarts all of the peers in an indexer cluster, a few at a time. When searchable is
+// true, peers are restarted one at a time so that all data remains searchable. You can only use this on a cluster master.
+// Progress is reported by GetClusterMasterInfo in RollingRestart.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fcontrol.2Fcontrol.2Frestart
+func (c *SplunkClient) RollingRestartIndexerCluster(searchable bool) error {
+	endpoint := fmt.Sprintf("%s/services/cluster/master/control/control/restart?searchable=%t", c.ManagementURI, searchable)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// SearchPeerInfo represents the status of a distributed search peer.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsearch#search.2Fdistributed.2Fpeers
+type SearchPeerInfo struct {
+	// Name of the search peer, using the format host:port
+	Name string `json:"-"`
+
+	// The name for the search peer, as reported by the peer.
+	PeerName string `json:"peerName"`
+
+	// Status of the search peer (e.g. Up, Down, Authentication Failed)
+	Status string `json:"status"`
+
+	// Server roles assigned to the search peer
+	ServerRoles []string `json:"server_roles"`
+}
+
+// GetSearchPeers queries a search head for info about its distributed search peers, keyed by host:port.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsearch#search.2Fdistributed.2Fpeers
+func (c *SplunkClient) GetSearchPeers() (map[string]SearchPeerInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Name    string         `json:"name"`
+			Content SearchPeerInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := "/services/search/distributed/peers"
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make(map[string]SearchPeerInfo)
+	for _, e := range apiResponse.Entry {
+		e.Content.Name = e.Name
+		peers[e.Name] = e.Content
+	}
+
+	return peers, nil
+}
+
+// AddSearchPeer adds a distributed search peer, where peer uses the format host:port.
+// See https://docs.splunk.com/Documentation/Splunk/latest/DistSearch/Configuredistributedsearch
+func (c *SplunkClient) AddSearchPeer(peer, remoteUsername, remotePassword string) error {
+	form := url.Values{}
+	form.Set("name", peer)
+	form.Set("remoteUsername", remoteUsername)
+	form.Set("remotePassword", remotePassword)
+	endpoint := fmt.Sprintf("%s/services/search/distributed/peers", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return c.Do(request, 201, nil)
+}
+
+// RemoveSearchPeer removes a distributed search peer, where peer uses the format host:port.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsearch#search.2Fdistributed.2Fpeers.2F.7Bname.7D
+func (c *SplunkClient) RemoveSearchPeer(peer string) error {
+	endpoint := fmt.Sprintf("%s/services/search/distributed/peers/%s", c.ManagementURI, url.PathEscape(peer))
+	request, err := http.NewRequest("DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// UpdateMonitoringConsoleAssets rebuilds the asset table of a distributed monitoring console, so that it
+// reflects the current set of search peers and their server roles.
+// See https://docs.splunk.com/Documentation/Splunk/latest/DMC/Configureindistributedmode
+func (c *SplunkClient) UpdateMonitoringConsoleAssets() error {
+	endpoint := fmt.Sprintf("%s/servicesNS/nobody/splunk_monitoring_console/saved/searches/DMC%%20Asset%%20-%%20Build%%20Full/dispatch", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 201, nil)
+}
+
+// ChangeAdminPassword changes the password of the admin user, and updates the client to use the new password.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTaccess#authentication.2Fusers.2F.7Bname.7D
+func (c *SplunkClient) ChangeAdminPassword(oldPassword, newPassword string) error {
+	form := url.Values{}
+	form.Set("oldpassword", oldPassword)
+	form.Set("password", newPassword)
+	endpoint := fmt.Sprintf("%s/services/authentication/users/admin", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err = c.Do(request, 200, nil); err != nil {
+		return err
+	}
+	c.Password = newPassword
+	return nil
+}
+
+// SetServerConfSecret sets pass4SymmKey within a stanza of server.conf (e.g. "general", "clustering" or "shclustering").
+// The change is written to etc/system/local. If the stanza does not exist, the instance does not use the secret and nothing is changed.
+// The new value takes effect after splunkd is restarted.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTconf#configs.2Fconf-.7Bfile.7D.2F.7Bstanza.7D
+func (c *SplunkClient) SetServerConfSecret(stanza, secret string) error {
+	form := url.Values{}
+	form.Set("pass4SymmKey", secret)
+	endpoint := fmt.Sprintf("%s/servicesNS/nobody/system/configs/conf-server/%s", c.ManagementURI, url.PathEscape(stanza))
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	err = c.Do(request, 200, nil)
+	if err != nil && strings.HasPrefix(err.Error(), "Response code=404 ") {
+		return nil
+	}
+	return err
+}
+
+// ServerInfo represents the status of a Splunk Enterprise instance.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTintrospect#server.2Finfo
+type ServerInfo struct {
+	// Name of the instance
+	ServerName string `json:"serverName"`
+
+	// Version of Splunk Enterprise
+	Version string `json:"version"`
+
+	// Time when splunkd was last started, in seconds since the epoch
+	StartupTime int64 `json:"startup_time"`
+}
+
+// GetServerInfo queries an instance for information about itself, including when splunkd was started.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTintrospect#server.2Finfo
+func (c *SplunkClient) GetServerInfo() (*ServerInfo, error) {
+	apiResponse := struct {
+		Entry []struct {
+			Content ServerInfo `json:"content"`
+		} `json:"entry"`
+	}{}
+	path := "/services/server/info"
+	err := c.Get(path, &apiResponse)
+	if err != nil {
+		return nil, err
+	}
+	if len(apiResponse.Entry) < 1 {
+		return nil, fmt.Errorf("Invalid response from %s%s", c.ManagementURI, path)
+	}
+	return &apiResponse.Entry[0].Content, nil
+}
+
+// Restart restarts splunkd.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTsystem#server.2Fcontrol.2Frestart
+func (c *SplunkClient) Restart() error {
+	endpoint := fmt.Sprintf("%s/services/server/control/restart", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// ApplyClusterBundle pushes the configuration bundle in master-apps from a cluster master to all of its peers.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fcontrol.2Fdefault.2Fapply
+func (c *SplunkClient) ApplyClusterBundle() error {
+	endpoint := fmt.Sprintf("%s/services/cluster/master/control/default/apply", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// ValidateClusterBundle validates the configuration bundle in master-apps on a cluster master, without pushing it to
+// the peers. If checkRestart is true, the master also checks whether applying the bundle requires restarting the peers.
+// The result is reported by GetClusterMasterInfo in LastValidatedBundle and LastCheckRestartBundleResult.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fcontrol.2Fdefault.2Fvalidate_bundle
+func (c *SplunkClient) ValidateClusterBundle(checkRestart bool) error {
+	endpoint := fmt.Sprintf("%s/services/cluster/master/control/default/validate_bundle", c.ManagementURI)
+	if checkRestart {
+		endpoint += "?check-restart=true"
+	}
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// RollbackClusterBundle restores the previously active configuration bundle on a cluster master, and pushes it to all
+// of its peers.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTcluster#cluster.2Fmaster.2Fcontrol.2Fdefault.2Frollback
+func (c *SplunkClient) RollbackClusterBundle() error {
+	endpoint := fmt.Sprintf("%s/services/cluster/master/control/default/rollback", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
+
+// ReloadIndexes reloads indexes.conf, so that new and changed indexes take effect without restarting splunkd.
+// See https://docs.splunk.com/Documentation/Splunk/latest/RESTREF/RESTindex#data.2Findexes
+func (c *SplunkClient) ReloadIndexes() error {
+	endpoint := fmt.Sprintf("%s/services/data/indexes/_reload", c.ManagementURI)
+	request, err := http.NewRequest("POST", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	return c.Do(request, 200, nil)
+}
@@ -16,6 +16,7 @@ package client
 
 import (
 	"net/http"
+	"reflect"
 	"testing"
 
 	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
@@ -24,7 +25,7 @@ import (
 func splunkClientTester(t *testing.T, testMethod string, status int, body string, wantRequest *http.Request, test func(SplunkClient) error) {
 	mockSplunkClient := &spltest.MockHTTPClient{}
 	mockSplunkClient.AddHandler(wantRequest, status, body, nil)
-	c := NewSplunkClient("https://localhost:8089", "admin", "p@ssw0rd")
+	c := NewSplunkClient("https://localhost:8089", "admin", "p@ssw0rd", nil)
 	c.Client = mockSplunkClient
 	err := test(*c)
 	if err != nil {
@@ -150,6 +151,14 @@ func TestGetSearchHeadCaptainMembers(t *testing.T) {
 	splunkClientTester(t, "TestGetSearchHeadCaptainMembers", 503, "", wantRequest, test)
 }
 
+func TestRollingRestartSearchHeadCluster(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/shcluster/captain/control/control/restart", nil)
+	test := func(c SplunkClient) error {
+		return c.RollingRestartSearchHeadCluster()
+	}
+	splunkClientTester(t, "TestRollingRestartSearchHeadCluster", 200, "", wantRequest, test)
+}
+
 func TestSetSearchHeadDetention(t *testing.T) {
 	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/shcluster/member/control/control/set_manual_detention?manual_detention=on", nil)
 	test := func(c SplunkClient) error {
@@ -219,14 +228,22 @@ func TestGetClusterMasterInfo(t *testing.T) {
 			Checksum:   "14310A4AABD23E85BBD4559C4A3B59F8",
 			Timestamp:  1583870198,
 		},
+		LastValidatedBundle: ClusterBundleInfo{
+			BundlePath:    "/opt/splunk/var/run/splunk/cluster/remote-bundle/0af7c0e95f313f7be3b0cb1d878df9a1-1583948640.bundle",
+			Checksum:      "14310A4AABD23E85BBD4559C4A3B59F8",
+			Timestamp:     1583948640,
+			IsValidBundle: true,
+		},
 		StartTime: 1583948636,
 	}
+	wantInfo.ApplyBundleStatus.InvalidBundle.BundleValidationErrors = []string{}
+	wantInfo.ApplyBundleStatus.Status = "None"
 	test := func(c SplunkClient) error {
 		gotInfo, err := c.GetClusterMasterInfo()
 		if err != nil {
 			return err
 		}
-		if *gotInfo != wantInfo {
+		if !reflect.DeepEqual(*gotInfo, wantInfo) {
 			t.Errorf("info.Status=%v; want %v", *gotInfo, wantInfo)
 		}
 		return nil
@@ -343,3 +360,208 @@ func TestDecommissionIndexerClusterPeer(t *testing.T) {
 	}
 	splunkClientTester(t, "TestDecommissionIndexerClusterPeer", 200, "", wantRequest, test)
 }
+
+func TestSetClusterMaintenanceMode(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/maintenance?mode=true", nil)
+	test := func(c SplunkClient) error {
+		return c.SetClusterMaintenanceMode(true)
+	}
+	splunkClientTester(t, "TestSetClusterMaintenanceMode", 200, "", wantRequest, test)
+
+	wantRequest, _ = http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/maintenance?mode=false", nil)
+	test = func(c SplunkClient) error {
+		return c.SetClusterMaintenanceMode(false)
+	}
+	splunkClientTester(t, "TestSetClusterMaintenanceMode", 200, "", wantRequest, test)
+}
+
+func TestRollingRestartIndexerCluster(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/control/restart?searchable=true", nil)
+	test := func(c SplunkClient) error {
+		return c.RollingRestartIndexerCluster(true)
+	}
+	splunkClientTester(t, "TestRollingRestartIndexerCluster", 200, "", wantRequest, test)
+}
+
+func TestGetClusterMasterGeneration(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089/services/cluster/master/generation?count=0&output_mode=json", nil)
+	test := func(want ClusterMasterGenerationInfo) func(c SplunkClient) error {
+		return func(c SplunkClient) error {
+			got, err := c.GetClusterMasterGeneration()
+			if err != nil {
+				return err
+			}
+			if *got != want {
+				t.Errorf("info=%v; want %v", *got, want)
+			}
+			return nil
+		}
+	}
+	body := `{"links":{},"origin":"https://localhost:8089/services/cluster/master/generation","updated":"2020-03-18T01:04:53+00:00","generator":{"build":"a7f645ddaf91","version":"8.0.2"},"entry":[{"name":"master","content":{"generation_id":3,"multisite_error":"","pending_generation_id":4,"replication_factor_met":"1","search_factor_met":"0","was_forced":"0"}}],"paging":{"total":1,"perPage":30,"offset":0},"messages":[]}`
+	splunkClientTester(t, "TestGetClusterMasterGeneration", 200, body, wantRequest, test(ClusterMasterGenerationInfo{ReplicationFactorMet: true, SearchFactorMet: false}))
+
+	body = `{"entry":[{"name":"master","content":{"replication_factor_met":true,"search_factor_met":1}}]}`
+	splunkClientTester(t, "TestGetClusterMasterGeneration", 200, body, wantRequest, test(ClusterMasterGenerationInfo{ReplicationFactorMet: true, SearchFactorMet: true}))
+
+	// test body with no entries
+	wantErr := func(c SplunkClient) error {
+		_, err := c.GetClusterMasterGeneration()
+		if err == nil {
+			t.Errorf("GetClusterMasterGeneration returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestGetClusterMasterGeneration", 200, `{"entry":[]}`, wantRequest, wantErr)
+
+	// test error code
+	splunkClientTester(t, "TestGetClusterMasterGeneration", 500, "", wantRequest, wantErr)
+}
+
+func TestGetSearchPeers(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089/services/search/distributed/peers?count=0&output_mode=json", nil)
+	wantName := "splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local:8089"
+	test := func(c SplunkClient) error {
+		peers, err := c.GetSearchPeers()
+		if err != nil {
+			return err
+		}
+		if len(peers) != 1 {
+			t.Errorf("len(peers)=%d; want %d", len(peers), 1)
+		}
+		p, ok := peers[wantName]
+		if !ok {
+			t.Fatalf("wanted peer not found: %s", wantName)
+		}
+		if p.Name != wantName || p.PeerName != "splunk-s1-indexer-0" || p.Status != "Up" {
+			t.Errorf("peer=%v; want name=%s peerName=%s status=%s", p, wantName, "splunk-s1-indexer-0", "Up")
+		}
+		if len(p.ServerRoles) != 1 || p.ServerRoles[0] != "indexer" {
+			t.Errorf("peer.ServerRoles=%v; want %v", p.ServerRoles, []string{"indexer"})
+		}
+		return nil
+	}
+	body := `{"links":{"create":"/services/search/distributed/peers/_new"},"origin":"https://localhost:8089/services/search/distributed/peers","updated":"2020-06-01T17:11:32+00:00","generator":{"build":"a1a6394cc5ae","version":"8.0.3"},"entry":[{"name":"splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local:8089","id":"https://localhost:8089/services/search/distributed/peers/splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local%3A8089","content":{"disabled":false,"peerName":"splunk-s1-indexer-0","server_roles":["indexer"],"status":"Up","version":"8.0.3"}}],"paging":{"total":1,"perPage":0,"offset":0},"messages":[]}`
+	splunkClientTester(t, "TestGetSearchPeers", 200, body, wantRequest, test)
+}
+
+func TestAddSearchPeer(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/search/distributed/peers", nil)
+	test := func(c SplunkClient) error {
+		return c.AddSearchPeer("splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local:8089", "admin", "p@ssw0rd")
+	}
+	splunkClientTester(t, "TestAddSearchPeer", 201, "", wantRequest, test)
+}
+
+func TestRemoveSearchPeer(t *testing.T) {
+	wantRequest, _ := http.NewRequest("DELETE", "https://localhost:8089/services/search/distributed/peers/splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local:8089", nil)
+	test := func(c SplunkClient) error {
+		return c.RemoveSearchPeer("splunk-s1-indexer-0.splunk-s1-indexer-headless.splunk.svc.cluster.local:8089")
+	}
+	splunkClientTester(t, "TestRemoveSearchPeer", 200, "", wantRequest, test)
+}
+
+func TestUpdateMonitoringConsoleAssets(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/servicesNS/nobody/splunk_monitoring_console/saved/searches/DMC%20Asset%20-%20Build%20Full/dispatch", nil)
+	test := func(c SplunkClient) error {
+		return c.UpdateMonitoringConsoleAssets()
+	}
+	splunkClientTester(t, "TestUpdateMonitoringConsoleAssets", 201, "", wantRequest, test)
+}
+
+func TestChangeAdminPassword(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/authentication/users/admin", nil)
+	test := func(c SplunkClient) error {
+		if err := c.ChangeAdminPassword("p@ssw0rd", "n3wp@ss"); err != nil {
+			return err
+		}
+		if c.Password != "n3wp@ss" {
+			t.Errorf("ChangeAdminPassword() client password = %s; want %s", c.Password, "n3wp@ss")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestChangeAdminPassword", 200, "", wantRequest, test)
+}
+
+func TestSetServerConfSecret(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/servicesNS/nobody/system/configs/conf-server/clustering", nil)
+	test := func(c SplunkClient) error {
+		return c.SetServerConfSecret("clustering", "s3cr3t")
+	}
+	splunkClientTester(t, "TestSetServerConfSecret", 200, "", wantRequest, test)
+
+	// missing stanzas are ignored
+	splunkClientTester(t, "TestSetServerConfSecret", 404, "", wantRequest, test)
+}
+
+func TestGetServerInfo(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "https://localhost:8089/services/server/info?count=0&output_mode=json", nil)
+	test := func(c SplunkClient) error {
+		info, err := c.GetServerInfo()
+		if err != nil {
+			return err
+		}
+		if info.StartupTime != 1602966813 || info.ServerName != "splunk-s1-standalone-0" {
+			t.Errorf("info = %v; want startup_time=1602966813, serverName=splunk-s1-standalone-0", info)
+		}
+		return nil
+	}
+	body := `{"links":{},"origin":"https://localhost:8089/services/server/info","updated":"2020-10-17T21:40:02+00:00","generator":{"build":"a1a6394cc5ae","version":"8.0.5"},"entry":[{"name":"server-info","id":"https://localhost:8089/services/server/info/server-info","updated":"1970-01-01T00:00:00+00:00","links":{"alternate":"/services/server/info/server-info","list":"/services/server/info/server-info"},"author":"system","acl":{"app":"","can_list":true,"can_write":true,"modifiable":false,"owner":"system","perms":{"read":["*"],"write":[]},"removable":false,"sharing":"system"},"content":{"eai:acl":null,"serverName":"splunk-s1-standalone-0","startup_time":1602966813,"version":"8.0.5"}}],"paging":{"total":1,"perPage":30,"offset":0},"messages":[]}`
+	splunkClientTester(t, "TestGetServerInfo", 200, body, wantRequest, test)
+
+	// test body with no entries
+	test = func(c SplunkClient) error {
+		_, err := c.GetServerInfo()
+		if err == nil {
+			t.Errorf("GetServerInfo returned nil; want error")
+		}
+		return nil
+	}
+	splunkClientTester(t, "TestGetServerInfo", 200, `{"entry":[]}`, wantRequest, test)
+}
+
+func TestRestart(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/server/control/restart", nil)
+	test := func(c SplunkClient) error {
+		return c.Restart()
+	}
+	splunkClientTester(t, "TestRestart", 200, "", wantRequest, test)
+}
+
+func TestApplyClusterBundle(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/apply", nil)
+	test := func(c SplunkClient) error {
+		return c.ApplyClusterBundle()
+	}
+	splunkClientTester(t, "TestApplyClusterBundle", 200, "", wantRequest, test)
+}
+
+func TestValidateClusterBundle(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/validate_bundle?check-restart=true", nil)
+	test := func(c SplunkClient) error {
+		return c.ValidateClusterBundle(true)
+	}
+	splunkClientTester(t, "TestValidateClusterBundle", 200, "", wantRequest, test)
+
+	// test without checking for restarts
+	wantRequest, _ = http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/validate_bundle", nil)
+	test = func(c SplunkClient) error {
+		return c.ValidateClusterBundle(false)
+	}
+	splunkClientTester(t, "TestValidateClusterBundle", 200, "", wantRequest, test)
+}
+
+func TestRollbackClusterBundle(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/cluster/master/control/default/rollback", nil)
+	test := func(c SplunkClient) error {
+		return c.RollbackClusterBundle()
+	}
+	splunkClientTester(t, "TestRollbackClusterBundle", 200, "", wantRequest, test)
+}
+
+func TestReloadIndexes(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "https://localhost:8089/services/data/indexes/_reload", nil)
+	test := func(c SplunkClient) error {
+		return c.ReloadIndexes()
+	}
+	splunkClientTester(t, "TestReloadIndexes", 200, "", wantRequest, test)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// SparkClient is a simple object used to query the status of a Spark master
+type SparkClient struct {
+	// http endpoint for the master's web interface (e.g. "http://server:8009")
+	MasterURI string
+
+	// HTTP client used to process requests
+	Client SplunkHTTPClient
+}
+
+// NewSparkClient returns a new SparkClient object.
+func NewSparkClient(masterURI string) *SparkClient {
+	return &SparkClient{
+		MasterURI: masterURI,
+		Client: &http.Client{
+			Timeout: 5 * time.Second,
+		},
+	}
+}
+
+// SparkWorkerInfo represents a worker registered with the Spark master
+type SparkWorkerInfo struct {
+	// Unique identifier of the worker
+	ID string `json:"id"`
+
+	// Host name or address of the worker
+	Host string `json:"host"`
+
+	// Port used by the worker to communicate with the master
+	Port int `json:"port"`
+
+	// State of the worker (e.g. ALIVE, DEAD, DECOMMISSIONED)
+	State string `json:"state"`
+
+	// Number of cores provided by the worker
+	Cores int `json:"cores"`
+
+	// Number of cores used by applications running on the worker
+	CoresUsed int `json:"coresused"`
+
+	// Memory provided by the worker, in megabytes
+	Memory int64 `json:"memory"`
+
+	// Memory used by applications running on the worker, in megabytes
+	MemoryUsed int64 `json:"memoryused"`
+
+	// Time of the last heartbeat received from the worker, in milliseconds since the epoch
+	LastHeartbeat int64 `json:"lastheartbeat"`
+}
+
+// SparkApplicationInfo represents an application submitted to the Spark master
+type SparkApplicationInfo struct {
+	// Unique identifier of the application
+	ID string `json:"id"`
+
+	// Name of the application
+	Name string `json:"name"`
+
+	// User that submitted the application
+	User string `json:"user"`
+
+	// State of the application (e.g. WAITING, RUNNING, FINISHED, FAILED)
+	State string `json:"state"`
+
+	// Number of cores used by the application
+	Cores int `json:"cores"`
+
+	// Time when the application started, in milliseconds since the epoch
+	StartTime int64 `json:"starttime"`
+
+	// Time the application has been running for, in milliseconds
+	Duration int64 `json:"duration"`
+}
+
+// SparkMasterStatus represents the status reported by the Spark master's JSON endpoint
+type SparkMasterStatus struct {
+	// URL used to submit applications to the master (e.g. "spark://server:7777")
+	URL string `json:"url"`
+
+	// Status of the master (e.g. ALIVE, STANDBY, RECOVERING)
+	Status string `json:"status"`
+
+	// Workers registered with the master
+	Workers []SparkWorkerInfo `json:"workers"`
+
+	// Number of workers that are alive
+	AliveWorkers int `json:"aliveworkers"`
+
+	// Total number of cores provided by alive workers
+	Cores int `json:"cores"`
+
+	// Number of cores used by applications
+	CoresUsed int `json:"coresused"`
+
+	// Total memory provided by alive workers, in megabytes
+	Memory int64 `json:"memory"`
+
+	// Memory used by applications, in megabytes
+	MemoryUsed int64 `json:"memoryused"`
+
+	// Applications that are waiting or running
+	ActiveApps []SparkApplicationInfo `json:"activeapps"`
+
+	// Applications that have finished
+	CompletedApps []SparkApplicationInfo `json:"completedapps"`
+}
+
+// GetMasterStatus queries the Spark master for the status of its workers and applications.
+func (c *SparkClient) GetMasterStatus() (*SparkMasterStatus, error) {
+	endpoint := fmt.Sprintf("%s/json/", c.MasterURI)
+	request, err := http.NewRequest("GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != 200 {
+		return nil, fmt.Errorf("Response code=%d from %s; want %d", response.StatusCode, request.URL, 200)
+	}
+	data, _ := ioutil.ReadAll(response.Body)
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Received empty response body from %s", request.URL)
+	}
+	var status SparkMasterStatus
+	err = json.Unmarshal(data, &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// DecommissionWorker asks the Spark master to decommission the workers running on a host, so that no new executors
+// are launched on them while their running executors finish. This requires Spark 3.1 or later, with
+// spark.master.ui.decommission.allow.mode set to ALLOW on the master and spark.decommission.enabled set on the workers.
+func (c *SparkClient) DecommissionWorker(host string) error {
+	form := url.Values{}
+	form.Set("host", host)
+	endpoint := fmt.Sprintf("%s/workers/kill/", c.MasterURI)
+	request, err := http.NewRequest("POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	response, err := c.Client.Do(request)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != 200 {
+		return fmt.Errorf("Response code=%d from %s; want %d", response.StatusCode, request.URL, 200)
+	}
+	return nil
+}
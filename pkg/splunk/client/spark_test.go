@@ -0,0 +1,87 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestSparkGetMasterStatus(t *testing.T) {
+	wantRequest, _ := http.NewRequest("GET", "http://localhost:8009/json/", nil)
+	test := func(status int, body string, wantErr bool) {
+		mockClient := &spltest.MockHTTPClient{}
+		mockClient.AddHandler(wantRequest, status, body, nil)
+		c := NewSparkClient("http://localhost:8009")
+		c.Client = mockClient
+		got, err := c.GetMasterStatus()
+		if wantErr {
+			if err == nil {
+				t.Errorf("GetMasterStatus() returned nil; want error")
+			}
+		} else if err != nil {
+			t.Errorf("GetMasterStatus() err = %v", err)
+		} else {
+			if got.Status != "ALIVE" || got.AliveWorkers != 2 || len(got.Workers) != 2 {
+				t.Errorf("GetMasterStatus() Status = %s, AliveWorkers = %d, Workers = %v; want ALIVE, 2, 2 workers", got.Status, got.AliveWorkers, got.Workers)
+			}
+			if got.Workers[0].Host != "10.0.0.1" || got.Workers[0].Cores != 4 || got.Workers[0].MemoryUsed != 1024 {
+				t.Errorf("GetMasterStatus() Workers[0] = %v; want 10.0.0.1 with 4 cores and 1024 MB used", got.Workers[0])
+			}
+			if got.Cores != 8 || got.CoresUsed != 2 || got.Memory != 12288 || got.MemoryUsed != 1024 {
+				t.Errorf("GetMasterStatus() cores = %d/%d, memory = %d/%d; want 2/8, 1024/12288", got.CoresUsed, got.Cores, got.MemoryUsed, got.Memory)
+			}
+			if len(got.ActiveApps) != 1 || got.ActiveApps[0].Name != "dsp-job" || len(got.CompletedApps) != 1 {
+				t.Errorf("GetMasterStatus() ActiveApps = %v, CompletedApps = %v; want dsp-job and 1 completed", got.ActiveApps, got.CompletedApps)
+			}
+		}
+		mockClient.CheckRequests(t, "TestSparkGetMasterStatus")
+	}
+
+	body := `{"url":"spark://splunk-stack1-spark-master-service:7777","workers":[` +
+		`{"id":"worker-20200320102030-10.0.0.1-7777","host":"10.0.0.1","port":7777,"webuiaddress":"http://10.0.0.1:7000","cores":4,"coresused":2,"coresfree":2,"memory":6144,"memoryused":1024,"memoryfree":5120,"state":"ALIVE","lastheartbeat":1584700000000},` +
+		`{"id":"worker-20200320102031-10.0.0.2-7777","host":"10.0.0.2","port":7777,"webuiaddress":"http://10.0.0.2:7000","cores":4,"coresused":0,"coresfree":4,"memory":6144,"memoryused":0,"memoryfree":6144,"state":"ALIVE","lastheartbeat":1584700000000}],` +
+		`"aliveworkers":2,"cores":8,"coresused":2,"memory":12288,"memoryused":1024,` +
+		`"activeapps":[{"id":"app-20200320102100-0001","starttime":1584700060000,"name":"dsp-job","cores":2,"user":"spark","memoryperslave":1024,"submitdate":"Fri Mar 20 10:21:00 UTC 2020","state":"RUNNING","duration":60000}],` +
+		`"completedapps":[{"id":"app-20200320101000-0000","starttime":1584699000000,"name":"dsp-test","cores":2,"user":"spark","memoryperslave":1024,"submitdate":"Fri Mar 20 10:10:00 UTC 2020","state":"FINISHED","duration":30000}],` +
+		`"activedrivers":[],"completeddrivers":[],"status":"ALIVE"}`
+	test(200, body, false)
+	test(200, "", true)
+	test(200, "not json", true)
+	test(500, "", true)
+}
+
+func TestSparkDecommissionWorker(t *testing.T) {
+	wantRequest, _ := http.NewRequest("POST", "http://localhost:8009/workers/kill/", nil)
+	test := func(status int, wantErr bool) {
+		mockClient := &spltest.MockHTTPClient{}
+		mockClient.AddHandler(wantRequest, status, "", nil)
+		c := NewSparkClient("http://localhost:8009")
+		c.Client = mockClient
+		err := c.DecommissionWorker("10.0.0.1")
+		if (err != nil) != wantErr {
+			t.Errorf("DecommissionWorker() err = %v; want error %t", err, wantErr)
+		}
+		mockClient.CheckRequests(t, "TestSparkDecommissionWorker")
+		if body, _ := ioutil.ReadAll(mockClient.GotRequests[0].Body); string(body) != "host=10.0.0.1" {
+			t.Errorf("DecommissionWorker() body = %s; want host=10.0.0.1", body)
+		}
+	}
+	test(200, false)
+	test(405, true)
+}
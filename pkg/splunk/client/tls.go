@@ -0,0 +1,80 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+)
+
+const (
+	// environment variable used to configure a CA bundle that is trusted for all Splunk instances
+	caFileEnv = "SPLUNK_CA_FILE"
+
+	// environment variables used to configure a client certificate used for all Splunk instances
+	clientCertFileEnv = "SPLUNK_CLIENT_CERT_FILE"
+	clientKeyFileEnv  = "SPLUNK_CLIENT_KEY_FILE"
+
+	// environment variable used to opt in to skipping verification of splunkd certificates
+	insecureSkipVerifyEnv = "SPLUNK_INSECURE_SKIP_VERIFY"
+)
+
+// NewTLSConfig returns a TLS configuration used to verify the certificates of Splunk instances.
+// Certificates are trusted if they are signed by a system root CA, the CA bundle in SPLUNK_CA_FILE,
+// or caBundle (if not empty). If clientCert and clientKey are not empty, they are presented to splunkd
+// as a client certificate; otherwise, the certificate in SPLUNK_CLIENT_CERT_FILE and SPLUNK_CLIENT_KEY_FILE
+// is used, if set. Certificate verification is only skipped if SPLUNK_INSECURE_SKIP_VERIFY is "true".
+func NewTLSConfig(caBundle, clientCert, clientKey []byte) (*tls.Config, error) {
+	if os.Getenv(insecureSkipVerifyEnv) == "true" {
+		return &tls.Config{InsecureSkipVerify: true}, nil
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if caFile := os.Getenv(caFileEnv); caFile != "" {
+		caFileBundle, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read %s: %v", caFileEnv, err)
+		}
+		if !rootCAs.AppendCertsFromPEM(caFileBundle) {
+			return nil, fmt.Errorf("No certificates found in %s", caFile)
+		}
+	}
+	if len(caBundle) > 0 && !rootCAs.AppendCertsFromPEM(caBundle) {
+		return nil, fmt.Errorf("No certificates found in CA bundle")
+	}
+
+	result := &tls.Config{RootCAs: rootCAs}
+	if len(clientCert) > 0 || len(clientKey) > 0 {
+		cert, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid client certificate: %v", err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	} else if certFile := os.Getenv(clientCertFileEnv); certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, os.Getenv(clientKeyFileEnv))
+		if err != nil {
+			return nil, fmt.Errorf("Invalid client certificate in %s: %v", clientCertFileEnv, err)
+		}
+		result.Certificates = []tls.Certificate{cert}
+	}
+
+	return result, nil
+}
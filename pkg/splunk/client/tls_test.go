@@ -0,0 +1,92 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"entry":[]}`)
+	}))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	// certificates should be verified using the CA bundle
+	tlsConfig, err := NewTLSConfig(caBundle, nil, nil)
+	if err != nil {
+		t.Fatalf("NewTLSConfig() returned %v; want nil", err)
+	}
+	if tlsConfig.InsecureSkipVerify {
+		t.Errorf("NewTLSConfig() InsecureSkipVerify = true; want false")
+	}
+	c := NewSplunkClient(server.URL, "admin", "p@ssw0rd", tlsConfig)
+	if _, err = c.GetSearchPeers(); err != nil {
+		t.Errorf("SplunkClient with CA bundle returned %v; want nil", err)
+	}
+
+	// certificates signed by an unknown CA should be rejected
+	c = NewSplunkClient(server.URL, "admin", "p@ssw0rd", nil)
+	if _, err = c.GetSearchPeers(); err == nil {
+		t.Errorf("SplunkClient without CA bundle returned nil; want error")
+	}
+
+	// insecure mode must be explicitly enabled
+	os.Setenv(insecureSkipVerifyEnv, "true")
+	defer os.Unsetenv(insecureSkipVerifyEnv)
+	c = NewSplunkClient(server.URL, "admin", "p@ssw0rd", nil)
+	if _, err = c.GetSearchPeers(); err != nil {
+		t.Errorf("SplunkClient with %s returned %v; want nil", insecureSkipVerifyEnv, err)
+	}
+	os.Unsetenv(insecureSkipVerifyEnv)
+
+	// operator-wide CA bundle
+	dir, err := ioutil.TempDir("", "splunk-tls")
+	if err != nil {
+		t.Fatalf("TempDir() returned %v", err)
+	}
+	defer os.RemoveAll(dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	if err = ioutil.WriteFile(caFile, caBundle, 0600); err != nil {
+		t.Fatalf("WriteFile() returned %v", err)
+	}
+	os.Setenv(caFileEnv, caFile)
+	defer os.Unsetenv(caFileEnv)
+	c = NewSplunkClient(server.URL, "admin", "p@ssw0rd", nil)
+	if _, err = c.GetSearchPeers(); err != nil {
+		t.Errorf("SplunkClient with %s returned %v; want nil", caFileEnv, err)
+	}
+	os.Setenv(caFileEnv, filepath.Join(dir, "missing.crt"))
+	if _, err = NewTLSConfig(nil, nil, nil); err == nil {
+		t.Errorf("NewTLSConfig() with missing %s returned nil; want error", caFileEnv)
+	}
+	os.Unsetenv(caFileEnv)
+
+	// invalid CA bundles and client certificates
+	if _, err = NewTLSConfig([]byte("invalid"), nil, nil); err == nil {
+		t.Errorf("NewTLSConfig() with invalid CA bundle returned nil; want error")
+	}
+	if _, err = NewTLSConfig(caBundle, caBundle, nil); err == nil {
+		t.Errorf("NewTLSConfig() with invalid client certificate returned nil; want error")
+	}
+}
@@ -0,0 +1,381 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+const (
+	// AppFrameworkStatusPort is the port used by the app framework sidecar to report the status of installed apps
+	AppFrameworkStatusPort = 9090
+
+	// appRepositoryPort is the port used by the app repository to serve app packages
+	appRepositoryPort = 8080
+
+	// appFrameworkCommand runs the app framework script using whichever python is available in the Splunk image
+	appFrameworkCommand = "exec $(command -v python3 || command -v python || echo /opt/splunk/bin/splunk cmd python) /mnt/splunk-app-framework/appframework.py"
+)
+
+// appNameRegex matches valid names for Splunk apps
+var appNameRegex = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]*$`)
+
+// appChecksumRegex matches SHA-256 checksums
+var appChecksumRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// appFrameworkManifest is the list of apps that the app framework sidecar installs on a Splunk instance
+type appFrameworkManifest struct {
+	// Directory apps are installed into: "apps", "master-apps" or "shcluster"
+	Target string `json:"target"`
+
+	// Management URI of the search head cluster member used to push the deployer's bundle
+	PushTarget string `json:"pushTarget,omitempty"`
+
+	// Apps to install
+	Apps []appFrameworkManifestApp `json:"apps"`
+}
+
+// appFrameworkManifestApp is an app listed in an appFrameworkManifest
+type appFrameworkManifestApp struct {
+	Name     string `json:"name"`
+	Version  string `json:"version,omitempty"`
+	URL      string `json:"url"`
+	Checksum string `json:"checksum,omitempty"`
+}
+
+// validateAppSources checks validity and updates defaults for a list of app sources
+func validateAppSources(sources []enterprisev1.AppSource) error {
+	names := make(map[string]bool)
+	for idx := range sources {
+		source := &sources[idx]
+		if !appNameRegex.MatchString(source.Name) {
+			return fmt.Errorf("Invalid app name \"%s\"", source.Name)
+		}
+		if names[source.Name] {
+			return fmt.Errorf("Duplicate app name \"%s\"", source.Name)
+		}
+		names[source.Name] = true
+
+		locations := 0
+		if source.ConfigMap != nil {
+			locations++
+			if source.ConfigMap.Name == "" || source.ConfigMap.Key == "" {
+				return fmt.Errorf("App %s: configMap requires a name and key", source.Name)
+			}
+		}
+		if source.PersistentVolumeClaim != nil {
+			locations++
+			source.PersistentVolumeClaim.Path = strings.TrimLeft(source.PersistentVolumeClaim.Path, "/")
+			if source.PersistentVolumeClaim.ClaimName == "" || source.PersistentVolumeClaim.Path == "" {
+				return fmt.Errorf("App %s: persistentVolumeClaim requires a claimName and path", source.Name)
+			}
+			for _, part := range strings.Split(source.PersistentVolumeClaim.Path, "/") {
+				if part == ".." {
+					return fmt.Errorf("App %s: persistentVolumeClaim path must not contain \"..\"", source.Name)
+				}
+			}
+		}
+		if source.URL != "" {
+			locations++
+			if !strings.HasPrefix(source.URL, "http://") && !strings.HasPrefix(source.URL, "https://") {
+				return fmt.Errorf("App %s: url must be an http:// or https:// URL", source.Name)
+			}
+		}
+		if locations != 1 {
+			return fmt.Errorf("App %s: exactly one of configMap, persistentVolumeClaim or url is required", source.Name)
+		}
+
+		source.Checksum = strings.ToLower(source.Checksum)
+		if source.Checksum != "" && !appChecksumRegex.MatchString(source.Checksum) {
+			return fmt.Errorf("App %s: checksum must be a SHA-256 hex digest", source.Name)
+		}
+	}
+	return nil
+}
+
+// IsAppFrameworkEnabled returns true if the status of apps installed by the app framework sidecar must be tracked. Note that
+// the sidecar itself is always present, so that adding or removing app sources does not change the pod template. Status
+// is tracked after all app sources have been removed, until the sidecar reports that all of the installed apps are gone.
+func IsAppFrameworkEnabled(sources []enterprisev1.AppSource, status []enterprisev1.AppTargetStatus) bool {
+	return len(sources) > 0 || len(status) > 0
+}
+
+// NeedsAppRepository returns true if any of the app sources must be served by an app repository
+func NeedsAppRepository(sources []enterprisev1.AppSource) bool {
+	for _, source := range sources {
+		if source.ConfigMap != nil || source.PersistentVolumeClaim != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// getAppFrameworkTarget returns the directory used to install apps for a type of Splunk instance.
+// Apps for clusters are installed into bundles, so that they are pushed to all members.
+func getAppFrameworkTarget(instanceType InstanceType) string {
+	switch instanceType {
+	case SplunkClusterMaster:
+		return "master-apps"
+	case SplunkDeployer:
+		return "shcluster"
+	}
+	return "apps"
+}
+
+// getAppSourceURL returns the URL used by the app framework sidecar to download an app package
+func getAppSourceURL(cr enterprisev1.MetaObject, instanceType InstanceType, source *enterprisev1.AppSource) string {
+	repositoryURL := fmt.Sprintf("http://%s:%d", resources.GetServiceFQDN(cr.GetNamespace(), GetSplunkAppRepositoryName(cr.GetIdentifier(), instanceType)), appRepositoryPort)
+	if source.ConfigMap != nil {
+		return fmt.Sprintf("%s/configmaps/%s/%s", repositoryURL, source.ConfigMap.Name, source.ConfigMap.Key)
+	}
+	if source.PersistentVolumeClaim != nil {
+		return fmt.Sprintf("%s/volumes/%s/%s", repositoryURL, source.PersistentVolumeClaim.ClaimName, source.PersistentVolumeClaim.Path)
+	}
+	return source.URL
+}
+
+// GetAppFrameworkConfigMap returns a Kubernetes ConfigMap containing the list of apps that the app framework sidecar
+// installs on a type of Splunk instance, along with the script used to install them.
+func GetAppFrameworkConfigMap(cr enterprisev1.MetaObject, instanceType InstanceType, sources []enterprisev1.AppSource) (*corev1.ConfigMap, error) {
+	manifest := appFrameworkManifest{
+		Target: getAppFrameworkTarget(instanceType),
+		Apps:   []appFrameworkManifestApp{},
+	}
+	if instanceType == SplunkDeployer {
+		manifest.PushTarget = fmt.Sprintf("https://%s:8089", GetSplunkStatefulsetURL(cr.GetNamespace(), SplunkSearchHead, cr.GetIdentifier(), 0, false))
+	}
+	for idx := range sources {
+		manifest.Apps = append(manifest.Apps, appFrameworkManifestApp{
+			Name:     sources[idx].Name,
+			Version:  sources[idx].Version,
+			URL:      getAppSourceURL(cr, instanceType, &sources[idx]),
+			Checksum: sources[idx].Checksum,
+		})
+	}
+	data, err := json.Marshal(&manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkAppFrameworkName(cr.GetIdentifier(), instanceType),
+			Namespace: cr.GetNamespace(),
+		},
+		Data: map[string]string{
+			"apps.json":       string(data),
+			"appframework.py": appFrameworkScript,
+		},
+	}
+	configMap.SetOwnerReferences(append(configMap.GetOwnerReferences(), resources.AsOwner(cr)))
+	return configMap, nil
+}
+
+// getAppFrameworkVolume returns a Kubernetes Volume used to mount the app framework ConfigMap
+func getAppFrameworkVolume(cr enterprisev1.MetaObject, instanceType InstanceType) corev1.Volume {
+	configMapVolDefaultMode := int32(corev1.ConfigMapVolumeSourceDefaultMode)
+	return corev1.Volume{
+		Name: "mnt-splunk-app-framework",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetSplunkAppFrameworkName(cr.GetIdentifier(), instanceType),
+				},
+				DefaultMode: &configMapVolDefaultMode,
+			},
+		},
+	}
+}
+
+// getAppFrameworkResources returns the resource requirements used by app framework containers
+func getAppFrameworkResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("0.1"),
+			corev1.ResourceMemory: resource.MustParse("128Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("1"),
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+		},
+	}
+}
+
+// GetAppRepositoryDeployment returns a Kubernetes Deployment that serves app packages stored in ConfigMaps and
+// PersistentVolumeClaims to the app framework sidecar for a type of Splunk instance.
+func GetAppRepositoryDeployment(cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, instanceType InstanceType, sources []enterprisev1.AppSource) *appsv1.Deployment {
+	selectLabels := resources.GetLabels(instanceType.ToKind(), instanceType.ToString()+"-app-repository", cr.GetIdentifier())
+	labels := make(map[string]string)
+	for k, v := range selectLabels {
+		labels[k] = v
+	}
+
+	// mount each ConfigMap and PersistentVolumeClaim once, using paths that match getAppSourceURL()
+	configMapVolDefaultMode := int32(corev1.ConfigMapVolumeSourceDefaultMode)
+	volumes := []corev1.Volume{getAppFrameworkVolume(cr, instanceType)}
+	volumeMounts := []corev1.VolumeMount{{Name: "mnt-splunk-app-framework", MountPath: "/mnt/splunk-app-framework"}}
+	mounted := make(map[string]bool)
+	for _, source := range sources {
+		var volume corev1.Volume
+		var mountPath string
+		if source.ConfigMap != nil {
+			mountPath = "/mnt/apps/configmaps/" + source.ConfigMap.Name
+			volume.VolumeSource.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: source.ConfigMap.LocalObjectReference,
+				DefaultMode:          &configMapVolDefaultMode,
+			}
+		} else if source.PersistentVolumeClaim != nil {
+			mountPath = "/mnt/apps/volumes/" + source.PersistentVolumeClaim.ClaimName
+			volume.VolumeSource.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: source.PersistentVolumeClaim.ClaimName,
+				ReadOnly:  true,
+			}
+		} else {
+			continue
+		}
+		if mounted[mountPath] {
+			continue
+		}
+		mounted[mountPath] = true
+		volume.Name = fmt.Sprintf("app-source-%d", len(volumes)-1)
+		volumes = append(volumes, volume)
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: volume.Name, MountPath: mountPath, ReadOnly: true})
+	}
+
+	replicas := int32(1)
+	runAsUser := int64(41812)
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkAppRepositoryName(cr.GetIdentifier(), instanceType),
+			Namespace: cr.GetNamespace(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectLabels,
+			},
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Tolerations:     spec.Tolerations,
+					SchedulerName:   spec.SchedulerName,
+					SecurityContext: &corev1.PodSecurityContext{RunAsUser: &runAsUser},
+					Volumes:         volumes,
+					Containers: []corev1.Container{
+						{
+							Image:           spec.Image,
+							ImagePullPolicy: corev1.PullPolicy(spec.ImagePullPolicy),
+							Name:            "app-repository",
+							Command:         []string{"sh", "-c", fmt.Sprintf("%s serve /mnt/apps %d", appFrameworkCommand, appRepositoryPort)},
+							Ports: []corev1.ContainerPort{
+								{Name: "http", ContainerPort: appRepositoryPort, Protocol: corev1.ProtocolTCP},
+							},
+							VolumeMounts: volumeMounts,
+							Resources:    getAppFrameworkResources(),
+							ReadinessProbe: &corev1.Probe{
+								Handler: corev1.Handler{
+									TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromInt(appRepositoryPort)},
+								},
+								InitialDelaySeconds: 5,
+								PeriodSeconds:       5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// append labels and annotations from parent
+	resources.AppendParentMeta(deployment.Spec.Template.GetObjectMeta(), cr.GetObjectMeta())
+
+	// make Splunk Enterprise object the owner
+	deployment.SetOwnerReferences(append(deployment.GetOwnerReferences(), resources.AsOwner(cr)))
+
+	return deployment
+}
+
+// GetAppRepositoryService returns a Kubernetes Service for the app repository of a type of Splunk instance.
+func GetAppRepositoryService(cr enterprisev1.MetaObject, instanceType InstanceType) *corev1.Service {
+	selectLabels := resources.GetLabels(instanceType.ToKind(), instanceType.ToString()+"-app-repository", cr.GetIdentifier())
+	labels := make(map[string]string)
+	for k, v := range selectLabels {
+		labels[k] = v
+	}
+
+	service := &corev1.Service{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Service",
+			APIVersion: "v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkAppRepositoryName(cr.GetIdentifier(), instanceType),
+			Namespace: cr.GetNamespace(),
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: selectLabels,
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: appRepositoryPort, TargetPort: intstr.FromInt(appRepositoryPort), Protocol: corev1.ProtocolTCP},
+			},
+		},
+	}
+	service.SetOwnerReferences(append(service.GetOwnerReferences(), resources.AsOwner(cr)))
+	return service
+}
+
+// addAppFrameworkToPodTemplate adds the app framework sidecar container to a pod template. Note that this must be
+// called after all other volumes have been added, since it shares volumes with the splunk container.
+func addAppFrameworkToPodTemplate(podTemplateSpec *corev1.PodTemplateSpec, cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, instanceType InstanceType) {
+	podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, getAppFrameworkVolume(cr, instanceType))
+
+	volumeMounts := append(getSplunkVolumeMounts(),
+		corev1.VolumeMount{Name: "mnt-splunk-secrets", MountPath: "/mnt/splunk-secrets"},
+		corev1.VolumeMount{Name: "mnt-splunk-app-framework", MountPath: "/mnt/splunk-app-framework"},
+	)
+	podTemplateSpec.Spec.Containers = append(podTemplateSpec.Spec.Containers, corev1.Container{
+		Image:           spec.Image,
+		ImagePullPolicy: corev1.PullPolicy(spec.ImagePullPolicy),
+		Name:            "app-framework",
+		Command:         []string{"sh", "-c", appFrameworkCommand + " sync"},
+		Ports: []corev1.ContainerPort{
+			{Name: "app-framework", ContainerPort: AppFrameworkStatusPort, Protocol: corev1.ProtocolTCP},
+		},
+		Env: []corev1.EnvVar{
+			{Name: "SPLUNK_HOME", Value: "/opt/splunk"},
+		},
+		VolumeMounts: volumeMounts,
+		Resources:    getAppFrameworkResources(),
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+// appFrameworkScript is run by the app framework sidecar container. In "sync" mode, it installs the apps listed in
+// the app framework manifest, removes apps that are no longer listed, triggers a bundle push (or restart) when apps
+// change, and reports the installed apps on its status port. In "serve" mode, it serves app packages over HTTP for
+// the app repository.
+//
+// Note that this script must remain compatible with both python 2.7 and python 3.
+const appFrameworkScript = `#!/usr/bin/env python
+# Generated by the Splunk Operator; do not edit.
+import base64
+import hashlib
+import json
+import os
+import shutil
+import ssl
+import subprocess
+import sys
+import tarfile
+import tempfile
+import threading
+import time
+
+try:
+    from urllib.request import Request, urlopen
+    from http.server import BaseHTTPRequestHandler, HTTPServer, SimpleHTTPRequestHandler
+except ImportError:
+    from urllib2 import Request, urlopen
+    from BaseHTTPServer import BaseHTTPRequestHandler, HTTPServer
+    from SimpleHTTPServer import SimpleHTTPRequestHandler
+
+SPLUNK_HOME = os.environ.get("SPLUNK_HOME", "/opt/splunk")
+MANIFEST_PATH = "/mnt/splunk-app-framework/apps.json"
+PASSWORD_PATH = "/mnt/splunk-secrets/password"
+STATE_PATH = os.path.join(SPLUNK_HOME, "etc", ".splunk-operator-apps.json")
+TARGET_DIRS = {
+    "apps": os.path.join(SPLUNK_HOME, "etc", "apps"),
+    "master-apps": os.path.join(SPLUNK_HOME, "etc", "master-apps"),
+    "shcluster": os.path.join(SPLUNK_HOME, "etc", "shcluster", "apps"),
+}
+SYNC_INTERVAL = 15
+STATUS_PORT = 9090
+
+status_lock = threading.Lock()
+status = {"apps": [], "errors": {}, "pendingPush": False}
+
+
+def log(message):
+    sys.stdout.write("%s app-framework: %s\n" % (time.strftime("%Y-%m-%dT%H:%M:%S"), message))
+    sys.stdout.flush()
+
+
+def read_json(path, default):
+    try:
+        with open(path) as f:
+            return json.load(f)
+    except (IOError, OSError, ValueError):
+        return default
+
+
+def write_json(path, obj):
+    tmp = path + ".tmp"
+    with open(tmp, "w") as f:
+        json.dump(obj, f)
+    os.rename(tmp, path)
+
+
+def splunkd(path, data=None):
+    with open(PASSWORD_PATH) as f:
+        password = f.read().strip()
+    request = Request("https://127.0.0.1:8089" + path, data=data)
+    auth = base64.b64encode(("admin:%s" % password).encode("utf-8")).decode("ascii")
+    request.add_header("Authorization", "Basic %s" % auth)
+    context = ssl._create_unverified_context()  # local management port uses a self-signed certificate
+    return urlopen(request, timeout=60, context=context).read()
+
+
+def download(app, directory):
+    path = os.path.join(directory, "package")
+    digest = hashlib.sha256()
+    response = urlopen(app["url"], timeout=300)
+    with open(path, "wb") as f:
+        while True:
+            chunk = response.read(1024 * 1024)
+            if not chunk:
+                break
+            digest.update(chunk)
+            f.write(chunk)
+    checksum = digest.hexdigest()
+    if app.get("checksum") and app["checksum"] != checksum:
+        raise Exception("checksum %s does not match expected %s" % (checksum, app["checksum"]))
+    return path, checksum
+
+
+def extract(app, package, directory):
+    name = app["name"]
+    with tarfile.open(package, "r:*") as tar:
+        members = tar.getmembers()
+        for member in members:
+            path = os.path.normpath(member.name)
+            if path != name and not path.startswith(name + os.sep):
+                raise Exception("package contains %s, which is outside of app directory %s" % (member.name, name))
+            if not (member.isfile() or member.isdir()):
+                raise Exception("package contains %s, which is not a regular file or directory" % member.name)
+        tar.extractall(directory, members)
+    return os.path.join(directory, name)
+
+
+def install(app, target_dir):
+    staging = tempfile.mkdtemp(prefix=".staging-", dir=target_dir)
+    try:
+        package, checksum = download(app, staging)
+        extracted = extract(app, package, staging)
+        destination = os.path.join(target_dir, app["name"])
+        if os.path.exists(destination):
+            os.rename(destination, os.path.join(staging, "previous"))
+        os.rename(extracted, destination)
+        return checksum
+    finally:
+        shutil.rmtree(staging, ignore_errors=True)
+
+
+def push(manifest):
+    target = manifest.get("target")
+    if target == "master-apps":
+        log("applying cluster bundle")
+        splunkd("/services/cluster/master/control/default/apply", b"")
+    elif target == "shcluster":
+        log("applying search head cluster bundle to %s" % manifest["pushTarget"])
+        with open(PASSWORD_PATH) as f:
+            password = f.read().strip()
+        subprocess.check_call([os.path.join(SPLUNK_HOME, "bin", "splunk"), "apply", "shcluster-bundle",
+                               "-target", manifest["pushTarget"], "--answer-yes", "-auth", "admin:%s" % password])
+    else:
+        log("restarting splunkd to load apps")
+        splunkd("/services/server/control/restart", b"")
+
+
+def sync():
+    manifest = read_json(MANIFEST_PATH, None)
+    if manifest is None:
+        return
+    target_dir = TARGET_DIRS[manifest.get("target", "apps")]
+    if not os.path.isdir(target_dir):
+        os.makedirs(target_dir)
+    state = read_json(STATE_PATH, {"apps": {}, "pendingPush": False})
+    errors = {}
+    wanted = {}
+    for app in manifest.get("apps", []):
+        wanted[app["name"]] = app
+        installed = state["apps"].get(app["name"])
+        if installed and installed["url"] == app["url"] and installed.get("version") == app.get("version") and \
+                (not app.get("checksum") or installed["checksum"] == app["checksum"]):
+            continue
+        try:
+            log("installing app %s version %s" % (app["name"], app.get("version", "")))
+            checksum = install(app, target_dir)
+            state["apps"][app["name"]] = {"url": app["url"], "version": app.get("version", ""), "checksum": checksum}
+            state["pendingPush"] = True
+            write_json(STATE_PATH, state)
+        except Exception as e:
+            log("failed to install app %s: %s" % (app["name"], e))
+            errors[app["name"]] = str(e)
+    for name in list(state["apps"].keys()):
+        if name not in wanted:
+            log("removing app %s" % name)
+            shutil.rmtree(os.path.join(target_dir, name), ignore_errors=True)
+            del state["apps"][name]
+            state["pendingPush"] = True
+            write_json(STATE_PATH, state)
+    if state["pendingPush"]:
+        try:
+            push(manifest)
+            state["pendingPush"] = False
+            write_json(STATE_PATH, state)
+        except Exception as e:
+            log("failed to push apps: %s" % e)
+            errors[""] = "push failed: %s" % e
+    apps = []
+    for name in sorted(state["apps"].keys()):
+        installed = state["apps"][name]
+        apps.append({"name": name, "version": installed.get("version", ""), "checksum": installed["checksum"]})
+    with status_lock:
+        status["apps"] = apps
+        status["errors"] = errors
+        status["pendingPush"] = state["pendingPush"]
+
+
+class StatusHandler(BaseHTTPRequestHandler):
+    def do_GET(self):
+        if self.path != "/status":
+            self.send_error(404)
+            return
+        with status_lock:
+            body = json.dumps(status).encode("utf-8")
+        self.send_response(200)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(body)))
+        self.end_headers()
+        self.wfile.write(body)
+
+    def log_message(self, format, *args):
+        pass
+
+
+def run_sync():
+    server = HTTPServer(("", STATUS_PORT), StatusHandler)
+    thread = threading.Thread(target=server.serve_forever)
+    thread.daemon = True
+    thread.start()
+    while True:
+        try:
+            splunkd("/services/server/info")
+        except Exception as e:
+            log("waiting for splunkd: %s" % e)
+            time.sleep(SYNC_INTERVAL)
+            continue
+        try:
+            sync()
+        except Exception as e:
+            log("failed to sync apps: %s" % e)
+        time.sleep(SYNC_INTERVAL)
+
+
+def run_serve(directory, port):
+    os.chdir(directory)
+    HTTPServer(("", port), SimpleHTTPRequestHandler).serve_forever()
+
+
+if __name__ == "__main__":
+    if len(sys.argv) > 1 and sys.argv[1] == "serve":
+        run_serve(sys.argv[2], int(sys.argv[3]))
+    else:
+        run_sync()
+`
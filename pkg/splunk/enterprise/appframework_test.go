@@ -0,0 +1,220 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"encoding/json"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func getTestAppSources() []enterprisev1.AppSource {
+	return []enterprisev1.AppSource{
+		{
+			Name:    "app1",
+			Version: "1.0",
+			ConfigMap: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "apps"},
+				Key:                  "app1.tgz",
+			},
+		}, {
+			Name:                  "app2",
+			PersistentVolumeClaim: &enterprisev1.AppSourceVolume{ClaimName: "packages", Path: "/app2/app2-2.1.tgz"},
+		}, {
+			Name:     "app3",
+			Version:  "3",
+			URL:      "https://bucket.s3.amazonaws.com/app3.spl",
+			Checksum: "5E884898DA28047151D0E56F8DC6292773603D0D6AABBDD62A11EF721D1542D8",
+		}, {
+			Name: "app4",
+			ConfigMap: &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "apps"},
+				Key:                  "app4.tgz",
+			},
+		},
+	}
+}
+
+func TestValidateAppSources(t *testing.T) {
+	sources := getTestAppSources()
+	if err := validateAppSources(sources); err != nil {
+		t.Errorf("validateAppSources() returned error: %v", err)
+	}
+	if sources[1].PersistentVolumeClaim.Path != "app2/app2-2.1.tgz" {
+		t.Errorf("validateAppSources() path = %s; want %s", sources[1].PersistentVolumeClaim.Path, "app2/app2-2.1.tgz")
+	}
+	if sources[2].Checksum != "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8" {
+		t.Errorf("validateAppSources() checksum = %s; want lowercase", sources[2].Checksum)
+	}
+
+	test := func(name string, update func(sources []enterprisev1.AppSource)) {
+		revised := getTestAppSources()
+		update(revised)
+		if err := validateAppSources(revised); err == nil {
+			t.Errorf("validateAppSources() did not return error for %s", name)
+		}
+	}
+	test("invalid name", func(sources []enterprisev1.AppSource) { sources[0].Name = "../etc" })
+	test("duplicate name", func(sources []enterprisev1.AppSource) { sources[1].Name = "app1" })
+	test("no location", func(sources []enterprisev1.AppSource) { sources[0].ConfigMap = nil })
+	test("multiple locations", func(sources []enterprisev1.AppSource) { sources[2].ConfigMap = sources[0].ConfigMap })
+	test("configMap without key", func(sources []enterprisev1.AppSource) { sources[0].ConfigMap.Key = "" })
+	test("pvc without path", func(sources []enterprisev1.AppSource) { sources[1].PersistentVolumeClaim.Path = "/" })
+	test("pvc path outside volume", func(sources []enterprisev1.AppSource) { sources[1].PersistentVolumeClaim.Path = "../app2.tgz" })
+	test("invalid url", func(sources []enterprisev1.AppSource) { sources[2].URL = "s3://bucket/app3.spl" })
+	test("invalid checksum", func(sources []enterprisev1.AppSource) { sources[2].Checksum = "abc123" })
+}
+
+func TestGetAppFrameworkConfigMap(t *testing.T) {
+	cr := enterprisev1.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	sources := getTestAppSources()
+	if err := validateAppSources(sources); err != nil {
+		t.Errorf("validateAppSources() returned error: %v", err)
+	}
+
+	test := func(instanceType InstanceType, want string) {
+		configMap, err := GetAppFrameworkConfigMap(&cr, instanceType, sources)
+		if err != nil {
+			t.Errorf("GetAppFrameworkConfigMap(%s) returned error: %v", instanceType, err)
+			return
+		}
+		if configMap.GetName() != GetSplunkAppFrameworkName("stack1", instanceType) {
+			t.Errorf("GetAppFrameworkConfigMap(%s) name = %s; want %s", instanceType, configMap.GetName(), GetSplunkAppFrameworkName("stack1", instanceType))
+		}
+		if configMap.Data["apps.json"] != want {
+			t.Errorf("GetAppFrameworkConfigMap(%s) apps.json = %s; want %s", instanceType, configMap.Data["apps.json"], want)
+		}
+		if configMap.Data["appframework.py"] != appFrameworkScript {
+			t.Errorf("GetAppFrameworkConfigMap(%s) is missing appframework.py", instanceType)
+		}
+	}
+
+	test(SplunkDeployer, `{"target":"shcluster","pushTarget":"https://splunk-stack1-search-head-0.splunk-stack1-search-head-headless.test.svc.cluster.local:8089","apps":[{"name":"app1","version":"1.0","url":"http://splunk-stack1-deployer-app-repository.test.svc.cluster.local:8080/configmaps/apps/app1.tgz"},{"name":"app2","url":"http://splunk-stack1-deployer-app-repository.test.svc.cluster.local:8080/volumes/packages/app2/app2-2.1.tgz"},{"name":"app3","version":"3","url":"https://bucket.s3.amazonaws.com/app3.spl","checksum":"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"},{"name":"app4","url":"http://splunk-stack1-deployer-app-repository.test.svc.cluster.local:8080/configmaps/apps/app4.tgz"}]}`)
+
+	sources = sources[2:3]
+	test(SplunkClusterMaster, `{"target":"master-apps","apps":[{"name":"app3","version":"3","url":"https://bucket.s3.amazonaws.com/app3.spl","checksum":"5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"}]}`)
+
+	sources = nil
+	test(SplunkStandalone, `{"target":"apps","apps":[]}`)
+}
+
+func TestGetAppRepositoryDeployment(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	spec := enterprisev1.CommonSplunkSpec{}
+	spec.Image = "splunk/splunk"
+	sources := getTestAppSources()
+	if NeedsAppRepository(sources[2:3]) {
+		t.Errorf("NeedsAppRepository() = true for url source; want false")
+	}
+	if !NeedsAppRepository(sources) {
+		t.Errorf("NeedsAppRepository() = false for configMap and persistentVolumeClaim sources; want true")
+	}
+
+	deployment := GetAppRepositoryDeployment(&cr, &spec, SplunkStandalone, sources)
+	if deployment.GetName() != "splunk-stack1-standalone-app-repository" {
+		t.Errorf("GetAppRepositoryDeployment() name = %s; want %s", deployment.GetName(), "splunk-stack1-standalone-app-repository")
+	}
+	wantMounts := map[string]string{
+		"mnt-splunk-app-framework": "/mnt/splunk-app-framework",
+		"app-source-0":             "/mnt/apps/configmaps/apps",
+		"app-source-1":             "/mnt/apps/volumes/packages",
+	}
+	volumeMounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	if len(deployment.Spec.Template.Spec.Volumes) != len(wantMounts) || len(volumeMounts) != len(wantMounts) {
+		t.Errorf("GetAppRepositoryDeployment() volumes = %d, mounts = %d; want %d", len(deployment.Spec.Template.Spec.Volumes), len(volumeMounts), len(wantMounts))
+	}
+	for _, volumeMount := range volumeMounts {
+		if wantMounts[volumeMount.Name] != volumeMount.MountPath {
+			t.Errorf("GetAppRepositoryDeployment() volume %s mounted at %s; want %s", volumeMount.Name, volumeMount.MountPath, wantMounts[volumeMount.Name])
+		}
+	}
+	if claim := deployment.Spec.Template.Spec.Volumes[2].PersistentVolumeClaim; claim == nil || claim.ClaimName != "packages" || !claim.ReadOnly {
+		t.Errorf("GetAppRepositoryDeployment() volume 2 = %v; want read-only claim packages", claim)
+	}
+
+	service := GetAppRepositoryService(&cr, SplunkStandalone)
+	if service.GetName() != deployment.GetName() || service.Spec.Selector["app.kubernetes.io/instance"] != "splunk-stack1-standalone-app-repository" {
+		t.Errorf("GetAppRepositoryService() = %s %v; want selector for %s", service.GetName(), service.Spec.Selector, deployment.GetName())
+	}
+}
+
+func TestAddAppFrameworkToPodTemplate(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	if err := ValidateStandaloneSpec(&cr.Spec); err != nil {
+		t.Errorf("ValidateStandaloneSpec() returned error: %v", err)
+	}
+
+	test := func() string {
+		ss, err := GetStandaloneStatefulSet(&cr)
+		if err != nil {
+			t.Errorf("GetStandaloneStatefulSet() returned error: %v", err)
+			return ""
+		}
+		template, _ := json.Marshal(ss.Spec.Template)
+		containers := ss.Spec.Template.Spec.Containers
+		if len(containers) != 2 {
+			t.Errorf("GetStandaloneStatefulSet() containers = %d; want %d", len(containers), 2)
+			return string(template)
+		}
+		sidecar := containers[1]
+		if sidecar.Name != "app-framework" || sidecar.Image != cr.Spec.Image {
+			t.Errorf("GetStandaloneStatefulSet() sidecar = %s,%s; want %s,%s", sidecar.Name, sidecar.Image, "app-framework", cr.Spec.Image)
+		}
+		wantMounts := []string{"/opt/splunk/etc", "/opt/splunk/var", "/mnt/splunk-secrets", "/mnt/splunk-app-framework"}
+		for idx, want := range wantMounts {
+			if idx >= len(sidecar.VolumeMounts) || sidecar.VolumeMounts[idx].MountPath != want {
+				t.Errorf("GetStandaloneStatefulSet() sidecar mounts = %v; want %v", sidecar.VolumeMounts, wantMounts)
+				break
+			}
+		}
+		for _, volumeMount := range containers[0].VolumeMounts {
+			if volumeMount.Name == "mnt-splunk-app-framework" {
+				t.Errorf("GetStandaloneStatefulSet() mounted app framework ConfigMap in splunk container")
+			}
+		}
+		return string(template)
+	}
+
+	// sidecar is present without apps, so that adding or removing apps does not change the pod template
+	want := test()
+	cr.Spec.AppSources = getTestAppSources()
+	if got := test(); got != want {
+		t.Errorf("GetStandaloneStatefulSet() pod template changed after adding apps: %s; want %s", got, want)
+	}
+	cr.Spec.AppSources = nil
+	cr.Status.Apps = []enterprisev1.AppTargetStatus{{Target: "splunk-stack1-standalone-0", Apps: []enterprisev1.AppStatus{{Name: "app1"}}}}
+	if got := test(); got != want {
+		t.Errorf("GetStandaloneStatefulSet() pod template changed after removing apps: %s; want %s", got, want)
+	}
+}
@@ -15,35 +15,50 @@
 package enterprise
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
+	"strings"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/yaml"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
 	"github.com/splunk/splunk-operator/pkg/splunk/resources"
 	"github.com/splunk/splunk-operator/pkg/splunk/spark"
 )
 
+const (
+	// defaultsChecksumAnnotation is used in pod templates to record the checksum of the defaults and defaultsUrl used by their pods
+	defaultsChecksumAnnotation = "enterprise.splunk.com/defaults-checksum"
+
+	// tlsCertificatesAnnotation is used in pod templates to record that splunkd is configured when its pods start to use the
+	// TLS certificates in their secrets. Pods created by older versions of the operator, which did not generate certificates,
+	// are recycled when it is added.
+	tlsCertificatesAnnotation = "enterprise.splunk.com/tls-certificates"
+)
+
 // getSplunkLabels returns a map of labels to use for Splunk Enterprise components.
 func getSplunkLabels(identifier string, instanceType InstanceType) map[string]string {
 	return resources.GetLabels(instanceType.ToKind(), instanceType.ToString(), identifier)
 }
 
 // getSplunkVolumeClaims returns a standard collection of Kubernetes volume claims.
-func getSplunkVolumeClaims(cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, labels map[string]string) ([]corev1.PersistentVolumeClaim, error) {
+func getSplunkVolumeClaims(cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, instanceType InstanceType, labels map[string]string) ([]corev1.PersistentVolumeClaim, error) {
 	var etcStorage, varStorage resource.Quantity
 	var err error
 
-	etcStorage, err = resources.ParseResourceQuantity(spec.EtcStorage, "10Gi")
+	etcStorage, err = resources.ParseResourceQuantity(spec.EtcStorage, defaultEtcStorage)
 	if err != nil {
 		return []corev1.PersistentVolumeClaim{}, fmt.Errorf("%s: %s", "etcStorage", err)
 	}
 
-	varStorage, err = resources.ParseResourceQuantity(spec.VarStorage, "100Gi")
+	varStorage, err = resources.ParseResourceQuantity(spec.VarStorage, defaultVarStorage)
 	if err != nil {
 		return []corev1.PersistentVolumeClaim{}, fmt.Errorf("%s: %s", "varStorage", err)
 	}
@@ -87,6 +102,18 @@ func getSplunkVolumeClaims(cr enterprisev1.MetaObject, spec *enterprisev1.Common
 		}
 	}
 
+	// seed new volumes from the VolumeSnapshots of a backup
+	if spec.RestoreFrom != "" && IsSplunkBackupInstanceType(instanceType) {
+		apiGroup := volumeSnapshotGroup
+		for idx := range volumeClaims {
+			volumeClaims[idx].Spec.DataSource = &corev1.TypedLocalObjectReference{
+				APIGroup: &apiGroup,
+				Kind:     volumeSnapshotKind,
+				Name:     GetSplunkVolumeSnapshotName(spec.RestoreFrom, volumeClaims[idx].GetName()),
+			}
+		}
+	}
+
 	return volumeClaims, nil
 }
 
@@ -104,6 +131,17 @@ func GetStandaloneStatefulSet(cr *enterprisev1.Standalone) (*appsv1.StatefulSet,
 		addDFCToPodTemplate(&ss.Spec.Template, cr.Spec.SparkRef, cr.Spec.SparkImage, cr.Spec.ImagePullPolicy, cr.Spec.Replicas > 1)
 	}
 
+	// add generated defaults for SmartStore
+	if len(cr.Spec.SmartStore.Volumes) > 0 {
+		addSmartStoreToPodTemplate(&ss.Spec.Template, cr, SplunkStandalone)
+	}
+
+	// add indexes defined by SplunkIndex resources
+	addSplunkIndexesToPodTemplate(&ss.Spec.Template, cr, SplunkStandalone)
+
+	// add sidecar used to install apps; this is always present, so that changes to appSources do not recreate pods
+	addAppFrameworkToPodTemplate(&ss.Spec.Template, cr, &cr.Spec.CommonSplunkSpec, SplunkStandalone)
+
 	return ss, nil
 }
 
@@ -133,17 +171,66 @@ func GetSearchHeadStatefulSet(cr *enterprisev1.SearchHeadCluster) (*appsv1.State
 
 // GetIndexerStatefulSet returns a Kubernetes StatefulSet object for Splunk Enterprise indexers.
 func GetIndexerStatefulSet(cr *enterprisev1.IndexerCluster) (*appsv1.StatefulSet, error) {
-	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer, cr.Spec.Replicas, getIndexerExtraEnv(cr, cr.Spec.Replicas))
+	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer, cr.Spec.Replicas, getIndexerExtraEnv(cr))
+}
+
+// GetIndexerClusterMasterStatefulSet returns a Kubernetes StatefulSet object for the Splunk Enterprise cluster master
+// that is created by an indexer cluster when clusterMasterRef is not used.
+func GetIndexerClusterMasterStatefulSet(cr *enterprisev1.IndexerCluster) (*appsv1.StatefulSet, error) {
+	return getClusterMasterStatefulSet(cr, &cr.Spec.CommonSplunkSpec, getIndexerExtraEnv(cr), len(cr.Spec.Sites) > 0, &cr.Spec.SmartStore)
 }
 
-// GetClusterMasterStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise license master.
-func GetClusterMasterStatefulSet(cr *enterprisev1.IndexerCluster) (*appsv1.StatefulSet, error) {
-	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkClusterMaster, 1, getIndexerExtraEnv(cr, cr.Spec.Replicas))
+// GetClusterMasterStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise cluster master.
+func GetClusterMasterStatefulSet(cr *enterprisev1.ClusterMaster) (*appsv1.StatefulSet, error) {
+	return getClusterMasterStatefulSet(cr, &cr.Spec.CommonSplunkSpec, []corev1.EnvVar{}, len(cr.Spec.Sites) > 0, &cr.Spec.SmartStore)
+}
+
+// getClusterMasterStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise cluster master.
+func getClusterMasterStatefulSet(cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, extraEnv []corev1.EnvVar, multisite bool,
+	smartstore *enterprisev1.SmartStoreSpec) (*appsv1.StatefulSet, error) {
+	ss, err := getSplunkStatefulSet(cr, spec, SplunkClusterMaster, 1, extraEnv)
+	if err != nil {
+		return nil, err
+	}
+
+	// add generated defaults for multisite clusters
+	if multisite {
+		configMapVolDefaultMode := int32(corev1.ConfigMapVolumeSourceDefaultMode)
+		addSplunkDefaultsToTemplate(&ss.Spec.Template, "multisite", corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetSplunkMultisiteDefaultsName(cr.GetIdentifier()),
+				},
+				DefaultMode: &configMapVolDefaultMode,
+			},
+		})
+	}
+
+	// add generated defaults for SmartStore, which are pushed to the peers using the cluster bundle
+	if len(smartstore.Volumes) > 0 {
+		addSmartStoreToPodTemplate(&ss.Spec.Template, cr, SplunkClusterMaster)
+	}
+
+	// add indexes defined by SplunkIndex resources, which are pushed to the peers using the cluster bundle
+	addSplunkIndexesToPodTemplate(&ss.Spec.Template, cr, SplunkClusterMaster)
+
+	// add sidecar used to install apps into the cluster bundle
+	addAppFrameworkToPodTemplate(&ss.Spec.Template, cr, spec, SplunkClusterMaster)
+
+	return ss, nil
 }
 
 // GetDeployerStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise license master.
 func GetDeployerStatefulSet(cr *enterprisev1.SearchHeadCluster) (*appsv1.StatefulSet, error) {
-	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkDeployer, 1, getSearchHeadExtraEnv(cr, cr.Spec.Replicas))
+	ss, err := getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkDeployer, 1, getSearchHeadExtraEnv(cr, cr.Spec.Replicas))
+	if err != nil {
+		return nil, err
+	}
+
+	// add sidecar used to install apps into the search head cluster bundle
+	addAppFrameworkToPodTemplate(&ss.Spec.Template, cr, &cr.Spec.CommonSplunkSpec, SplunkDeployer)
+
+	return ss, nil
 }
 
 // GetLicenseMasterStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise license master.
@@ -151,6 +238,11 @@ func GetLicenseMasterStatefulSet(cr *enterprisev1.LicenseMaster) (*appsv1.Statef
 	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkLicenseMaster, 1, []corev1.EnvVar{})
 }
 
+// GetMonitoringConsoleStatefulSet returns a Kubernetes StatefulSet object for a Splunk Enterprise monitoring console.
+func GetMonitoringConsoleStatefulSet(cr *enterprisev1.MonitoringConsole) (*appsv1.StatefulSet, error) {
+	return getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkMonitoringConsole, 1, []corev1.EnvVar{})
+}
+
 // GetSplunkService returns a Kubernetes Service object for Splunk instances configured for a Splunk Enterprise resource.
 func GetSplunkService(cr enterprisev1.MetaObject, spec enterprisev1.CommonSpec, instanceType InstanceType, isHeadless bool) *corev1.Service {
 
@@ -201,6 +293,79 @@ func GetSplunkService(cr enterprisev1.MetaObject, spec enterprisev1.CommonSpec,
 	return service
 }
 
+// GetSplunkPodDisruptionBudget returns a Kubernetes PodDisruptionBudget for the pods of a StatefulSet, with the same name
+// as the StatefulSet. Unless overridden by the spec, at most maxUnavailable pods may be disrupted at once.
+func GetSplunkPodDisruptionBudget(cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, statefulSet *appsv1.StatefulSet, maxUnavailable int) *policyv1beta1.PodDisruptionBudget {
+	value := intstr.FromInt(maxUnavailable)
+	if spec.MaxUnavailable != nil {
+		value = *spec.MaxUnavailable
+	}
+
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "PodDisruptionBudget",
+			APIVersion: "policy/v1beta1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statefulSet.GetName(),
+			Namespace: statefulSet.GetNamespace(),
+			Labels:    make(map[string]string),
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &value,
+			Selector:       statefulSet.Spec.Selector.DeepCopy(),
+		},
+	}
+
+	// append same labels as selector
+	for k, v := range statefulSet.Spec.Selector.MatchLabels {
+		pdb.ObjectMeta.Labels[k] = v
+	}
+
+	// append labels and annotations from parent
+	resources.AppendParentMeta(pdb.GetObjectMeta(), cr.GetObjectMeta())
+
+	pdb.SetOwnerReferences(append(pdb.GetOwnerReferences(), resources.AsOwner(cr)))
+
+	return pdb
+}
+
+// GetIndexerReplicationFactor returns the replication factor of an indexer cluster, given the spec of its cluster master.
+// This is the replication_factor in its inline defaults, if any, or else the default replication factor of splunk-ansible.
+func GetIndexerReplicationFactor(spec *enterprisev1.CommonSplunkSpec) int {
+	var defaults struct {
+		Splunk struct {
+			Idxc struct {
+				ReplicationFactor int `json:"replication_factor"`
+			} `json:"idxc"`
+		} `json:"splunk"`
+	}
+	if err := yaml.Unmarshal([]byte(spec.Defaults), &defaults); err != nil || defaults.Splunk.Idxc.ReplicationFactor < 1 {
+		return defaultReplicationFactor
+	}
+	return defaults.Splunk.Idxc.ReplicationFactor
+}
+
+// GetIndexerMaxUnavailable returns the default maximum number of indexers that may be disrupted at once, which is
+// one less than the replication factor, and at least one so that nodes can still be drained. Multisite clusters have a
+// PodDisruptionBudget for each site, and the copies of a bucket are not necessarily spread evenly across sites, so only
+// one indexer of each site may be disrupted.
+func GetIndexerMaxUnavailable(cr *enterprisev1.IndexerCluster, replicationFactor int) int {
+	if len(cr.Spec.Sites) > 0 || replicationFactor < 3 {
+		return 1
+	}
+	return replicationFactor - 1
+}
+
+// GetSearchHeadMaxUnavailable returns the default maximum number of search heads that may be disrupted at once, which
+// leaves a majority of the search head cluster members available to elect a captain
+func GetSearchHeadMaxUnavailable(replicas int32) int {
+	if replicas > 2 {
+		return int(replicas-1) / 2
+	}
+	return 1
+}
+
 // setVolumeDefaults set properties in Volumes to default values
 func setVolumeDefaults(spec *enterprisev1.CommonSplunkSpec) {
 
@@ -263,26 +428,128 @@ func validateCommonSplunkSpec(spec *enterprisev1.CommonSplunkSpec) error {
 		},
 	}
 
+	// make sure storage capacities can be parsed, and set defaults if not provided
+	if _, err := resources.ParseResourceQuantity(spec.EtcStorage, defaultEtcStorage); err != nil {
+		return fmt.Errorf("etcStorage: %s", err)
+	}
+	if _, err := resources.ParseResourceQuantity(spec.VarStorage, defaultVarStorage); err != nil {
+		return fmt.Errorf("varStorage: %s", err)
+	}
+	if spec.EtcStorage == "" {
+		spec.EtcStorage = defaultEtcStorage
+	}
+	if spec.VarStorage == "" {
+		spec.VarStorage = defaultVarStorage
+	}
+	if spec.MaxUnavailable != nil {
+		if value, err := intstr.GetValueFromIntOrPercent(spec.MaxUnavailable, 100, false); err != nil || value < 0 {
+			return fmt.Errorf("maxUnavailable must be a non-negative integer or percentage; got \"%s\"", spec.MaxUnavailable.String())
+		}
+	}
+	if spec.VolumeReclaimPolicy == "" {
+		spec.VolumeReclaimPolicy = enterprisev1.VolumeReclaimDelete
+	}
+	if spec.RetainedVolumeGracePeriodHours < 0 {
+		return fmt.Errorf("retainedVolumeGracePeriodHours must not be negative; got %d", spec.RetainedVolumeGracePeriodHours)
+	}
+
+	// restored volumes only work with the secrets of the resource that was backed up, which are not in the backup
+	if spec.RestoreFrom != "" && spec.SecretRef == "" {
+		return fmt.Errorf("restoreFrom requires secretRef, referring to a Secret with the values of the resource that was backed up")
+	}
+
 	setVolumeDefaults(spec)
 	setServiceTemplateDefaults(spec)
 
 	return resources.ValidateCommonSpec(&spec.CommonSpec, defaultResources)
 }
 
+// ValidateCommonSplunkSpecUpdate checks that changes made to the common spec of a Splunk Enterprise resource are allowed.
+// Both specs are expected to have already been validated (and updated with defaults).
+func ValidateCommonSplunkSpecUpdate(current, revised *enterprisev1.CommonSplunkSpec) error {
+	if current.StorageClassName != revised.StorageClassName {
+		return fmt.Errorf("storageClassName cannot be changed from \"%s\" to \"%s\"", current.StorageClassName, revised.StorageClassName)
+	}
+
+	storage := []struct {
+		name             string
+		current, revised string
+		defaultCapacity  string
+	}{
+		{"etcStorage", current.EtcStorage, revised.EtcStorage, defaultEtcStorage},
+		{"varStorage", current.VarStorage, revised.VarStorage, defaultVarStorage},
+	}
+	for _, s := range storage {
+		currentCapacity, err := resources.ParseResourceQuantity(s.current, s.defaultCapacity)
+		if err != nil {
+			continue // allow invalid capacities to be fixed
+		}
+		revisedCapacity, err := resources.ParseResourceQuantity(s.revised, s.defaultCapacity)
+		if err != nil {
+			return fmt.Errorf("%s: %s", s.name, err)
+		}
+		if revisedCapacity.Cmp(currentCapacity) < 0 {
+			return fmt.Errorf("%s cannot be reduced from %s to %s", s.name, currentCapacity.String(), revisedCapacity.String())
+		}
+	}
+
+	return nil
+}
+
 // ValidateIndexerClusterSpec checks validity and makes default updates to a IndexerClusterSpec, and returns error if something is wrong.
 func ValidateIndexerClusterSpec(spec *enterprisev1.IndexerClusterSpec) error {
 	if spec.Replicas == 0 {
 		spec.Replicas = 1
 	}
+	if err := validateIndexerClusterSites(spec); err != nil {
+		return err
+	}
+	if spec.ClusterMasterRef.Name != "" {
+		// these are configured on the cluster master, and pushed to the peers using its bundle
+		if len(spec.SmartStore.Volumes) > 0 || len(spec.SmartStore.Indexes) > 0 {
+			return fmt.Errorf("smartstore must be configured on ClusterMaster %s when using clusterMasterRef", spec.ClusterMasterRef.Name)
+		}
+		if len(spec.AppSources) > 0 {
+			return fmt.Errorf("appSources must be configured on ClusterMaster %s when using clusterMasterRef", spec.ClusterMasterRef.Name)
+		}
+		if spec.RestoreFrom != "" {
+			return fmt.Errorf("restoreFrom must be configured on ClusterMaster %s when using clusterMasterRef", spec.ClusterMasterRef.Name)
+		}
+	}
+	if err := validateSmartStoreSpec(&spec.SmartStore); err != nil {
+		return err
+	}
+	if err := validateAppSources(spec.AppSources); err != nil {
+		return err
+	}
+	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
+}
+
+// ValidateClusterMasterSpec checks validity and makes default updates to a ClusterMasterSpec, and returns error if something is wrong.
+func ValidateClusterMasterSpec(spec *enterprisev1.ClusterMasterSpec) error {
+	if err := validateClusterMasterSites(spec); err != nil {
+		return err
+	}
+	if err := validateSmartStoreSpec(&spec.SmartStore); err != nil {
+		return err
+	}
+	if err := validateAppSources(spec.AppSources); err != nil {
+		return err
+	}
 	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
 }
 
 // ValidateSearchHeadClusterSpec checks validity and makes default updates to a SearchHeadClusterSpec, and returns error if something is wrong.
 func ValidateSearchHeadClusterSpec(spec *enterprisev1.SearchHeadClusterSpec) error {
-	if spec.Replicas < 3 {
+	if spec.Replicas == 0 {
 		spec.Replicas = 3
+	} else if spec.Replicas < 3 {
+		return fmt.Errorf("SearchHeadCluster requires at least 3 replicas; got %d", spec.Replicas)
 	}
 	spec.SparkImage = spark.GetSparkImage(spec.SparkImage)
+	if err := validateAppSources(spec.AppSources); err != nil {
+		return err
+	}
 	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
 }
 
@@ -291,7 +558,16 @@ func ValidateStandaloneSpec(spec *enterprisev1.StandaloneSpec) error {
 	if spec.Replicas == 0 {
 		spec.Replicas = 1
 	}
+	if spec.RestoreFrom != "" && spec.Replicas > 1 {
+		return fmt.Errorf("restoreFrom is only supported for a single standalone instance")
+	}
 	spec.SparkImage = spark.GetSparkImage(spec.SparkImage)
+	if err := validateSmartStoreSpec(&spec.SmartStore); err != nil {
+		return err
+	}
+	if err := validateAppSources(spec.AppSources); err != nil {
+		return err
+	}
 	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
 }
 
@@ -300,6 +576,14 @@ func ValidateLicenseMasterSpec(spec *enterprisev1.LicenseMasterSpec) error {
 	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
 }
 
+// ValidateMonitoringConsoleSpec checks validity and makes default updates to a MonitoringConsoleSpec, and returns error if something is wrong.
+func ValidateMonitoringConsoleSpec(spec *enterprisev1.MonitoringConsoleSpec) error {
+	if spec.RestoreFrom != "" {
+		return fmt.Errorf("restoreFrom is not supported for MonitoringConsole")
+	}
+	return validateCommonSplunkSpec(&spec.CommonSplunkSpec)
+}
+
 // GetSplunkDefaults returns a Kubernetes ConfigMap containing defaults for a Splunk Enterprise resource.
 func GetSplunkDefaults(identifier, namespace string, instanceType InstanceType, defaults string) *corev1.ConfigMap {
 	return &corev1.ConfigMap{
@@ -313,6 +597,12 @@ func GetSplunkDefaults(identifier, namespace string, instanceType InstanceType,
 	}
 }
 
+// splunkSecretKeys are the keys of Splunk secrets that may be provided by a user-provided Secret referenced by secretRef
+var splunkSecretKeys = []string{"hec_token", "password", "pass4SymmKey", "idxc_secret", "shc_secret"}
+
+// requiredSplunkSecretKeys are the keys that must be included in a user-provided Secret referenced by secretRef
+var requiredSplunkSecretKeys = []string{"password"}
+
 // GetSplunkSecrets returns a Kubernetes Secret containing randomly generated default secrets to use for a Splunk Enterprise resource.
 func GetSplunkSecrets(cr enterprisev1.MetaObject, instanceType InstanceType, idxcSecret []byte, pass4SymmKey []byte) *corev1.Secret {
 	// idxc_secret is option, and may be used to override random generation
@@ -333,31 +623,88 @@ func GetSplunkSecrets(cr enterprisev1.MetaObject, instanceType InstanceType, idx
 		"idxc_secret":  idxcSecret,
 		"shc_secret":   generateSplunkSecret(),
 	}
-	secretData["default.yml"] = []byte(fmt.Sprintf(`
+	secretData["default.yml"] = getSplunkSecretsDefaults(secretData)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkSecretsName(cr.GetIdentifier(), instanceType),
+			Namespace: cr.GetNamespace(),
+		},
+		Data: secretData,
+	}
+}
+
+// GetChangedSplunkSecrets validates a user-provided Secret referenced by secretRef, and returns its values that differ from
+// those in a Kubernetes Secret created by GetSplunkSecrets, keyed by name.
+func GetChangedSplunkSecrets(secrets *corev1.Secret, userSecrets *corev1.Secret) (map[string][]byte, error) {
+	for _, key := range requiredSplunkSecretKeys {
+		if _, ok := userSecrets.Data[key]; !ok {
+			return nil, fmt.Errorf("Secret %s is missing required key %s", userSecrets.GetName(), key)
+		}
+	}
+	for _, key := range splunkSecretKeys {
+		if value, ok := userSecrets.Data[key]; ok && len(value) == 0 {
+			return nil, fmt.Errorf("Secret %s has an empty value for %s", userSecrets.GetName(), key)
+		}
+	}
+
+	changed := make(map[string][]byte)
+	for _, key := range splunkSecretKeys {
+		value, ok := userSecrets.Data[key]
+		if ok && !bytes.Equal(secrets.Data[key], value) {
+			changed[key] = value
+		}
+	}
+	return changed, nil
+}
+
+// MergeSplunkSecrets overrides the values in a Kubernetes Secret created by GetSplunkSecrets with those provided by a user-provided
+// Secret referenced by secretRef, and rebuilds default.yml from the merged values. It returns true if any values were changed.
+func MergeSplunkSecrets(secrets *corev1.Secret, userSecrets *corev1.Secret) (bool, error) {
+	changed, err := GetChangedSplunkSecrets(secrets, userSecrets)
+	if err != nil {
+		return false, err
+	}
+	if len(changed) > 0 {
+		UpdateSplunkSecrets(secrets, changed)
+	}
+	return len(changed) > 0, nil
+}
+
+// getSplunkSecretsDefaults returns the contents of a default.yml file used to pass secrets to Splunk Enterprise containers.
+// If secretData includes a generated server certificate, splunkd is configured to use it for its management port.
+// Values are quoted and escaped, since user-provided secrets may include any characters.
+func getSplunkSecretsDefaults(secretData map[string][]byte) []byte {
+	defaults := fmt.Sprintf(`
 splunk:
     hec_disabled: 0
     hec_enableSSL: 0
-    hec_token: "%s"
-    password: "%s"
-    pass4SymmKey: "%s"
+    hec_token: %q
+    password: %q
+    pass4SymmKey: %q
     idxc:
-        secret: "%s"
+        secret: %q
     shc:
-        secret: "%s"
+        secret: %q
 `,
 		secretData["hec_token"],
 		secretData["password"],
 		secretData["pass4SymmKey"],
 		secretData["idxc_secret"],
-		secretData["shc_secret"]))
-
-	return &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      GetSplunkSecretsName(cr.GetIdentifier(), instanceType),
-			Namespace: cr.GetNamespace(),
-		},
-		Data: secretData,
+		secretData["shc_secret"])
+
+	if len(secretData["server.pem"]) > 0 {
+		defaults += `    conf:
+        server:
+            directory: /opt/splunk/etc/system/local
+            content:
+                sslConfig:
+                    serverCert: /mnt/splunk-secrets/server.pem
+                    sslRootCAPath: /mnt/splunk-secrets/ca.crt
+`
 	}
+
+	return []byte(defaults)
 }
 
 // generateSplunkSecret returns a randomly generated Splunk secret.
@@ -460,6 +807,23 @@ func addSplunkVolumeToTemplate(podTemplateSpec *corev1.PodTemplateSpec, name str
 	}
 }
 
+// addSplunkDefaultsToTemplate adds a volume containing a generated default.yml file to all containers in a pod template,
+// and includes it in SPLUNK_DEFAULTS_URL after the secrets but before any defaults provided by the spec
+func addSplunkDefaultsToTemplate(podTemplateSpec *corev1.PodTemplateSpec, name string, volumeSource corev1.VolumeSource) {
+	addSplunkVolumeToTemplate(podTemplateSpec, name, volumeSource)
+
+	secretsDefaults := "/mnt/splunk-secrets/default.yml"
+	for idx := range podTemplateSpec.Spec.Containers {
+		env := podTemplateSpec.Spec.Containers[idx].Env
+		for e := range env {
+			if env[e].Name == "SPLUNK_DEFAULTS_URL" {
+				env[e].Value = strings.Replace(env[e].Value, secretsDefaults,
+					fmt.Sprintf("%s,/mnt/splunk-%s/default.yml", secretsDefaults, name), 1)
+			}
+		}
+	}
+}
+
 // addDFCToPodTemplate modifies the podTemplateSpec object to incorporate support for DFS.
 func addDFCToPodTemplate(podTemplateSpec *corev1.PodTemplateSpec, sparkRef corev1.ObjectReference, sparkImage string, imagePullPolicy string, slotsEnabled bool) {
 	// create an init container in the pod, which is just used to populate the jdk and spark mount directories
@@ -531,7 +895,7 @@ func getSplunkStatefulSet(cr enterprisev1.MetaObject, spec *enterprisev1.CommonS
 	}
 
 	// prepare volume claims
-	volumeClaims, err := getSplunkVolumeClaims(cr, spec, labels)
+	volumeClaims, err := getSplunkVolumeClaims(cr, spec, instanceType, labels)
 	if err != nil {
 		return nil, err
 	}
@@ -651,6 +1015,18 @@ func updateSplunkPodTemplateWithConfig(podTemplateSpec *corev1.PodTemplateSpec,
 		})
 	}
 
+	// splunkd only uses the TLS certificates in secrets once pods are started with them
+	if podTemplateSpec.ObjectMeta.Annotations == nil {
+		podTemplateSpec.ObjectMeta.Annotations = make(map[string]string)
+	}
+	podTemplateSpec.ObjectMeta.Annotations[tlsCertificatesAnnotation] = "secrets"
+
+	// defaults are only applied when pods start, so their checksum is included in the pod template to recycle pods
+	// when they change (note that changes to environment variables alone do not update pod templates)
+	if spec.Defaults != "" || spec.DefaultsURL != "" {
+		podTemplateSpec.ObjectMeta.Annotations[defaultsChecksumAnnotation] = fmt.Sprintf("%x", sha256.Sum256([]byte(spec.DefaultsURL+"\n"+spec.Defaults)))
+	}
+
 	// update security context
 	runAsUser := int64(41812)
 	fsGroup := int64(41812)
@@ -728,7 +1104,12 @@ func updateSplunkPodTemplateWithConfig(podTemplateSpec *corev1.PodTemplateSpec,
 
 	// append URL for cluster master, if configured
 	var clusterMasterURL string
-	if instanceType == SplunkIndexer {
+	if instanceType != SplunkClusterMaster && spec.ClusterMasterRef.Name != "" {
+		clusterMasterURL = GetSplunkServiceName(SplunkClusterMaster, spec.ClusterMasterRef.Name, false)
+		if spec.ClusterMasterRef.Namespace != "" {
+			clusterMasterURL = resources.GetServiceFQDN(spec.ClusterMasterRef.Namespace, clusterMasterURL)
+		}
+	} else if instanceType == SplunkIndexer {
 		clusterMasterURL = GetSplunkServiceName(SplunkClusterMaster, cr.GetIdentifier(), false)
 	} else if instanceType != SplunkClusterMaster && spec.IndexerClusterRef.Name != "" {
 		clusterMasterURL = GetSplunkServiceName(SplunkClusterMaster, spec.IndexerClusterRef.Name, false)
@@ -768,12 +1149,23 @@ func getSearchHeadExtraEnv(cr enterprisev1.MetaObject, replicas int32) []corev1.
 	}
 }
 
-// getIndexerExtraEnv returns extra environment variables used by search head clusters
-func getIndexerExtraEnv(cr enterprisev1.MetaObject, replicas int32) []corev1.EnvVar {
+// getIndexerExtraEnv returns extra environment variables used by indexer clusters
+func getIndexerExtraEnv(cr *enterprisev1.IndexerCluster) []corev1.EnvVar {
+	indexerURLs := GetSplunkStatefulsetUrls(cr.GetNamespace(), SplunkIndexer, cr.GetIdentifier(), cr.Spec.Replicas, false)
+	if len(cr.Spec.Sites) > 0 {
+		urls := []string{}
+		for _, site := range cr.Spec.Sites {
+			for n := int32(0); n < site.Replicas; n++ {
+				urls = append(urls, GetSplunkSiteStatefulsetURL(cr.GetNamespace(), SplunkIndexer, cr.GetIdentifier(), site.Name, n, false))
+			}
+		}
+		indexerURLs = strings.Join(urls, ",")
+	}
+
 	return []corev1.EnvVar{
 		{
 			Name:  "SPLUNK_INDEXER_URL",
-			Value: GetSplunkStatefulsetUrls(cr.GetNamespace(), SplunkIndexer, cr.GetIdentifier(), replicas, false),
+			Value: indexerURLs,
 		},
 	}
 }
@@ -0,0 +1,70 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"fmt"
+	"strings"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+// splitStatefulsetUrls returns a list of fully qualified domain names for all pods within a Splunk StatefulSet.
+func splitStatefulsetUrls(namespace string, instanceType InstanceType, identifier string, replicas int32) []string {
+	if replicas < 1 {
+		return []string{}
+	}
+	return strings.Split(GetSplunkStatefulsetUrls(namespace, instanceType, identifier, replicas, false), ",")
+}
+
+// GetMonitoringConsolePeers returns the fully qualified domain names of all Splunk Enterprise instances
+// managed by a custom resource, which are added as search peers of a monitoring console.
+// The custom resource's spec is expected to have been validated, so that defaults have been applied.
+func GetMonitoringConsolePeers(cr enterprisev1.MetaObject) ([]string, error) {
+	namespace := cr.GetNamespace()
+	identifier := cr.GetIdentifier()
+
+	switch cr := cr.(type) {
+	case *enterprisev1.Standalone:
+		return splitStatefulsetUrls(namespace, SplunkStandalone, identifier, cr.Spec.Replicas), nil
+
+	case *enterprisev1.LicenseMaster:
+		return splitStatefulsetUrls(namespace, SplunkLicenseMaster, identifier, 1), nil
+
+	case *enterprisev1.ClusterMaster:
+		return splitStatefulsetUrls(namespace, SplunkClusterMaster, identifier, 1), nil
+
+	case *enterprisev1.SearchHeadCluster:
+		peers := splitStatefulsetUrls(namespace, SplunkDeployer, identifier, 1)
+		return append(peers, splitStatefulsetUrls(namespace, SplunkSearchHead, identifier, cr.Spec.Replicas)...), nil
+
+	case *enterprisev1.IndexerCluster:
+		peers := []string{}
+		if cr.Spec.ClusterMasterRef.Name == "" {
+			peers = splitStatefulsetUrls(namespace, SplunkClusterMaster, identifier, 1)
+		}
+		if len(cr.Spec.Sites) == 0 {
+			return append(peers, splitStatefulsetUrls(namespace, SplunkIndexer, identifier, cr.Spec.Replicas)...), nil
+		}
+		for _, site := range cr.Spec.Sites {
+			for n := int32(0); n < site.Replicas; n++ {
+				peers = append(peers, GetSplunkSiteStatefulsetURL(namespace, SplunkIndexer, identifier, site.Name, n, false))
+			}
+		}
+		return peers, nil
+	}
+
+	return nil, fmt.Errorf("Monitoring console peers are not supported for kind %s", cr.GetTypeMeta().Kind)
+}
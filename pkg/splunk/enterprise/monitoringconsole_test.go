@@ -0,0 +1,75 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestGetMonitoringConsolePeers(t *testing.T) {
+	test := func(cr enterprisev1.MetaObject, want []string) {
+		got, err := GetMonitoringConsolePeers(cr)
+		if err != nil {
+			t.Errorf("GetMonitoringConsolePeers(%s) returned error: %v", cr.GetIdentifier(), err)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetMonitoringConsolePeers(%s) = %v; want %v", cr.GetIdentifier(), got, want)
+		}
+	}
+	objectMeta := metav1.ObjectMeta{Name: "stack1", Namespace: "test"}
+
+	test(&enterprisev1.Standalone{ObjectMeta: objectMeta, Spec: enterprisev1.StandaloneSpec{Replicas: 2}}, []string{
+		"splunk-stack1-standalone-0.splunk-stack1-standalone-headless.test.svc.cluster.local",
+		"splunk-stack1-standalone-1.splunk-stack1-standalone-headless.test.svc.cluster.local",
+	})
+	test(&enterprisev1.LicenseMaster{ObjectMeta: objectMeta}, []string{
+		"splunk-stack1-license-master-0.splunk-stack1-license-master-headless.test.svc.cluster.local",
+	})
+	test(&enterprisev1.ClusterMaster{ObjectMeta: objectMeta}, []string{
+		"splunk-stack1-cluster-master-0.splunk-stack1-cluster-master-headless.test.svc.cluster.local",
+	})
+	test(&enterprisev1.SearchHeadCluster{ObjectMeta: objectMeta, Spec: enterprisev1.SearchHeadClusterSpec{Replicas: 3}}, []string{
+		"splunk-stack1-deployer-0.splunk-stack1-deployer-headless.test.svc.cluster.local",
+		"splunk-stack1-search-head-0.splunk-stack1-search-head-headless.test.svc.cluster.local",
+		"splunk-stack1-search-head-1.splunk-stack1-search-head-headless.test.svc.cluster.local",
+		"splunk-stack1-search-head-2.splunk-stack1-search-head-headless.test.svc.cluster.local",
+	})
+	test(&enterprisev1.IndexerCluster{ObjectMeta: objectMeta, Spec: enterprisev1.IndexerClusterSpec{Replicas: 1}}, []string{
+		"splunk-stack1-cluster-master-0.splunk-stack1-cluster-master-headless.test.svc.cluster.local",
+		"splunk-stack1-indexer-0.splunk-stack1-indexer-headless.test.svc.cluster.local",
+	})
+
+	// multisite indexer cluster using a separate cluster master
+	idxc := enterprisev1.IndexerCluster{ObjectMeta: objectMeta}
+	idxc.Spec.ClusterMasterRef = corev1.ObjectReference{Name: "cm"}
+	idxc.Spec.Sites = []enterprisev1.IndexerClusterSite{{Name: "site1", Replicas: 1}, {Name: "site2", Replicas: 2}}
+	test(&idxc, []string{
+		"splunk-stack1-indexer-site1-0.splunk-stack1-indexer-headless.test.svc.cluster.local",
+		"splunk-stack1-indexer-site2-0.splunk-stack1-indexer-headless.test.svc.cluster.local",
+		"splunk-stack1-indexer-site2-1.splunk-stack1-indexer-headless.test.svc.cluster.local",
+	})
+
+	// unsupported kinds return an error
+	if _, err := GetMonitoringConsolePeers(&enterprisev1.MonitoringConsole{ObjectMeta: objectMeta}); err == nil {
+		t.Errorf("GetMonitoringConsolePeers(MonitoringConsole) returned nil; want error")
+	}
+}
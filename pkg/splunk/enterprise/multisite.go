@@ -0,0 +1,292 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+const (
+	// siteLabel is added to the pods of multisite indexer clusters, to distinguish between sites
+	siteLabel = "enterprise.splunk.com/site"
+
+	// defaultZoneTopologyKey is the node label used to pin sites to zones, unless overridden
+	defaultZoneTopologyKey = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// siteNameRegex matches valid Splunk Enterprise site names (site1 through site63)
+var siteNameRegex = regexp.MustCompile(`^site([1-9]|[1-5][0-9]|6[0-3])$`)
+
+// siteFactor represents a parsed site_replication_factor or site_search_factor
+type siteFactor struct {
+	origin int
+	total  int
+}
+
+// parseSiteFactor parses a site replication or search factor of the form "origin:<n>,total:<n>"
+func parseSiteFactor(str string) (siteFactor, error) {
+	result := siteFactor{origin: 1}
+	for _, part := range strings.Split(str, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), ":", 2)
+		if len(kv) != 2 {
+			return result, fmt.Errorf("Invalid site factor \"%s\": expected origin:<n>,total:<n>", str)
+		}
+		value, err := strconv.Atoi(strings.TrimSpace(kv[1]))
+		if err != nil || value < 1 {
+			return result, fmt.Errorf("Invalid site factor \"%s\": %s must be a positive integer", str, kv[0])
+		}
+		switch strings.TrimSpace(kv[0]) {
+		case "origin":
+			result.origin = value
+		case "total":
+			result.total = value
+		default:
+			return result, fmt.Errorf("Invalid site factor \"%s\": only origin and total are supported", str)
+		}
+	}
+	if result.total == 0 {
+		return result, fmt.Errorf("Invalid site factor \"%s\": total is required", str)
+	}
+	if result.origin > result.total {
+		return result, fmt.Errorf("Invalid site factor \"%s\": origin must not be greater than total", str)
+	}
+	return result, nil
+}
+
+// validateIndexerClusterSites checks validity and updates defaults for the sites of a multisite indexer cluster
+func validateIndexerClusterSites(spec *enterprisev1.IndexerClusterSpec) error {
+	if len(spec.Sites) == 0 {
+		return nil
+	}
+
+	var replicas int32
+	siteNames := make(map[string]bool)
+	for idx := range spec.Sites {
+		site := &spec.Sites[idx]
+		if !siteNameRegex.MatchString(site.Name) {
+			return fmt.Errorf("Invalid site name \"%s\": must be one of site1 through site63", site.Name)
+		}
+		if siteNames[site.Name] {
+			return fmt.Errorf("Duplicate site name \"%s\"", site.Name)
+		}
+		siteNames[site.Name] = true
+		if site.Replicas < 1 {
+			site.Replicas = 1
+		}
+		replicas += site.Replicas
+	}
+
+	// total number of peers is the sum of all sites
+	spec.Replicas = replicas
+
+	defaultFactor := fmt.Sprintf("origin:1,total:%d", len(spec.Sites))
+	if spec.SiteReplicationFactor == "" {
+		spec.SiteReplicationFactor = defaultFactor
+	}
+	replicationFactor, err := parseSiteFactor(spec.SiteReplicationFactor)
+	if err != nil {
+		return fmt.Errorf("siteReplicationFactor: %v", err)
+	}
+	for _, site := range spec.Sites {
+		// each site must have enough peers to store all of the copies for data that originates there
+		if int(site.Replicas) < replicationFactor.origin {
+			return fmt.Errorf("Site %s must have at least %d replicas to satisfy siteReplicationFactor", site.Name, replicationFactor.origin)
+		}
+	}
+	if spec.SiteSearchFactor == "" {
+		spec.SiteSearchFactor = defaultFactor
+	}
+	if _, err := parseSiteFactor(spec.SiteSearchFactor); err != nil {
+		return fmt.Errorf("siteSearchFactor: %v", err)
+	}
+
+	if spec.ZoneTopologyKey == "" {
+		spec.ZoneTopologyKey = defaultZoneTopologyKey
+	}
+
+	return nil
+}
+
+// validateClusterMasterSites checks validity and updates defaults for the sites configured on a cluster master
+func validateClusterMasterSites(spec *enterprisev1.ClusterMasterSpec) error {
+	if len(spec.Sites) == 0 {
+		return nil
+	}
+
+	siteNames := make(map[string]bool)
+	for _, name := range spec.Sites {
+		if !siteNameRegex.MatchString(name) {
+			return fmt.Errorf("Invalid site name \"%s\": must be one of site1 through site63", name)
+		}
+		if siteNames[name] {
+			return fmt.Errorf("Duplicate site name \"%s\"", name)
+		}
+		siteNames[name] = true
+	}
+
+	defaultFactor := fmt.Sprintf("origin:1,total:%d", len(spec.Sites))
+	if spec.SiteReplicationFactor == "" {
+		spec.SiteReplicationFactor = defaultFactor
+	}
+	if _, err := parseSiteFactor(spec.SiteReplicationFactor); err != nil {
+		return fmt.Errorf("siteReplicationFactor: %v", err)
+	}
+	if spec.SiteSearchFactor == "" {
+		spec.SiteSearchFactor = defaultFactor
+	}
+	if _, err := parseSiteFactor(spec.SiteSearchFactor); err != nil {
+		return fmt.Errorf("siteSearchFactor: %v", err)
+	}
+
+	return nil
+}
+
+// GetIndexerClusterSiteNames returns the names of all the sites in a multisite indexer cluster
+func GetIndexerClusterSiteNames(cr *enterprisev1.IndexerCluster) []string {
+	siteNames := make([]string, len(cr.Spec.Sites))
+	for idx := range cr.Spec.Sites {
+		siteNames[idx] = cr.Spec.Sites[idx].Name
+	}
+	return siteNames
+}
+
+// GetIndexerStatefulSets returns a list of Kubernetes StatefulSet objects for Splunk Enterprise indexers.
+// A single StatefulSet is used unless sites are defined, in which case there is one StatefulSet per site.
+func GetIndexerStatefulSets(cr *enterprisev1.IndexerCluster) ([]*appsv1.StatefulSet, error) {
+	if len(cr.Spec.Sites) == 0 {
+		statefulSet, err := GetIndexerStatefulSet(cr)
+		if err != nil {
+			return nil, err
+		}
+		return []*appsv1.StatefulSet{statefulSet}, nil
+	}
+
+	statefulSets := []*appsv1.StatefulSet{}
+	for idx := range cr.Spec.Sites {
+		statefulSet, err := GetIndexerSiteStatefulSet(cr, &cr.Spec.Sites[idx])
+		if err != nil {
+			return nil, err
+		}
+		statefulSets = append(statefulSets, statefulSet)
+	}
+	return statefulSets, nil
+}
+
+// GetIndexerSiteStatefulSet returns a Kubernetes StatefulSet object for the Splunk Enterprise indexers within a site.
+func GetIndexerSiteStatefulSet(cr *enterprisev1.IndexerCluster, site *enterprisev1.IndexerClusterSite) (*appsv1.StatefulSet, error) {
+	env := append(getIndexerExtraEnv(cr), corev1.EnvVar{
+		Name:  "SPLUNK_SITE",
+		Value: site.Name,
+	})
+
+	// get generic statefulset for Splunk Enterprise objects
+	ss, err := getSplunkStatefulSet(cr, &cr.Spec.CommonSplunkSpec, SplunkIndexer, site.Replicas, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// each site has its own statefulset, but they all share the same headless service
+	ss.ObjectMeta.Name = GetSplunkSiteStatefulsetName(SplunkIndexer, cr.GetIdentifier(), site.Name)
+	ss.Spec.Selector.MatchLabels[siteLabel] = site.Name
+	ss.Spec.Template.ObjectMeta.Labels[siteLabel] = site.Name
+	for idx := range ss.Spec.VolumeClaimTemplates {
+		ss.Spec.VolumeClaimTemplates[idx].ObjectMeta.Labels[siteLabel] = site.Name
+	}
+
+	// pin pods for the site to its zone
+	if site.Zone != "" {
+		appendNodeAffinityForZone(&ss.Spec.Template, cr.Spec.ZoneTopologyKey, site.Zone)
+	}
+
+	return ss, nil
+}
+
+// appendNodeAffinityForZone updates a pod template to require nodes having a zone topology key with the given value
+func appendNodeAffinityForZone(podTemplateSpec *corev1.PodTemplateSpec, topologyKey, zone string) {
+	if topologyKey == "" {
+		topologyKey = defaultZoneTopologyKey
+	}
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      topologyKey,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   []string{zone},
+	}
+
+	affinity := podTemplateSpec.Spec.Affinity
+	if affinity.NodeAffinity == nil {
+		affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	if affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+
+	// node selector terms are ORed, so the zone requirement must be added to every term
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+	for idx := range selector.NodeSelectorTerms {
+		selector.NodeSelectorTerms[idx].MatchExpressions = append(selector.NodeSelectorTerms[idx].MatchExpressions, requirement)
+	}
+}
+
+// GetSplunkMultisiteDefaults returns a Kubernetes ConfigMap with a default.yml used to configure a multisite cluster master.
+func GetSplunkMultisiteDefaults(cr enterprisev1.MetaObject, siteNames []string, siteReplicationFactor, siteSearchFactor string) (*corev1.ConfigMap, error) {
+	replicationFactor, err := parseSiteFactor(siteReplicationFactor)
+	if err != nil {
+		return nil, err
+	}
+	searchFactor, err := parseSiteFactor(siteSearchFactor)
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := fmt.Sprintf(`
+splunk:
+    site: %s
+    all_sites: %s
+    multisite_master: %s
+    multisite_replication_factor_origin: %d
+    multisite_replication_factor_total: %d
+    multisite_search_factor_origin: %d
+    multisite_search_factor_total: %d
+`,
+		siteNames[0],
+		strings.Join(siteNames, ","),
+		GetSplunkServiceName(SplunkClusterMaster, cr.GetIdentifier(), false),
+		replicationFactor.origin,
+		replicationFactor.total,
+		searchFactor.origin,
+		searchFactor.total)
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkMultisiteDefaultsName(cr.GetIdentifier()),
+			Namespace: cr.GetNamespace(),
+		},
+		Data: map[string]string{
+			"default.yml": defaults,
+		},
+	}, nil
+}
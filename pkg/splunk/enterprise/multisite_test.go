@@ -0,0 +1,242 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestParseSiteFactor(t *testing.T) {
+	test := func(str string, wantOrigin, wantTotal int, wantErr bool) {
+		got, err := parseSiteFactor(str)
+		if (err != nil) != wantErr {
+			t.Errorf("parseSiteFactor(\"%s\") error = %v; want error %t", str, err, wantErr)
+			return
+		}
+		if !wantErr && (got.origin != wantOrigin || got.total != wantTotal) {
+			t.Errorf("parseSiteFactor(\"%s\") = %d,%d; want %d,%d", str, got.origin, got.total, wantOrigin, wantTotal)
+		}
+	}
+
+	test("origin:2,total:3", 2, 3, false)
+	test("total:2", 1, 2, false)
+	test(" origin : 1 , total : 2 ", 1, 2, false)
+	test("origin:2", 0, 0, true)
+	test("origin:3,total:2", 0, 0, true)
+	test("origin:1,site1:1,total:2", 0, 0, true)
+	test("origin:x,total:2", 0, 0, true)
+	test("total", 0, 0, true)
+}
+
+func TestValidateIndexerClusterSites(t *testing.T) {
+	spec := enterprisev1.IndexerClusterSpec{
+		Sites: []enterprisev1.IndexerClusterSite{
+			{Name: "site1", Replicas: 3, Zone: "us-west-2a"},
+			{Name: "site2", Zone: "us-west-2b"},
+		},
+	}
+	if err := ValidateIndexerClusterSpec(&spec); err != nil {
+		t.Errorf("ValidateIndexerClusterSpec() returned error: %v", err)
+	}
+	if spec.Replicas != 4 {
+		t.Errorf("ValidateIndexerClusterSpec() Replicas = %d; want %d", spec.Replicas, 4)
+	}
+	if spec.Sites[1].Replicas != 1 {
+		t.Errorf("ValidateIndexerClusterSpec() Sites[1].Replicas = %d; want %d", spec.Sites[1].Replicas, 1)
+	}
+	if spec.SiteReplicationFactor != "origin:1,total:2" || spec.SiteSearchFactor != "origin:1,total:2" {
+		t.Errorf("ValidateIndexerClusterSpec() factors = %s,%s; want origin:1,total:2", spec.SiteReplicationFactor, spec.SiteSearchFactor)
+	}
+	if spec.ZoneTopologyKey != defaultZoneTopologyKey {
+		t.Errorf("ValidateIndexerClusterSpec() ZoneTopologyKey = %s; want %s", spec.ZoneTopologyKey, defaultZoneTopologyKey)
+	}
+
+	// site does not have enough peers for its origin copies
+	spec.SiteReplicationFactor = "origin:2,total:3"
+	if err := ValidateIndexerClusterSpec(&spec); err == nil {
+		t.Errorf("ValidateIndexerClusterSpec() did not return error for site with too few replicas")
+	}
+
+	// invalid and duplicate site names
+	spec.SiteReplicationFactor = ""
+	spec.Sites[1].Name = "site1"
+	if err := ValidateIndexerClusterSpec(&spec); err == nil {
+		t.Errorf("ValidateIndexerClusterSpec() did not return error for duplicate site names")
+	}
+	spec.Sites[1].Name = "site64"
+	if err := ValidateIndexerClusterSpec(&spec); err == nil {
+		t.Errorf("ValidateIndexerClusterSpec() did not return error for invalid site name")
+	}
+}
+
+func TestGetIndexerStatefulSets(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	// single site uses a single statefulset
+	if err := ValidateIndexerClusterSpec(&cr.Spec); err != nil {
+		t.Errorf("ValidateIndexerClusterSpec() returned error: %v", err)
+	}
+	statefulSets, err := GetIndexerStatefulSets(&cr)
+	if err != nil {
+		t.Errorf("GetIndexerStatefulSets() returned error: %v", err)
+	}
+	if len(statefulSets) != 1 || statefulSets[0].GetName() != "splunk-stack1-indexer" {
+		t.Errorf("GetIndexerStatefulSets() returned %d statefulsets; want splunk-stack1-indexer", len(statefulSets))
+	}
+
+	// multisite uses one statefulset per site
+	cr.Spec.Sites = []enterprisev1.IndexerClusterSite{
+		{Name: "site1", Replicas: 2, Zone: "us-west-2a"},
+		{Name: "site2", Replicas: 1},
+	}
+	if err := ValidateIndexerClusterSpec(&cr.Spec); err != nil {
+		t.Errorf("ValidateIndexerClusterSpec() returned error: %v", err)
+	}
+	statefulSets, err = GetIndexerStatefulSets(&cr)
+	if err != nil {
+		t.Errorf("GetIndexerStatefulSets() returned error: %v", err)
+	}
+	if len(statefulSets) != 2 {
+		t.Fatalf("GetIndexerStatefulSets() returned %d statefulsets; want %d", len(statefulSets), 2)
+	}
+
+	wantURLs := "splunk-stack1-indexer-site1-0.splunk-stack1-indexer-headless.test.svc.cluster.local,splunk-stack1-indexer-site1-1.splunk-stack1-indexer-headless.test.svc.cluster.local,splunk-stack1-indexer-site2-0.splunk-stack1-indexer-headless.test.svc.cluster.local"
+	for idx, site := range cr.Spec.Sites {
+		ss := statefulSets[idx]
+		if ss.GetName() != "splunk-stack1-indexer-"+site.Name {
+			t.Errorf("GetIndexerStatefulSets()[%d] name = %s; want %s", idx, ss.GetName(), "splunk-stack1-indexer-"+site.Name)
+		}
+		if *ss.Spec.Replicas != site.Replicas {
+			t.Errorf("GetIndexerStatefulSets()[%d] replicas = %d; want %d", idx, *ss.Spec.Replicas, site.Replicas)
+		}
+		if ss.Spec.ServiceName != "splunk-stack1-indexer-headless" {
+			t.Errorf("GetIndexerStatefulSets()[%d] serviceName = %s; want %s", idx, ss.Spec.ServiceName, "splunk-stack1-indexer-headless")
+		}
+		if ss.Spec.Selector.MatchLabels[siteLabel] != site.Name || ss.Spec.Template.ObjectMeta.Labels[siteLabel] != site.Name {
+			t.Errorf("GetIndexerStatefulSets()[%d] site label missing; want %s", idx, site.Name)
+		}
+		env := map[string]string{}
+		for _, e := range ss.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		if env["SPLUNK_SITE"] != site.Name {
+			t.Errorf("GetIndexerStatefulSets()[%d] SPLUNK_SITE = %s; want %s", idx, env["SPLUNK_SITE"], site.Name)
+		}
+		if env["SPLUNK_INDEXER_URL"] != wantURLs {
+			t.Errorf("GetIndexerStatefulSets()[%d] SPLUNK_INDEXER_URL = %s; want %s", idx, env["SPLUNK_INDEXER_URL"], wantURLs)
+		}
+	}
+
+	// only sites with a zone are pinned to it
+	nodeAffinity := statefulSets[0].Spec.Template.Spec.Affinity.NodeAffinity
+	if nodeAffinity == nil {
+		t.Fatalf("GetIndexerStatefulSets()[0] has no node affinity")
+	}
+	requirement := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms[0].MatchExpressions[0]
+	if requirement.Key != defaultZoneTopologyKey || len(requirement.Values) != 1 || requirement.Values[0] != "us-west-2a" {
+		t.Errorf("GetIndexerStatefulSets()[0] node affinity = %v; want %s in us-west-2a", requirement, defaultZoneTopologyKey)
+	}
+	if statefulSets[1].Spec.Template.Spec.Affinity.NodeAffinity != nil {
+		t.Errorf("GetIndexerStatefulSets()[1] has unexpected node affinity")
+	}
+}
+
+func TestGetSplunkMultisiteDefaults(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.IndexerClusterSpec{
+			Sites: []enterprisev1.IndexerClusterSite{
+				{Name: "site1", Replicas: 2},
+				{Name: "site2", Replicas: 2},
+			},
+			SiteReplicationFactor: "origin:2,total:3",
+		},
+	}
+	if err := ValidateIndexerClusterSpec(&cr.Spec); err != nil {
+		t.Errorf("ValidateIndexerClusterSpec() returned error: %v", err)
+	}
+
+	configMap, err := GetSplunkMultisiteDefaults(&cr, GetIndexerClusterSiteNames(&cr), cr.Spec.SiteReplicationFactor, cr.Spec.SiteSearchFactor)
+	if err != nil {
+		t.Errorf("GetSplunkMultisiteDefaults() returned error: %v", err)
+	}
+	if configMap.GetName() != "splunk-stack1-cluster-master-multisite" {
+		t.Errorf("GetSplunkMultisiteDefaults() name = %s; want %s", configMap.GetName(), "splunk-stack1-cluster-master-multisite")
+	}
+	want := `
+splunk:
+    site: site1
+    all_sites: site1,site2
+    multisite_master: splunk-stack1-cluster-master-service
+    multisite_replication_factor_origin: 2
+    multisite_replication_factor_total: 3
+    multisite_search_factor_origin: 1
+    multisite_search_factor_total: 2
+`
+	if configMap.Data["default.yml"] != want {
+		t.Errorf("GetSplunkMultisiteDefaults() = %s; want %s", configMap.Data["default.yml"], want)
+	}
+
+	// cluster master includes the multisite defaults
+	ss, err := GetIndexerClusterMasterStatefulSet(&cr)
+	if err != nil {
+		t.Errorf("GetIndexerClusterMasterStatefulSet() returned error: %v", err)
+	}
+	found := false
+	for _, e := range ss.Spec.Template.Spec.Containers[0].Env {
+		if e.Name == "SPLUNK_DEFAULTS_URL" {
+			found = true
+			if !strings.HasPrefix(e.Value, "/mnt/splunk-secrets/default.yml,/mnt/splunk-multisite/default.yml") {
+				t.Errorf("GetIndexerClusterMasterStatefulSet() SPLUNK_DEFAULTS_URL = %s; want multisite defaults", e.Value)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("GetIndexerClusterMasterStatefulSet() SPLUNK_DEFAULTS_URL not found")
+	}
+}
+
+func TestValidateClusterMasterSites(t *testing.T) {
+	spec := enterprisev1.ClusterMasterSpec{Sites: []string{"site1", "site2", "site3"}}
+	if err := ValidateClusterMasterSpec(&spec); err != nil {
+		t.Errorf("ValidateClusterMasterSpec() returned error: %v", err)
+	}
+	if spec.SiteReplicationFactor != "origin:1,total:3" || spec.SiteSearchFactor != "origin:1,total:3" {
+		t.Errorf("ValidateClusterMasterSpec() site factors = %s, %s; want origin:1,total:3", spec.SiteReplicationFactor, spec.SiteSearchFactor)
+	}
+
+	test := func(spec enterprisev1.ClusterMasterSpec) {
+		if err := ValidateClusterMasterSpec(&spec); err == nil {
+			t.Errorf("ValidateClusterMasterSpec(%v) did not return error", spec)
+		}
+	}
+	test(enterprisev1.ClusterMasterSpec{Sites: []string{"site0"}})
+	test(enterprisev1.ClusterMasterSpec{Sites: []string{"site1", "site1"}})
+	test(enterprisev1.ClusterMasterSpec{Sites: []string{"site1"}, SiteReplicationFactor: "origin:2"})
+	test(enterprisev1.ClusterMasterSpec{Sites: []string{"site1"}, SiteSearchFactor: "bad"})
+}
@@ -32,6 +32,12 @@ const (
 	// identifier, instanceType, index (ex: 0, 1, 2, ...)
 	statefulSetPodTemplateStr = "splunk-%s-%s-%d"
 
+	// identifier, instanceType, site (ex: site1, site2, ...)
+	siteStatefulSetTemplateStr = "splunk-%s-%s-%s"
+
+	// identifier, instanceType, site, index (ex: 0, 1, 2, ...)
+	siteStatefulSetPodTemplateStr = "splunk-%s-%s-%s-%d"
+
 	// identifier, instanceType, "headless" or "service"
 	serviceTemplateStr = "splunk-%s-%s-%s"
 
@@ -41,6 +47,39 @@ const (
 	// identifier
 	defaultsTemplateStr = "splunk-%s-%s-defaults"
 
+	// identifier
+	multisiteDefaultsTemplateStr = "splunk-%s-cluster-master-multisite"
+
+	// identifier, instanceType kind
+	smartstoreDefaultsTemplateStr = "splunk-%s-%s-smartstore"
+
+	// identifier, instanceType (ex: standalone, deployer, cluster-master)
+	appFrameworkTemplateStr = "splunk-%s-%s-app-framework"
+
+	// identifier, instanceType (ex: standalone, deployer, cluster-master)
+	appRepositoryTemplateStr = "splunk-%s-%s-app-repository"
+
+	// identifier, instanceType (ex: standalone, cluster-master)
+	indexesTemplateStr = "splunk-%s-%s-indexes"
+
+	// identifier, unix time the backup was started
+	backupTemplateStr = "%s-%d"
+
+	// backup name, volume (ex: etc, var)
+	volumeSnapshotTemplateStr = "%s-%s"
+
+	// volume (ex: etc, var), pod name
+	persistentVolumeClaimTemplateStr = "pvc-%s-%s"
+
+	// default replication factor of indexer clusters
+	defaultReplicationFactor = 3
+
+	// default capacity of the volume used for /opt/splunk/etc
+	defaultEtcStorage = "10Gi"
+
+	// default capacity of the volume used for /opt/splunk/var
+	defaultVarStorage = "100Gi"
+
 	// default docker image used for Splunk instances
 	defaultSplunkImage = "splunk/splunk"
 
@@ -66,6 +105,16 @@ func GetSplunkStatefulsetPodName(instanceType InstanceType, identifier string, i
 	return fmt.Sprintf(statefulSetPodTemplateStr, identifier, instanceType, index)
 }
 
+// GetSplunkSiteStatefulsetName uses a template to name a Kubernetes StatefulSet for Splunk instances within a site.
+func GetSplunkSiteStatefulsetName(instanceType InstanceType, identifier string, site string) string {
+	return fmt.Sprintf(siteStatefulSetTemplateStr, identifier, instanceType, site)
+}
+
+// GetSplunkSiteStatefulsetPodName uses a template to name a specific pod within a Kubernetes StatefulSet for Splunk instances within a site.
+func GetSplunkSiteStatefulsetPodName(instanceType InstanceType, identifier string, site string, index int32) string {
+	return fmt.Sprintf(siteStatefulSetPodTemplateStr, identifier, instanceType, site, index)
+}
+
 // GetSplunkServiceName uses a template to name a Kubernetes Service for Splunk instances.
 func GetSplunkServiceName(instanceType InstanceType, identifier string, isHeadless bool) string {
 	var result string
@@ -89,6 +138,46 @@ func GetSplunkDefaultsName(identifier string, instanceType InstanceType) string
 	return fmt.Sprintf(defaultsTemplateStr, identifier, instanceType.ToKind())
 }
 
+// GetSplunkMultisiteDefaultsName uses a template to name a Kubernetes ConfigMap used to configure a multisite cluster master.
+func GetSplunkMultisiteDefaultsName(identifier string) string {
+	return fmt.Sprintf(multisiteDefaultsTemplateStr, identifier)
+}
+
+// GetSplunkSmartStoreDefaultsName uses a template to name a Kubernetes Secret used to configure SmartStore for a SplunkEnterprise resource.
+func GetSplunkSmartStoreDefaultsName(identifier string, instanceType InstanceType) string {
+	return fmt.Sprintf(smartstoreDefaultsTemplateStr, identifier, instanceType.ToKind())
+}
+
+// GetSplunkAppFrameworkName uses a template to name a Kubernetes ConfigMap used to manage apps installed on Splunk instances.
+func GetSplunkAppFrameworkName(identifier string, instanceType InstanceType) string {
+	return fmt.Sprintf(appFrameworkTemplateStr, identifier, instanceType)
+}
+
+// GetSplunkAppRepositoryName uses a template to name a Kubernetes Deployment and Service used to serve app packages.
+func GetSplunkAppRepositoryName(identifier string, instanceType InstanceType) string {
+	return fmt.Sprintf(appRepositoryTemplateStr, identifier, instanceType)
+}
+
+// GetSplunkIndexesName uses a template to name a Kubernetes ConfigMap used to configure indexes defined by SplunkIndex resources.
+func GetSplunkIndexesName(identifier string, instanceType InstanceType) string {
+	return fmt.Sprintf(indexesTemplateStr, identifier, instanceType)
+}
+
+// GetSplunkBackupName uses a template to name a backup taken by a SplunkBackup resource.
+func GetSplunkBackupName(identifier string, timestamp int64) string {
+	return fmt.Sprintf(backupTemplateStr, identifier, timestamp)
+}
+
+// GetSplunkVolumeSnapshotName uses a template to name the VolumeSnapshot of a volume within a backup.
+func GetSplunkVolumeSnapshotName(backupName, volume string) string {
+	return fmt.Sprintf(volumeSnapshotTemplateStr, backupName, volume)
+}
+
+// GetSplunkPersistentVolumeClaimName returns the name of the PersistentVolumeClaim created for a volume of a StatefulSet pod.
+func GetSplunkPersistentVolumeClaimName(volume, podName string) string {
+	return fmt.Sprintf(persistentVolumeClaimTemplateStr, volume, podName)
+}
+
 // GetSplunkStatefulsetUrls returns a list of fully qualified domain names for all pods within a Splunk StatefulSet.
 func GetSplunkStatefulsetUrls(namespace string, instanceType InstanceType, identifier string, replicas int32, hostnameOnly bool) string {
 	urls := make([]string, replicas)
@@ -114,6 +203,23 @@ func GetSplunkStatefulsetURL(namespace string, instanceType InstanceType, identi
 		))
 }
 
+// GetSplunkSiteStatefulsetURL returns a fully qualified domain name for a specific pod within a Kubernetes StatefulSet of Splunk instances within a site.
+// Note that all sites share the same headless service.
+func GetSplunkSiteStatefulsetURL(namespace string, instanceType InstanceType, identifier string, site string, index int32, hostnameOnly bool) string {
+	podName := GetSplunkSiteStatefulsetPodName(instanceType, identifier, site, index)
+
+	if hostnameOnly {
+		return podName
+	}
+
+	return resources.GetServiceFQDN(namespace,
+		fmt.Sprintf(
+			"%s.%s",
+			podName,
+			GetSplunkServiceName(instanceType, identifier, true),
+		))
+}
+
 // GetSplunkImage returns the docker image to use for Splunk instances.
 func GetSplunkImage(specImage string) string {
 	var name string
@@ -43,6 +43,30 @@ func TestGetSplunkStatefulsetPodName(t *testing.T) {
 	}
 }
 
+func TestGetSplunkSiteStatefulsetName(t *testing.T) {
+	got := GetSplunkSiteStatefulsetName(SplunkIndexer, "t2", "site1")
+	want := "splunk-t2-indexer-site1"
+	if got != want {
+		t.Errorf("GetSplunkSiteStatefulsetName(\"%s\",\"%s\",\"%s\") = %s; want %s", SplunkIndexer.ToString(), "t2", "site1", got, want)
+	}
+}
+
+func TestGetSplunkSiteStatefulsetPodName(t *testing.T) {
+	got := GetSplunkSiteStatefulsetPodName(SplunkIndexer, "t3", "site2", 1)
+	want := "splunk-t3-indexer-site2-1"
+	if got != want {
+		t.Errorf("GetSplunkSiteStatefulsetPodName(\"%s\",\"%s\",\"%s\",%d) = %s; want %s", SplunkIndexer.ToString(), "t3", "site2", 1, got, want)
+	}
+}
+
+func TestGetSplunkSiteStatefulsetURL(t *testing.T) {
+	got := GetSplunkSiteStatefulsetURL("test", SplunkIndexer, "t1", "site1", 0, false)
+	want := "splunk-t1-indexer-site1-0.splunk-t1-indexer-headless.test.svc.cluster.local"
+	if got != want {
+		t.Errorf("GetSplunkSiteStatefulsetURL() = %s; want %s", got, want)
+	}
+}
+
 func TestGetSplunkServiceName(t *testing.T) {
 	test := func(want string, instanceType InstanceType, identifier string, isHeadless bool) {
 		got := GetSplunkServiceName(instanceType, identifier, isHeadless)
@@ -72,6 +96,87 @@ func TestGetSplunkDefaultsName(t *testing.T) {
 	}
 }
 
+func TestGetSplunkMultisiteDefaultsName(t *testing.T) {
+	got := GetSplunkMultisiteDefaultsName("t1")
+	want := "splunk-t1-cluster-master-multisite"
+	if got != want {
+		t.Errorf("GetSplunkMultisiteDefaultsName(\"%s\") = %s; want %s", "t1", got, want)
+	}
+}
+
+func TestGetSplunkSmartStoreDefaultsName(t *testing.T) {
+	test := func(want string, identifier string, instanceType InstanceType) {
+		got := GetSplunkSmartStoreDefaultsName(identifier, instanceType)
+		if got != want {
+			t.Errorf("GetSplunkSmartStoreDefaultsName(\"%s\",\"%s\") = %s; want %s", identifier, instanceType.ToString(), got, want)
+		}
+	}
+
+	test("splunk-t1-standalone-smartstore", "t1", SplunkStandalone)
+	test("splunk-t2-indexer-smartstore", "t2", SplunkClusterMaster)
+}
+
+func TestGetSplunkAppFrameworkName(t *testing.T) {
+	test := func(want string, identifier string, instanceType InstanceType) {
+		got := GetSplunkAppFrameworkName(identifier, instanceType)
+		if got != want {
+			t.Errorf("GetSplunkAppFrameworkName(\"%s\",\"%s\") = %s; want %s", identifier, instanceType.ToString(), got, want)
+		}
+	}
+
+	test("splunk-t1-standalone-app-framework", "t1", SplunkStandalone)
+	test("splunk-t2-deployer-app-framework", "t2", SplunkDeployer)
+	test("splunk-t3-cluster-master-app-framework", "t3", SplunkClusterMaster)
+}
+
+func TestGetSplunkAppRepositoryName(t *testing.T) {
+	test := func(want string, identifier string, instanceType InstanceType) {
+		got := GetSplunkAppRepositoryName(identifier, instanceType)
+		if got != want {
+			t.Errorf("GetSplunkAppRepositoryName(\"%s\",\"%s\") = %s; want %s", identifier, instanceType.ToString(), got, want)
+		}
+	}
+
+	test("splunk-t1-standalone-app-repository", "t1", SplunkStandalone)
+	test("splunk-t2-deployer-app-repository", "t2", SplunkDeployer)
+}
+
+func TestGetSplunkIndexesName(t *testing.T) {
+	test := func(want string, identifier string, instanceType InstanceType) {
+		got := GetSplunkIndexesName(identifier, instanceType)
+		if got != want {
+			t.Errorf("GetSplunkIndexesName(\"%s\",\"%s\") = %s; want %s", identifier, instanceType.ToString(), got, want)
+		}
+	}
+
+	test("splunk-t1-standalone-indexes", "t1", SplunkStandalone)
+	test("splunk-t2-cluster-master-indexes", "t2", SplunkClusterMaster)
+}
+
+func TestGetSplunkBackupName(t *testing.T) {
+	got := GetSplunkBackupName("t1", 1590000000)
+	want := "t1-1590000000"
+	if got != want {
+		t.Errorf("GetSplunkBackupName(\"t1\",1590000000) = %s; want %s", got, want)
+	}
+}
+
+func TestGetSplunkVolumeSnapshotName(t *testing.T) {
+	got := GetSplunkVolumeSnapshotName("t1-1590000000", "etc")
+	want := "t1-1590000000-etc"
+	if got != want {
+		t.Errorf("GetSplunkVolumeSnapshotName(\"t1-1590000000\",\"etc\") = %s; want %s", got, want)
+	}
+}
+
+func TestGetSplunkPersistentVolumeClaimName(t *testing.T) {
+	got := GetSplunkPersistentVolumeClaimName("var", "splunk-t1-standalone-0")
+	want := "pvc-var-splunk-t1-standalone-0"
+	if got != want {
+		t.Errorf("GetSplunkPersistentVolumeClaimName(\"var\",\"splunk-t1-standalone-0\") = %s; want %s", got, want)
+	}
+}
+
 func TestGetSplunkStatefulsetUrls(t *testing.T) {
 	test := func(want string, namespace string, instanceType InstanceType, identifier string, replicas int32, hostnameOnly bool) {
 		got := GetSplunkStatefulsetUrls(namespace, instanceType, identifier, replicas, hostnameOnly)
@@ -0,0 +1,98 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+// rotatableSecretKeys are the Splunk secrets that may be rotated, in the order that they are applied
+var rotatableSecretKeys = []string{"pass4SymmKey", "idxc_secret", "shc_secret", "password"}
+
+// serverConfStanzas maps secrets shared between Splunk instances to the server.conf stanzas that use them
+var serverConfStanzas = map[string]string{
+	"pass4SymmKey": "general",
+	"idxc_secret":  "clustering",
+	"shc_secret":   "shclustering",
+}
+
+// ParseSecretsRotation parses a comma-separated list of Splunk secrets to rotate, and returns them in the order that they are applied.
+func ParseSecretsRotation(value string) ([]string, error) {
+	requested := make(map[string]bool)
+	for _, key := range strings.Split(value, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if !IsRotatableSecret(key) {
+			return nil, fmt.Errorf("Secret %s cannot be rotated; must be one of %s", key, strings.Join(rotatableSecretKeys, ", "))
+		}
+		requested[key] = true
+	}
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("No secrets requested for rotation")
+	}
+
+	keys := []string{}
+	for _, key := range rotatableSecretKeys {
+		if requested[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// IsRotatableSecret returns true if a Splunk secret may be changed after it is created, by rotating it
+func IsRotatableSecret(key string) bool {
+	return key == "password" || serverConfStanzas[key] != ""
+}
+
+// GetServerConfStanza returns the server.conf stanza that uses a secret shared between Splunk instances,
+// or an empty string if the secret is not shared (e.g. the admin password)
+func GetServerConfStanza(key string) string {
+	return serverConfStanzas[key]
+}
+
+// GetRotatedSplunkSecrets returns new randomly generated values for a list of Splunk secrets.
+func GetRotatedSplunkSecrets(keys []string) map[string][]byte {
+	values := make(map[string][]byte)
+	for _, key := range keys {
+		values[key] = generateSplunkSecret()
+	}
+	return values
+}
+
+// UpdateSplunkSecrets changes values in a Kubernetes Secret created by GetSplunkSecrets, and rebuilds default.yml.
+func UpdateSplunkSecrets(secrets *corev1.Secret, values map[string][]byte) {
+	for key, value := range values {
+		secrets.Data[key] = value
+	}
+	secrets.Data["default.yml"] = getSplunkSecretsDefaults(secrets.Data)
+}
+
+// GetSplunkInstanceHosts returns the fully qualified domain names of all Splunk Enterprise instances managed by a custom resource.
+// For indexer and search head clusters, the cluster master and deployer are listed before the other members.
+// The custom resource's spec is expected to have been validated, so that defaults have been applied.
+func GetSplunkInstanceHosts(cr enterprisev1.MetaObject) ([]string, error) {
+	if _, ok := cr.(*enterprisev1.MonitoringConsole); ok {
+		return splitStatefulsetUrls(cr.GetNamespace(), SplunkMonitoringConsole, cr.GetIdentifier(), 1), nil
+	}
+	return GetMonitoringConsolePeers(cr)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestParseSecretsRotation(t *testing.T) {
+	test := func(value string, want []string, wantErr bool) {
+		got, err := ParseSecretsRotation(value)
+		if (err != nil) != wantErr {
+			t.Errorf("ParseSecretsRotation(%q) returned error %v; want error %t", value, err, wantErr)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ParseSecretsRotation(%q) = %v; want %v", value, got, want)
+		}
+	}
+
+	test("password", []string{"password"}, false)
+	test(" password , pass4SymmKey,idxc_secret,", []string{"pass4SymmKey", "idxc_secret", "password"}, false)
+	test("shc_secret,shc_secret", []string{"shc_secret"}, false)
+	test("", nil, true)
+	test(" , ", nil, true)
+	test("hec_token", nil, true)
+	test("password,bogus", nil, true)
+}
+
+func TestGetServerConfStanza(t *testing.T) {
+	for key, want := range map[string]string{
+		"pass4SymmKey": "general",
+		"idxc_secret":  "clustering",
+		"shc_secret":   "shclustering",
+		"password":     "",
+	} {
+		if got := GetServerConfStanza(key); got != want {
+			t.Errorf("GetServerConfStanza(%s) = %q; want %q", key, got, want)
+		}
+	}
+}
+
+func TestUpdateSplunkSecrets(t *testing.T) {
+	cr := enterprisev1.Standalone{ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"}}
+	secrets := GetSplunkSecrets(&cr, SplunkStandalone, nil, nil)
+	oldPassword := secrets.Data["password"]
+
+	values := GetRotatedSplunkSecrets([]string{"pass4SymmKey"})
+	if len(values) != 1 || len(values["pass4SymmKey"]) == 0 {
+		t.Fatalf("GetRotatedSplunkSecrets() = %v; want new pass4SymmKey", values)
+	}
+	UpdateSplunkSecrets(secrets, values)
+	if !bytes.Equal(secrets.Data["pass4SymmKey"], values["pass4SymmKey"]) {
+		t.Errorf("UpdateSplunkSecrets() did not update pass4SymmKey")
+	}
+	if !bytes.Equal(secrets.Data["password"], oldPassword) {
+		t.Errorf("UpdateSplunkSecrets() changed password")
+	}
+	if !bytes.Equal(secrets.Data["default.yml"], getSplunkSecretsDefaults(secrets.Data)) {
+		t.Errorf("UpdateSplunkSecrets() did not rebuild default.yml")
+	}
+}
+
+func TestGetSplunkInstanceHosts(t *testing.T) {
+	objectMeta := metav1.ObjectMeta{Name: "stack1", Namespace: "test"}
+	test := func(cr enterprisev1.MetaObject, want []string) {
+		got, err := GetSplunkInstanceHosts(cr)
+		if err != nil {
+			t.Errorf("GetSplunkInstanceHosts(%s) returned error: %v", cr.GetIdentifier(), err)
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("GetSplunkInstanceHosts(%s) = %v; want %v", cr.GetIdentifier(), got, want)
+		}
+	}
+
+	test(&enterprisev1.MonitoringConsole{ObjectMeta: objectMeta}, []string{
+		"splunk-stack1-monitoring-console-0.splunk-stack1-monitoring-console-headless.test.svc.cluster.local",
+	})
+	test(&enterprisev1.LicenseMaster{ObjectMeta: objectMeta}, []string{
+		"splunk-stack1-license-master-0.splunk-stack1-license-master-headless.test.svc.cluster.local",
+	})
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+const (
+	// operatorAppName is the name of the Splunk app used for configuration generated by the operator
+	operatorAppName = "splunk-operator"
+
+	// smartstoreAccessKey is the key in a volume's secret that contains the S3 access key
+	smartstoreAccessKey = "s3_access_key"
+
+	// smartstoreSecretKey is the key in a volume's secret that contains the S3 secret key
+	smartstoreSecretKey = "s3_secret_key"
+)
+
+// smartstoreVolumeNameRegex matches valid names for SmartStore remote storage volumes
+var smartstoreVolumeNameRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// indexNameRegex matches valid names for Splunk Enterprise indexes
+var indexNameRegex = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]*$`)
+
+// builtinIndexes are indexes defined by Splunk Enterprise; their local storage paths must not be changed
+var builtinIndexes = map[string]bool{
+	"main":           true,
+	"history":        true,
+	"summary":        true,
+	"splunklogger":   true,
+	"_audit":         true,
+	"_internal":      true,
+	"_introspection": true,
+	"_telemetry":     true,
+	"_thefishbucket": true,
+	"_metrics":       true,
+}
+
+// validateSmartStoreSpec checks validity and updates defaults for SmartStore configuration
+func validateSmartStoreSpec(spec *enterprisev1.SmartStoreSpec) error {
+	if len(spec.Indexes) > 0 && len(spec.Volumes) == 0 {
+		return fmt.Errorf("SmartStore indexes require at least one remote storage volume")
+	}
+
+	volumeNames := make(map[string]bool)
+	for idx := range spec.Volumes {
+		volume := &spec.Volumes[idx]
+		if !smartstoreVolumeNameRegex.MatchString(volume.Name) {
+			return fmt.Errorf("Invalid SmartStore volume name \"%s\"", volume.Name)
+		}
+		if volumeNames[volume.Name] {
+			return fmt.Errorf("Duplicate SmartStore volume name \"%s\"", volume.Name)
+		}
+		volumeNames[volume.Name] = true
+		if !strings.HasPrefix(volume.Endpoint, "http://") && !strings.HasPrefix(volume.Endpoint, "https://") {
+			return fmt.Errorf("SmartStore volume %s: endpoint must be an http:// or https:// URL", volume.Name)
+		}
+		volume.Path = strings.Trim(strings.TrimPrefix(volume.Path, "s3://"), "/")
+		if volume.Path == "" {
+			return fmt.Errorf("SmartStore volume %s: path is required", volume.Name)
+		}
+		if strings.ContainsAny(volume.Endpoint+volume.Path, "\"\\\n") {
+			return fmt.Errorf("SmartStore volume %s: endpoint and path must not contain quotes, backslashes or newlines", volume.Name)
+		}
+	}
+
+	indexNames := make(map[string]bool)
+	for idx := range spec.Indexes {
+		index := &spec.Indexes[idx]
+		if !indexNameRegex.MatchString(index.Name) {
+			return fmt.Errorf("Invalid SmartStore index name \"%s\"", index.Name)
+		}
+		if indexNames[index.Name] {
+			return fmt.Errorf("Duplicate SmartStore index name \"%s\"", index.Name)
+		}
+		indexNames[index.Name] = true
+		if index.VolumeName == "" {
+			index.VolumeName = spec.Volumes[0].Name
+		}
+		if !volumeNames[index.VolumeName] {
+			return fmt.Errorf("SmartStore index %s: volume %s does not exist", index.Name, index.VolumeName)
+		}
+		index.RemotePath = strings.Trim(index.RemotePath, "/")
+		if index.RemotePath == "" {
+			index.RemotePath = index.Name
+		}
+		if strings.ContainsAny(index.RemotePath, "\"\\\n") {
+			return fmt.Errorf("SmartStore index %s: remotePath must not contain quotes, backslashes or newlines", index.Name)
+		}
+	}
+
+	cacheManager := spec.CacheManager
+	if cacheManager.MaxCacheSizeMB < 0 || cacheManager.EvictionPaddingMB < 0 || cacheManager.HotlistRecencySecs < 0 || cacheManager.HotlistBloomFilterRecencyHours < 0 {
+		return fmt.Errorf("SmartStore cacheManager settings must not be negative")
+	}
+
+	return nil
+}
+
+// getSmartStoreAppDirectory returns the directory used to store SmartStore configuration for a type of Splunk instance.
+// Configuration for indexer clusters is stored in the cluster master's bundle, so that it is pushed to all peers.
+func getSmartStoreAppDirectory(instanceType InstanceType) string {
+	if instanceType == SplunkClusterMaster {
+		return fmt.Sprintf("/opt/splunk/etc/master-apps/%s/local", operatorAppName)
+	}
+	return fmt.Sprintf("/opt/splunk/etc/apps/%s/local", operatorAppName)
+}
+
+// getSmartStoreCredentials returns the access and secret keys for a SmartStore volume, if it has a secretRef.
+func getSmartStoreCredentials(volume enterprisev1.SmartStoreVolume, volumeSecrets map[string]*corev1.Secret) (string, string, error) {
+	if volume.SecretRef == "" {
+		return "", "", nil
+	}
+	secret, ok := volumeSecrets[volume.SecretRef]
+	if !ok || secret == nil {
+		return "", "", fmt.Errorf("SmartStore volume %s: secret %s not found", volume.Name, volume.SecretRef)
+	}
+	result := []string{}
+	for _, key := range []string{smartstoreAccessKey, smartstoreSecretKey} {
+		value := string(secret.Data[key])
+		if value == "" {
+			return "", "", fmt.Errorf("SmartStore volume %s: secret %s is missing %s", volume.Name, volume.SecretRef, key)
+		}
+		if strings.ContainsAny(value, "\"\\\n") {
+			return "", "", fmt.Errorf("SmartStore volume %s: secret %s has an invalid %s", volume.Name, volume.SecretRef, key)
+		}
+		result = append(result, value)
+	}
+	return result[0], result[1], nil
+}
+
+// GetSplunkSmartStoreDefaults returns a Kubernetes Secret with a default.yml used to configure SmartStore for a
+// Splunk Enterprise resource. volumeSecrets must include all of the Secrets referenced by volumes, keyed by name.
+func GetSplunkSmartStoreDefaults(cr enterprisev1.MetaObject, instanceType InstanceType, spec *enterprisev1.SmartStoreSpec, volumeSecrets map[string]*corev1.Secret) (*corev1.Secret, error) {
+	directory := getSmartStoreAppDirectory(instanceType)
+
+	var defaults strings.Builder
+	fmt.Fprintf(&defaults, `
+splunk:
+    conf:
+        indexes:
+            directory: %s
+            content:
+`, directory)
+
+	for _, volume := range spec.Volumes {
+		accessKey, secretKey, err := getSmartStoreCredentials(volume, volumeSecrets)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&defaults, `                "volume:%s":
+                    storageType: remote
+                    path: "s3://%s"
+                    remote.s3.endpoint: "%s"
+`, volume.Name, volume.Path, volume.Endpoint)
+		if volume.SecretRef != "" {
+			fmt.Fprintf(&defaults, `                    remote.s3.access_key: "%s"
+                    remote.s3.secret_key: "%s"
+`, accessKey, secretKey)
+		}
+	}
+
+	for _, index := range spec.Indexes {
+		fmt.Fprintf(&defaults, `                "%s":
+                    remotePath: "volume:%s/%s"
+`, index.Name, index.VolumeName, index.RemotePath)
+		if !builtinIndexes[index.Name] {
+			fmt.Fprintf(&defaults, `                    homePath: "$SPLUNK_DB/%s/db"
+                    coldPath: "$SPLUNK_DB/%s/colddb"
+                    thawedPath: "$SPLUNK_DB/%s/thaweddb"
+`, index.Name, index.Name, index.Name)
+		}
+		if instanceType == SplunkClusterMaster {
+			defaults.WriteString("                    repFactor: auto\n")
+		}
+	}
+
+	cacheManager := spec.CacheManager
+	if cacheManager != (enterprisev1.SmartStoreCacheManager{}) {
+		fmt.Fprintf(&defaults, `        server:
+            directory: %s
+            content:
+                cachemanager:
+`, directory)
+		settings := []struct {
+			key   string
+			value int64
+		}{
+			{"max_cache_size", cacheManager.MaxCacheSizeMB},
+			{"eviction_padding", cacheManager.EvictionPaddingMB},
+			{"hotlist_recency_secs", cacheManager.HotlistRecencySecs},
+			{"hotlist_bloom_filter_recency_hours", cacheManager.HotlistBloomFilterRecencyHours},
+		}
+		for _, setting := range settings {
+			if setting.value > 0 {
+				fmt.Fprintf(&defaults, "                    %s: %d\n", setting.key, setting.value)
+			}
+		}
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSplunkSmartStoreDefaultsName(cr.GetIdentifier(), instanceType),
+			Namespace: cr.GetNamespace(),
+		},
+		Data: map[string][]byte{
+			"default.yml": []byte(defaults.String()),
+		},
+	}, nil
+}
+
+// addSmartStoreToPodTemplate adds generated SmartStore defaults to all containers in a pod template
+func addSmartStoreToPodTemplate(podTemplateSpec *corev1.PodTemplateSpec, cr enterprisev1.MetaObject, instanceType InstanceType) {
+	secretVolDefaultMode := int32(corev1.SecretVolumeSourceDefaultMode)
+	addSplunkDefaultsToTemplate(podTemplateSpec, "smartstore", corev1.VolumeSource{
+		Secret: &corev1.SecretVolumeSource{
+			SecretName:  GetSplunkSmartStoreDefaultsName(cr.GetIdentifier(), instanceType),
+			DefaultMode: &secretVolDefaultMode,
+		},
+	})
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestValidateSmartStoreSpec(t *testing.T) {
+	spec := enterprisev1.SmartStoreSpec{
+		Volumes: []enterprisev1.SmartStoreVolume{
+			{Name: "s3", Endpoint: "http://minio:9000", Path: "s3://smartstore/indexes/"},
+			{Name: "archive", Endpoint: "https://s3.us-west-2.amazonaws.com", Path: "archive"},
+		},
+		Indexes: []enterprisev1.SmartStoreIndex{
+			{Name: "main"},
+			{Name: "web", VolumeName: "archive", RemotePath: "/web/data/"},
+		},
+	}
+	if err := validateSmartStoreSpec(&spec); err != nil {
+		t.Errorf("validateSmartStoreSpec() returned error: %v", err)
+	}
+	if spec.Volumes[0].Path != "smartstore/indexes" {
+		t.Errorf("validateSmartStoreSpec() Volumes[0].Path = %s; want %s", spec.Volumes[0].Path, "smartstore/indexes")
+	}
+	if spec.Indexes[0].VolumeName != "s3" || spec.Indexes[0].RemotePath != "main" {
+		t.Errorf("validateSmartStoreSpec() Indexes[0] = %s,%s; want %s,%s", spec.Indexes[0].VolumeName, spec.Indexes[0].RemotePath, "s3", "main")
+	}
+	if spec.Indexes[1].VolumeName != "archive" || spec.Indexes[1].RemotePath != "web/data" {
+		t.Errorf("validateSmartStoreSpec() Indexes[1] = %s,%s; want %s,%s", spec.Indexes[1].VolumeName, spec.Indexes[1].RemotePath, "archive", "web/data")
+	}
+
+	test := func(name string, update func(spec *enterprisev1.SmartStoreSpec)) {
+		revised := spec.DeepCopy()
+		update(revised)
+		if err := validateSmartStoreSpec(revised); err == nil {
+			t.Errorf("validateSmartStoreSpec() did not return error for %s", name)
+		}
+	}
+	test("indexes without volumes", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes = nil })
+	test("invalid volume name", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes[0].Name = "s3:remote" })
+	test("duplicate volume name", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes[1].Name = "s3" })
+	test("missing endpoint", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes[0].Endpoint = "" })
+	test("invalid endpoint", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes[0].Endpoint = "minio:9000" })
+	test("missing path", func(spec *enterprisev1.SmartStoreSpec) { spec.Volumes[0].Path = "s3://" })
+	test("invalid index name", func(spec *enterprisev1.SmartStoreSpec) { spec.Indexes[0].Name = "Main" })
+	test("duplicate index name", func(spec *enterprisev1.SmartStoreSpec) { spec.Indexes[1].Name = "main" })
+	test("unknown volume", func(spec *enterprisev1.SmartStoreSpec) { spec.Indexes[1].VolumeName = "missing" })
+	test("negative cache size", func(spec *enterprisev1.SmartStoreSpec) { spec.CacheManager.MaxCacheSizeMB = -1 })
+}
+
+func TestGetSplunkSmartStoreDefaults(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.StandaloneSpec{
+			SmartStore: enterprisev1.SmartStoreSpec{
+				Volumes: []enterprisev1.SmartStoreVolume{
+					{Name: "s3", Endpoint: "http://minio:9000", Path: "smartstore", SecretRef: "s3-creds"},
+				},
+				Indexes: []enterprisev1.SmartStoreIndex{
+					{Name: "main"},
+					{Name: "web"},
+				},
+				CacheManager: enterprisev1.SmartStoreCacheManager{
+					MaxCacheSizeMB:     20000,
+					HotlistRecencySecs: 86400,
+				},
+			},
+		},
+	}
+	if err := ValidateStandaloneSpec(&cr.Spec); err != nil {
+		t.Errorf("ValidateStandaloneSpec() returned error: %v", err)
+	}
+	volumeSecrets := map[string]*corev1.Secret{
+		"s3-creds": {
+			Data: map[string][]byte{
+				"s3_access_key": []byte("access"),
+				"s3_secret_key": []byte("secret"),
+			},
+		},
+	}
+
+	secret, err := GetSplunkSmartStoreDefaults(&cr, SplunkStandalone, &cr.Spec.SmartStore, volumeSecrets)
+	if err != nil {
+		t.Errorf("GetSplunkSmartStoreDefaults() returned error: %v", err)
+	}
+	if secret.GetName() != "splunk-stack1-standalone-smartstore" {
+		t.Errorf("GetSplunkSmartStoreDefaults() name = %s; want %s", secret.GetName(), "splunk-stack1-standalone-smartstore")
+	}
+	want := `
+splunk:
+    conf:
+        indexes:
+            directory: /opt/splunk/etc/apps/splunk-operator/local
+            content:
+                "volume:s3":
+                    storageType: remote
+                    path: "s3://smartstore"
+                    remote.s3.endpoint: "http://minio:9000"
+                    remote.s3.access_key: "access"
+                    remote.s3.secret_key: "secret"
+                "main":
+                    remotePath: "volume:s3/main"
+                "web":
+                    remotePath: "volume:s3/web"
+                    homePath: "$SPLUNK_DB/web/db"
+                    coldPath: "$SPLUNK_DB/web/colddb"
+                    thawedPath: "$SPLUNK_DB/web/thaweddb"
+        server:
+            directory: /opt/splunk/etc/apps/splunk-operator/local
+            content:
+                cachemanager:
+                    max_cache_size: 20000
+                    hotlist_recency_secs: 86400
+`
+	if string(secret.Data["default.yml"]) != want {
+		t.Errorf("GetSplunkSmartStoreDefaults() = %s; want %s", secret.Data["default.yml"], want)
+	}
+
+	// indexer clusters use the cluster master bundle, and replicate remote indexes
+	secret, err = GetSplunkSmartStoreDefaults(&cr, SplunkClusterMaster, &cr.Spec.SmartStore, volumeSecrets)
+	if err != nil {
+		t.Errorf("GetSplunkSmartStoreDefaults() returned error: %v", err)
+	}
+	got := string(secret.Data["default.yml"])
+	if !strings.Contains(got, "directory: /opt/splunk/etc/master-apps/splunk-operator/local") || !strings.Contains(got, "repFactor: auto") {
+		t.Errorf("GetSplunkSmartStoreDefaults() = %s; want master-apps with repFactor", got)
+	}
+
+	// secrets must include both keys
+	delete(volumeSecrets["s3-creds"].Data, "s3_secret_key")
+	if _, err = GetSplunkSmartStoreDefaults(&cr, SplunkStandalone, &cr.Spec.SmartStore, volumeSecrets); err == nil {
+		t.Errorf("GetSplunkSmartStoreDefaults() did not return error for secret missing s3_secret_key")
+	}
+	if _, err = GetSplunkSmartStoreDefaults(&cr, SplunkStandalone, &cr.Spec.SmartStore, map[string]*corev1.Secret{}); err == nil {
+		t.Errorf("GetSplunkSmartStoreDefaults() did not return error for missing secret")
+	}
+
+	// statefulset includes the generated defaults
+	ss, err := GetStandaloneStatefulSet(&cr)
+	if err != nil {
+		t.Errorf("GetStandaloneStatefulSet() returned error: %v", err)
+	}
+	for _, e := range ss.Spec.Template.Spec.Containers[0].Env {
+		if e.Name == "SPLUNK_DEFAULTS_URL" && e.Value != "/mnt/splunk-secrets/default.yml,/mnt/splunk-smartstore/default.yml" {
+			t.Errorf("GetStandaloneStatefulSet() SPLUNK_DEFAULTS_URL = %s; want smartstore defaults", e.Value)
+		}
+	}
+	found := false
+	for _, v := range ss.Spec.Template.Spec.Volumes {
+		if v.Name == "mnt-splunk-smartstore" && v.Secret != nil && v.Secret.SecretName == "splunk-stack1-standalone-smartstore" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("GetStandaloneStatefulSet() did not include smartstore volume")
+	}
+}
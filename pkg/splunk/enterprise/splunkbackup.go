@@ -0,0 +1,237 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+const (
+	// volumeSnapshotGroup is the API group of CSI VolumeSnapshots
+	volumeSnapshotGroup = "snapshot.storage.k8s.io"
+
+	// volumeSnapshotVersion is the API version of CSI VolumeSnapshots
+	volumeSnapshotVersion = "v1beta1"
+
+	// volumeSnapshotKind is the kind of CSI VolumeSnapshots
+	volumeSnapshotKind = "VolumeSnapshot"
+
+	// backupLabel is added to VolumeSnapshots to identify the SplunkBackup resource that created them
+	backupLabel = "enterprise.splunk.com/backup"
+
+	// backupNameAnnotation records the name of the backup that a VolumeSnapshot belongs to
+	backupNameAnnotation = "enterprise.splunk.com/backup-name"
+
+	// backupTimeAnnotation records the time that the backup a VolumeSnapshot belongs to was started
+	backupTimeAnnotation = "enterprise.splunk.com/backup-time"
+
+	// defaultBackupRetain is the default number of backups kept by a SplunkBackup
+	defaultBackupRetain = 7
+
+	// minBackupInterval is the shortest time allowed between backups
+	minBackupInterval = time.Minute
+)
+
+// splunkBackupVolumes are the volumes of a Splunk instance that are included in each backup
+var splunkBackupVolumes = []string{"etc", "var"}
+
+// splunkBackupInstanceTypes are the types of Splunk instance that are backed up for each kind of target resource.
+// Indexer clusters are backed up using their cluster master, and search head clusters using their deployer.
+var splunkBackupInstanceTypes = map[string]InstanceType{
+	"Standalone":        SplunkStandalone,
+	"LicenseMaster":     SplunkLicenseMaster,
+	"ClusterMaster":     SplunkClusterMaster,
+	"IndexerCluster":    SplunkClusterMaster,
+	"SearchHeadCluster": SplunkDeployer,
+}
+
+// ValidateSplunkBackup checks validity and updates defaults for a SplunkBackup resource
+func ValidateSplunkBackup(cr *enterprisev1.SplunkBackup) error {
+	spec := &cr.Spec
+	if _, ok := splunkBackupInstanceTypes[spec.TargetRef.Kind]; !ok {
+		return fmt.Errorf("targetRef kind must be Standalone, LicenseMaster, ClusterMaster, IndexerCluster or SearchHeadCluster")
+	}
+	if spec.TargetRef.Name == "" {
+		return fmt.Errorf("targetRef name is required")
+	}
+	if spec.TargetRef.Namespace != "" && spec.TargetRef.Namespace != cr.GetNamespace() {
+		return fmt.Errorf("targetRef must refer to a resource in namespace %s", cr.GetNamespace())
+	}
+
+	if spec.Interval != "" {
+		interval, err := time.ParseDuration(spec.Interval)
+		if err != nil {
+			return fmt.Errorf("Invalid interval \"%s\": %v", spec.Interval, err)
+		}
+		if interval < minBackupInterval {
+			return fmt.Errorf("interval must be at least %s", minBackupInterval)
+		}
+	}
+
+	if spec.Retain == 0 {
+		spec.Retain = defaultBackupRetain
+	} else if spec.Retain < 0 {
+		return fmt.Errorf("retain must not be negative")
+	}
+
+	return nil
+}
+
+// ValidateSplunkBackupUpdate checks that a revised SplunkBackup does not change the resource that it backs up.
+func ValidateSplunkBackupUpdate(current, revised *enterprisev1.SplunkBackup) error {
+	if current.Spec.TargetRef.Kind != revised.Spec.TargetRef.Kind || current.Spec.TargetRef.Name != revised.Spec.TargetRef.Name {
+		return fmt.Errorf("targetRef cannot be changed")
+	}
+	return nil
+}
+
+// GetSplunkBackupInstanceType returns the type of Splunk instance that is backed up for a kind of target resource
+func GetSplunkBackupInstanceType(kind string) InstanceType {
+	return splunkBackupInstanceTypes[kind]
+}
+
+// IsSplunkBackupInstanceType returns true if a type of Splunk instance may be backed up and restored
+func IsSplunkBackupInstanceType(instanceType InstanceType) bool {
+	switch instanceType {
+	case SplunkStandalone, SplunkLicenseMaster, SplunkClusterMaster, SplunkDeployer:
+		return true
+	}
+	return false
+}
+
+// GetSplunkBackupInterval returns the time between backups taken by a SplunkBackup, or 0 if it takes a single backup.
+// The SplunkBackup is expected to have been validated.
+func GetSplunkBackupInterval(cr *enterprisev1.SplunkBackup) time.Duration {
+	interval, _ := time.ParseDuration(cr.Spec.Interval)
+	return interval
+}
+
+// GetSplunkBackupLabels returns the labels of all VolumeSnapshots created by a SplunkBackup
+func GetSplunkBackupLabels(cr *enterprisev1.SplunkBackup) map[string]string {
+	return map[string]string{backupLabel: cr.GetName()}
+}
+
+// NewVolumeSnapshotList returns an empty list of CSI VolumeSnapshots. The operator uses unstructured objects for
+// VolumeSnapshots, so that it does not depend on the CSI snapshot client.
+func NewVolumeSnapshotList() *unstructured.UnstructuredList {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: volumeSnapshotGroup, Version: volumeSnapshotVersion, Kind: volumeSnapshotKind + "List"})
+	return list
+}
+
+// GetSplunkVolumeSnapshots returns a CSI VolumeSnapshot for each volume of the Splunk instance backed up by a SplunkBackup.
+// VolumeSnapshots are not owned by the SplunkBackup, so that they are kept if it is deleted.
+func GetSplunkVolumeSnapshots(cr *enterprisev1.SplunkBackup, target enterprisev1.MetaObject, instanceType InstanceType, backupName string, started time.Time) []*unstructured.Unstructured {
+	podName := GetSplunkStatefulsetPodName(instanceType, target.GetIdentifier(), 0)
+	snapshots := make([]*unstructured.Unstructured, len(splunkBackupVolumes))
+	for i, volume := range splunkBackupVolumes {
+		spec := map[string]interface{}{
+			"source": map[string]interface{}{
+				"persistentVolumeClaimName": GetSplunkPersistentVolumeClaimName(volume, podName),
+			},
+		}
+		if cr.Spec.VolumeSnapshotClassName != "" {
+			spec["volumeSnapshotClassName"] = cr.Spec.VolumeSnapshotClassName
+		}
+
+		snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+		snapshot.SetGroupVersionKind(schema.GroupVersionKind{Group: volumeSnapshotGroup, Version: volumeSnapshotVersion, Kind: volumeSnapshotKind})
+		snapshot.SetName(GetSplunkVolumeSnapshotName(backupName, volume))
+		snapshot.SetNamespace(cr.GetNamespace())
+		snapshot.SetLabels(GetSplunkBackupLabels(cr))
+		snapshot.SetAnnotations(map[string]string{
+			backupNameAnnotation: backupName,
+			backupTimeAnnotation: started.UTC().Format(time.RFC3339),
+		})
+		snapshots[i] = snapshot
+	}
+	return snapshots
+}
+
+// GetPersistentVolumeClaimSnapshot returns a CSI VolumeSnapshot of a PersistentVolumeClaim that is about to be deleted.
+// The VolumeSnapshot has the same labels as the claim, and is named using the time that it was taken.
+func GetPersistentVolumeClaimSnapshot(pvc *corev1.PersistentVolumeClaim, taken time.Time) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": pvc.GetName(),
+		},
+	}
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	snapshot.SetGroupVersionKind(schema.GroupVersionKind{Group: volumeSnapshotGroup, Version: volumeSnapshotVersion, Kind: volumeSnapshotKind})
+	snapshot.SetName(GetSplunkBackupName(pvc.GetName(), taken.Unix()))
+	snapshot.SetNamespace(pvc.GetNamespace())
+	snapshot.SetLabels(pvc.GetLabels())
+	return snapshot
+}
+
+// GetVolumeSnapshotBackupName returns the name of the backup that a VolumeSnapshot belongs to
+func GetVolumeSnapshotBackupName(snapshot *unstructured.Unstructured) string {
+	return snapshot.GetAnnotations()[backupNameAnnotation]
+}
+
+// GetSplunkBackups returns the status of each backup that VolumeSnapshots belong to, from oldest to newest.
+// A backup is ready to use once the VolumeSnapshots of all of its volumes are ready. An error is returned
+// if any VolumeSnapshot reports an error.
+func GetSplunkBackups(snapshots []unstructured.Unstructured) ([]enterprisev1.SplunkBackupSnapshotStatus, error) {
+	backups := make(map[string]*enterprisev1.SplunkBackupSnapshotStatus)
+	readyCount := make(map[string]int)
+	for i := range snapshots {
+		snapshot := &snapshots[i]
+		name := GetVolumeSnapshotBackupName(snapshot)
+		if name == "" {
+			continue
+		}
+		message, _, _ := unstructured.NestedString(snapshot.Object, "status", "error", "message")
+		if message != "" {
+			return nil, fmt.Errorf("VolumeSnapshot %s failed: %s", snapshot.GetName(), message)
+		}
+
+		backup, ok := backups[name]
+		if !ok {
+			backup = &enterprisev1.SplunkBackupSnapshotStatus{Name: name}
+			if started, err := time.Parse(time.RFC3339, snapshot.GetAnnotations()[backupTimeAnnotation]); err == nil {
+				backup.Time = metav1.NewTime(started)
+			} else {
+				backup.Time = snapshot.GetCreationTimestamp()
+			}
+			backups[name] = backup
+		}
+		if ready, _, _ := unstructured.NestedBool(snapshot.Object, "status", "readyToUse"); ready {
+			readyCount[name]++
+		}
+	}
+
+	result := make([]enterprisev1.SplunkBackupSnapshotStatus, 0, len(backups))
+	for name, backup := range backups {
+		backup.ReadyToUse = readyCount[name] == len(splunkBackupVolumes)
+		result = append(result, *backup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Time.Equal(&result[j].Time) {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].Time.Before(&result[j].Time)
+	})
+	return result, nil
+}
@@ -0,0 +1,216 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func newTestSplunkBackup(name string) *enterprisev1.SplunkBackup {
+	cr := enterprisev1.SplunkBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+		},
+	}
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "ClusterMaster", Name: "stack1"}
+	return &cr
+}
+
+func TestValidateSplunkBackup(t *testing.T) {
+	test := func(cr *enterprisev1.SplunkBackup, wantErr string) {
+		err := ValidateSplunkBackup(cr)
+		if wantErr == "" && err != nil {
+			t.Errorf("ValidateSplunkBackup(%s) returned error: %v", cr.GetName(), err)
+		} else if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("ValidateSplunkBackup(%s) returned %v; want error containing %q", cr.GetName(), err, wantErr)
+		}
+	}
+
+	// defaults are applied
+	cr := newTestSplunkBackup("daily")
+	test(cr, "")
+	if cr.Spec.Retain != 7 || GetSplunkBackupInterval(cr) != 0 {
+		t.Errorf("ValidateSplunkBackup() defaults = %d, %s; want 7, 0s", cr.Spec.Retain, GetSplunkBackupInterval(cr))
+	}
+
+	cr.Spec.TargetRef.Kind = "MonitoringConsole"
+	test(cr, "targetRef kind")
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "Standalone"}
+	test(cr, "targetRef name")
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "Standalone", Name: "s1", Namespace: "other"}
+	test(cr, "must refer to a resource in namespace test")
+
+	cr = newTestSplunkBackup("daily")
+	cr.Spec.Interval = "1d"
+	test(cr, "Invalid interval")
+	cr.Spec.Interval = "30s"
+	test(cr, "interval must be at least 1m0s")
+	cr.Spec.Interval = "24h"
+	test(cr, "")
+	if GetSplunkBackupInterval(cr) != 24*time.Hour {
+		t.Errorf("GetSplunkBackupInterval() = %s; want 24h0m0s", GetSplunkBackupInterval(cr))
+	}
+	cr.Spec.Retain = -1
+	test(cr, "retain must not be negative")
+
+	current := newTestSplunkBackup("daily")
+	revised := newTestSplunkBackup("daily")
+	revised.Spec.Interval = "1h"
+	if err := ValidateSplunkBackupUpdate(current, revised); err != nil {
+		t.Errorf("ValidateSplunkBackupUpdate() returned error: %v", err)
+	}
+	revised.Spec.TargetRef.Name = "stack2"
+	if err := ValidateSplunkBackupUpdate(current, revised); err == nil {
+		t.Errorf("ValidateSplunkBackupUpdate() returned nil; want error for changed targetRef")
+	}
+}
+
+func TestGetSplunkBackupInstanceType(t *testing.T) {
+	test := func(kind string, want InstanceType) {
+		got := GetSplunkBackupInstanceType(kind)
+		if got != want {
+			t.Errorf("GetSplunkBackupInstanceType(%s) = %s; want %s", kind, got, want)
+		}
+		if !IsSplunkBackupInstanceType(got) {
+			t.Errorf("IsSplunkBackupInstanceType(%s) = false; want true", got)
+		}
+	}
+
+	test("Standalone", SplunkStandalone)
+	test("LicenseMaster", SplunkLicenseMaster)
+	test("ClusterMaster", SplunkClusterMaster)
+	test("IndexerCluster", SplunkClusterMaster)
+	test("SearchHeadCluster", SplunkDeployer)
+
+	if IsSplunkBackupInstanceType(SplunkIndexer) || IsSplunkBackupInstanceType(SplunkSearchHead) {
+		t.Errorf("IsSplunkBackupInstanceType() = true for indexers or search heads; want false")
+	}
+}
+
+func TestGetSplunkVolumeSnapshots(t *testing.T) {
+	cr := newTestSplunkBackup("daily")
+	cr.Spec.VolumeSnapshotClassName = "csi-snapclass"
+	target := enterprisev1.ClusterMaster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	started := time.Date(2020, 5, 20, 18, 40, 0, 0, time.UTC)
+
+	snapshots := GetSplunkVolumeSnapshots(cr, &target, SplunkClusterMaster, "daily-1590000000", started)
+	if len(snapshots) != 2 {
+		t.Fatalf("GetSplunkVolumeSnapshots() returned %d VolumeSnapshots; want 2", len(snapshots))
+	}
+	for i, volume := range []string{"etc", "var"} {
+		configTester(t, "GetSplunkVolumeSnapshots()", func() (interface{}, error) { return snapshots[i], nil },
+			`{"apiVersion":"snapshot.storage.k8s.io/v1beta1","kind":"VolumeSnapshot","metadata":{"annotations":{"enterprise.splunk.com/backup-name":"daily-1590000000","enterprise.splunk.com/backup-time":"2020-05-20T18:40:00Z"},"labels":{"enterprise.splunk.com/backup":"daily"},"name":"daily-1590000000-`+volume+`","namespace":"test"},"spec":{"source":{"persistentVolumeClaimName":"pvc-`+volume+`-splunk-stack1-cluster-master-0"},"volumeSnapshotClassName":"csi-snapclass"}}`)
+	}
+}
+
+func TestGetPersistentVolumeClaimSnapshot(t *testing.T) {
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pvc-var-splunk-stack1-indexer-2",
+			Namespace: "test",
+			Labels:    map[string]string{"app.kubernetes.io/instance": "splunk-stack1-indexer"},
+		},
+	}
+	taken := time.Date(2020, 5, 20, 18, 40, 0, 0, time.UTC)
+	configTester(t, "GetPersistentVolumeClaimSnapshot()", func() (interface{}, error) { return GetPersistentVolumeClaimSnapshot(&pvc, taken), nil },
+		`{"apiVersion":"snapshot.storage.k8s.io/v1beta1","kind":"VolumeSnapshot","metadata":{"labels":{"app.kubernetes.io/instance":"splunk-stack1-indexer"},"name":"pvc-var-splunk-stack1-indexer-2-1590000000","namespace":"test"},"spec":{"source":{"persistentVolumeClaimName":"pvc-var-splunk-stack1-indexer-2"}}}`)
+}
+
+func TestGetSplunkBackups(t *testing.T) {
+	cr := newTestSplunkBackup("daily")
+	target := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	started := time.Date(2020, 5, 20, 18, 40, 0, 0, time.UTC)
+
+	// newest backup is created first, to check ordering
+	var snapshots []unstructured.Unstructured
+	for _, s := range GetSplunkVolumeSnapshots(cr, &target, SplunkStandalone, "daily-2", started.Add(time.Hour)) {
+		snapshots = append(snapshots, *s)
+	}
+	for _, s := range GetSplunkVolumeSnapshots(cr, &target, SplunkStandalone, "daily-1", started) {
+		unstructured.SetNestedField(s.Object, true, "status", "readyToUse")
+		snapshots = append(snapshots, *s)
+	}
+	unstructured.SetNestedField(snapshots[0].Object, true, "status", "readyToUse")
+
+	backups, err := GetSplunkBackups(snapshots)
+	if err != nil {
+		t.Fatalf("GetSplunkBackups() returned error: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("GetSplunkBackups() returned %d backups; want 2", len(backups))
+	}
+	if backups[0].Name != "daily-1" || !backups[0].ReadyToUse || !backups[0].Time.Time.Equal(started) {
+		t.Errorf("GetSplunkBackups()[0] = %v; want daily-1, ready at %s", backups[0], started)
+	}
+	if backups[1].Name != "daily-2" || backups[1].ReadyToUse {
+		t.Errorf("GetSplunkBackups()[1] = %v; want daily-2, not ready", backups[1])
+	}
+
+	unstructured.SetNestedField(snapshots[1].Object, "snapshot quota exceeded", "status", "error", "message")
+	if _, err = GetSplunkBackups(snapshots); err == nil || !strings.Contains(err.Error(), "daily-2-var failed: snapshot quota exceeded") {
+		t.Errorf("GetSplunkBackups() returned %v; want error for failed VolumeSnapshot", err)
+	}
+}
+
+func TestGetSplunkVolumeClaimsRestoreFrom(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.RestoreFrom = "daily-1590000000"
+
+	test := func(instanceType InstanceType, want []string) {
+		claims, err := getSplunkVolumeClaims(&cr, &cr.Spec.CommonSplunkSpec, instanceType, map[string]string{})
+		if err != nil {
+			t.Fatalf("getSplunkVolumeClaims() returned error: %v", err)
+		}
+		for i := range claims {
+			var got string
+			if dataSource := claims[i].Spec.DataSource; dataSource != nil {
+				got = *dataSource.APIGroup + "/" + dataSource.Kind + "/" + dataSource.Name
+			}
+			if got != want[i] {
+				t.Errorf("getSplunkVolumeClaims(%s) dataSource = %q; want %q", instanceType, got, want[i])
+			}
+		}
+	}
+
+	test(SplunkStandalone, []string{
+		"snapshot.storage.k8s.io/VolumeSnapshot/daily-1590000000-etc",
+		"snapshot.storage.k8s.io/VolumeSnapshot/daily-1590000000-var",
+	})
+	test(SplunkIndexer, []string{"", ""})
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+const (
+	// indexesAppName is the name of the Splunk app used for indexes defined by SplunkIndex resources
+	indexesAppName = "splunk-operator-indexes"
+
+	// indexesConfKey is the key in an indexes ConfigMap that contains the generated indexes.conf
+	indexesConfKey = "indexes.conf"
+
+	// indexesStateKey is the key in an indexes ConfigMap that tracks all of the indexes defined by SplunkIndex
+	// resources, including those that have been disabled because their resources were deleted
+	indexesStateKey = "indexes.json"
+)
+
+// splunkIndexDataTypes are the valid values for the datatype of an index
+var splunkIndexDataTypes = map[string]bool{
+	"event":  true,
+	"metric": true,
+}
+
+// splunkIndexDefinition is the configuration of an index, as tracked by an indexes ConfigMap
+type splunkIndexDefinition struct {
+	// Name of the SplunkIndex resource that defines the index
+	Resource string `json:"resource"`
+
+	DataType               string `json:"datatype"`
+	FrozenTimePeriodInSecs int64  `json:"frozenTimePeriodInSecs,omitempty"`
+	MaxTotalDataSizeMB     int64  `json:"maxTotalDataSizeMB,omitempty"`
+	RemotePath             string `json:"remotePath,omitempty"`
+
+	// Disabled is true if the SplunkIndex resource was deleted; the index is kept so that its data is not lost
+	Disabled bool `json:"disabled,omitempty"`
+}
+
+// ValidateSplunkIndex checks validity and updates defaults for a SplunkIndex resource
+func ValidateSplunkIndex(cr *enterprisev1.SplunkIndex) error {
+	spec := &cr.Spec
+	if spec.IndexName == "" {
+		spec.IndexName = cr.GetName()
+	}
+	if !indexNameRegex.MatchString(spec.IndexName) {
+		return fmt.Errorf("Invalid index name \"%s\"; set indexName to a name that only contains lowercase letters, numbers, underscores and hyphens", spec.IndexName)
+	}
+	if builtinIndexes[spec.IndexName] {
+		return fmt.Errorf("Index %s is defined by Splunk Enterprise and cannot be managed using a SplunkIndex", spec.IndexName)
+	}
+
+	if spec.TargetRef.Kind != "IndexerCluster" && spec.TargetRef.Kind != "Standalone" {
+		return fmt.Errorf("targetRef kind must be IndexerCluster or Standalone")
+	}
+	if spec.TargetRef.Name == "" {
+		return fmt.Errorf("targetRef name is required")
+	}
+	if spec.TargetRef.Namespace != "" && spec.TargetRef.Namespace != cr.GetNamespace() {
+		return fmt.Errorf("targetRef must refer to a resource in namespace %s", cr.GetNamespace())
+	}
+
+	if spec.DataType == "" {
+		spec.DataType = "event"
+	}
+	if !splunkIndexDataTypes[spec.DataType] {
+		return fmt.Errorf("Invalid datatype \"%s\"; must be event or metric", spec.DataType)
+	}
+	if spec.FrozenTimePeriodInSecs < 0 || spec.MaxTotalDataSizeMB < 0 {
+		return fmt.Errorf("frozenTimePeriodInSecs and maxTotalDataSizeMB must not be negative")
+	}
+	if spec.RemotePath != "" && !strings.HasPrefix(spec.RemotePath, "volume:") {
+		return fmt.Errorf("remotePath must refer to a SmartStore volume (for example, \"volume:s3/%s\")", spec.IndexName)
+	}
+	if strings.ContainsAny(spec.RemotePath, "\"\\\n") {
+		return fmt.Errorf("remotePath must not contain quotes, backslashes or newlines")
+	}
+
+	return nil
+}
+
+// ValidateSplunkIndexUpdate checks that a revised SplunkIndex does not change the index that it manages.
+// Both resources are expected to have been validated, so that defaults have been applied.
+func ValidateSplunkIndexUpdate(current, revised *enterprisev1.SplunkIndex) error {
+	if current.Spec.IndexName != revised.Spec.IndexName {
+		return fmt.Errorf("indexName cannot be changed")
+	}
+	if current.Spec.TargetRef.Kind != revised.Spec.TargetRef.Kind || current.Spec.TargetRef.Name != revised.Spec.TargetRef.Name {
+		return fmt.Errorf("targetRef cannot be changed")
+	}
+	return nil
+}
+
+// getSplunkIndexesAppDirectory returns the directory used to store configuration for indexes defined by SplunkIndex resources.
+// Indexes for indexer clusters are stored in the cluster master's bundle, so that they are pushed to all peers.
+func getSplunkIndexesAppDirectory(instanceType InstanceType) string {
+	if instanceType == SplunkClusterMaster {
+		return fmt.Sprintf("/opt/splunk/etc/master-apps/%s/local", indexesAppName)
+	}
+	return fmt.Sprintf("/opt/splunk/etc/apps/%s/local", indexesAppName)
+}
+
+// getSplunkIndexDefinitions returns the indexes tracked by an indexes ConfigMap, keyed by index name
+func getSplunkIndexDefinitions(configMap *corev1.ConfigMap) (map[string]splunkIndexDefinition, error) {
+	indexes := make(map[string]splunkIndexDefinition)
+	if configMap == nil || configMap.Data[indexesStateKey] == "" {
+		return indexes, nil
+	}
+	if err := json.Unmarshal([]byte(configMap.Data[indexesStateKey]), &indexes); err != nil {
+		return nil, fmt.Errorf("Unable to parse %s in ConfigMap %s: %v", indexesStateKey, configMap.GetName(), err)
+	}
+	return indexes, nil
+}
+
+// getSplunkIndexesConf returns the contents of indexes.conf for a set of index definitions
+func getSplunkIndexesConf(indexes map[string]splunkIndexDefinition, instanceType InstanceType) string {
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var conf strings.Builder
+	conf.WriteString("# Generated by the Splunk Operator; do not edit.\n")
+	for _, name := range names {
+		index := indexes[name]
+		fmt.Fprintf(&conf, "\n[%s]\n", name)
+		fmt.Fprintf(&conf, "homePath = $SPLUNK_DB/%s/db\n", name)
+		fmt.Fprintf(&conf, "coldPath = $SPLUNK_DB/%s/colddb\n", name)
+		fmt.Fprintf(&conf, "thawedPath = $SPLUNK_DB/%s/thaweddb\n", name)
+		fmt.Fprintf(&conf, "datatype = %s\n", index.DataType)
+		if index.FrozenTimePeriodInSecs > 0 {
+			fmt.Fprintf(&conf, "frozenTimePeriodInSecs = %d\n", index.FrozenTimePeriodInSecs)
+		}
+		if index.MaxTotalDataSizeMB > 0 {
+			fmt.Fprintf(&conf, "maxTotalDataSizeMB = %d\n", index.MaxTotalDataSizeMB)
+		}
+		if index.RemotePath != "" {
+			fmt.Fprintf(&conf, "remotePath = %s\n", index.RemotePath)
+		}
+		if instanceType == SplunkClusterMaster {
+			conf.WriteString("repFactor = auto\n")
+		}
+		fmt.Fprintf(&conf, "disabled = %t\n", index.Disabled)
+	}
+	return conf.String()
+}
+
+// GetSplunkIndexesConfigMap returns a Kubernetes ConfigMap containing indexes.conf for all of the indexes defined on a
+// type of Splunk instance managed by target, after adding, updating or disabling the index defined by a SplunkIndex.
+// current is the existing ConfigMap, if any; indexes defined by other SplunkIndex resources are preserved, and indexes
+// that are disabled are never removed, so that their data remains available if they are defined again.
+func GetSplunkIndexesConfigMap(target enterprisev1.MetaObject, instanceType InstanceType, current *corev1.ConfigMap, cr *enterprisev1.SplunkIndex, disabled bool) (*corev1.ConfigMap, error) {
+	indexes, err := getSplunkIndexDefinitions(current)
+	if err != nil {
+		return nil, err
+	}
+
+	name := cr.Spec.IndexName
+	existing, ok := indexes[name]
+	if disabled && (!ok || existing.Resource != cr.GetName()) {
+		// the index was never defined by this resource, or another resource has since taken it over
+		return current, nil
+	}
+	if ok && existing.Resource != cr.GetName() && !existing.Disabled {
+		return nil, fmt.Errorf("Index %s is already defined by SplunkIndex %s", name, existing.Resource)
+	}
+	indexes[name] = splunkIndexDefinition{
+		Resource:               cr.GetName(),
+		DataType:               cr.Spec.DataType,
+		FrozenTimePeriodInSecs: cr.Spec.FrozenTimePeriodInSecs,
+		MaxTotalDataSizeMB:     cr.Spec.MaxTotalDataSizeMB,
+		RemotePath:             cr.Spec.RemotePath,
+		Disabled:               disabled,
+	}
+	state, err := json.Marshal(indexes)
+	if err != nil {
+		return nil, err
+	}
+
+	var configMap *corev1.ConfigMap
+	if current != nil {
+		configMap = current.DeepCopy()
+	} else {
+		configMap = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      GetSplunkIndexesName(target.GetIdentifier(), instanceType),
+				Namespace: target.GetNamespace(),
+			},
+		}
+		configMap.SetOwnerReferences(append(configMap.GetOwnerReferences(), resources.AsOwner(target)))
+	}
+	configMap.Data = map[string]string{
+		indexesConfKey:  getSplunkIndexesConf(indexes, instanceType),
+		indexesStateKey: string(state),
+	}
+	return configMap, nil
+}
+
+// GetSplunkIndexesConf returns the indexes.conf contained in an indexes ConfigMap
+func GetSplunkIndexesConf(configMap *corev1.ConfigMap) string {
+	return configMap.Data[indexesConfKey]
+}
+
+// addSplunkIndexesToPodTemplate mounts the indexes ConfigMap into the app directory used for indexes defined by
+// SplunkIndex resources. The ConfigMap is optional, since it is only created once an index is defined.
+func addSplunkIndexesToPodTemplate(podTemplateSpec *corev1.PodTemplateSpec, cr enterprisev1.MetaObject, instanceType InstanceType) {
+	configMapVolDefaultMode := int32(corev1.ConfigMapVolumeSourceDefaultMode)
+	optional := true
+	podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, corev1.Volume{
+		Name: "mnt-splunk-indexes",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: GetSplunkIndexesName(cr.GetIdentifier(), instanceType),
+				},
+				Items:       []corev1.KeyToPath{{Key: indexesConfKey, Path: indexesConfKey}},
+				DefaultMode: &configMapVolDefaultMode,
+				Optional:    &optional,
+			},
+		},
+	})
+
+	for idx := range podTemplateSpec.Spec.Containers {
+		containerSpec := &podTemplateSpec.Spec.Containers[idx]
+		containerSpec.VolumeMounts = append(containerSpec.VolumeMounts, corev1.VolumeMount{
+			Name:      "mnt-splunk-indexes",
+			MountPath: getSplunkIndexesAppDirectory(instanceType),
+		})
+	}
+}
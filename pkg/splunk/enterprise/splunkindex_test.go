@@ -0,0 +1,240 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func newTestSplunkIndex(name string) *enterprisev1.SplunkIndex {
+	cr := enterprisev1.SplunkIndex{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "test",
+		},
+	}
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "IndexerCluster", Name: "stack1"}
+	return &cr
+}
+
+func TestValidateSplunkIndex(t *testing.T) {
+	test := func(cr *enterprisev1.SplunkIndex, wantErr string) {
+		err := ValidateSplunkIndex(cr)
+		if wantErr == "" && err != nil {
+			t.Errorf("ValidateSplunkIndex(%s) returned error: %v", cr.GetName(), err)
+		} else if wantErr != "" && (err == nil || !strings.Contains(err.Error(), wantErr)) {
+			t.Errorf("ValidateSplunkIndex(%s) returned %v; want error containing %q", cr.GetName(), err, wantErr)
+		}
+	}
+
+	// defaults are applied
+	cr := newTestSplunkIndex("web")
+	test(cr, "")
+	if cr.Spec.IndexName != "web" || cr.Spec.DataType != "event" {
+		t.Errorf("ValidateSplunkIndex() defaults = %s, %s; want web, event", cr.Spec.IndexName, cr.Spec.DataType)
+	}
+
+	cr = newTestSplunkIndex("web.logs")
+	test(cr, "Invalid index name")
+	cr.Spec.IndexName = "web_logs"
+	test(cr, "")
+
+	test(newTestSplunkIndex("main"), "defined by Splunk Enterprise")
+
+	cr = newTestSplunkIndex("web")
+	cr.Spec.TargetRef.Kind = "SearchHeadCluster"
+	test(cr, "targetRef kind")
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "Standalone"}
+	test(cr, "targetRef name")
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "Standalone", Name: "s1", Namespace: "other"}
+	test(cr, "must refer to a resource in namespace test")
+
+	cr = newTestSplunkIndex("metrics")
+	cr.Spec.DataType = "metrics"
+	test(cr, "Invalid datatype")
+	cr.Spec.DataType = "metric"
+	cr.Spec.MaxTotalDataSizeMB = -1
+	test(cr, "must not be negative")
+
+	cr = newTestSplunkIndex("web")
+	cr.Spec.RemotePath = "s3://bucket/web"
+	test(cr, "remotePath must refer to a SmartStore volume")
+	cr.Spec.RemotePath = "volume:s3/\"web"
+	test(cr, "must not contain quotes")
+	cr.Spec.RemotePath = "volume:s3/web"
+	test(cr, "")
+}
+
+func TestValidateSplunkIndexUpdate(t *testing.T) {
+	current := newTestSplunkIndex("web")
+	if err := ValidateSplunkIndex(current); err != nil {
+		t.Fatalf("ValidateSplunkIndex() returned error: %v", err)
+	}
+
+	revised := current.DeepCopy()
+	revised.Spec.FrozenTimePeriodInSecs = 86400
+	if err := ValidateSplunkIndexUpdate(current, revised); err != nil {
+		t.Errorf("ValidateSplunkIndexUpdate() returned error for retention change: %v", err)
+	}
+
+	revised = current.DeepCopy()
+	revised.Spec.IndexName = "web2"
+	if err := ValidateSplunkIndexUpdate(current, revised); err == nil {
+		t.Errorf("ValidateSplunkIndexUpdate() returned nil for indexName change; want error")
+	}
+
+	revised = current.DeepCopy()
+	revised.Spec.TargetRef.Name = "stack2"
+	if err := ValidateSplunkIndexUpdate(current, revised); err == nil {
+		t.Errorf("ValidateSplunkIndexUpdate() returned nil for targetRef change; want error")
+	}
+}
+
+func TestGetSplunkIndexesConfigMap(t *testing.T) {
+	target := enterprisev1.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	web := newTestSplunkIndex("web")
+	web.Spec.FrozenTimePeriodInSecs = 86400
+	web.Spec.RemotePath = "volume:s3/web"
+	metrics := newTestSplunkIndex("metrics")
+	metrics.Spec.DataType = "metric"
+	metrics.Spec.MaxTotalDataSizeMB = 1024
+	for _, cr := range []*enterprisev1.SplunkIndex{web, metrics} {
+		if err := ValidateSplunkIndex(cr); err != nil {
+			t.Fatalf("ValidateSplunkIndex() returned error: %v", err)
+		}
+	}
+
+	test := func(current *corev1.ConfigMap, cr *enterprisev1.SplunkIndex, disabled bool, want string) *corev1.ConfigMap {
+		got, err := GetSplunkIndexesConfigMap(&target, SplunkClusterMaster, current, cr, disabled)
+		if err != nil {
+			t.Fatalf("GetSplunkIndexesConfigMap(%s) returned error: %v", cr.GetName(), err)
+		}
+		if got.GetName() != "splunk-stack1-cluster-master-indexes" || got.GetNamespace() != "test" {
+			t.Errorf("GetSplunkIndexesConfigMap(%s) name = %s/%s; want test/splunk-stack1-cluster-master-indexes", cr.GetName(), got.GetNamespace(), got.GetName())
+		}
+		if conf := GetSplunkIndexesConf(got); conf != want {
+			t.Errorf("GetSplunkIndexesConfigMap(%s) indexes.conf = %s;\nwant %s", cr.GetName(), conf, want)
+		}
+		return got
+	}
+
+	configMap := test(nil, web, false, `# Generated by the Splunk Operator; do not edit.
+
+[web]
+homePath = $SPLUNK_DB/web/db
+coldPath = $SPLUNK_DB/web/colddb
+thawedPath = $SPLUNK_DB/web/thaweddb
+datatype = event
+frozenTimePeriodInSecs = 86400
+remotePath = volume:s3/web
+repFactor = auto
+disabled = false
+`)
+	if len(configMap.GetOwnerReferences()) != 1 || configMap.GetOwnerReferences()[0].Name != "stack1" {
+		t.Errorf("GetSplunkIndexesConfigMap() owner references = %v; want stack1", configMap.GetOwnerReferences())
+	}
+
+	// indexes defined by other resources are preserved
+	configMap = test(configMap, metrics, false, `# Generated by the Splunk Operator; do not edit.
+
+[metrics]
+homePath = $SPLUNK_DB/metrics/db
+coldPath = $SPLUNK_DB/metrics/colddb
+thawedPath = $SPLUNK_DB/metrics/thaweddb
+datatype = metric
+maxTotalDataSizeMB = 1024
+repFactor = auto
+disabled = false
+
+[web]
+homePath = $SPLUNK_DB/web/db
+coldPath = $SPLUNK_DB/web/colddb
+thawedPath = $SPLUNK_DB/web/thaweddb
+datatype = event
+frozenTimePeriodInSecs = 86400
+remotePath = volume:s3/web
+repFactor = auto
+disabled = false
+`)
+
+	// deleted indexes are disabled, not removed
+	configMap = test(configMap, web, true, `# Generated by the Splunk Operator; do not edit.
+
+[metrics]
+homePath = $SPLUNK_DB/metrics/db
+coldPath = $SPLUNK_DB/metrics/colddb
+thawedPath = $SPLUNK_DB/metrics/thaweddb
+datatype = metric
+maxTotalDataSizeMB = 1024
+repFactor = auto
+disabled = false
+
+[web]
+homePath = $SPLUNK_DB/web/db
+coldPath = $SPLUNK_DB/web/colddb
+thawedPath = $SPLUNK_DB/web/thaweddb
+datatype = event
+frozenTimePeriodInSecs = 86400
+remotePath = volume:s3/web
+repFactor = auto
+disabled = true
+`)
+
+	// an index can only be defined by one resource at a time
+	other := newTestSplunkIndex("other")
+	other.Spec.IndexName = "metrics"
+	if err := ValidateSplunkIndex(other); err != nil {
+		t.Fatalf("ValidateSplunkIndex() returned error: %v", err)
+	}
+	if _, err := GetSplunkIndexesConfigMap(&target, SplunkClusterMaster, configMap, other, false); err == nil {
+		t.Errorf("GetSplunkIndexesConfigMap() returned nil for an index defined by another resource; want error")
+	}
+	if got, err := GetSplunkIndexesConfigMap(&target, SplunkClusterMaster, configMap, other, true); err != nil || got != configMap {
+		t.Errorf("GetSplunkIndexesConfigMap() changed an index defined by another resource when disabling: %v", err)
+	}
+
+	// disabled indexes may be taken over by another resource
+	other.Spec = web.Spec
+	configMap = test(configMap, other, false, strings.Replace(GetSplunkIndexesConf(configMap), "disabled = true", "disabled = false", 1))
+
+	// nothing is disabled if an index was never defined
+	if got, err := GetSplunkIndexesConfigMap(&target, SplunkClusterMaster, nil, web, true); err != nil || got != nil {
+		t.Errorf("GetSplunkIndexesConfigMap() = %v, %v; want nil, nil", got, err)
+	}
+
+	// standalone instances do not use replication
+	got, err := GetSplunkIndexesConfigMap(&target, SplunkStandalone, nil, metrics, false)
+	if err != nil {
+		t.Fatalf("GetSplunkIndexesConfigMap() returned error: %v", err)
+	}
+	if conf := GetSplunkIndexesConf(got); strings.Contains(conf, "repFactor") {
+		t.Errorf("GetSplunkIndexesConfigMap(standalone) indexes.conf = %s; want no repFactor", conf)
+	}
+}
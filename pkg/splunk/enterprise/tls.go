@@ -0,0 +1,133 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+const (
+	// size of RSA keys generated for certificate authorities and splunkd server certificates
+	tlsKeyBits = 2048
+
+	// validity period of generated certificate authorities and splunkd server certificates
+	tlsValidity = 10 * 365 * 24 * time.Hour
+)
+
+// allInstanceTypes is a list of every type of Splunk instance managed by the operator
+var allInstanceTypes = []InstanceType{
+	SplunkStandalone,
+	SplunkClusterMaster,
+	SplunkSearchHead,
+	SplunkIndexer,
+	SplunkDeployer,
+	SplunkLicenseMaster,
+	SplunkMonitoringConsole,
+}
+
+// GetSplunkTLSHostnames returns the DNS names that splunkd server certificates for a custom resource must be valid for.
+// This includes the regular and headless services for every type of instance that shares the resource's secrets.
+func GetSplunkTLSHostnames(cr enterprisev1.MetaObject, instanceType InstanceType) []string {
+	hostnames := []string{}
+	for _, t := range allInstanceTypes {
+		if t.ToKind() != instanceType.ToKind() {
+			continue
+		}
+		serviceName := GetSplunkServiceName(t, cr.GetIdentifier(), false)
+		headlessName := GetSplunkServiceName(t, cr.GetIdentifier(), true)
+		hostnames = append(hostnames,
+			serviceName,
+			fmt.Sprintf("%s.%s", serviceName, cr.GetNamespace()),
+			resources.GetServiceFQDN(cr.GetNamespace(), serviceName),
+			fmt.Sprintf("*.%s", resources.GetServiceFQDN(cr.GetNamespace(), headlessName)))
+	}
+	return hostnames
+}
+
+// AddSplunkTLSSecrets generates a certificate authority and server certificate for splunkd's management port, and adds them
+// to a Kubernetes Secret created by GetSplunkSecrets. The server certificate is valid for every name returned by GetSplunkTLSHostnames.
+func AddSplunkTLSSecrets(cr enterprisev1.MetaObject, instanceType InstanceType, secret *corev1.Secret) error {
+	commonName := fmt.Sprintf("splunk-%s-%s", cr.GetIdentifier(), instanceType.ToKind())
+
+	caKey, err := rsa.GenerateKey(rand.Reader, tlsKeyBits)
+	if err != nil {
+		return err
+	}
+	caTemplate := newCertificateTemplate(commonName + "-ca")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		return err
+	}
+
+	serverKey, err := rsa.GenerateKey(rand.Reader, tlsKeyBits)
+	if err != nil {
+		return err
+	}
+	serverTemplate := newCertificateTemplate(commonName)
+	serverTemplate.DNSNames = GetSplunkTLSHostnames(cr, instanceType)
+	serverTemplate.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+	serverDER, err := x509.CreateCertificate(rand.Reader, serverTemplate, caCert, &serverKey.PublicKey, caKey)
+	if err != nil {
+		return err
+	}
+
+	// splunkd expects the server certificate, its private key and the CA certificate to be concatenated into one file
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	var serverPEM bytes.Buffer
+	pem.Encode(&serverPEM, &pem.Block{Type: "CERTIFICATE", Bytes: serverDER})
+	pem.Encode(&serverPEM, &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(serverKey)})
+	serverPEM.Write(caPEM)
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data["ca.crt"] = caPEM
+	secret.Data["server.pem"] = serverPEM.Bytes()
+	secret.Data["default.yml"] = getSplunkSecretsDefaults(secret.Data)
+	return nil
+}
+
+// newCertificateTemplate returns a template for a new x509 certificate with a random serial number
+func newCertificateTemplate(commonName string) *x509.Certificate {
+	serialNumber, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	notBefore := time.Now().Add(-5 * time.Minute)
+	return &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject:      pkix.Name{CommonName: commonName, Organization: []string{"Splunk Operator"}},
+		NotBefore:    notBefore,
+		NotAfter:     notBefore.Add(tlsValidity),
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package enterprise
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestGetSplunkTLSHostnames(t *testing.T) {
+	cr := enterprisev1.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	want := []string{
+		"splunk-stack1-search-head-service",
+		"splunk-stack1-search-head-service.test",
+		"splunk-stack1-search-head-service.test.svc.cluster.local",
+		"*.splunk-stack1-search-head-headless.test.svc.cluster.local",
+		"splunk-stack1-deployer-service",
+		"splunk-stack1-deployer-service.test",
+		"splunk-stack1-deployer-service.test.svc.cluster.local",
+		"*.splunk-stack1-deployer-headless.test.svc.cluster.local",
+	}
+	got := GetSplunkTLSHostnames(&cr, SplunkSearchHead)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("GetSplunkTLSHostnames() = %v; want %v", got, want)
+	}
+}
+
+func TestAddSplunkTLSSecrets(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	secret := GetSplunkSecrets(&cr, SplunkIndexer, nil, nil)
+	if err := AddSplunkTLSSecrets(&cr, SplunkIndexer, secret); err != nil {
+		t.Fatalf("AddSplunkTLSSecrets() returned %v; want nil", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data["ca.crt"]) {
+		t.Fatalf("AddSplunkTLSSecrets() ca.crt has no certificates")
+	}
+	block, rest := pem.Decode(secret.Data["server.pem"])
+	if block == nil || block.Type != "CERTIFICATE" {
+		t.Fatalf("AddSplunkTLSSecrets() server.pem does not start with a certificate")
+	}
+	serverCert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("AddSplunkTLSSecrets() server.pem has invalid certificate: %v", err)
+	}
+	if key, _ := pem.Decode(rest); key == nil || key.Type != "RSA PRIVATE KEY" {
+		t.Errorf("AddSplunkTLSSecrets() server.pem does not include a private key")
+	}
+
+	// the server certificate must be valid for the names used by the operator to reach splunkd
+	for _, name := range []string{
+		"splunk-stack1-cluster-master-service.test.svc.cluster.local",
+		"splunk-stack1-indexer-0.splunk-stack1-indexer-headless.test.svc.cluster.local",
+		"splunk-stack1-indexer-site1-2.splunk-stack1-indexer-headless.test.svc.cluster.local",
+	} {
+		_, err := serverCert.Verify(x509.VerifyOptions{DNSName: name, Roots: roots})
+		if err != nil {
+			t.Errorf("AddSplunkTLSSecrets() server certificate is not valid for %s: %v", name, err)
+		}
+	}
+	if _, err := serverCert.Verify(x509.VerifyOptions{DNSName: "splunk-stack2-indexer-0.splunk-stack2-indexer-headless.test.svc.cluster.local", Roots: roots}); err == nil {
+		t.Errorf("AddSplunkTLSSecrets() server certificate is valid for another indexer cluster")
+	}
+
+	if !strings.Contains(string(secret.Data["default.yml"]), "serverCert: /mnt/splunk-secrets/server.pem") {
+		t.Errorf("AddSplunkTLSSecrets() default.yml does not configure serverCert:\n%s", secret.Data["default.yml"])
+	}
+	if !strings.Contains(string(secret.Data["default.yml"]), string(secret.Data["password"])) {
+		t.Errorf("AddSplunkTLSSecrets() default.yml does not include existing secrets")
+	}
+
+	// secrets without any data should be initialized
+	empty := corev1.Secret{}
+	if err := AddSplunkTLSSecrets(&cr, SplunkIndexer, &empty); err != nil || len(empty.Data["ca.crt"]) == 0 {
+		t.Errorf("AddSplunkTLSSecrets() did not initialize empty secret: %v", err)
+	}
+}
@@ -35,6 +35,9 @@ const (
 
 	// SplunkLicenseMaster controls one or more license slaves
 	SplunkLicenseMaster InstanceType = "license-master"
+
+	// SplunkMonitoringConsole is a distributed monitoring console that searches all other Splunk instances, see https://docs.splunk.com/Documentation/Splunk/latest/DMC/DMCoverview
+	SplunkMonitoringConsole InstanceType = "monitoring-console"
 )
 
 // ToString returns a string for a given InstanceType
@@ -58,6 +61,8 @@ func (instanceType InstanceType) ToRole() string {
 		role = "splunk_deployer"
 	case SplunkLicenseMaster:
 		role = "splunk_license_master"
+	case SplunkMonitoringConsole:
+		role = "splunk_monitor"
 	}
 	return role
 }
@@ -78,6 +83,8 @@ func (instanceType InstanceType) ToKind() string {
 		kind = "search-head"
 	case SplunkLicenseMaster:
 		kind = "license-master"
+	case SplunkMonitoringConsole:
+		kind = "monitoring-console"
 	}
 	return kind
 }
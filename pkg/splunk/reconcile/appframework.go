@@ -0,0 +1,215 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// ApplyAppFramework creates or updates the manifest and app repository used to install apps on a type of Splunk instance.
+func ApplyAppFramework(client ControllerClient, cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, instanceType enterprise.InstanceType, sources []enterprisev1.AppSource) error {
+	configMap, err := enterprise.GetAppFrameworkConfigMap(cr, instanceType, sources)
+	if err != nil {
+		return err
+	}
+	err = ApplyConfigMap(client, configMap)
+	if err != nil {
+		return err
+	}
+
+	if !enterprise.NeedsAppRepository(sources) {
+		return deleteAppRepository(client, cr, instanceType)
+	}
+	err = ApplyService(client, enterprise.GetAppRepositoryService(cr, instanceType))
+	if err != nil {
+		return err
+	}
+	_, err = ApplyDeployment(client, enterprise.GetAppRepositoryDeployment(cr, spec, instanceType, sources))
+	return err
+}
+
+// deleteAppRepository removes the app repository for a type of Splunk instance, if it exists
+func deleteAppRepository(client ControllerClient, cr enterprisev1.MetaObject, instanceType enterprise.InstanceType) error {
+	namespacedName := types.NamespacedName{
+		Namespace: cr.GetNamespace(),
+		Name:      enterprise.GetSplunkAppRepositoryName(cr.GetIdentifier(), instanceType),
+	}
+	var deployment appsv1.Deployment
+	if err := client.Get(context.TODO(), namespacedName, &deployment); err == nil {
+		log.Info("Removing app repository", "name", namespacedName.Name, "namespace", namespacedName.Namespace)
+		if err = client.Delete(context.TODO(), &deployment); err != nil {
+			return err
+		}
+	}
+	var service corev1.Service
+	if err := client.Get(context.TODO(), namespacedName, &service); err == nil {
+		if err = client.Delete(context.TODO(), &service); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateAppFrameworkStatus queries the app framework sidecar of each pod in a StatefulSet, updates the status of the
+// apps installed on them and the AppsInstalled condition, and returns true if all of the app sources are installed.
+func updateAppFrameworkStatus(client ControllerClient, cr enterprisev1.MetaObject, generation int64, sources []enterprisev1.AppSource, statefulSet *appsv1.StatefulSet,
+	status *[]enterprisev1.AppTargetStatus, conditions *[]enterprisev1.Condition, newAppFrameworkClient func(statusURI string) *splclient.AppFrameworkClient) bool {
+
+	if !enterprise.IsAppFrameworkEnabled(sources, *status) {
+		resources.RemoveCondition(conditions, enterprisev1.ConditionAppsInstalled)
+		return true
+	}
+
+	previous := make(map[string]enterprisev1.AppTargetStatus)
+	for _, target := range *status {
+		previous[target.Target] = target
+	}
+
+	problems := []string{}
+	targets := []enterprisev1.AppTargetStatus{}
+	for n := int32(0); n < statefulSet.Status.Replicas; n++ {
+		podName := fmt.Sprintf("%s-%d", statefulSet.GetName(), n)
+		target, err := getAppTargetStatus(client, cr.GetNamespace(), podName, newAppFrameworkClient)
+		if err != nil {
+			log.Info("Unable to retrieve app framework status", "podName", podName, "error", err.Error())
+			problems = append(problems, fmt.Sprintf("%s: status unavailable", podName))
+			if prev, ok := previous[podName]; ok {
+				target = &prev
+			} else {
+				target = &enterprisev1.AppTargetStatus{Target: podName, Apps: []enterprisev1.AppStatus{}}
+			}
+		} else {
+			problems = append(problems, getAppTargetProblems(sources, target)...)
+		}
+		targets = append(targets, *target)
+	}
+	if statefulSet.Status.Replicas == 0 {
+		problems = append(problems, "waiting for pods")
+	}
+
+	installed := len(problems) == 0
+	if len(sources) == 0 && installed {
+		// all apps have been removed; the app framework sidecar is no longer needed
+		*status = nil
+		resources.RemoveCondition(conditions, enterprisev1.ConditionAppsInstalled)
+		return true
+	}
+
+	*status = targets
+	if installed {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionAppsInstalled, corev1.ConditionTrue, "Installed", "")
+	} else {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionAppsInstalled, corev1.ConditionFalse, "Installing", strings.Join(problems, "; "))
+	}
+	return installed
+}
+
+// getAppTargetStatus queries the app framework sidecar running in a pod for the status of installed apps
+func getAppTargetStatus(client ControllerClient, namespace, podName string, newAppFrameworkClient func(statusURI string) *splclient.AppFrameworkClient) (*enterprisev1.AppTargetStatus, error) {
+	var pod corev1.Pod
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: podName}, &pod)
+	if err != nil {
+		return nil, err
+	}
+	if pod.Status.PodIP == "" {
+		return nil, fmt.Errorf("Pod %s does not have an IP address", podName)
+	}
+
+	c := newAppFrameworkClient(fmt.Sprintf("http://%s:%d", pod.Status.PodIP, enterprise.AppFrameworkStatusPort))
+	appStatus, err := c.GetStatus()
+	if err != nil {
+		return nil, err
+	}
+
+	target := enterprisev1.AppTargetStatus{
+		Target:      podName,
+		Apps:        []enterprisev1.AppStatus{},
+		PendingPush: appStatus.PendingPush,
+	}
+	for _, app := range appStatus.Apps {
+		target.Apps = append(target.Apps, enterprisev1.AppStatus{
+			Name:     app.Name,
+			Version:  app.Version,
+			Checksum: app.Checksum,
+			Error:    appStatus.Errors[app.Name],
+		})
+	}
+
+	// include errors for apps that could not be installed, and for the bundle push (which uses an empty name)
+	names := []string{}
+	for name := range appStatus.Errors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		found := false
+		for idx := range target.Apps {
+			found = found || target.Apps[idx].Name == name
+		}
+		if !found {
+			target.Apps = append(target.Apps, enterprisev1.AppStatus{Name: name, Error: appStatus.Errors[name]})
+		}
+	}
+	return &target, nil
+}
+
+// getAppTargetProblems returns a list of reasons why the apps installed on a target do not match the app sources
+func getAppTargetProblems(sources []enterprisev1.AppSource, target *enterprisev1.AppTargetStatus) []string {
+	problems := []string{}
+	installed := make(map[string]enterprisev1.AppStatus)
+	for _, app := range target.Apps {
+		if app.Error != "" {
+			if app.Name == "" {
+				problems = append(problems, fmt.Sprintf("%s: %s", target.Target, app.Error))
+			} else {
+				problems = append(problems, fmt.Sprintf("%s: app %s: %s", target.Target, app.Name, app.Error))
+			}
+		}
+		if app.Checksum != "" {
+			installed[app.Name] = app
+		}
+	}
+	for _, source := range sources {
+		app, ok := installed[source.Name]
+		if !ok || app.Version != source.Version || (source.Checksum != "" && app.Checksum != source.Checksum) {
+			problems = append(problems, fmt.Sprintf("%s: app %s is not installed", target.Target, source.Name))
+		}
+		delete(installed, source.Name)
+	}
+	removed := []string{}
+	for name := range installed {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		problems = append(problems, fmt.Sprintf("%s: app %s has not been removed", target.Target, name))
+	}
+	if target.PendingPush {
+		problems = append(problems, fmt.Sprintf("%s: waiting for apps to be pushed", target.Target))
+	}
+	return problems
+}
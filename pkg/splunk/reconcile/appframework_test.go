@@ -0,0 +1,168 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"net/http"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplyAppFramework(t *testing.T) {
+	funcCalls := []mockFuncCall{
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-standalone-app-framework"},
+		{metaName: "*v1.Service-test-splunk-stack1-standalone-app-repository"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-standalone-app-repository"},
+	}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": {funcCalls[0]}}
+	current := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.StandaloneSpec{
+			AppSources: []enterprisev1.AppSource{
+				{
+					Name:    "app1",
+					Version: "1.0",
+					ConfigMap: &corev1.ConfigMapKeySelector{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "apps"},
+						Key:                  "app1.tgz",
+					},
+				},
+			},
+		},
+	}
+	current.Spec.Image = "splunk/splunk"
+	revised := current.DeepCopy()
+	revised.Spec.AppSources[0].Version = "1.1"
+	reconcile := func(c *mockClient, cr interface{}) error {
+		obj := cr.(*enterprisev1.Standalone)
+		return ApplyAppFramework(c, obj, &obj.Spec.CommonSplunkSpec, enterprise.SplunkStandalone, obj.Spec.AppSources)
+	}
+	reconcileTester(t, "TestApplyAppFramework", &current, revised, createCalls, updateCalls, reconcile)
+
+	// app repository is removed when it is no longer needed
+	c := newMockClient()
+	if err := reconcile(c, &current); err != nil {
+		t.Errorf("ApplyAppFramework() returned error: %v", err)
+	}
+	c.resetCalls()
+	revised.Spec.AppSources[0].ConfigMap = nil
+	revised.Spec.AppSources[0].URL = "https://example.com/app1.tgz"
+	if err := reconcile(c, revised); err != nil {
+		t.Errorf("ApplyAppFramework() returned error: %v", err)
+	}
+	c.checkCalls(t, "TestApplyAppFramework(remove-repository)", map[string][]mockFuncCall{
+		"Get":    {funcCalls[0], funcCalls[2], funcCalls[1]},
+		"Update": {funcCalls[0]},
+		"Delete": {funcCalls[2], funcCalls[1]},
+	})
+}
+
+func TestUpdateAppFrameworkStatus(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "stack1",
+			Namespace:  "test",
+			Generation: 2,
+		},
+		Spec: enterprisev1.StandaloneSpec{
+			AppSources: []enterprisev1.AppSource{
+				{Name: "app1", Version: "1.0", URL: "https://example.com/app1.tgz"},
+				{Name: "app2", URL: "https://example.com/app2.tgz"},
+			},
+		},
+	}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-standalone",
+			Namespace: "test",
+		},
+		Status: appsv1.StatefulSetStatus{Replicas: 1},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-standalone-0",
+			Namespace: "test",
+		},
+		Status: corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+
+	test := func(body string, wantInstalled bool, wantApps int, wantStatus corev1.ConditionStatus) {
+		c := newMockClient()
+		c.state[getStateKey(pod)] = pod
+		mockHTTPClient := &spltest.MockHTTPClient{}
+		wantRequest, _ := http.NewRequest("GET", "http://10.0.0.1:9090/status", nil)
+		mockHTTPClient.AddHandler(wantRequest, 200, body, nil)
+		newClient := func(statusURI string) *splclient.AppFrameworkClient {
+			appClient := splclient.NewAppFrameworkClient(statusURI)
+			appClient.Client = mockHTTPClient
+			return appClient
+		}
+		installed := updateAppFrameworkStatus(c, &cr, cr.GetGeneration(), cr.Spec.AppSources, statefulSet, &cr.Status.Apps, &cr.Status.Conditions, newClient)
+		mockHTTPClient.CheckRequests(t, "TestUpdateAppFrameworkStatus")
+		if installed != wantInstalled {
+			t.Errorf("updateAppFrameworkStatus() = %t; want %t", installed, wantInstalled)
+		}
+		if wantApps < 0 {
+			if cr.Status.Apps != nil {
+				t.Errorf("updateAppFrameworkStatus() Apps = %v; want nil", cr.Status.Apps)
+			}
+		} else if len(cr.Status.Apps) != 1 || cr.Status.Apps[0].Target != pod.GetName() || len(cr.Status.Apps[0].Apps) != wantApps {
+			t.Errorf("updateAppFrameworkStatus() Apps = %v; want %d apps on %s", cr.Status.Apps, wantApps, pod.GetName())
+		}
+		condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionAppsInstalled)
+		if wantStatus == "" {
+			if condition != nil {
+				t.Errorf("updateAppFrameworkStatus() AppsInstalled = %v; want none", condition)
+			}
+		} else if condition == nil || condition.Status != wantStatus {
+			t.Errorf("updateAppFrameworkStatus() AppsInstalled = %v; want %s", condition, wantStatus)
+		}
+	}
+
+	// one app is installed, the other failed
+	test(`{"apps":[{"name":"app1","version":"1.0","checksum":"abc"}],"errors":{"app2":"download failed"},"pendingPush":false}`, false, 2, corev1.ConditionFalse)
+	if cr.Status.Apps[0].Apps[1].Error != "download failed" {
+		t.Errorf("updateAppFrameworkStatus() app2 error = %s; want %s", cr.Status.Apps[0].Apps[1].Error, "download failed")
+	}
+
+	// apps are installed but waiting to be pushed
+	test(`{"apps":[{"name":"app1","version":"1.0","checksum":"abc"},{"name":"app2","checksum":"def"}],"errors":{},"pendingPush":true}`, false, 2, corev1.ConditionFalse)
+
+	// all apps are installed
+	test(`{"apps":[{"name":"app1","version":"1.0","checksum":"abc"},{"name":"app2","checksum":"def"}],"errors":{},"pendingPush":false}`, true, 2, corev1.ConditionTrue)
+
+	// apps have been removed from the spec but not yet from the target
+	cr.Spec.AppSources = nil
+	test(`{"apps":[{"name":"app1","version":"1.0","checksum":"abc"},{"name":"app2","checksum":"def"}],"errors":{},"pendingPush":false}`, false, 2, corev1.ConditionFalse)
+
+	// all apps have been removed, so the app framework is disabled
+	test(`{"apps":[],"errors":{},"pendingPush":false}`, true, -1, "")
+}
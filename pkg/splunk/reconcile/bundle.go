@@ -0,0 +1,169 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// clusterBundleState describes the configuration bundles of an indexer cluster, as reported by its cluster master
+type clusterBundleState struct {
+	// configuration bundle currently being used by the peers
+	activeBundle enterprisev1.ClusterBundleStatus
+
+	// most recent configuration bundle
+	latestBundle enterprisev1.ClusterBundleStatus
+
+	// true if the peers must be restarted to apply the latest bundle
+	restartRequired bool
+
+	// names of the peers that are not using the active bundle
+	outdatedPeers []string
+
+	// true while a bundle is waiting to be validated, pushed or activated on all peers
+	pending bool
+}
+
+// applyClusterBundle pushes changes to the operator-managed configuration in a cluster master's master-apps to all of
+// its peers, and returns the current state of the indexer cluster's bundles. Changes are only pushed if push is true
+// (cr manages the cluster master) and the bundle passes validation; validation failures are reported by the
+// BundlePushed condition, and the bundle is not pushed again until the configuration changes.
+func applyClusterBundle(c ControllerClient, cr enterprisev1.MetaObject, splunkClient *splclient.SplunkClient, info *splclient.ClusterMasterInfo, push bool, conditions *[]enterprisev1.Condition) (*clusterBundleState, error) {
+	scopedLog := log.WithName("applyClusterBundle").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	peers, err := splunkClient.GetClusterMasterPeers()
+	if err != nil {
+		return nil, err
+	}
+	state := getClusterBundleState(info, peers)
+
+	// get the operator-managed configuration that is stored in master-apps
+	var configMap *corev1.ConfigMap
+	if push {
+		namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: enterprise.GetSplunkIndexesName(cr.GetIdentifier(), enterprise.SplunkClusterMaster)}
+		var current corev1.ConfigMap
+		err = c.Get(context.TODO(), namespacedName, &current)
+		if err == nil {
+			configMap = &current
+		} else if !errors.IsNotFound(err) {
+			return state, err
+		}
+	}
+
+	if configMap != nil {
+		checksum := getSplunkIndexesChecksum(configMap)
+		annotations := configMap.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		switch {
+		case annotations[indexesPushedAnnotation] == checksum:
+			// nothing has changed since the last push
+
+		case annotations[indexesRejectedAnnotation] == checksum:
+			resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionFalse, "ValidationFailed", getClusterBundleValidationErrors(info))
+			return state, nil
+
+		case !isSplunkIndexesSynced(configMap):
+			state.pending = true
+			resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionFalse, "WaitingForSync",
+				fmt.Sprintf("Waiting for ConfigMap %s to be updated in pods", configMap.GetName()))
+			return state, nil
+
+		default:
+			// validate the bundle before pushing it, so that invalid configuration never reaches the peers
+			scopedLog.Info("Validating cluster bundle", "configMap", configMap.GetName())
+			if err = splunkClient.ValidateClusterBundle(true); err != nil {
+				return state, err
+			}
+			if info, err = splunkClient.GetClusterMasterInfo(); err != nil {
+				return state, err
+			}
+			state.restartRequired = info.LastCheckRestartBundleResult
+			if !info.LastValidatedBundle.IsValidBundle {
+				scopedLog.Info("Cluster bundle failed validation", "errors", info.ApplyBundleStatus.InvalidBundle.BundleValidationErrors)
+				resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionFalse, "ValidationFailed", getClusterBundleValidationErrors(info))
+				annotations[indexesRejectedAnnotation] = checksum
+				configMap.SetAnnotations(annotations)
+				return state, UpdateResource(c, configMap)
+			}
+
+			scopedLog.Info("Applying cluster bundle", "checksum", info.LastValidatedBundle.Checksum, "restartRequired", state.restartRequired)
+			if err = splunkClient.ApplyClusterBundle(); err != nil {
+				resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionFalse, "PushFailed", err.Error())
+				return state, err
+			}
+			state.pending = true
+			annotations[indexesPushedAnnotation] = checksum
+			delete(annotations, indexesRejectedAnnotation)
+			configMap.SetAnnotations(annotations)
+			if err = UpdateResource(c, configMap); err != nil {
+				return state, err
+			}
+		}
+	}
+
+	// wait for all of the peers to use the latest bundle
+	if state.activeBundle.Checksum != state.latestBundle.Checksum || len(state.outdatedPeers) > 0 {
+		state.pending = true
+	}
+	if state.pending {
+		message := fmt.Sprintf("Waiting for peers to use bundle %s", state.latestBundle.Checksum)
+		if len(state.outdatedPeers) > 0 {
+			message = fmt.Sprintf("%s: %s", message, strings.Join(state.outdatedPeers, ", "))
+		}
+		resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionFalse, "Pushing", message)
+	} else {
+		resources.SetCondition(conditions, cr.GetObjectMeta().GetGeneration(), enterprisev1.ConditionBundlePushed, corev1.ConditionTrue, "Pushed", "")
+	}
+	return state, nil
+}
+
+// getClusterBundleState returns the state of an indexer cluster's bundles, using information from its cluster master
+func getClusterBundleState(info *splclient.ClusterMasterInfo, peers map[string]splclient.ClusterMasterPeerInfo) *clusterBundleState {
+	state := clusterBundleState{
+		activeBundle:    enterprisev1.ClusterBundleStatus{Checksum: info.ActiveBundle.Checksum, Timestamp: info.ActiveBundle.Timestamp},
+		latestBundle:    enterprisev1.ClusterBundleStatus{Checksum: info.LatestBundle.Checksum, Timestamp: info.LatestBundle.Timestamp},
+		restartRequired: info.LastCheckRestartBundleResult,
+	}
+	for name, peer := range peers {
+		if peer.ActiveBundleID != info.ActiveBundle.Checksum {
+			state.outdatedPeers = append(state.outdatedPeers, name)
+		}
+	}
+	sort.Strings(state.outdatedPeers)
+	return &state
+}
+
+// getClusterBundleValidationErrors returns a message describing why the last bundle validated by a cluster master is invalid
+func getClusterBundleValidationErrors(info *splclient.ClusterMasterInfo) string {
+	validationErrors := info.ApplyBundleStatus.InvalidBundle.BundleValidationErrors
+	if len(validationErrors) == 0 {
+		return "Bundle validation failed"
+	}
+	return fmt.Sprintf("Bundle validation failed: %s", strings.Join(validationErrors, "; "))
+}
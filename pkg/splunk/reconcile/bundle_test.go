@@ -0,0 +1,145 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplyClusterBundle(t *testing.T) {
+	c := newMockClient()
+	c.notFoundError = k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, "")
+	cr := enterprisev1.ClusterMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ClusterMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	splunkClient := splclient.NewSplunkClient("https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089", "admin", "p@ssw0rd", nil)
+	splunkClient.Client = mockSplunkClient
+
+	baseURL := "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master"
+	peersRequest, _ := http.NewRequest("GET", baseURL+"/peers?count=0&output_mode=json", nil)
+	infoRequest, _ := http.NewRequest("GET", baseURL+"/info?count=0&output_mode=json", nil)
+	validateRequest, _ := http.NewRequest("POST", baseURL+"/control/default/validate_bundle?check-restart=true", nil)
+	applyRequest, _ := http.NewRequest("POST", baseURL+"/control/default/apply", nil)
+	peersBody := `{"entry":[{"name":"A","content":{"label":"splunk-stack1-indexer-0","active_bundle_id":"ABC"}},{"name":"B","content":{"label":"splunk-stack1-indexer-1","active_bundle_id":"ABC"}}]}`
+	info := &splclient.ClusterMasterInfo{
+		ActiveBundle: splclient.ClusterBundleInfo{Checksum: "ABC"},
+		LatestBundle: splclient.ClusterBundleInfo{Checksum: "ABC"},
+	}
+
+	test := func(wantPending bool, wantReason string) *clusterBundleState {
+		state, err := applyClusterBundle(c, &cr, splunkClient, info, true, &cr.Status.Conditions)
+		if err != nil {
+			t.Errorf("applyClusterBundle() returned error: %v", err)
+			return state
+		}
+		mockSplunkClient.CheckRequests(t, "TestApplyClusterBundle")
+		if state.pending != wantPending {
+			t.Errorf("applyClusterBundle() pending = %t; want %t", state.pending, wantPending)
+		}
+		condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionBundlePushed)
+		if condition == nil || condition.Reason != wantReason {
+			t.Errorf("applyClusterBundle() condition = %v; want reason %s", condition, wantReason)
+		}
+		return state
+	}
+
+	// all peers are using the active bundle, and there is nothing to push
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	test(false, "Pushed")
+
+	// peers that are not using the active bundle are reported
+	mockSplunkClient.AddHandler(peersRequest, 200, `{"entry":[{"name":"A","content":{"label":"splunk-stack1-indexer-0","active_bundle_id":"ABC"}},{"name":"B","content":{"label":"splunk-stack1-indexer-1","active_bundle_id":"OLD"}}]}`, nil)
+	state := test(true, "Pushing")
+	if !reflect.DeepEqual(state.outdatedPeers, []string{"splunk-stack1-indexer-1"}) {
+		t.Errorf("applyClusterBundle() outdatedPeers = %v; want [splunk-stack1-indexer-1]", state.outdatedPeers)
+	}
+
+	// changes to operator-managed configuration wait for pods to be updated
+	configMap := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-cluster-master-indexes",
+			Namespace: "test",
+			Annotations: map[string]string{
+				indexesUpdatedAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Data: map[string]string{"indexes.conf": "[web]\n"},
+	}
+	c.state[getStateKey(&configMap)] = &configMap
+	getConfigMap := func() *corev1.ConfigMap {
+		return c.state[getStateKey(&configMap)].(*corev1.ConfigMap)
+	}
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	test(true, "WaitingForSync")
+
+	// invalid bundles are not pushed
+	getConfigMap().Annotations[indexesUpdatedAnnotation] = time.Now().Add(-indexesSyncDelay).UTC().Format(time.RFC3339)
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	mockSplunkClient.AddHandler(validateRequest, 200, "", nil)
+	mockSplunkClient.AddHandler(infoRequest, 200, `{"entry":[{"content":{"active_bundle":{"checksum":"ABC"},"latest_bundle":{"checksum":"DEF"},"last_validated_bundle":{"checksum":"DEF","is_valid_bundle":false},"apply_bundle_status":{"invalid_bundle":{"checksum":"DEF","bundle_validation_errors_on_master":["Invalid key in stanza [web]"]}}}}]}`, nil)
+	test(false, "ValidationFailed")
+	if getConfigMap().Annotations[indexesRejectedAnnotation] != getSplunkIndexesChecksum(getConfigMap()) || getConfigMap().Annotations[indexesPushedAnnotation] != "" {
+		t.Errorf("applyClusterBundle() annotations = %v; want rejected checksum", getConfigMap().Annotations)
+	}
+	if condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionBundlePushed); condition.Message != "Bundle validation failed: Invalid key in stanza [web]" {
+		t.Errorf("applyClusterBundle() message = %s; want validation errors", condition.Message)
+	}
+
+	// rejected bundles are not validated again until the configuration changes
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	test(false, "ValidationFailed")
+
+	// valid bundles are pushed to the peers
+	getConfigMap().Data["indexes.conf"] = "[web]\ndisabled = false\n"
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	mockSplunkClient.AddHandler(validateRequest, 200, "", nil)
+	mockSplunkClient.AddHandler(infoRequest, 200, `{"entry":[{"content":{"active_bundle":{"checksum":"ABC"},"latest_bundle":{"checksum":"GHI"},"last_validated_bundle":{"checksum":"GHI","is_valid_bundle":true},"last_check_restart_bundle_result":true}}]}`, nil)
+	mockSplunkClient.AddHandler(applyRequest, 200, "", nil)
+	state = test(true, "Pushing")
+	if !state.restartRequired {
+		t.Errorf("applyClusterBundle() restartRequired = false; want true")
+	}
+	if getConfigMap().Annotations[indexesPushedAnnotation] != getSplunkIndexesChecksum(getConfigMap()) || getConfigMap().Annotations[indexesRejectedAnnotation] != "" {
+		t.Errorf("applyClusterBundle() annotations = %v; want pushed checksum", getConfigMap().Annotations)
+	}
+
+	// configuration is only pushed by the resource that manages the cluster master
+	getConfigMap().Data["indexes.conf"] = "[web]\ndisabled = true\n"
+	mockSplunkClient.AddHandler(peersRequest, 200, peersBody, nil)
+	if _, err := applyClusterBundle(c, &cr, splunkClient, info, false, &cr.Status.Conditions); err != nil {
+		t.Errorf("applyClusterBundle() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestApplyClusterBundle")
+}
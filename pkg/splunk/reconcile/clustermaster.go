@@ -0,0 +1,233 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// ApplyClusterMaster reconciles the state of a Splunk Enterprise cluster master.
+func ApplyClusterMaster(client ControllerClient, cr *enterprisev1.ClusterMaster) (reconcile.Result, error) {
+
+	// unless modified, reconcile for this object will be requeued after 5 seconds
+	result := reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: time.Second * 5,
+	}
+	scopedLog := log.WithName("ApplyClusterMaster").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	// validate and updates defaults for CR
+	err := enterprise.ValidateClusterMasterSpec(&cr.Spec)
+	if err != nil {
+		return result, err
+	}
+
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			return updatePausedClusterMasterStatus(client, cr)
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
+	// updates status after function completes
+	cr.Status.Phase = enterprisev1.PhaseError
+	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		err = client.Status().Update(context.TODO(), cr)
+		if err != nil {
+			scopedLog.Error(err, "Status update failed")
+		}
+	}()
+
+	// check if deletion has been requested
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		terminating, err := CheckSplunkDeletion(cr, client)
+		if terminating && err != nil { // don't bother if no error, since it will just be removed immmediately after
+			cr.Status.Phase = enterprisev1.PhaseTerminating
+		} else {
+			result.Requeue = false
+		}
+		return result, err
+	}
+
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
+	if err != nil {
+		return result, err
+	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		return result, nil
+	}
+
+	// create or update general config resources
+	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkClusterMaster)
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
+		return result, err
+	}
+
+	// create or update generated defaults for multisite indexer clusters
+	if len(cr.Spec.Sites) > 0 {
+		multisiteDefaults, err := enterprise.GetSplunkMultisiteDefaults(cr, cr.Spec.Sites, cr.Spec.SiteReplicationFactor, cr.Spec.SiteSearchFactor)
+		if err != nil {
+			return result, err
+		}
+		multisiteDefaults.SetOwnerReferences(append(multisiteDefaults.GetOwnerReferences(), resources.AsOwner(cr)))
+		if err = ApplyConfigMap(client, multisiteDefaults); err != nil {
+			return result, err
+		}
+	}
+
+	// create or update generated defaults for SmartStore, which are pushed to the peers by the cluster master
+	if err = ApplySmartStoreConfig(client, cr, &cr.Spec.SmartStore, enterprise.SplunkClusterMaster); err != nil {
+		return result, err
+	}
+
+	// create or update a regular service for the cluster master
+	err = ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkClusterMaster, false))
+	if err != nil {
+		return result, err
+	}
+
+	// create or update resources used by the app framework to install apps on the cluster master
+	if err = ApplyAppFramework(client, cr, &cr.Spec.CommonSplunkSpec, enterprise.SplunkClusterMaster, cr.Spec.AppSources); err != nil {
+		return result, err
+	}
+
+	// create or update statefulset for the cluster master
+	statefulSet, err := enterprise.GetClusterMasterStatefulSet(cr)
+	if err != nil {
+		return result, err
+	}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the cluster master
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return result, err
+	}
+	mgr := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
+	phase, err := mgr.Update(client, statefulSet, 1)
+	if err != nil {
+		return result, err
+	}
+	cr.Status.Phase = phase
+
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// update status of apps installed by the app framework on the cluster master
+	appsInstalled := updateAppFrameworkStatus(client, cr, cr.GetGeneration(), cr.Spec.AppSources, statefulSet, &cr.Status.Apps, &cr.Status.Conditions, splclient.NewAppFrameworkClient)
+
+	// update status of the indexer cluster using the cluster master's REST API
+	// and push changes to the operator-managed configuration in the cluster bundle
+	bundlePending := false
+	tlsConfig, statusErr := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if statusErr == nil {
+		bundlePending, statusErr = updateClusterMasterStatus(client, cr, secrets, tlsConfig, true, splclient.NewSplunkClient)
+	}
+	if statusErr != nil {
+		scopedLog.Error(statusErr, "Unable to get indexer cluster status from cluster master")
+	}
+	setIndexingReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.IndexingReady)
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// keep polling until the replication and search factors are met, so that the status remains current
+	if cr.Status.Phase == enterprisev1.PhaseReady && appsInstalled && cr.Status.ReplicationFactorMet && cr.Status.SearchFactorMet && !rotationPending && !bundlePending {
+		result.Requeue = false
+	}
+	return result, nil
+}
+
+// updatePausedClusterMasterStatus updates the status of a ClusterMaster resource while its reconciliation is paused,
+// without pushing any changes to the cluster bundle
+func updatePausedClusterMasterStatus(client ControllerClient, cr *enterprisev1.ClusterMaster) error {
+	secrets, err := getCurrentSplunkSecrets(client, cr, enterprise.SplunkClusterMaster)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if err != nil {
+		return err
+	}
+	_, err = updateClusterMasterStatus(client, cr, secrets, tlsConfig, false, splclient.NewSplunkClient)
+	return err
+}
+
+// updateClusterMasterStatus uses the REST API to update the status of a ClusterMaster custom resource, and pushes
+// changes to the cluster bundle if push is true. It returns true while a bundle is waiting to be pushed or activated on all peers.
+func updateClusterMasterStatus(client ControllerClient, cr *enterprisev1.ClusterMaster, secrets *corev1.Secret, tlsConfig *tls.Config, push bool, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) (bool, error) {
+	if cr.Status.Phase != enterprisev1.PhaseReady {
+		cr.Status.Initialized = false
+		cr.Status.IndexingReady = false
+		cr.Status.ServiceReady = false
+		cr.Status.MaintenanceMode = false
+		cr.Status.ReplicationFactorMet = false
+		cr.Status.SearchFactorMet = false
+		cr.Status.RestartRequired = false
+		cr.Status.OutdatedPeers = nil
+		return false, nil
+	}
+
+	fqdnName := resources.GetServiceFQDN(cr.GetNamespace(), enterprise.GetSplunkServiceName(enterprise.SplunkClusterMaster, cr.GetIdentifier(), false))
+	c := newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(secrets.Data["password"]), tlsConfig)
+
+	clusterInfo, err := c.GetClusterMasterInfo()
+	if err != nil {
+		return false, err
+	}
+	cr.Status.Initialized = clusterInfo.Initialized
+	cr.Status.IndexingReady = clusterInfo.IndexingReady
+	cr.Status.ServiceReady = clusterInfo.ServiceReady
+	cr.Status.MaintenanceMode = clusterInfo.MaintenanceMode
+
+	generationInfo, err := c.GetClusterMasterGeneration()
+	if err != nil {
+		return false, err
+	}
+	cr.Status.ReplicationFactorMet = generationInfo.ReplicationFactorMet
+	cr.Status.SearchFactorMet = generationInfo.SearchFactorMet
+
+	bundleState, err := applyClusterBundle(client, cr, c, clusterInfo, push, &cr.Status.Conditions)
+	if err != nil {
+		return false, err
+	}
+	cr.Status.ActiveBundle = bundleState.activeBundle
+	cr.Status.LatestBundle = bundleState.latestBundle
+	cr.Status.RestartRequired = bundleState.restartRequired
+	cr.Status.OutdatedPeers = bundleState.outdatedPeers
+	return bundleState.pending, nil
+}
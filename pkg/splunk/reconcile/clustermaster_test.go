@@ -0,0 +1,158 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplyClusterMaster(t *testing.T) {
+	funcCalls := []mockFuncCall{
+		{metaName: "*v1.Secret-test-splunk-stack1-indexer-secrets"},
+		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-service"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-cluster-master-app-framework"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-cluster-master"},
+		{metaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
+	}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[0], funcCalls[1], funcCalls[2], funcCalls[5], funcCalls[6]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[6]}}
+	current := enterprisev1.ClusterMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ClusterMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Spec.Image = "splunk/test"
+	reconcile := func(c *mockClient, cr interface{}) error {
+		_, err := ApplyClusterMaster(c, cr.(*enterprisev1.ClusterMaster))
+		return err
+	}
+	reconcileTester(t, "TestApplyClusterMaster", &current, revised, createCalls, updateCalls, reconcile)
+
+	// test multisite
+	funcCalls = []mockFuncCall{
+		{metaName: "*v1.Secret-test-splunk-stack1-indexer-secrets"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-cluster-master-multisite"},
+		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-service"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-cluster-master-app-framework"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-cluster-master"},
+		{metaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
+	}
+	createCalls = map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[0], funcCalls[1], funcCalls[2], funcCalls[3], funcCalls[6], funcCalls[7]}}
+	updateCalls = map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[7]}}
+	current.Spec.Sites = []string{"site1", "site2"}
+	revised = current.DeepCopy()
+	revised.Spec.Image = "splunk/test"
+	reconcileTester(t, "TestApplyClusterMaster", &current, revised, createCalls, updateCalls, reconcile)
+
+	// test deletion
+	currentTime := metav1.NewTime(time.Now())
+	revised.ObjectMeta.DeletionTimestamp = &currentTime
+	revised.ObjectMeta.Finalizers = []string{"enterprise.splunk.com/delete-pvc"}
+	deleteFunc := func(cr enterprisev1.MetaObject, c ControllerClient) (bool, error) {
+		_, err := ApplyClusterMaster(c, cr.(*enterprisev1.ClusterMaster))
+		return true, err
+	}
+	splunkDeletionTester(t, revised, deleteFunc)
+}
+
+func TestUpdateClusterMasterStatus(t *testing.T) {
+	cr := enterprisev1.ClusterMaster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Status: enterprisev1.ClusterMasterStatus{
+			Phase: enterprisev1.PhaseReady,
+		},
+	}
+	secrets := &corev1.Secret{
+		Data: map[string][]byte{
+			"password": []byte{'1', '2', '3'},
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+
+	infoRequest, _ := http.NewRequest("GET", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/info?count=0&output_mode=json", nil)
+	generationRequest, _ := http.NewRequest("GET", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/generation?count=0&output_mode=json", nil)
+	mockSplunkClient.AddHandler(infoRequest, 200, `{"entry":[{"content":{"initialized_flag":true,"indexing_ready_flag":true,"service_ready_flag":true,"maintenance_mode":true,"active_bundle":{"checksum":"ABC","timestamp":1583870198},"latest_bundle":{"checksum":"DEF","timestamp":1583948640}}}]}`, nil)
+	peersRequest, _ := http.NewRequest("GET", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/peers?count=0&output_mode=json", nil)
+	mockSplunkClient.AddHandler(generationRequest, 200, `{"entry":[{"content":{"replication_factor_met":"1","search_factor_met":"0"}}]}`, nil)
+	mockSplunkClient.AddHandler(peersRequest, 200, `{"entry":[{"name":"A","content":{"label":"splunk-stack1-indexer-0","active_bundle_id":"ABC"}},{"name":"B","content":{"label":"splunk-stack1-indexer-1","active_bundle_id":"XYZ"}}]}`, nil)
+
+	c := newMockClient()
+	c.notFoundError = k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, "")
+	pending, err := updateClusterMasterStatus(c, &cr, secrets, nil, true, newSplunkClient)
+	if err != nil {
+		t.Errorf("updateClusterMasterStatus() returned error: %v", err)
+	}
+	if !pending {
+		t.Errorf("updateClusterMasterStatus() = false; want true while the latest bundle is being pushed")
+	}
+	mockSplunkClient.CheckRequests(t, "TestUpdateClusterMasterStatus")
+	want := enterprisev1.ClusterMasterStatus{
+		Phase:                enterprisev1.PhaseReady,
+		Initialized:          true,
+		IndexingReady:        true,
+		ServiceReady:         true,
+		MaintenanceMode:      true,
+		ReplicationFactorMet: true,
+		SearchFactorMet:      false,
+		ActiveBundle:         enterprisev1.ClusterBundleStatus{Checksum: "ABC", Timestamp: 1583870198},
+		LatestBundle:         enterprisev1.ClusterBundleStatus{Checksum: "DEF", Timestamp: 1583948640},
+	}
+	if cr.Status.Initialized != want.Initialized || cr.Status.IndexingReady != want.IndexingReady ||
+		cr.Status.ServiceReady != want.ServiceReady || cr.Status.MaintenanceMode != want.MaintenanceMode ||
+		cr.Status.ReplicationFactorMet != want.ReplicationFactorMet || cr.Status.SearchFactorMet != want.SearchFactorMet ||
+		cr.Status.ActiveBundle != want.ActiveBundle || cr.Status.LatestBundle != want.LatestBundle ||
+		!reflect.DeepEqual(cr.Status.OutdatedPeers, []string{"splunk-stack1-indexer-1"}) {
+		t.Errorf("updateClusterMasterStatus() status = %v; want %v", cr.Status, want)
+	}
+
+	// status is reset when the cluster master is not ready
+	cr.Status.Phase = enterprisev1.PhasePending
+	if _, err := updateClusterMasterStatus(c, &cr, secrets, nil, true, newSplunkClient); err != nil {
+		t.Errorf("updateClusterMasterStatus() returned error: %v", err)
+	}
+	if cr.Status.Initialized || cr.Status.MaintenanceMode || cr.Status.ReplicationFactorMet || cr.Status.OutdatedPeers != nil {
+		t.Errorf("updateClusterMasterStatus() did not reset status for pending cluster master: %v", cr.Status)
+	}
+}
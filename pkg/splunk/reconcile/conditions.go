@@ -0,0 +1,80 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// setReadyCondition updates the Ready condition using the phase and error that resulted from reconciling a custom resource
+func setReadyCondition(conditions *[]enterprisev1.Condition, generation int64, phase enterprisev1.ResourcePhase, err error) {
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	resources.SetConditionFromBool(conditions, generation, enterprisev1.ConditionReady, phase == enterprisev1.PhaseReady, string(phase), message)
+}
+
+// setSecretsAppliedCondition updates the SecretsApplied condition using the result of ApplySplunkConfig. It returns err,
+// unless err only reports user-provided secrets that have not been applied, so that reconciliation can continue.
+func setSecretsAppliedCondition(conditions *[]enterprisev1.Condition, generation int64, err error) error {
+	if _, ok := err.(*secretsPendingError); ok {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsApplied, corev1.ConditionFalse, "NotApplied", err.Error())
+		return nil
+	}
+	if err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsApplied, corev1.ConditionFalse, "ApplyFailed", err.Error())
+		return err
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsApplied, corev1.ConditionTrue, "Applied", "")
+	return nil
+}
+
+// setPhaseCondition updates a condition that tracks whether or not a component has reached the Ready phase
+func setPhaseCondition(conditions *[]enterprisev1.Condition, generation int64, conditionType enterprisev1.ConditionType, phase enterprisev1.ResourcePhase) {
+	resources.SetConditionFromBool(conditions, generation, conditionType, phase == enterprisev1.PhaseReady, string(phase), "")
+}
+
+// setScalingBlockedCondition updates the ScalingBlocked condition; scaling is blocked when the desired number
+// of replicas differs from the current number, but the cluster is not ready for scaling to proceed
+func setScalingBlockedCondition(conditions *[]enterprisev1.Condition, generation int64, statefulSet *appsv1.StatefulSet, desiredReplicas int32, clusterReady bool, message string) {
+	if !clusterReady && statefulSet.Spec.Replicas != nil && *statefulSet.Spec.Replicas != desiredReplicas {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionScalingBlocked, corev1.ConditionTrue, "ClusterNotReady", message)
+		return
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionScalingBlocked, corev1.ConditionFalse, "NotBlocked", "")
+}
+
+// setIndexingReadyCondition updates the IndexingReady condition using the status reported by the cluster master
+func setIndexingReadyCondition(conditions *[]enterprisev1.Condition, generation int64, indexingReady bool) {
+	if indexingReady {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionIndexingReady, corev1.ConditionTrue, "Indexing", "")
+		return
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionIndexingReady, corev1.ConditionFalse, "NotIndexing", "")
+}
+
+// setCaptainReadyCondition updates the CaptainReady condition using the captain reported by the search head cluster
+func setCaptainReadyCondition(conditions *[]enterprisev1.Condition, generation int64, captainReady bool, captain string) {
+	if captainReady {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionCaptainReady, corev1.ConditionTrue, "CaptainElected", captain)
+		return
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionCaptainReady, corev1.ConditionFalse, "NoReadyCaptain", captain)
+}
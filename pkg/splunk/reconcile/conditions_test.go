@@ -0,0 +1,86 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"errors"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+func conditionTester(t *testing.T, method string, conditions []enterprisev1.Condition, conditionType enterprisev1.ConditionType, wantStatus corev1.ConditionStatus, wantReason string) {
+	got := resources.GetCondition(conditions, conditionType)
+	if got == nil {
+		t.Errorf("%s: condition %s not found", method, conditionType)
+		return
+	}
+	if got.Status != wantStatus || got.Reason != wantReason {
+		t.Errorf("%s: %s = %s (%s); want %s (%s)", method, conditionType, got.Status, got.Reason, wantStatus, wantReason)
+	}
+}
+
+func TestSetReadyCondition(t *testing.T) {
+	conditions := []enterprisev1.Condition{}
+	setReadyCondition(&conditions, 1, enterprisev1.PhasePending, nil)
+	conditionTester(t, "setReadyCondition(Pending)", conditions, enterprisev1.ConditionReady, corev1.ConditionFalse, "Pending")
+	setReadyCondition(&conditions, 1, enterprisev1.PhaseError, errors.New("failed"))
+	conditionTester(t, "setReadyCondition(Error)", conditions, enterprisev1.ConditionReady, corev1.ConditionFalse, "Error")
+	if conditions[0].Message != "failed" {
+		t.Errorf("setReadyCondition(Error) Message = %s; want %s", conditions[0].Message, "failed")
+	}
+	setReadyCondition(&conditions, 1, enterprisev1.PhaseReady, nil)
+	conditionTester(t, "setReadyCondition(Ready)", conditions, enterprisev1.ConditionReady, corev1.ConditionTrue, "Ready")
+
+	setSecretsAppliedCondition(&conditions, 1, errors.New("failed"))
+	conditionTester(t, "setSecretsAppliedCondition(err)", conditions, enterprisev1.ConditionSecretsApplied, corev1.ConditionFalse, "ApplyFailed")
+	if err := setSecretsAppliedCondition(&conditions, 1, &secretsPendingError{secretRef: "my-secrets", keys: []string{"password"}}); err != nil {
+		t.Errorf("setSecretsAppliedCondition() returned %v; want nil for pending secrets", err)
+	}
+	conditionTester(t, "setSecretsAppliedCondition(pending)", conditions, enterprisev1.ConditionSecretsApplied, corev1.ConditionFalse, "NotApplied")
+	setSecretsAppliedCondition(&conditions, 1, nil)
+	conditionTester(t, "setSecretsAppliedCondition(nil)", conditions, enterprisev1.ConditionSecretsApplied, corev1.ConditionTrue, "Applied")
+}
+
+func TestSetScalingBlockedCondition(t *testing.T) {
+	var replicas int32 = 3
+	statefulSet := &appsv1.StatefulSet{Spec: appsv1.StatefulSetSpec{Replicas: &replicas}}
+	conditions := []enterprisev1.Condition{}
+
+	setScalingBlockedCondition(&conditions, 1, statefulSet, 3, false, "not ready")
+	conditionTester(t, "setScalingBlockedCondition(3,false)", conditions, enterprisev1.ConditionScalingBlocked, corev1.ConditionFalse, "NotBlocked")
+	setScalingBlockedCondition(&conditions, 1, statefulSet, 5, false, "not ready")
+	conditionTester(t, "setScalingBlockedCondition(5,false)", conditions, enterprisev1.ConditionScalingBlocked, corev1.ConditionTrue, "ClusterNotReady")
+	setScalingBlockedCondition(&conditions, 1, statefulSet, 5, true, "")
+	conditionTester(t, "setScalingBlockedCondition(5,true)", conditions, enterprisev1.ConditionScalingBlocked, corev1.ConditionFalse, "NotBlocked")
+}
+
+func TestSetIndexingAndCaptainReadyConditions(t *testing.T) {
+	conditions := []enterprisev1.Condition{}
+
+	setIndexingReadyCondition(&conditions, 1, false)
+	conditionTester(t, "setIndexingReadyCondition(false)", conditions, enterprisev1.ConditionIndexingReady, corev1.ConditionFalse, "NotIndexing")
+	setIndexingReadyCondition(&conditions, 1, true)
+	conditionTester(t, "setIndexingReadyCondition(true)", conditions, enterprisev1.ConditionIndexingReady, corev1.ConditionTrue, "Indexing")
+
+	setCaptainReadyCondition(&conditions, 1, false, "")
+	conditionTester(t, "setCaptainReadyCondition(false)", conditions, enterprisev1.ConditionCaptainReady, corev1.ConditionFalse, "NoReadyCaptain")
+	setCaptainReadyCondition(&conditions, 1, true, "splunk-stack1-search-head-0")
+	conditionTester(t, "setCaptainReadyCondition(true)", conditions, enterprisev1.ConditionCaptainReady, corev1.ConditionTrue, "CaptainElected")
+}
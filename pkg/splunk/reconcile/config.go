@@ -16,54 +16,61 @@ package reconcile
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"fmt"
 	"reflect"
+	"sort"
+	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 	"github.com/splunk/splunk-operator/pkg/splunk/resources"
 )
 
+// tlsPendingAnnotation records when TLS certificates were added to the existing secrets of a resource. Until its pods
+// are restarted to use them, the operator does not verify their certificates.
+const tlsPendingAnnotation = "enterprise.splunk.com/tls-pending-since"
+
 // ApplySplunkConfig reconciles the state of Kubernetes Secrets, ConfigMaps and other general settings for Splunk Enterprise instances.
+// If user-provided secrets differ from those in use, it returns the active secrets along with a *secretsPendingError.
 func ApplySplunkConfig(client ControllerClient, cr enterprisev1.MetaObject, spec enterprisev1.CommonSplunkSpec, instanceType enterprise.InstanceType) (*corev1.Secret, error) {
 	var err error
 
-	// if reference to indexer cluster, extract and re-use idxc.secret
-	// IndexerRef is not relevant for Indexer, and Indexer will use value from LicenseMaster to prevent cyclical dependency
-	var idxcSecret []byte
-	if instanceType.ToKind() != "indexer" && instanceType.ToKind() != "license-master" && spec.IndexerClusterRef.Name != "" {
-		idxcSecret, err = GetSplunkSecret(client, cr, spec.IndexerClusterRef, enterprise.SplunkIndexer, "idxc_secret")
+	// re-use secrets that are shared with other resources that this one references
+	var idxcSecret, pass4SymmKey []byte
+	copiedSecrets := getCopiedSecrets(&spec, instanceType)
+	if source, ok := copiedSecrets["pass4SymmKey"]; ok {
+		pass4SymmKey, err = GetSplunkSecret(client, cr, source.ref, source.instanceType, "pass4SymmKey")
 		if err != nil {
 			return nil, err
 		}
 	}
-
-	// if reference to license master, extract and re-use pass4SymmKey
-	var pass4SymmKey []byte
-	if instanceType.ToKind() != "license-master" && spec.LicenseMasterRef.Name != "" {
-		pass4SymmKey, err = GetSplunkSecret(client, cr, spec.LicenseMasterRef, enterprise.SplunkLicenseMaster, "pass4SymmKey")
+	if source, ok := copiedSecrets["idxc_secret"]; ok {
+		idxcSecret, err = GetSplunkSecret(client, cr, source.ref, source.instanceType, "idxc_secret")
 		if err != nil {
 			return nil, err
 		}
-		if instanceType.ToKind() == "indexer" {
-			// get pass4SymmKey from LicenseMaster to avoid cyclical dependency
-			idxcSecret, err = GetSplunkSecret(client, cr, spec.LicenseMasterRef, enterprise.SplunkLicenseMaster, "idxc_secret")
-			if err != nil {
-				return nil, err
-			}
-		}
 	}
 
-	// create or retrieve splunk secrets
-	secrets := enterprise.GetSplunkSecrets(cr, instanceType, idxcSecret, pass4SymmKey)
-	secrets.SetOwnerReferences(append(secrets.GetOwnerReferences(), resources.AsOwner(cr)))
-	if secrets, err = ApplySecret(client, secrets); err != nil {
+	// retrieve user-provided secrets, which override any generated values
+	userSecrets, err := getUserSecrets(client, cr, &spec)
+	if err != nil {
 		return nil, err
 	}
 
+	// create or retrieve splunk secrets
+	secrets, secretsErr := applySplunkSecrets(client, cr, instanceType, idxcSecret, pass4SymmKey, userSecrets)
+	if _, pending := secretsErr.(*secretsPendingError); secretsErr != nil && !pending {
+		return nil, secretsErr
+	}
+
 	// create splunk defaults (for inline config)
 	if spec.Defaults != "" {
 		defaultsMap := enterprise.GetSplunkDefaults(cr.GetIdentifier(), cr.GetNamespace(), instanceType, spec.Defaults)
@@ -73,7 +80,222 @@ func ApplySplunkConfig(client ControllerClient, cr enterprisev1.MetaObject, spec
 		}
 	}
 
-	return secrets, nil
+	return secrets, secretsErr
+}
+
+// getUserSecrets returns the user-provided Secret referenced by spec.SecretRef, or nil if there is none
+func getUserSecrets(client ControllerClient, cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec) (*corev1.Secret, error) {
+	if spec.SecretRef == "" {
+		return nil, nil
+	}
+	var userSecrets corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: spec.SecretRef}
+	if err := client.Get(context.TODO(), namespacedName, &userSecrets); err != nil {
+		return nil, fmt.Errorf("Unable to get secret %s: %v", spec.SecretRef, err)
+	}
+	return &userSecrets, nil
+}
+
+// secretsPendingError reports user-provided secrets that differ from those used by the existing instances of a resource.
+// These are only applied by rotating them, so that every instance that shares them changes at the same time.
+type secretsPendingError struct {
+	secretRef string
+	keys      []string
+}
+
+func (e *secretsPendingError) Error() string {
+	fixed := []string{}
+	for _, key := range e.keys {
+		if !enterprise.IsRotatableSecret(key) {
+			fixed = append(fixed, key)
+		}
+	}
+	if len(fixed) > 0 {
+		return fmt.Sprintf("Secret %s changes %s, which cannot be changed after the resource is created", e.secretRef, strings.Join(fixed, ", "))
+	}
+	return fmt.Sprintf("Secret %s changes %s, which are only applied when rotated using the %s annotation", e.secretRef, strings.Join(e.keys, ", "), rotateSecretsAnnotation)
+}
+
+// copiedSecret identifies a secret that is copied from the secrets of another custom resource
+type copiedSecret struct {
+	ref          corev1.ObjectReference
+	instanceType enterprise.InstanceType
+}
+
+// getCopiedSecrets returns the secrets of a Splunk Enterprise resource that are copied from other resources that it references,
+// keyed by name. These secrets are shared by all of the resources, so they are not generated.
+func getCopiedSecrets(spec *enterprisev1.CommonSplunkSpec, instanceType enterprise.InstanceType) map[string]copiedSecret {
+	copied := make(map[string]copiedSecret)
+
+	// if reference to cluster master or indexer cluster, re-use idxc.secret
+	// IndexerRef is not relevant for Indexer, and Indexer will use value from LicenseMaster to prevent cyclical dependency
+	if instanceType.ToKind() != "indexer" && instanceType.ToKind() != "license-master" {
+		if spec.ClusterMasterRef.Name != "" {
+			copied["idxc_secret"] = copiedSecret{ref: spec.ClusterMasterRef, instanceType: enterprise.SplunkClusterMaster}
+		} else if spec.IndexerClusterRef.Name != "" {
+			copied["idxc_secret"] = copiedSecret{ref: spec.IndexerClusterRef, instanceType: enterprise.SplunkIndexer}
+		}
+	}
+
+	// if reference to license master, re-use pass4SymmKey
+	if instanceType.ToKind() != "license-master" && spec.LicenseMasterRef.Name != "" {
+		copied["pass4SymmKey"] = copiedSecret{ref: spec.LicenseMasterRef, instanceType: enterprise.SplunkLicenseMaster}
+		if instanceType.ToKind() == "indexer" {
+			// get idxc.secret from LicenseMaster to avoid cyclical dependency
+			copied["idxc_secret"] = copiedSecret{ref: spec.LicenseMasterRef, instanceType: enterprise.SplunkLicenseMaster}
+		}
+	}
+
+	// indexer cluster peers that join a separate cluster master must use the same idxc.secret
+	if instanceType == enterprise.SplunkIndexer && spec.ClusterMasterRef.Name != "" {
+		copied["idxc_secret"] = copiedSecret{ref: spec.ClusterMasterRef, instanceType: enterprise.SplunkClusterMaster}
+	}
+
+	return copied
+}
+
+// applySplunkSecrets creates a Kubernetes Secret containing randomly generated secrets and TLS certificates for a Splunk Enterprise
+// resource, if it does not already exist, and returns the active secrets. TLS certificates are added to existing secrets that do not have them.
+// If userSecrets is not nil, its values override generated ones when the Secret is created. Existing secrets are never changed to match
+// userSecrets, since their values are in use by Splunk instances; a *secretsPendingError is returned if they differ.
+func applySplunkSecrets(client ControllerClient, cr enterprisev1.MetaObject, instanceType enterprise.InstanceType, idxcSecret, pass4SymmKey []byte, userSecrets *corev1.Secret) (*corev1.Secret, error) {
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: enterprise.GetSplunkSecretsName(cr.GetIdentifier(), instanceType)}
+	scopedLog := log.WithName("applySplunkSecrets").WithValues("name", namespacedName.Name, "namespace", namespacedName.Namespace)
+
+	var current corev1.Secret
+	err := client.Get(context.TODO(), namespacedName, &current)
+	if err != nil {
+		secrets := enterprise.GetSplunkSecrets(cr, instanceType, idxcSecret, pass4SymmKey)
+		if err = enterprise.AddSplunkTLSSecrets(cr, instanceType, secrets); err != nil {
+			return nil, err
+		}
+		if userSecrets != nil {
+			if _, err = enterprise.MergeSplunkSecrets(secrets, userSecrets); err != nil {
+				return nil, err
+			}
+		}
+		secrets.SetOwnerReferences(append(secrets.GetOwnerReferences(), resources.AsOwner(cr)))
+		return secrets, CreateResource(client, secrets)
+	}
+
+	changed := false
+	if len(current.Data["server.pem"]) == 0 {
+		// secrets created by older versions of the operator do not include TLS certificates.
+		// note that splunkd will only start using the new certificate after its pods are restarted
+		scopedLog.Info("Adding TLS certificates to existing Secret")
+		if err = enterprise.AddSplunkTLSSecrets(cr, instanceType, &current); err != nil {
+			return nil, err
+		}
+		annotations := current.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[tlsPendingAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		current.SetAnnotations(annotations)
+		changed = true
+	}
+	if changed {
+		if err = UpdateResource(client, &current); err != nil {
+			return nil, err
+		}
+	}
+
+	if userSecrets != nil {
+		pending, err := enterprise.GetChangedSplunkSecrets(&current, userSecrets)
+		if err != nil {
+			return nil, err
+		}
+		if len(pending) > 0 {
+			keys := []string{}
+			for key := range pending {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			scopedLog.Info("User-provided secrets differ from existing Secret", "secretRef", userSecrets.GetName(), "keys", keys)
+			return &current, &secretsPendingError{secretRef: userSecrets.GetName(), keys: keys}
+		}
+	}
+
+	return &current, nil
+}
+
+// getSplunkTLSConfig returns a TLS configuration used by the operator to verify splunkd certificates for a Splunk Enterprise resource.
+// If spec.TLSSecretRef is set, the CA bundle and client certificate are read from the referenced Secret; otherwise, the
+// CA generated by the operator for the resource (in secrets) is used. Certificates are not verified while the generated
+// certificates are pending, since pods started by older versions of the operator still serve their own certificates.
+func getSplunkTLSConfig(client ControllerClient, cr enterprisev1.MetaObject, spec *enterprisev1.CommonSplunkSpec, secrets *corev1.Secret) (*tls.Config, error) {
+	if spec.TLSSecretRef == "" {
+		if isSplunkTLSPending(secrets) {
+			return &tls.Config{InsecureSkipVerify: true}, nil
+		}
+		return splclient.NewTLSConfig(secrets.Data["ca.crt"], nil, nil)
+	}
+
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: spec.TLSSecretRef}
+	var tlsSecret corev1.Secret
+	if err := client.Get(context.TODO(), namespacedName, &tlsSecret); err != nil {
+		return nil, fmt.Errorf("Unable to get TLS secret %s: %v", spec.TLSSecretRef, err)
+	}
+	if len(tlsSecret.Data["ca.crt"]) == 0 {
+		return nil, fmt.Errorf("TLS secret %s is missing ca.crt", spec.TLSSecretRef)
+	}
+	return splclient.NewTLSConfig(tlsSecret.Data["ca.crt"], tlsSecret.Data["tls.crt"], tlsSecret.Data["tls.key"])
+}
+
+// isSplunkTLSPending returns true if TLS certificates were added to the existing secrets of a resource, and its pods may
+// not have been restarted to use them yet
+func isSplunkTLSPending(secrets *corev1.Secret) bool {
+	_, ok := secrets.GetAnnotations()[tlsPendingAnnotation]
+	return ok
+}
+
+// updateSplunkTLSPending clears pending TLS certificates once every pod that mounts secrets has been created since they
+// were added. splunkd is only configured to use the certificates when its pods start, so the pods of existing resources
+// are recycled to use them (see tlsCertificatesAnnotation in the enterprise package).
+func updateSplunkTLSPending(c ControllerClient, secrets *corev1.Secret) error {
+	if !isSplunkTLSPending(secrets) {
+		return nil
+	}
+	added, err := time.Parse(time.RFC3339, secrets.GetAnnotations()[tlsPendingAnnotation])
+	if err == nil {
+		var pods corev1.PodList
+		if err = c.List(context.TODO(), &pods, client.InNamespace(secrets.GetNamespace())); err != nil {
+			return err
+		}
+		for _, pod := range pods.Items {
+			if pod.GetCreationTimestamp().Time.Before(added) && isSecretMounted(&pod, secrets.GetName()) {
+				return nil
+			}
+		}
+	}
+
+	log.Info("Verifying certificates added to existing Secret", "name", secrets.GetName(), "namespace", secrets.GetNamespace())
+	annotations := secrets.GetAnnotations()
+	delete(annotations, tlsPendingAnnotation)
+	secrets.SetAnnotations(annotations)
+	return UpdateResource(c, secrets)
+}
+
+// isSecretMounted returns true if a pod has a volume for the named Secret
+func isSecretMounted(pod *corev1.Pod, secretName string) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.Secret != nil && volume.Secret.SecretName == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// getRestartConfigChecksum returns the checksum of the secrets that splunkd reads directly from the files mounted in its
+// pods, rather than through the defaults applied when a pod starts. Changes to these only require splunkd to be restarted.
+// Changes to defaults, including default.yml in secrets, inline defaults and defaultsUrl, are only applied by splunk-ansible
+// when a container starts, and so require pods to be recycled.
+func getRestartConfigChecksum(secrets *corev1.Secret) string {
+	hash := sha256.New()
+	for _, key := range []string{"server.pem", "ca.crt"} {
+		fmt.Fprintf(hash, "%s:%x\n", key, sha256.Sum256(secrets.Data[key]))
+	}
+	return fmt.Sprintf("%x", hash.Sum(nil))
 }
 
 // ApplyConfigMap creates or updates a Kubernetes ConfigMap
@@ -123,6 +345,31 @@ func ApplySecret(client ControllerClient, secret *corev1.Secret) (*corev1.Secret
 	return result, err
 }
 
+// ApplyGeneratedSecret creates or updates a Kubernetes Secret containing data that is generated by the operator
+func ApplyGeneratedSecret(client ControllerClient, secret *corev1.Secret) error {
+	scopedLog := log.WithName("ApplyGeneratedSecret").WithValues(
+		"name", secret.GetObjectMeta().GetName(),
+		"namespace", secret.GetObjectMeta().GetNamespace())
+
+	namespacedName := types.NamespacedName{Namespace: secret.GetNamespace(), Name: secret.GetName()}
+	var current corev1.Secret
+
+	err := client.Get(context.TODO(), namespacedName, &current)
+	if err == nil {
+		if !reflect.DeepEqual(secret.Data, current.Data) {
+			scopedLog.Info("Updating existing Secret")
+			current.Data = secret.Data
+			err = UpdateResource(client, &current)
+		} else {
+			scopedLog.Info("No changes for Secret")
+		}
+	} else {
+		err = CreateResource(client, secret)
+	}
+
+	return err
+}
+
 // GetSplunkSecret is used to retrieve a secret from another custom resource.
 func GetSplunkSecret(client ControllerClient, cr enterprisev1.MetaObject, ref corev1.ObjectReference, instanceType enterprise.InstanceType, secretName string) ([]byte, error) {
 	namespace := ref.Namespace
@@ -15,10 +15,13 @@
 package reconcile
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
 	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -103,6 +106,25 @@ func TestApplySplunkConfig(t *testing.T) {
 	createCalls = map[string][]mockFuncCall{"Get": {funcCalls[2]}, "Create": {funcCalls[2]}}
 	updateCalls = map[string][]mockFuncCall{"Get": funcCalls}
 	reconcileTester(t, "TestApplySplunkConfig", &indexerCR, indexerRevised, createCalls, updateCalls, reconcile, &secret)
+
+	// test indexer with cluster master reference
+	secret = corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-master1-indexer-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"idxc_secret": []byte{'a', 'b'},
+		},
+	}
+	indexerRevised = indexerCR.DeepCopy()
+	indexerRevised.Spec.ClusterMasterRef.Name = "master1"
+	funcCalls = []mockFuncCall{
+		{metaName: "*v1.Secret-test-splunk-master1-indexer-secrets"},
+		{metaName: "*v1.Secret-test-splunk-stack1-indexer-secrets"},
+	}
+	updateCalls = map[string][]mockFuncCall{"Get": funcCalls}
+	reconcileTester(t, "TestApplySplunkConfig", &indexerCR, indexerRevised, createCalls, updateCalls, reconcile, &secret)
 }
 
 func TestApplyConfigMap(t *testing.T) {
@@ -141,3 +163,353 @@ func TestApplySecret(t *testing.T) {
 	}
 	reconcileTester(t, "TestApplySecret", &current, revised, createCalls, updateCalls, reconcile)
 }
+
+func TestApplyGeneratedSecret(t *testing.T) {
+	funcCalls := []mockFuncCall{{metaName: "*v1.Secret-test-secrets"}}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": funcCalls}
+	current := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "secrets",
+			Namespace: "test",
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Data = map[string][]byte{"a": []byte{'1', '2'}}
+	reconcile := func(c *mockClient, cr interface{}) error {
+		return ApplyGeneratedSecret(c, cr.(*corev1.Secret))
+	}
+	reconcileTester(t, "TestApplyGeneratedSecret", &current, revised, createCalls, updateCalls, reconcile)
+}
+
+func TestApplySplunkSecrets(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	// new secrets should include generated TLS certificates
+	c := newMockClient()
+	secrets, err := applySplunkSecrets(c, &cr, enterprise.SplunkStandalone, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("applySplunkSecrets() returned %v; want nil", err)
+	}
+	if len(secrets.Data["ca.crt"]) == 0 || len(secrets.Data["server.pem"]) == 0 {
+		t.Errorf("applySplunkSecrets() did not generate TLS certificates for new secrets")
+	}
+	c.checkCalls(t, "TestApplySplunkSecrets", map[string][]mockFuncCall{
+		"Get":    {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+		"Create": {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+	})
+
+	// TLS certificates should be added to existing secrets that do not have them
+	existing := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-standalone-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password": []byte("1234"),
+		},
+	}
+	c = newMockClient()
+	c.state[getStateKey(&existing)] = &existing
+	secrets, err = applySplunkSecrets(c, &cr, enterprise.SplunkStandalone, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("applySplunkSecrets() returned %v; want nil", err)
+	}
+	if string(secrets.Data["password"]) != "1234" || len(secrets.Data["server.pem"]) == 0 {
+		t.Errorf("applySplunkSecrets() did not add TLS certificates to existing secrets")
+	}
+	if !isSplunkTLSPending(secrets) {
+		t.Errorf("applySplunkSecrets() did not record pending TLS certificates for existing secrets")
+	}
+	c.checkCalls(t, "TestApplySplunkSecrets", map[string][]mockFuncCall{
+		"Get":    {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+		"Update": {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+	})
+
+	// no changes are needed when TLS certificates already exist
+	c.resetCalls()
+	if _, err = applySplunkSecrets(c, &cr, enterprise.SplunkStandalone, nil, nil, nil); err != nil {
+		t.Fatalf("applySplunkSecrets() returned %v; want nil", err)
+	}
+	c.checkCalls(t, "TestApplySplunkSecrets", map[string][]mockFuncCall{
+		"Get": {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+	})
+}
+
+func TestApplySplunkConfigWithSecretRef(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.SecretRef = "my-secrets"
+
+	// referenced secret must exist
+	c := newMockClient()
+	if _, err := ApplySplunkConfig(c, &cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone); err == nil {
+		t.Errorf("ApplySplunkConfig() returned nil; want error for missing secret")
+	}
+
+	// new secrets should use user-provided values
+	userSecrets := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password": []byte("my-password"),
+		},
+	}
+	c.state[getStateKey(&userSecrets)] = &userSecrets
+	c.resetCalls()
+	secrets, err := ApplySplunkConfig(c, &cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone)
+	if err != nil {
+		t.Fatalf("ApplySplunkConfig() returned %v; want nil", err)
+	}
+	if string(secrets.Data["password"]) != "my-password" {
+		t.Errorf("ApplySplunkConfig() password = %s; want my-password", secrets.Data["password"])
+	}
+	c.checkCalls(t, "TestApplySplunkConfigWithSecretRef", map[string][]mockFuncCall{
+		"Get": {
+			{metaName: "*v1.Secret-test-my-secrets"},
+			{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"},
+		},
+		"Create": {{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"}},
+	})
+
+	// existing secrets are not changed when user-provided values change, since they must be rotated
+	userSecrets.Data["password"] = []byte("new-password")
+	c.resetCalls()
+	secrets, err = ApplySplunkConfig(c, &cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone)
+	if _, ok := err.(*secretsPendingError); !ok || !strings.Contains(err.Error(), rotateSecretsAnnotation) {
+		t.Fatalf("ApplySplunkConfig() returned %v; want secretsPendingError for password", err)
+	}
+	if string(secrets.Data["password"]) != "my-password" {
+		t.Errorf("ApplySplunkConfig() password = %s; want my-password", secrets.Data["password"])
+	}
+	c.checkCalls(t, "TestApplySplunkConfigWithSecretRef", map[string][]mockFuncCall{
+		"Get": {
+			{metaName: "*v1.Secret-test-my-secrets"},
+			{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"},
+		},
+	})
+
+	// secrets that cannot be rotated are refused
+	userSecrets.Data["hec_token"] = []byte("my-hec-token")
+	secrets, err = ApplySplunkConfig(c, &cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone)
+	if _, ok := err.(*secretsPendingError); !ok || !strings.Contains(err.Error(), "hec_token, which cannot be changed") {
+		t.Errorf("ApplySplunkConfig() returned %v; want secretsPendingError for hec_token", err)
+	}
+	if string(secrets.Data["hec_token"]) == "my-hec-token" {
+		t.Errorf("ApplySplunkConfig() changed hec_token of existing secrets")
+	}
+
+	// user-provided secrets must include a password
+	delete(userSecrets.Data, "password")
+	if _, err = ApplySplunkConfig(c, &cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone); err == nil {
+		t.Errorf("ApplySplunkConfig() returned nil; want error for missing password")
+	}
+}
+
+func TestGetSplunkTLSConfig(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	generated := corev1.Secret{}
+	if err := enterprise.AddSplunkTLSSecrets(&cr, enterprise.SplunkStandalone, &generated); err != nil {
+		t.Fatalf("AddSplunkTLSSecrets() returned %v", err)
+	}
+
+	// use CA generated by the operator
+	c := newMockClient()
+	tlsConfig, err := getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, &generated)
+	if err != nil {
+		t.Fatalf("getSplunkTLSConfig() returned %v; want nil", err)
+	}
+	if tlsConfig.InsecureSkipVerify || tlsConfig.RootCAs == nil {
+		t.Errorf("getSplunkTLSConfig() did not verify certificates using generated CA")
+	}
+
+	// do not verify certificates that pods may not be using yet
+	generated.SetAnnotations(map[string]string{tlsPendingAnnotation: time.Now().UTC().Format(time.RFC3339)})
+	tlsConfig, err = getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, &generated)
+	if err != nil {
+		t.Fatalf("getSplunkTLSConfig() returned %v; want nil", err)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Errorf("getSplunkTLSConfig() verified pending certificates")
+	}
+
+	// use CA bundle from tlsSecretRef
+	cr.Spec.TLSSecretRef = "splunk-tls"
+	if _, err = getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, &generated); err == nil {
+		t.Errorf("getSplunkTLSConfig() returned nil; want error for missing TLS secret")
+	}
+	tlsSecret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-tls",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{},
+	}
+	c.state[getStateKey(&tlsSecret)] = &tlsSecret
+	if _, err = getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, &generated); err == nil {
+		t.Errorf("getSplunkTLSConfig() returned nil; want error for missing ca.crt")
+	}
+	tlsSecret.Data["ca.crt"] = generated.Data["ca.crt"]
+	tlsConfig, err = getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, &generated)
+	if err != nil {
+		t.Fatalf("getSplunkTLSConfig() returned %v; want nil", err)
+	}
+	if len(tlsConfig.Certificates) != 0 {
+		t.Errorf("getSplunkTLSConfig() returned %d client certificates; want 0", len(tlsConfig.Certificates))
+	}
+}
+
+func TestSplunkTLSUpgrade(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.Replicas = 1
+
+	// secrets, statefulset and pod created by an older version of the operator
+	existing := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-indexer-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password": []byte("1234"),
+		},
+	}
+	current, err := enterprise.GetIndexerStatefulSet(&cr)
+	if err != nil {
+		t.Fatalf("GetIndexerStatefulSet() returned error: %v", err)
+	}
+	delete(current.Spec.Template.Annotations, "enterprise.splunk.com/tls-certificates")
+	current.Status = appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1, UpdateRevision: "v1"}
+	started := metav1.NewTime(time.Now().Add(-time.Hour))
+	pod := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "splunk-stack1-indexer-0",
+			Namespace:         "test",
+			CreationTimestamp: started,
+			Labels:            map[string]string{"controller-revision-hash": "v1"},
+		},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "mnt-splunk-secrets", VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: existing.GetName()}}}},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Ready: true}},
+		},
+	}
+	other := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "splunk-stack2-indexer-0",
+			Namespace:         "test",
+			CreationTimestamp: started,
+		},
+	}
+	c := newMockClient()
+	c.state[getStateKey(&existing)] = &existing
+	c.state[getStateKey(current)] = current
+	c.state[getStateKey(&pod)] = &pod
+	c.state[getStateKey(&other)] = &other
+
+	// certificates are not verified until pods are started with them
+	secrets, err := applySplunkSecrets(c, &cr, enterprise.SplunkIndexer, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("applySplunkSecrets() returned %v; want nil", err)
+	}
+	verifies := func(want bool) {
+		tlsConfig, err := getSplunkTLSConfig(c, &cr, &cr.Spec.CommonSplunkSpec, secrets)
+		if err != nil {
+			t.Fatalf("getSplunkTLSConfig() returned %v; want nil", err)
+		}
+		if tlsConfig.InsecureSkipVerify == want {
+			t.Errorf("getSplunkTLSConfig() InsecureSkipVerify = %t; want %t", tlsConfig.InsecureSkipVerify, !want)
+		}
+	}
+	verifies(false)
+	if err = updateSplunkTLSPending(c, secrets); err != nil || !isSplunkTLSPending(secrets) {
+		t.Errorf("updateSplunkTLSPending() = %v, pending %t; want nil, true", err, isSplunkTLSPending(secrets))
+	}
+	verifies(false)
+
+	// pods are recycled to use the certificates, even by managers that apply restart-only configuration using rolling restarts
+	revised, err := enterprise.GetIndexerStatefulSet(&cr)
+	if err != nil {
+		t.Fatalf("GetIndexerStatefulSet() returned error: %v", err)
+	}
+	phase, err := ApplyStatefulSet(c, revised, nil)
+	if err != nil || phase != enterprisev1.PhaseUpdating {
+		t.Fatalf("ApplyStatefulSet() = %s, %v; want %s, nil", phase, err, enterprisev1.PhaseUpdating)
+	}
+	revised.Status.UpdateRevision = "v2"
+	c.resetCalls()
+	if _, err = UpdateStatefulSetPods(c, revised, &restartingPodManager{}, 1, "", 0); err != nil {
+		t.Errorf("UpdateStatefulSetPods() returned error: %v", err)
+	}
+	if len(c.calls["Delete"]) != 1 {
+		t.Errorf("UpdateStatefulSetPods() did not recycle pod started before TLS certificates were added")
+	}
+
+	// certificates are verified once every pod that mounts the secrets has been recreated
+	pod.CreationTimestamp = metav1.NewTime(time.Now().Add(time.Minute))
+	pod.Labels["controller-revision-hash"] = "v2"
+	c.state[getStateKey(&pod)] = &pod
+	if err = updateSplunkTLSPending(c, secrets); err != nil || isSplunkTLSPending(secrets) {
+		t.Errorf("updateSplunkTLSPending() = %v, pending %t; want nil, false", err, isSplunkTLSPending(secrets))
+	}
+	if isSplunkTLSPending(c.state[getStateKey(&existing)].(*corev1.Secret)) {
+		t.Errorf("updateSplunkTLSPending() did not update Secret")
+	}
+	verifies(true)
+}
+
+func TestGetRestartConfigChecksum(t *testing.T) {
+	secrets := corev1.Secret{Data: map[string][]byte{"password": []byte("one")}}
+	want := getRestartConfigChecksum(&secrets)
+
+	// changes to secrets that are applied when pods start are not restart-only
+	secrets.Data["password"] = []byte("two")
+	secrets.Data["default.yml"] = []byte("splunk: {}")
+	if got := getRestartConfigChecksum(&secrets); got != want {
+		t.Errorf("getRestartConfigChecksum() changed for password and default.yml")
+	}
+
+	// certificates are read by splunkd when it restarts
+	for _, key := range []string{"server.pem", "ca.crt"} {
+		secrets.Data[key] = []byte(key)
+		got := getRestartConfigChecksum(&secrets)
+		if got == want {
+			t.Errorf("getRestartConfigChecksum() did not change for %s", key)
+		}
+		want = got
+	}
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// dependency is a custom resource that is referenced by another custom resource
+type dependency struct {
+	// name of the spec field that holds the reference
+	field string
+
+	// kind of the referenced custom resource
+	kind string
+
+	// reference to the custom resource
+	ref corev1.ObjectReference
+
+	// whether or not the referenced custom resource must be ready before the referencing one is rolled out
+	wait bool
+}
+
+// getDependencies returns the custom resources referenced by a Splunk Enterprise custom resource, or an error if it
+// uses references that are not supported for its kind
+func getDependencies(cr enterprisev1.MetaObject) ([]dependency, error) {
+	var spec *enterprisev1.CommonSplunkSpec
+	var sparkRef corev1.ObjectReference
+	kind := ""
+	switch cr := cr.(type) {
+	case *enterprisev1.Standalone:
+		spec, sparkRef, kind = &cr.Spec.CommonSplunkSpec, cr.Spec.SparkRef, "Standalone"
+	case *enterprisev1.LicenseMaster:
+		spec, kind = &cr.Spec.CommonSplunkSpec, "LicenseMaster"
+	case *enterprisev1.ClusterMaster:
+		spec, kind = &cr.Spec.CommonSplunkSpec, "ClusterMaster"
+	case *enterprisev1.IndexerCluster:
+		spec, kind = &cr.Spec.CommonSplunkSpec, "IndexerCluster"
+	case *enterprisev1.SearchHeadCluster:
+		spec, sparkRef, kind = &cr.Spec.CommonSplunkSpec, cr.Spec.SparkRef, "SearchHeadCluster"
+	case *enterprisev1.MonitoringConsole:
+		spec, kind = &cr.Spec.CommonSplunkSpec, "MonitoringConsole"
+	default:
+		return nil, nil
+	}
+
+	// references that make no sense for a kind, such as an indexer cluster that refers to an indexer cluster
+	unsupported := map[string][]string{
+		"LicenseMaster":  {"licenseMasterRef"},
+		"ClusterMaster":  {"clusterMasterRef", "indexerClusterRef"},
+		"IndexerCluster": {"indexerClusterRef"},
+	}
+
+	// license masters do not wait for the cluster they refer to, since its peers wait for the license master
+	waitForCluster := kind != "LicenseMaster"
+
+	candidates := []dependency{
+		{field: "licenseMasterRef", kind: "LicenseMaster", ref: spec.LicenseMasterRef, wait: true},
+		{field: "clusterMasterRef", kind: "ClusterMaster", ref: spec.ClusterMasterRef, wait: waitForCluster},
+		{field: "indexerClusterRef", kind: "IndexerCluster", ref: spec.IndexerClusterRef, wait: waitForCluster},
+		{field: "sparkRef", kind: "Spark", ref: sparkRef, wait: true},
+	}
+	dependencies := []dependency{}
+	for _, dep := range candidates {
+		if dep.ref.Name == "" {
+			continue
+		}
+		for _, field := range unsupported[kind] {
+			if dep.field == field {
+				return nil, fmt.Errorf("%s is not supported by %s resources", field, kind)
+			}
+		}
+		if dep.ref.Kind != "" && dep.ref.Kind != dep.kind {
+			return nil, fmt.Errorf("%s must refer to a resource of kind %s, not %s", dep.field, dep.kind, dep.ref.Kind)
+		}
+		if dep.field == "indexerClusterRef" && spec.ClusterMasterRef.Name != "" {
+			// clusterMasterRef takes precedence over indexerClusterRef
+			continue
+		}
+		if dep.ref.Namespace == "" {
+			dep.ref.Namespace = cr.GetNamespace()
+		}
+		dependencies = append(dependencies, dep)
+	}
+	return dependencies, nil
+}
+
+// getDependencyPhase returns the phase of a referenced custom resource that its dependents wait for. Dependents of an
+// indexer cluster only require its cluster master to be ready.
+func getDependencyPhase(c ControllerClient, dep dependency) (enterprisev1.ResourcePhase, error) {
+	var target enterprisev1.MetaObject
+	switch dep.kind {
+	case "LicenseMaster":
+		target = &enterprisev1.LicenseMaster{}
+	case "ClusterMaster":
+		target = &enterprisev1.ClusterMaster{}
+	case "IndexerCluster":
+		target = &enterprisev1.IndexerCluster{}
+	case "Spark":
+		target = &enterprisev1.Spark{}
+	}
+
+	namespacedName := types.NamespacedName{Namespace: dep.ref.Namespace, Name: dep.ref.Name}
+	if err := c.Get(context.TODO(), namespacedName, target); err != nil {
+		return enterprisev1.PhasePending, fmt.Errorf("Unable to get %s %s/%s referenced by %s: %v", dep.kind, dep.ref.Namespace, dep.ref.Name, dep.field, err)
+	}
+
+	switch target := target.(type) {
+	case *enterprisev1.IndexerCluster:
+		return target.Status.ClusterMasterPhase, nil
+	case *enterprisev1.Spark:
+		return target.Status.Phase, nil
+	}
+	return getResourcePhase(target), nil
+}
+
+// applyDependencies checks that all of the custom resources referenced by a Splunk Enterprise custom resource exist and
+// are ready, and updates its DependenciesReady condition. It returns true once the custom resource may be rolled out,
+// and an error if a referenced custom resource does not exist or is not supported.
+func applyDependencies(c ControllerClient, cr enterprisev1.MetaObject, conditions *[]enterprisev1.Condition) (bool, error) {
+	generation := cr.GetObjectMeta().GetGeneration()
+	dependencies, err := getDependencies(cr)
+	if err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionDependenciesReady, corev1.ConditionFalse, "InvalidReference", err.Error())
+		recordEvent(cr, corev1.EventTypeWarning, eventReasonValidationFailed, "Invalid reference: %v", err)
+		return false, err
+	}
+
+	waiting := []string{}
+	for _, dep := range dependencies {
+		phase, err := getDependencyPhase(c, dep)
+		if err != nil {
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionDependenciesReady, corev1.ConditionFalse, "NotFound", err.Error())
+			return false, err
+		}
+		if dep.wait && phase != enterprisev1.PhaseReady {
+			waiting = append(waiting, fmt.Sprintf("%s %s/%s (%s)", dep.kind, dep.ref.Namespace, dep.ref.Name, phase))
+		}
+	}
+
+	if len(waiting) > 0 {
+		message := "Waiting for " + strings.Join(waiting, ", ")
+		log.Info(message, "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionDependenciesReady, corev1.ConditionFalse, "Waiting", message)
+		return false, nil
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionDependenciesReady, corev1.ConditionTrue, "Ready", "")
+	return true, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+func TestGetDependencies(t *testing.T) {
+	test := func(cr enterprisev1.MetaObject, want []string, wantErr string) {
+		dependencies, err := getDependencies(cr)
+		gotErr := ""
+		if err != nil {
+			gotErr = err.Error()
+		}
+		if gotErr != wantErr {
+			t.Errorf("getDependencies() err = %q; want %q", gotErr, wantErr)
+		}
+		got := []string{}
+		for _, dep := range dependencies {
+			got = append(got, dep.field+"="+dep.kind+"/"+dep.ref.Namespace+"/"+dep.ref.Name)
+		}
+		if len(got) != len(want) {
+			t.Errorf("getDependencies() = %v; want %v", got, want)
+			return
+		}
+		for idx := range got {
+			if got[idx] != want[idx] {
+				t.Errorf("getDependencies() = %v; want %v", got, want)
+			}
+		}
+	}
+
+	objectMeta := metav1.ObjectMeta{Name: "stack1", Namespace: "test"}
+
+	standalone := enterprisev1.Standalone{ObjectMeta: objectMeta}
+	test(&standalone, []string{}, "")
+	standalone.Spec.LicenseMasterRef.Name = "lm"
+	standalone.Spec.IndexerClusterRef = corev1.ObjectReference{Name: "idxc", Namespace: "other"}
+	standalone.Spec.SparkRef.Name = "spark"
+	test(&standalone, []string{"licenseMasterRef=LicenseMaster/test/lm", "indexerClusterRef=IndexerCluster/other/idxc", "sparkRef=Spark/test/spark"}, "")
+	standalone.Spec.ClusterMasterRef.Name = "cm"
+	test(&standalone, []string{"licenseMasterRef=LicenseMaster/test/lm", "clusterMasterRef=ClusterMaster/test/cm", "sparkRef=Spark/test/spark"}, "")
+	standalone.Spec.LicenseMasterRef.Kind = "Standalone"
+	test(&standalone, []string{}, "licenseMasterRef must refer to a resource of kind LicenseMaster, not Standalone")
+
+	idxc := enterprisev1.IndexerCluster{ObjectMeta: objectMeta}
+	idxc.Spec.ClusterMasterRef.Name = "cm"
+	test(&idxc, []string{"clusterMasterRef=ClusterMaster/test/cm"}, "")
+	idxc.Spec.IndexerClusterRef.Name = "stack1"
+	test(&idxc, []string{}, "indexerClusterRef is not supported by IndexerCluster resources")
+
+	lm := enterprisev1.LicenseMaster{ObjectMeta: objectMeta}
+	lm.Spec.IndexerClusterRef.Name = "idxc"
+	dependencies, _ := getDependencies(&lm)
+	if len(dependencies) != 1 || dependencies[0].wait {
+		t.Errorf("getDependencies() = %v; want indexerClusterRef without wait", dependencies)
+	}
+	lm.Spec.LicenseMasterRef.Name = "stack1"
+	test(&lm, []string{}, "licenseMasterRef is not supported by LicenseMaster resources")
+
+	cm := enterprisev1.ClusterMaster{ObjectMeta: objectMeta}
+	cm.Spec.ClusterMasterRef.Name = "stack1"
+	test(&cm, []string{}, "clusterMasterRef is not supported by ClusterMaster resources")
+}
+
+func TestApplyDependencies(t *testing.T) {
+	c := newMockClient()
+	cr := enterprisev1.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "stack1", Namespace: "test"},
+	}
+	cr.Spec.LicenseMasterRef.Name = "lm"
+	cr.Spec.IndexerClusterRef.Name = "idxc"
+
+	test := func(wantReady bool, wantErr bool, wantReason, wantMessage string) {
+		ready, err := applyDependencies(c, &cr, &cr.Status.Conditions)
+		if ready != wantReady || (err != nil) != wantErr {
+			t.Errorf("applyDependencies() = %t, %v; want %t, error %t", ready, err, wantReady, wantErr)
+		}
+		condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionDependenciesReady)
+		if condition == nil || condition.Reason != wantReason || condition.Message != wantMessage {
+			t.Errorf("applyDependencies() condition = %v; want reason %q, message %q", condition, wantReason, wantMessage)
+		}
+	}
+
+	// license master does not exist
+	test(false, true, "NotFound", "Unable to get LicenseMaster test/lm referenced by licenseMasterRef: NotFound")
+
+	// license master and indexer cluster are not ready
+	lm := enterprisev1.LicenseMaster{ObjectMeta: metav1.ObjectMeta{Name: "lm", Namespace: "test"}}
+	lm.Status.Phase = enterprisev1.PhasePending
+	idxc := enterprisev1.IndexerCluster{ObjectMeta: metav1.ObjectMeta{Name: "idxc", Namespace: "test"}}
+	idxc.Status.Phase = enterprisev1.PhaseReady
+	idxc.Status.ClusterMasterPhase = enterprisev1.PhaseUpdating
+	c.state[getStateKey(&lm)] = &lm
+	c.state[getStateKey(&idxc)] = &idxc
+	test(false, false, "Waiting", "Waiting for LicenseMaster test/lm (Pending), IndexerCluster test/idxc (Updating)")
+
+	// both are ready
+	lm.Status.Phase = enterprisev1.PhaseReady
+	idxc.Status.ClusterMasterPhase = enterprisev1.PhaseReady
+	test(true, false, "Ready", "")
+
+	// invalid references are rejected
+	cr.Spec.IndexerClusterRef.Kind = "SearchHeadCluster"
+	test(false, true, "InvalidReference", "indexerClusterRef must refer to a resource of kind IndexerCluster, not SearchHeadCluster")
+}
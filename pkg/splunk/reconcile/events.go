@@ -0,0 +1,81 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// reasons for the events recorded on custom resources
+const (
+	eventReasonValidationFailed        = "ValidationFailed"
+	eventReasonScalingUp               = "ScalingUp"
+	eventReasonScalingDown             = "ScalingDown"
+	eventReasonScaleDownFailed         = "ScaleDownFailed"
+	eventReasonPodRecycled             = "PodRecycled"
+	eventReasonRecycleFailed           = "RecycleFailed"
+	eventReasonDrainTimedOut           = "DrainTimedOut"
+	eventReasonPVCDeleted              = "PVCDeleted"
+	eventReasonPVCRetained             = "PVCRetained"
+	eventReasonPVCReattached           = "PVCReattached"
+	eventReasonPVCSnapshotCreated      = "PVCSnapshotCreated"
+	eventReasonVolumeExpansionFailed   = "VolumeExpansionFailed"
+	eventReasonPeerDecommissioning     = "PeerDecommissioning"
+	eventReasonPeerRemoved             = "PeerRemoved"
+	eventReasonMaintenanceModeEnabled  = "MaintenanceModeEnabled"
+	eventReasonMaintenanceModeDisabled = "MaintenanceModeDisabled"
+	eventReasonMemberDetained          = "MemberDetained"
+	eventReasonMemberReleased          = "MemberReleased"
+	eventReasonMemberRemoved           = "MemberRemoved"
+	eventReasonCaptainLost             = "CaptainLost"
+	eventReasonCaptainChanged          = "CaptainChanged"
+	eventReasonDeleting                = "Deleting"
+	eventReasonDeletionFailed          = "DeletionFailed"
+)
+
+// eventRecorder is used to record events on custom resources; no events are recorded if it is nil
+var eventRecorder record.EventRecorder
+
+// SetEventRecorder sets the recorder used for events about actions taken on custom resources.
+func SetEventRecorder(recorder record.EventRecorder) {
+	eventRecorder = recorder
+}
+
+// recordEvent records an event on a custom resource, so that it is shown by "kubectl describe"
+func recordEvent(obj runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	if eventRecorder == nil || obj == nil {
+		return
+	}
+	eventRecorder.Eventf(obj, eventType, reason, messageFmt, args...)
+}
+
+// getEventOwner returns a reference to the custom resource that controls a Kubernetes resource,
+// or nil if it does not have one
+func getEventOwner(obj metav1.Object) runtime.Object {
+	owner := metav1.GetControllerOf(obj)
+	if owner == nil {
+		return nil
+	}
+	return &corev1.ObjectReference{
+		APIVersion: owner.APIVersion,
+		Kind:       owner.Kind,
+		Name:       owner.Name,
+		Namespace:  obj.GetNamespace(),
+		UID:        owner.UID,
+	}
+}
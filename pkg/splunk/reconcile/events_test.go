@@ -0,0 +1,87 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+func TestGetEventOwner(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "enterprise.splunk.com/v1alpha2",
+			Kind:       "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+			UID:       "1234",
+		},
+	}
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pvc-etc-splunk-stack1-indexer-0",
+			Namespace: "test",
+		},
+	}
+	if owner := getEventOwner(&pvc); owner != nil {
+		t.Errorf("getEventOwner() = %v; want nil", owner)
+	}
+
+	pvc.SetOwnerReferences([]metav1.OwnerReference{resources.AsOwner(&cr)})
+	owner, ok := getEventOwner(&pvc).(*corev1.ObjectReference)
+	if !ok {
+		t.Fatalf("getEventOwner() did not return an ObjectReference")
+	}
+	want := corev1.ObjectReference{APIVersion: "enterprise.splunk.com/v1alpha2", Kind: "IndexerCluster", Name: "stack1", Namespace: "test", UID: "1234"}
+	if *owner != want {
+		t.Errorf("getEventOwner() = %v; want %v", *owner, want)
+	}
+}
+
+func TestRecordEvent(t *testing.T) {
+	// no events are recorded without a recorder
+	recordEvent(&corev1.ObjectReference{}, corev1.EventTypeNormal, eventReasonPVCDeleted, "Deleted PVC %s", "pvc-etc")
+
+	recorder := record.NewFakeRecorder(10)
+	SetEventRecorder(recorder)
+	defer SetEventRecorder(nil)
+
+	recordEvent(nil, corev1.EventTypeNormal, eventReasonPVCDeleted, "Deleted PVC %s", "pvc-etc")
+	c := newMockClient()
+	pvc := corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "pvc-etc-splunk-stack1-indexer-0",
+			Namespace: "test",
+		},
+	}
+	if err := reclaimVolume(c, &corev1.ObjectReference{}, &pvc, enterprisev1.VolumeReclaimDelete); err != nil {
+		t.Errorf("reclaimVolume() returned error: %v", err)
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("recordEvent() recorded %d events; want 1", len(recorder.Events))
+	}
+	want := "Normal PVCDeleted Deleted PVC pvc-etc-splunk-stack1-indexer-0"
+	if got := <-recorder.Events; got != want {
+		t.Errorf("recordEvent() = %q; want %q", got, want)
+	}
+}
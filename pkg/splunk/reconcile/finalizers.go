@@ -17,16 +17,31 @@ package reconcile
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 )
 
 const (
 	splunkFinalizerDeletePVC = "enterprise.splunk.com/delete-pvc"
+
+	// orphanedAtLabel is added to PersistentVolumeClaims that are retained after the instance using them was removed,
+	// and records when that happened (in seconds since the epoch)
+	orphanedAtLabel = "enterprise.splunk.com/orphaned-at"
+
+	// defaultRetainedVolumeGracePeriod is how long retained PersistentVolumeClaims may be re-attached by scaling back up,
+	// unless retainedVolumeGracePeriodHours is specified; older claims are deleted instead, so that new instances have clean state
+	defaultRetainedVolumeGracePeriod = 24 * time.Hour
 )
 
 // CheckSplunkDeletion checks to see if deletion was requested for the custom resource.
@@ -50,19 +65,23 @@ func CheckSplunkDeletion(cr enterprisev1.MetaObject, c ControllerClient) (bool,
 	}
 
 	scopedLog.Info("Deletion requested")
+	recordEvent(cr, corev1.EventTypeNormal, eventReasonDeleting, "Deleting %s %s", cr.GetTypeMeta().Kind, cr.GetIdentifier())
 
 	// process each finalizer
 	for _, finalizer := range cr.GetObjectMeta().GetFinalizers() {
 		switch finalizer {
 		case splunkFinalizerDeletePVC:
 			if err := DeleteSplunkPvc(cr, c); err != nil {
+				recordEvent(cr, corev1.EventTypeWarning, eventReasonDeletionFailed, "Unable to delete PVCs: %v", err)
 				return false, err
 			}
 			if err := RemoveSplunkFinalizer(cr, c, finalizer); err != nil {
 				return false, err
 			}
 		default:
-			return false, fmt.Errorf("Finalizer in %s %s/%s not recognized: %s", cr.GetTypeMeta().Kind, cr.GetNamespace(), cr.GetIdentifier(), finalizer)
+			err := fmt.Errorf("Finalizer in %s %s/%s not recognized: %s", cr.GetTypeMeta().Kind, cr.GetNamespace(), cr.GetIdentifier(), finalizer)
+			recordEvent(cr, corev1.EventTypeWarning, eventReasonDeletionFailed, "%v", err)
+			return false, err
 		}
 	}
 
@@ -76,6 +95,7 @@ func DeleteSplunkPvc(cr enterprisev1.MetaObject, c ControllerClient) error {
 	scopedLog := log.WithName("DeleteSplunkPvc").WithValues("kind", cr.GetTypeMeta().Kind, "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
 
 	var component string
+	labelName := "app.kubernetes.io/part-of"
 	switch cr.GetTypeMeta().Kind {
 	case "Standalone":
 		component = "standalone"
@@ -85,6 +105,12 @@ func DeleteSplunkPvc(cr enterprisev1.MetaObject, c ControllerClient) error {
 		component = "search-head"
 	case "IndexerCluster":
 		component = "indexer"
+	case "MonitoringConsole":
+		component = "monitoring-console"
+	case "ClusterMaster":
+		// cluster masters are part of an indexer cluster, so only match the cluster master instance
+		component = "cluster-master"
+		labelName = "app.kubernetes.io/instance"
 	default:
 		scopedLog.Info("Skipping PVC removal")
 		return nil
@@ -92,7 +118,7 @@ func DeleteSplunkPvc(cr enterprisev1.MetaObject, c ControllerClient) error {
 
 	// get list of PVCs for this cluster
 	labels := map[string]string{
-		"app.kubernetes.io/part-of": fmt.Sprintf("splunk-%s-%s", cr.GetIdentifier(), component),
+		labelName: fmt.Sprintf("splunk-%s-%s", cr.GetIdentifier(), component),
 	}
 	listOpts := []client.ListOption{
 		client.InNamespace(cr.GetNamespace()),
@@ -108,10 +134,13 @@ func DeleteSplunkPvc(cr enterprisev1.MetaObject, c ControllerClient) error {
 		return nil
 	}
 
-	// delete each PVC
-	for _, pvc := range pvclist.Items {
-		scopedLog.Info("Deleting PVC", "name", pvc.ObjectMeta.Name)
-		if err := c.Delete(context.Background(), &pvc); err != nil {
+	// reclaim each PVC
+	var reclaimPolicy enterprisev1.VolumeReclaimPolicy
+	if spec := getCommonSplunkSpec(cr); spec != nil {
+		reclaimPolicy = spec.VolumeReclaimPolicy
+	}
+	for i := range pvclist.Items {
+		if err := reclaimVolume(c, cr, &pvclist.Items[i], reclaimPolicy); err != nil {
 			return err
 		}
 	}
@@ -119,6 +148,118 @@ func DeleteSplunkPvc(cr enterprisev1.MetaObject, c ControllerClient) error {
 	return nil
 }
 
+// reclaimVolume deletes, retains or snapshots and then deletes a PersistentVolumeClaim, according to a reclaim policy.
+// Retained claims are labeled with the time that they were orphaned. Events are recorded on owner.
+func reclaimVolume(c ControllerClient, owner runtime.Object, pvc *corev1.PersistentVolumeClaim, reclaimPolicy enterprisev1.VolumeReclaimPolicy) error {
+	scopedLog := log.WithName("reclaimVolume").WithValues("name", pvc.GetName(), "namespace", pvc.GetNamespace())
+
+	switch reclaimPolicy {
+	case enterprisev1.VolumeReclaimRetain:
+		if _, ok := pvc.GetLabels()[orphanedAtLabel]; ok {
+			return nil
+		}
+		scopedLog.Info("Retaining PVC")
+		labels := pvc.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[orphanedAtLabel] = strconv.FormatInt(time.Now().Unix(), 10)
+		pvc.SetLabels(labels)
+		if err := UpdateResource(c, pvc); err != nil {
+			return err
+		}
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonPVCRetained, "Retained PVC %s", pvc.GetName())
+		return nil
+	case enterprisev1.VolumeReclaimSnapshot:
+		snapshot := enterprise.GetPersistentVolumeClaimSnapshot(pvc, time.Now())
+		scopedLog.Info("Creating VolumeSnapshot of PVC", "snapshot", snapshot.GetName())
+		if err := c.Create(context.TODO(), snapshot); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonPVCSnapshotCreated, "Created VolumeSnapshot %s of PVC %s", snapshot.GetName(), pvc.GetName())
+	}
+
+	scopedLog.Info("Deleting PVC")
+	if err := c.Delete(context.Background(), pvc); err != nil {
+		return err
+	}
+	recordEvent(owner, corev1.EventTypeNormal, eventReasonPVCDeleted, "Deleted PVC %s", pvc.GetName())
+	return nil
+}
+
+// getRetainedVolumeGracePeriod returns how long PersistentVolumeClaims retained by scaling down may be re-attached
+func getRetainedVolumeGracePeriod(spec *enterprisev1.CommonSplunkSpec) time.Duration {
+	if spec.RetainedVolumeGracePeriodHours > 0 {
+		return time.Duration(spec.RetainedVolumeGracePeriodHours) * time.Hour
+	}
+	return defaultRetainedVolumeGracePeriod
+}
+
+// isRetainedVolumeExpired returns true if a retained PersistentVolumeClaim was orphaned for longer than gracePeriod
+func isRetainedVolumeExpired(pvc *corev1.PersistentVolumeClaim, gracePeriod time.Duration) bool {
+	orphaned, err := strconv.ParseInt(pvc.GetLabels()[orphanedAtLabel], 10, 64)
+	return err != nil || time.Since(time.Unix(orphaned, 0)) > gracePeriod
+}
+
+// deleteExpiredPodVolumes deletes retained PersistentVolumeClaims of a pod that is about to be created by scaling up,
+// if they were retained for longer than gracePeriod. It returns true once none of them remain, so that the pod is
+// not created until they are gone. Events are recorded on owner.
+func deleteExpiredPodVolumes(c ControllerClient, owner runtime.Object, statefulSet *appsv1.StatefulSet, podName string, gracePeriod time.Duration) (bool, error) {
+	scopedLog := log.WithName("deleteExpiredPodVolumes").WithValues("podName", podName, "namespace", statefulSet.GetNamespace())
+
+	removed := true
+	for _, tmpl := range statefulSet.Spec.VolumeClaimTemplates {
+		namespacedName := types.NamespacedName{Namespace: statefulSet.GetNamespace(), Name: fmt.Sprintf("%s-%s", tmpl.GetName(), podName)}
+		var pvc corev1.PersistentVolumeClaim
+		if err := c.Get(context.TODO(), namespacedName, &pvc); err != nil {
+			// no PVC to delete
+			continue
+		}
+		if _, ok := pvc.GetLabels()[orphanedAtLabel]; !ok || !isRetainedVolumeExpired(&pvc, gracePeriod) {
+			continue
+		}
+		removed = false
+		if pvc.GetObjectMeta().GetDeletionTimestamp() != nil {
+			scopedLog.Info("Waiting for expired PVC to be removed", "pvcName", pvc.GetName())
+			continue
+		}
+		scopedLog.Info("Deleting expired PVC", "pvcName", pvc.GetName(), "orphanedAt", pvc.GetLabels()[orphanedAtLabel])
+		if err := c.Delete(context.Background(), &pvc); err != nil {
+			return false, err
+		}
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonPVCDeleted, "Deleted expired PVC %s instead of re-attaching it", pvc.GetName())
+	}
+	return removed, nil
+}
+
+// reattachPodVolumes removes the orphaned label from retained PersistentVolumeClaims of a pod that is about to be
+// created by scaling up, so that the pod uses them again. Expired claims must already have been removed using
+// deleteExpiredPodVolumes. Events are recorded on owner.
+func reattachPodVolumes(c ControllerClient, owner runtime.Object, statefulSet *appsv1.StatefulSet, podName string) error {
+	scopedLog := log.WithName("reattachPodVolumes").WithValues("podName", podName, "namespace", statefulSet.GetNamespace())
+
+	for _, tmpl := range statefulSet.Spec.VolumeClaimTemplates {
+		namespacedName := types.NamespacedName{Namespace: statefulSet.GetNamespace(), Name: fmt.Sprintf("%s-%s", tmpl.GetName(), podName)}
+		var pvc corev1.PersistentVolumeClaim
+		if err := c.Get(context.TODO(), namespacedName, &pvc); err != nil {
+			// no PVC to re-attach
+			continue
+		}
+		if _, ok := pvc.GetLabels()[orphanedAtLabel]; !ok {
+			continue
+		}
+		scopedLog.Info("Re-attaching retained PVC", "pvcName", pvc.GetName())
+		labels := pvc.GetLabels()
+		delete(labels, orphanedAtLabel)
+		pvc.SetLabels(labels)
+		if err := UpdateResource(c, &pvc); err != nil {
+			return err
+		}
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonPVCReattached, "Re-attached retained PVC %s", pvc.GetName())
+	}
+	return nil
+}
+
 // RemoveSplunkFinalizer removes a finalizer from a custom resource.
 func RemoveSplunkFinalizer(cr enterprisev1.MetaObject, c ControllerClient, finalizer string) error {
 	scopedLog := log.WithName("RemoveSplunkFinalizer").WithValues("kind", cr.GetTypeMeta().Kind, "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
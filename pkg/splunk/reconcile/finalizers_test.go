@@ -19,8 +19,10 @@ import (
 	"testing"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
@@ -28,6 +30,7 @@ import (
 
 func splunkDeletionTester(t *testing.T, cr enterprisev1.MetaObject, delete func(enterprisev1.MetaObject, ControllerClient) (bool, error)) {
 	var component string
+	labelName := "app.kubernetes.io/part-of"
 	switch cr.GetTypeMeta().Kind {
 	case "Standalone":
 		component = "standalone"
@@ -37,10 +40,15 @@ func splunkDeletionTester(t *testing.T, cr enterprisev1.MetaObject, delete func(
 		component = "search-head"
 	case "IndexerCluster":
 		component = "indexer"
+	case "MonitoringConsole":
+		component = "monitoring-console"
+	case "ClusterMaster":
+		component = "cluster-master"
+		labelName = "app.kubernetes.io/instance"
 	}
 
 	labels := map[string]string{
-		"app.kubernetes.io/part-of": fmt.Sprintf("splunk-%s-%s", cr.GetIdentifier(), component),
+		labelName: fmt.Sprintf("splunk-%s-%s", cr.GetIdentifier(), component),
 	}
 	listOpts := []client.ListOption{
 		client.InNamespace(cr.GetNamespace()),
@@ -109,3 +117,132 @@ func TestCheckSplunkDeletion(t *testing.T) {
 		t.Errorf("CheckSplunkDeletion() returned %t, %v; want false, (error)", deleted, err)
 	}
 }
+
+func TestReclaimVolume(t *testing.T) {
+	newClaim := func() *corev1.PersistentVolumeClaim {
+		return &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pvc-var-splunk-stack1-indexer-2",
+				Namespace: "test",
+			},
+		}
+	}
+	pvcCalls := []mockFuncCall{{metaName: "*v1.PersistentVolumeClaim-test-pvc-var-splunk-stack1-indexer-2"}}
+
+	// delete
+	c := newMockClient()
+	if err := reclaimVolume(c, nil, newClaim(), enterprisev1.VolumeReclaimDelete); err != nil {
+		t.Errorf("reclaimVolume(Delete) returned error: %v", err)
+	}
+	c.checkCalls(t, "reclaimVolume(Delete)", map[string][]mockFuncCall{"Delete": pvcCalls})
+
+	// retain
+	c = newMockClient()
+	pvc := newClaim()
+	if err := reclaimVolume(c, nil, pvc, enterprisev1.VolumeReclaimRetain); err != nil {
+		t.Errorf("reclaimVolume(Retain) returned error: %v", err)
+	}
+	c.checkCalls(t, "reclaimVolume(Retain)", map[string][]mockFuncCall{"Update": pvcCalls})
+	if _, ok := pvc.GetLabels()[orphanedAtLabel]; !ok {
+		t.Errorf("reclaimVolume(Retain) did not add label %s", orphanedAtLabel)
+	}
+
+	// snapshot
+	c = newMockClient()
+	if err := reclaimVolume(c, nil, newClaim(), enterprisev1.VolumeReclaimSnapshot); err != nil {
+		t.Errorf("reclaimVolume(Snapshot) returned error: %v", err)
+	}
+	if len(c.calls["Create"]) != 1 || len(c.calls["Delete"]) != 1 {
+		t.Errorf("reclaimVolume(Snapshot) calls = %v; want one Create and one Delete", c.calls)
+	}
+	snapshot, ok := c.calls["Create"][0].obj.(*unstructured.Unstructured)
+	if !ok || snapshot.GetKind() != "VolumeSnapshot" {
+		t.Errorf("reclaimVolume(Snapshot) created %v; want VolumeSnapshot", c.calls["Create"][0].obj)
+	}
+}
+
+func TestReattachPodVolumes(t *testing.T) {
+	var replicas int32 = 2
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-indexer",
+			Namespace: "test",
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "pvc-etc"}}, {ObjectMeta: metav1.ObjectMeta{Name: "pvc-var"}}},
+		},
+		Status: appsv1.StatefulSetStatus{
+			Replicas:      2,
+			ReadyReplicas: 2,
+		},
+	}
+	gracePeriod := getRetainedVolumeGracePeriod(&enterprisev1.CommonSplunkSpec{RetainedVolumeGracePeriodHours: 2})
+	if gracePeriod != 2*time.Hour {
+		t.Errorf("getRetainedVolumeGracePeriod(2) = %v; want 2h", gracePeriod)
+	}
+	if got := getRetainedVolumeGracePeriod(&enterprisev1.CommonSplunkSpec{}); got != defaultRetainedVolumeGracePeriod {
+		t.Errorf("getRetainedVolumeGracePeriod() = %v; want %v", got, defaultRetainedVolumeGracePeriod)
+	}
+	now := time.Now()
+	c := newMockClient()
+	for vol, orphaned := range map[string]time.Time{"pvc-etc": now.Add(-time.Hour), "pvc-var": now.Add(-3 * time.Hour)} {
+		pvc := &corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      vol + "-splunk-stack1-indexer-2",
+				Namespace: "test",
+				Labels:    map[string]string{orphanedAtLabel: fmt.Sprintf("%d", orphaned.Unix())},
+			},
+		}
+		c.state[getStateKey(pvc)] = pvc
+	}
+	etcName := "*v1.PersistentVolumeClaim-test-pvc-etc-splunk-stack1-indexer-2"
+	varName := "*v1.PersistentVolumeClaim-test-pvc-var-splunk-stack1-indexer-2"
+	mgr := &DefaultStatefulSetPodManager{}
+	scaleUp := func(wantPhase enterprisev1.ResourcePhase, wantCalls map[string][]mockFuncCall) {
+		c.resetCalls()
+		phase, err := UpdateStatefulSetPods(c, statefulSet, mgr, 3, enterprisev1.VolumeReclaimRetain, gracePeriod)
+		if err != nil || phase != wantPhase {
+			t.Errorf("UpdateStatefulSetPods() = %s, %v; want %s", phase, err, wantPhase)
+		}
+		c.checkCalls(t, "UpdateStatefulSetPods()", wantCalls)
+	}
+
+	// expired claims are deleted in their own pass, without scaling up
+	expired := c.state[varName].(*corev1.PersistentVolumeClaim)
+	scaleUp(enterprisev1.PhaseScalingUp, map[string][]mockFuncCall{
+		"Get":    {{metaName: etcName}, {metaName: varName}},
+		"Delete": {{metaName: varName}},
+	})
+	if *statefulSet.Spec.Replicas != 2 {
+		t.Errorf("UpdateStatefulSetPods() scaled up to %d replicas before expired PVC was removed", *statefulSet.Spec.Replicas)
+	}
+
+	// wait for expired claims to be removed
+	deletionTime := metav1.NewTime(now)
+	expired.ObjectMeta.DeletionTimestamp = &deletionTime
+	c.state[varName] = expired
+	scaleUp(enterprisev1.PhaseScalingUp, map[string][]mockFuncCall{
+		"Get": {{metaName: etcName}, {metaName: varName}},
+	})
+
+	// claims that are not expired are re-attached once expired claims are gone, and then the StatefulSet is scaled up
+	c.state[varName] = nil
+	scaleUp(enterprisev1.PhaseScalingUp, map[string][]mockFuncCall{
+		"Get":    {{metaName: etcName}, {metaName: varName}, {metaName: etcName}, {metaName: varName}},
+		"Update": {{metaName: etcName}, {metaName: "*v1.StatefulSet-test-splunk-stack1-indexer"}},
+	})
+	pvc := c.state[etcName].(*corev1.PersistentVolumeClaim)
+	if _, ok := pvc.GetLabels()[orphanedAtLabel]; ok {
+		t.Errorf("reattachPodVolumes() did not remove label %s", orphanedAtLabel)
+	}
+	if *statefulSet.Spec.Replicas != 3 {
+		t.Errorf("UpdateStatefulSetPods() replicas = %d; want 3", *statefulSet.Spec.Replicas)
+	}
+
+	// nothing to re-attach
+	c = newMockClient()
+	if err := reattachPodVolumes(c, nil, statefulSet, "splunk-stack1-indexer-2"); err != nil {
+		t.Errorf("reattachPodVolumes() returned error: %v", err)
+	}
+}
@@ -16,11 +16,13 @@ package reconcile
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	"github.com/go-logr/logr"
@@ -43,9 +45,20 @@ func ApplyIndexerCluster(client ControllerClient, cr *enterprisev1.IndexerCluste
 	// validate and updates defaults for CR
 	err := enterprise.ValidateIndexerClusterSpec(&cr.Spec)
 	if err != nil {
+		recordEvent(cr, corev1.EventTypeWarning, eventReasonValidationFailed, "Invalid spec: %v", err)
 		return result, err
 	}
 
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			err := updatePausedIndexerClusterStatus(client, cr, scopedLog)
+			updateIndexerClusterMetrics(cr)
+			return err
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
 	// updates status after function completes
 	cr.Status.Phase = enterprisev1.PhaseError
 	cr.Status.ClusterMasterPhase = enterprisev1.PhaseError
@@ -55,6 +68,8 @@ func ApplyIndexerCluster(client ControllerClient, cr *enterprisev1.IndexerCluste
 		cr.Status.Peers = []enterprisev1.IndexerClusterMemberStatus{}
 	}
 	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		updateIndexerClusterMetrics(cr)
 		err = client.Status().Update(context.TODO(), cr)
 		if err != nil {
 			scopedLog.Error(err, "Status update failed")
@@ -73,9 +88,20 @@ func ApplyIndexerCluster(client ControllerClient, cr *enterprisev1.IndexerCluste
 		return result, err
 	}
 
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
+	if err != nil {
+		return result, err
+	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		cr.Status.ClusterMasterPhase = enterprisev1.PhasePending
+		return result, nil
+	}
+
 	// create or update general config resources
 	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkIndexer)
-	if err != nil {
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
 		return result, err
 	}
 
@@ -91,72 +117,327 @@ func ApplyIndexerCluster(client ControllerClient, cr *enterprisev1.IndexerCluste
 		return result, err
 	}
 
-	// create or update a regular service for the cluster master
-	err = ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkClusterMaster, false))
+	// get TLS configuration used to verify the certificates of the indexers
+	tlsConfig, err := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
 	if err != nil {
 		return result, err
 	}
 
-	// create or update statefulset for the cluster master
-	statefulSet, err := enterprise.GetClusterMasterStatefulSet(cr)
-	if err != nil {
-		return result, err
+	// create or update the cluster master, unless the peers join a separate ClusterMaster resource
+	appsInstalled := true
+	clusterMasterPassword := secrets.Data["password"]
+	clusterMasterTLSConfig := tlsConfig
+	if cr.Spec.ClusterMasterRef.Name == "" {
+		appsInstalled, err = applyIndexerClusterMaster(client, cr)
+	} else {
+		clusterMasterPassword, err = GetSplunkSecret(client, cr, cr.Spec.ClusterMasterRef, enterprise.SplunkClusterMaster, "password")
+		if err == nil {
+			cr.Status.ClusterMasterPhase, clusterMasterTLSConfig, err = getClusterMasterRefState(client, cr, cr.Spec.ClusterMasterRef)
+		}
+		setPhaseCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionClusterMasterReady, cr.Status.ClusterMasterPhase)
 	}
-	clusterMasterManager := DefaultStatefulSetPodManager{}
-	phase, err := clusterMasterManager.Update(client, statefulSet, 1)
 	if err != nil {
 		return result, err
 	}
-	cr.Status.ClusterMasterPhase = phase
 
-	// create or update statefulset for the indexers
-	statefulSet, err = enterprise.GetIndexerStatefulSet(cr)
+	// create or update statefulsets for the indexers (one per site for multisite clusters)
+	statefulSets, err := enterprise.GetIndexerStatefulSets(cr)
 	if err != nil {
 		return result, err
 	}
-	mgr := IndexerClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, newSplunkClient: splclient.NewSplunkClient}
-	phase, err = mgr.Update(client, statefulSet, cr.Spec.Replicas)
+	mgr := IndexerClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, tlsConfig: tlsConfig, clusterMasterPassword: clusterMasterPassword, clusterMasterTLSConfig: clusterMasterTLSConfig, newSplunkClient: splclient.NewSplunkClient}
+	phase, err := applyIndexerStatefulSets(client, mgr, statefulSets)
+	setIndexingReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.IndexingReady)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
 
-	// no need to requeue if everything is ready
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
 	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// push changes to the operator-managed configuration in the cluster bundle, and track which peers are using it
+	// (the phase is only pending until the cluster master is ready and able to report the state of the cluster)
+	bundlePending := false
+	if cr.Status.Phase != enterprisev1.PhasePending {
+		if bundlePending, err = mgr.updateBundleStatus(client); err != nil {
+			return result, err
+		}
+	}
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// no need to requeue if everything is ready
+	if cr.Status.Phase == enterprisev1.PhaseReady && appsInstalled && !rotationPending && !bundlePending {
 		result.Requeue = false
 	}
 	return result, nil
 }
 
+// updatePausedIndexerClusterStatus updates the status of an IndexerCluster resource while its reconciliation is paused
+func updatePausedIndexerClusterStatus(client ControllerClient, cr *enterprisev1.IndexerCluster, scopedLog logr.Logger) error {
+	secrets, err := getCurrentSplunkSecrets(client, cr, enterprise.SplunkIndexer)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if err != nil {
+		return err
+	}
+	mgr := IndexerClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, tlsConfig: tlsConfig, newSplunkClient: splclient.NewSplunkClient}
+	if ref := cr.Spec.ClusterMasterRef; ref.Name != "" {
+		if mgr.clusterMasterPassword, err = GetSplunkSecret(client, cr, ref, enterprise.SplunkClusterMaster, "password"); err != nil {
+			return err
+		}
+		if cr.Status.ClusterMasterPhase, mgr.clusterMasterTLSConfig, err = getClusterMasterRefState(client, cr, ref); err != nil {
+			return err
+		}
+	}
+
+	statefulSets, err := enterprise.GetIndexerStatefulSets(cr)
+	if err != nil {
+		return err
+	}
+	peers := []enterprisev1.IndexerClusterMemberStatus{}
+	var readyReplicas int32
+	for idx, revised := range statefulSets {
+		if len(cr.Spec.Sites) > 0 {
+			mgr.site = cr.Spec.Sites[idx].Name
+		}
+		statefulSet, err := getCurrentStatefulSet(client, revised.GetNamespace(), revised.GetName())
+		if err != nil {
+			return err
+		}
+		if err = mgr.updateStatus(statefulSet); err != nil {
+			return err
+		}
+		peers = append(peers, mgr.peers...)
+		readyReplicas += statefulSet.Status.ReadyReplicas
+	}
+	cr.Status.Peers = peers
+	cr.Status.ReadyReplicas = readyReplicas
+	return nil
+}
+
+// applyIndexerClusterMaster creates or updates the resources used for the cluster master of an indexer cluster,
+// when clusterMasterRef is not used. It returns true if all apps have been installed by the app framework.
+func applyIndexerClusterMaster(client ControllerClient, cr *enterprisev1.IndexerCluster) (bool, error) {
+	// create or update generated defaults for multisite indexer clusters
+	if len(cr.Spec.Sites) > 0 {
+		multisiteDefaults, err := enterprise.GetSplunkMultisiteDefaults(cr, enterprise.GetIndexerClusterSiteNames(cr), cr.Spec.SiteReplicationFactor, cr.Spec.SiteSearchFactor)
+		if err != nil {
+			return false, err
+		}
+		multisiteDefaults.SetOwnerReferences(append(multisiteDefaults.GetOwnerReferences(), resources.AsOwner(cr)))
+		if err = ApplyConfigMap(client, multisiteDefaults); err != nil {
+			return false, err
+		}
+	}
+
+	// create or update generated defaults for SmartStore, which are pushed to the peers by the cluster master
+	if err := ApplySmartStoreConfig(client, cr, &cr.Spec.SmartStore, enterprise.SplunkClusterMaster); err != nil {
+		return false, err
+	}
+
+	// create or update a regular service for the cluster master
+	err := ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkClusterMaster, false))
+	if err != nil {
+		return false, err
+	}
+
+	// create or update resources used by the app framework to install apps on the cluster master
+	if err = ApplyAppFramework(client, cr, &cr.Spec.CommonSplunkSpec, enterprise.SplunkClusterMaster, cr.Spec.AppSources); err != nil {
+		return false, err
+	}
+
+	// create or update statefulset for the cluster master
+	statefulSet, err := enterprise.GetIndexerClusterMasterStatefulSet(cr)
+	if err != nil {
+		return false, err
+	}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the cluster master
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return false, err
+	}
+	clusterMasterManager := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
+	phase, err := clusterMasterManager.Update(client, statefulSet, 1)
+	if err != nil {
+		return false, err
+	}
+	cr.Status.ClusterMasterPhase = phase
+	setPhaseCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionClusterMasterReady, phase)
+
+	// update status of apps installed by the app framework on the cluster master
+	return updateAppFrameworkStatus(client, cr, cr.GetGeneration(), cr.Spec.AppSources, statefulSet, &cr.Status.Apps, &cr.Status.Conditions, splclient.NewAppFrameworkClient), nil
+}
+
+// getClusterMasterRefState returns the current phase of a ClusterMaster resource that is referenced by another resource,
+// and the TLS configuration used to verify the certificate of its splunkd management port
+func getClusterMasterRefState(client ControllerClient, cr enterprisev1.MetaObject, ref corev1.ObjectReference) (enterprisev1.ResourcePhase, *tls.Config, error) {
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cr.GetNamespace()
+	}
+	var clusterMaster enterprisev1.ClusterMaster
+	err := client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.Name}, &clusterMaster)
+	if err != nil {
+		return enterprisev1.PhasePending, nil, fmt.Errorf("Unable to get ClusterMaster %s/%s: %v", namespace, ref.Name, err)
+	}
+	var secrets corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: namespace, Name: enterprise.GetSplunkSecretsName(ref.Name, enterprise.SplunkClusterMaster)}
+	if err = client.Get(context.TODO(), namespacedName, &secrets); err != nil {
+		return clusterMaster.Status.Phase, nil, fmt.Errorf("Unable to get secret %s: %v", namespacedName.Name, err)
+	}
+	tlsConfig, err := getSplunkTLSConfig(client, &clusterMaster, &clusterMaster.Spec.CommonSplunkSpec, &secrets)
+	return clusterMaster.Status.Phase, tlsConfig, err
+}
+
+// getIndexerReplicationFactor returns the replication factor of an indexer cluster, which is configured by the defaults of
+// its cluster master: either a referenced ClusterMaster resource, or the cluster master created for the IndexerCluster
+func getIndexerReplicationFactor(client ControllerClient, cr *enterprisev1.IndexerCluster) (int, error) {
+	ref := cr.Spec.ClusterMasterRef
+	if ref.Name == "" {
+		return enterprise.GetIndexerReplicationFactor(&cr.Spec.CommonSplunkSpec), nil
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = cr.GetNamespace()
+	}
+	var clusterMaster enterprisev1.ClusterMaster
+	if err := client.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: ref.Name}, &clusterMaster); err != nil {
+		return 0, fmt.Errorf("Unable to get ClusterMaster %s/%s: %v", namespace, ref.Name, err)
+	}
+	return enterprise.GetIndexerReplicationFactor(&clusterMaster.Spec.CommonSplunkSpec), nil
+}
+
+// applyIndexerStatefulSets creates or updates the statefulsets for indexer cluster peers, and updates the status of
+// the indexer cluster to include all of its peers. Pods are only scaled or updated within one statefulset (site) at a time.
+// Each statefulset is managed using a copy of the template pod manager.
+func applyIndexerStatefulSets(client ControllerClient, template IndexerClusterPodManager, statefulSets []*appsv1.StatefulSet) (enterprisev1.ResourcePhase, error) {
+	cr := template.cr
+	phase := enterprisev1.PhaseReady
+	peers := []enterprisev1.IndexerClusterMemberStatus{}
+	var readyReplicas int32
+
+	replicationFactor, err := getIndexerReplicationFactor(client, cr)
+	if err != nil {
+		return enterprisev1.PhaseError, err
+	}
+
+	for idx, statefulSet := range statefulSets {
+		mgr := template
+		desiredReplicas := cr.Spec.Replicas
+		if len(cr.Spec.Sites) > 0 {
+			mgr.site = cr.Spec.Sites[idx].Name
+			desiredReplicas = cr.Spec.Sites[idx].Replicas
+			mgr.log = template.log.WithValues("site", mgr.site)
+		}
+		mgr.deferPodUpdates = phase != enterprisev1.PhaseReady
+
+		// create or update a PodDisruptionBudget that limits voluntary disruptions of the peers
+		err := ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, enterprise.GetIndexerMaxUnavailable(cr, replicationFactor)))
+		if err != nil {
+			return enterprisev1.PhaseError, err
+		}
+		sitePhase, err := mgr.Update(client, statefulSet, desiredReplicas)
+		peers = append(peers, mgr.peers...)
+		readyReplicas += statefulSet.Status.ReadyReplicas
+		if err != nil {
+			return enterprisev1.PhaseError, err
+		}
+		if phase == enterprisev1.PhaseReady {
+			phase = sitePhase
+		}
+	}
+
+	cr.Status.Peers = peers
+	cr.Status.ReadyReplicas = readyReplicas
+
+	// end any maintenance mode started by the operator, once the peers in all sites have been updated
+	if phase == enterprisev1.PhaseReady && cr.Status.OperatorMaintenanceMode {
+		return template.finishMaintenanceMode()
+	}
+
+	// apply changes to restart-only configuration with a searchable rolling restart of the peers in all sites
+	if phase == enterprisev1.PhaseReady {
+		return updateRotatedStatefulSetConfig(client, cr, statefulSets, &template, getRestartConfigChecksum(template.secrets))
+	}
+	return phase, nil
+}
+
 // IndexerClusterPodManager is used to manage the pods within a search head cluster
 type IndexerClusterPodManager struct {
 	log             logr.Logger
 	cr              *enterprisev1.IndexerCluster
 	secrets         *corev1.Secret
-	newSplunkClient func(managementURI, username, password string) *splclient.SplunkClient
+	tlsConfig       *tls.Config
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient
+
+	// password used to access the cluster master (defaults to the password in secrets)
+	clusterMasterPassword []byte
+
+	// TLS configuration used to verify the certificate of the cluster master (defaults to tlsConfig)
+	clusterMasterTLSConfig *tls.Config
+
+	// site managed by this pod manager, for multisite indexer clusters
+	site string
+
+	// when true, scaling and updates to pods are deferred until a later reconcile
+	deferPodUpdates bool
+
+	// status of the indexer cluster peers managed by this pod manager
+	peers []enterprisev1.IndexerClusterMemberStatus
 }
 
 // Update for IndexerClusterPodManager handles all updates for a statefulset of indexers
 func (mgr *IndexerClusterPodManager) Update(c ControllerClient, statefulSet *appsv1.StatefulSet, desiredReplicas int32) (enterprisev1.ResourcePhase, error) {
 	// update statefulset, if necessary
-	_, err := ApplyStatefulSet(c, statefulSet)
+	_, err := ApplyStatefulSet(c, statefulSet, &mgr.cr.Status.Volumes)
 	if err != nil {
 		return enterprisev1.PhaseError, err
 	}
 
 	// update CR status with SHC information
 	err = mgr.updateStatus(statefulSet)
-	if err != nil || mgr.cr.Status.ReadyReplicas == 0 || !mgr.cr.Status.Initialized || !mgr.cr.Status.IndexingReady || !mgr.cr.Status.ServiceReady {
+	clusterReady := err == nil && statefulSet.Status.ReadyReplicas > 0 && mgr.cr.Status.Initialized && mgr.cr.Status.IndexingReady && mgr.cr.Status.ServiceReady
+	setScalingBlockedCondition(&mgr.cr.Status.Conditions, mgr.cr.GetGeneration(), statefulSet, desiredReplicas, clusterReady, "Waiting for indexer cluster to become ready")
+	if !clusterReady {
 		mgr.log.Error(err, "Indexer cluster is not ready")
 		return enterprisev1.PhasePending, nil
 	}
 
+	// wait for other sites to finish updating, and for the master to finish restarting the peers
+	if mgr.deferPodUpdates || mgr.cr.Status.RollingRestart {
+		return enterprisev1.PhaseUpdating, nil
+	}
+
 	// manage scaling and updates
-	return UpdateStatefulSetPods(c, statefulSet, mgr, desiredReplicas)
+	return UpdateStatefulSetPods(c, statefulSet, mgr, desiredReplicas, mgr.cr.Spec.VolumeReclaimPolicy, getRetainedVolumeGracePeriod(&mgr.cr.Spec.CommonSplunkSpec))
 }
 
 // PrepareScaleDown for IndexerClusterPodManager prepares indexer pod to be removed via scale down event; it returns true when ready
 func (mgr *IndexerClusterPodManager) PrepareScaleDown(n int32) (bool, error) {
+	// decommissioning a peer requires bucket fixups, which are halted by maintenance mode
+	if mgr.cr.Status.OperatorMaintenanceMode && mgr.cr.Status.MaintenanceMode {
+		mgr.log.Info("Disabling maintenance mode to scale down indexer cluster")
+		if err := mgr.getClusterMasterClient().SetClusterMaintenanceMode(false); err != nil {
+			return false, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMaintenanceModeDisabled, "Disabled maintenance mode to scale down indexer cluster")
+		mgr.cr.Status.MaintenanceMode = false
+	}
+
 	// first, decommission indexer peer with enforceCounts=true; this will rebalance buckets across other peers
 	complete, err := mgr.decommission(n, true)
 	if err != nil {
@@ -168,28 +449,87 @@ func (mgr *IndexerClusterPodManager) PrepareScaleDown(n int32) (bool, error) {
 
 	// next, remove the peer
 	c := mgr.getClusterMasterClient()
-	return true, c.RemoveIndexerClusterPeer(mgr.cr.Status.Peers[n].ID)
+	if err := c.RemoveIndexerClusterPeer(mgr.peers[n].ID); err != nil {
+		return true, err
+	}
+	recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonPeerRemoved, "Removed peer %s from indexer cluster", mgr.getPeerName(n))
+	return true, nil
 }
 
 // PrepareRecycle for IndexerClusterPodManager prepares indexer pod to be recycled for updates; it returns true when ready
 func (mgr *IndexerClusterPodManager) PrepareRecycle(n int32) (bool, error) {
+	// put the cluster into maintenance mode before recycling the first peer, so that restarting peers do not
+	// trigger bucket fixups; this is left alone if maintenance mode was already enabled manually
+	if !mgr.cr.Status.MaintenanceMode {
+		mgr.log.Info("Enabling maintenance mode for rolling upgrade of indexer cluster")
+		if err := mgr.getClusterMasterClient().SetClusterMaintenanceMode(true); err != nil {
+			return false, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMaintenanceModeEnabled, "Enabled maintenance mode for rolling upgrade of indexer cluster")
+		mgr.cr.Status.MaintenanceMode = true
+		mgr.cr.Status.OperatorMaintenanceMode = true
+	}
 	return mgr.decommission(n, false)
 }
 
 // FinishRecycle for IndexerClusterPodManager completes recycle event for indexer pod; it returns true when complete
 func (mgr *IndexerClusterPodManager) FinishRecycle(n int32) (bool, error) {
-	return mgr.cr.Status.Peers[n].Status == "Up", nil
+	return mgr.peers[n].Status == "Up", nil
+}
+
+// RollingRestart for IndexerClusterPodManager starts a searchable rolling restart of all indexer cluster peers
+func (mgr *IndexerClusterPodManager) RollingRestart() error {
+	mgr.log.Info("Starting searchable rolling restart of indexer cluster peers")
+	if err := mgr.getClusterMasterClient().RollingRestartIndexerCluster(true); err != nil {
+		return err
+	}
+	mgr.cr.Status.RollingRestart = true
+	return nil
+}
+
+// IsRollingRestart for IndexerClusterPodManager returns true while the master is restarting the indexer cluster peers
+func (mgr *IndexerClusterPodManager) IsRollingRestart() bool {
+	return mgr.cr.Status.RollingRestart
+}
+
+// finishMaintenanceMode for IndexerClusterPodManager disables the maintenance mode enabled by the operator for a
+// rolling upgrade, and returns PhaseReady once the replication and search factors are met again
+func (mgr *IndexerClusterPodManager) finishMaintenanceMode() (enterprisev1.ResourcePhase, error) {
+	c := mgr.getClusterMasterClient()
+	if mgr.cr.Status.MaintenanceMode {
+		mgr.log.Info("Disabling maintenance mode after rolling upgrade of indexer cluster")
+		if err := c.SetClusterMaintenanceMode(false); err != nil {
+			return enterprisev1.PhaseError, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMaintenanceModeDisabled, "Disabled maintenance mode after rolling upgrade of indexer cluster")
+		mgr.cr.Status.MaintenanceMode = false
+	}
+
+	generationInfo, err := c.GetClusterMasterGeneration()
+	if err != nil {
+		return enterprisev1.PhaseError, err
+	}
+	if !generationInfo.ReplicationFactorMet || !generationInfo.SearchFactorMet {
+		mgr.log.Info("Waiting for replication and search factors to be met", "replicationFactorMet", generationInfo.ReplicationFactorMet, "searchFactorMet", generationInfo.SearchFactorMet)
+		return enterprisev1.PhaseUpdating, nil
+	}
+	mgr.cr.Status.OperatorMaintenanceMode = false
+	return enterprisev1.PhaseReady, nil
 }
 
 // decommission for IndexerClusterPodManager decommissions an indexer pod; it returns true when ready
 func (mgr *IndexerClusterPodManager) decommission(n int32, enforceCounts bool) (bool, error) {
-	peerName := enterprise.GetSplunkStatefulsetPodName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), n)
+	peerName := mgr.getPeerName(n)
 
-	switch mgr.cr.Status.Peers[n].Status {
+	switch mgr.peers[n].Status {
 	case "Up":
 		mgr.log.Info("Decommissioning indexer cluster peer", "peerName", peerName, "enforceCounts", enforceCounts)
 		c := mgr.getClient(n)
-		return false, c.DecommissionIndexerClusterPeer(enforceCounts)
+		if err := c.DecommissionIndexerClusterPeer(enforceCounts); err != nil {
+			return false, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonPeerDecommissioning, "Decommissioning peer %s (enforceCounts=%t)", peerName, enforceCounts)
+		return false, nil
 
 	case "Decommissioning":
 		mgr.log.Info("Waiting for decommission to complete", "peerName", peerName)
@@ -200,11 +540,11 @@ func (mgr *IndexerClusterPodManager) decommission(n int32, enforceCounts bool) (
 		return false, nil
 
 	case "GracefulShutdown":
-		mgr.log.Info("Decommission complete", "peerName", peerName, "Status", mgr.cr.Status.Peers[n].Status)
+		mgr.log.Info("Decommission complete", "peerName", peerName, "Status", mgr.peers[n].Status)
 		return true, nil
 
 	case "Down":
-		mgr.log.Info("Decommission complete", "peerName", peerName, "Status", mgr.cr.Status.Peers[n].Status)
+		mgr.log.Info("Decommission complete", "peerName", peerName, "Status", mgr.peers[n].Status)
 		return true, nil
 
 	case "": // this can happen after the peer has been removed from the indexer cluster
@@ -213,32 +553,56 @@ func (mgr *IndexerClusterPodManager) decommission(n int32, enforceCounts bool) (
 	}
 
 	// unhandled status
-	return false, fmt.Errorf("Status=%s", mgr.cr.Status.Peers[n].Status)
+	return false, fmt.Errorf("Status=%s", mgr.peers[n].Status)
+}
+
+// getPeerName for IndexerClusterPodManager returns the name of peer n
+func (mgr *IndexerClusterPodManager) getPeerName(n int32) string {
+	if mgr.site != "" {
+		return enterprise.GetSplunkSiteStatefulsetPodName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), mgr.site, n)
+	}
+	return enterprise.GetSplunkStatefulsetPodName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), n)
 }
 
 // getClient for IndexerClusterPodManager returns a SplunkClient for the member n
 func (mgr *IndexerClusterPodManager) getClient(n int32) *splclient.SplunkClient {
-	memberName := enterprise.GetSplunkStatefulsetPodName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), n)
+	memberName := mgr.getPeerName(n)
 	fqdnName := resources.GetServiceFQDN(mgr.cr.GetNamespace(),
 		fmt.Sprintf("%s.%s", memberName, enterprise.GetSplunkServiceName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), true)))
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]))
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]), mgr.tlsConfig)
 }
 
 // getClusterMasterClient for IndexerClusterPodManager returns a SplunkClient for cluster master
 func (mgr *IndexerClusterPodManager) getClusterMasterClient() *splclient.SplunkClient {
-	fqdnName := resources.GetServiceFQDN(mgr.cr.GetNamespace(), enterprise.GetSplunkServiceName(enterprise.SplunkClusterMaster, mgr.cr.GetIdentifier(), false))
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]))
+	namespace, name := mgr.cr.GetNamespace(), mgr.cr.GetIdentifier()
+	if ref := mgr.cr.Spec.ClusterMasterRef; ref.Name != "" {
+		name = ref.Name
+		if ref.Namespace != "" {
+			namespace = ref.Namespace
+		}
+	}
+	password := mgr.clusterMasterPassword
+	if len(password) == 0 {
+		password = mgr.secrets.Data["password"]
+	}
+	tlsConfig := mgr.clusterMasterTLSConfig
+	if tlsConfig == nil {
+		tlsConfig = mgr.tlsConfig
+	}
+	fqdnName := resources.GetServiceFQDN(namespace, enterprise.GetSplunkServiceName(enterprise.SplunkClusterMaster, name, false))
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(password), tlsConfig)
 }
 
 // updateStatus for IndexerClusterPodManager uses the REST API to update the status for a SearcHead custom resource
 func (mgr *IndexerClusterPodManager) updateStatus(statefulSet *appsv1.StatefulSet) error {
-	mgr.cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	mgr.peers = []enterprisev1.IndexerClusterMemberStatus{}
 
 	if mgr.cr.Status.ClusterMasterPhase != enterprisev1.PhaseReady {
 		mgr.cr.Status.Initialized = false
 		mgr.cr.Status.IndexingReady = false
 		mgr.cr.Status.ServiceReady = false
 		mgr.cr.Status.MaintenanceMode = false
+		mgr.cr.Status.RollingRestart = false
 		return fmt.Errorf("Waiting for cluster master to become ready")
 	}
 
@@ -252,6 +616,7 @@ func (mgr *IndexerClusterPodManager) updateStatus(statefulSet *appsv1.StatefulSe
 	mgr.cr.Status.IndexingReady = clusterInfo.IndexingReady
 	mgr.cr.Status.ServiceReady = clusterInfo.ServiceReady
 	mgr.cr.Status.MaintenanceMode = clusterInfo.MaintenanceMode
+	mgr.cr.Status.RollingRestart = clusterInfo.RollingRestart
 
 	// get peer information from cluster master
 	peers, err := c.GetClusterMasterPeers()
@@ -259,8 +624,8 @@ func (mgr *IndexerClusterPodManager) updateStatus(statefulSet *appsv1.StatefulSe
 		return err
 	}
 	for n := int32(0); n < statefulSet.Status.Replicas; n++ {
-		peerName := enterprise.GetSplunkStatefulsetPodName(enterprise.SplunkIndexer, mgr.cr.GetIdentifier(), n)
-		peerStatus := enterprisev1.IndexerClusterMemberStatus{Name: peerName}
+		peerName := mgr.getPeerName(n)
+		peerStatus := enterprisev1.IndexerClusterMemberStatus{Name: peerName, Site: mgr.site}
 		peerInfo, ok := peers[peerName]
 		if ok {
 			peerStatus.ID = peerInfo.ID
@@ -271,17 +636,28 @@ func (mgr *IndexerClusterPodManager) updateStatus(statefulSet *appsv1.StatefulSe
 		} else {
 			mgr.log.Info("Peer is not known by cluster master", "peerName", peerName)
 		}
-		if n < int32(len(mgr.cr.Status.Peers)) {
-			mgr.cr.Status.Peers[n] = peerStatus
-		} else {
-			mgr.cr.Status.Peers = append(mgr.cr.Status.Peers, peerStatus)
-		}
-	}
-
-	// truncate any extra peers that we didn't check (leftover from scale down)
-	if statefulSet.Status.Replicas < int32(len(mgr.cr.Status.Peers)) {
-		mgr.cr.Status.Peers = mgr.cr.Status.Peers[:statefulSet.Status.Replicas]
+		mgr.peers = append(mgr.peers, peerStatus)
 	}
 
 	return nil
 }
+
+// updateBundleStatus for IndexerClusterPodManager updates the status of the configuration bundles used by the
+// indexer cluster, and pushes changes to the cluster bundle if the cluster master is not a separate ClusterMaster
+// resource. It returns true while a bundle is waiting to be pushed or activated on all peers.
+func (mgr *IndexerClusterPodManager) updateBundleStatus(c ControllerClient) (bool, error) {
+	splunkClient := mgr.getClusterMasterClient()
+	clusterInfo, err := splunkClient.GetClusterMasterInfo()
+	if err != nil {
+		return false, err
+	}
+	bundleState, err := applyClusterBundle(c, mgr.cr, splunkClient, clusterInfo, mgr.cr.Spec.ClusterMasterRef.Name == "", &mgr.cr.Status.Conditions)
+	if err != nil {
+		return false, err
+	}
+	mgr.cr.Status.ActiveBundle = bundleState.activeBundle
+	mgr.cr.Status.LatestBundle = bundleState.latestBundle
+	mgr.cr.Status.RestartRequired = bundleState.restartRequired
+	mgr.cr.Status.OutdatedPeers = bundleState.outdatedPeers
+	return bundleState.pending, nil
+}
@@ -15,6 +15,8 @@
 package reconcile
 
 import (
+	"crypto/tls"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -26,6 +28,7 @@ import (
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
 	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
 )
 
@@ -35,11 +38,16 @@ func TestApplyIndexerCluster(t *testing.T) {
 		{metaName: "*v1.Service-test-splunk-stack1-indexer-headless"},
 		{metaName: "*v1.Service-test-splunk-stack1-indexer-service"},
 		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-service"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-cluster-master-app-framework"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1.Service-test-splunk-stack1-cluster-master-app-repository"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-cluster-master"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-cluster-master"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-indexer"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-indexer"},
 	}
-	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[4], funcCalls[5]}}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[0], funcCalls[1], funcCalls[2], funcCalls[3], funcCalls[4], funcCalls[7], funcCalls[8], funcCalls[9], funcCalls[10]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[8], funcCalls[10]}}
 
 	current := enterprisev1.IndexerCluster{
 		TypeMeta: metav1.TypeMeta{
@@ -69,6 +77,104 @@ func TestApplyIndexerCluster(t *testing.T) {
 	splunkDeletionTester(t, revised, deleteFunc)
 }
 
+func TestApplyIndexerClusterWithClusterMasterRef(t *testing.T) {
+	funcCalls := []mockFuncCall{
+		{metaName: "*v1alpha2.ClusterMaster-test-master1"},
+		{metaName: "*v1.Secret-test-splunk-master1-indexer-secrets"},
+		{metaName: "*v1.Secret-test-splunk-stack1-indexer-secrets"},
+		{metaName: "*v1.Service-test-splunk-stack1-indexer-headless"},
+		{metaName: "*v1.Service-test-splunk-stack1-indexer-service"},
+		{metaName: "*v1.Secret-test-splunk-master1-indexer-secrets"},
+		{metaName: "*v1alpha2.ClusterMaster-test-master1"},
+		{metaName: "*v1.Secret-test-splunk-master1-indexer-secrets"},
+		{metaName: "*v1alpha2.ClusterMaster-test-master1"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-indexer"},
+		{metaName: "*v1.StatefulSet-test-splunk-stack1-indexer"},
+	}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[2], funcCalls[3], funcCalls[4], funcCalls[9], funcCalls[10]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[10]}}
+
+	clusterMaster := enterprisev1.ClusterMaster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "master1",
+			Namespace: "test",
+		},
+		Status: enterprisev1.ClusterMasterStatus{
+			Phase: enterprisev1.PhaseReady,
+		},
+	}
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-master1-indexer-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"idxc_secret": []byte{'a', 'b'},
+			"password":    []byte{'1', '2', '3'},
+		},
+	}
+	if err := enterprise.AddSplunkTLSSecrets(&clusterMaster, enterprise.SplunkClusterMaster, &secret); err != nil {
+		t.Fatalf("AddSplunkTLSSecrets() returned %v", err)
+	}
+	current := enterprisev1.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	current.Spec.ClusterMasterRef.Name = "master1"
+	revised := current.DeepCopy()
+	revised.Spec.Image = "splunk/test"
+	reconcile := func(c *mockClient, cr interface{}) error {
+		_, err := ApplyIndexerCluster(c, cr.(*enterprisev1.IndexerCluster))
+		return err
+	}
+	reconcileTester(t, "TestApplyIndexerClusterWithClusterMasterRef", &current, revised, createCalls, updateCalls, reconcile, &clusterMaster, &secret)
+	if revised.Status.ClusterMasterPhase != enterprisev1.PhaseReady {
+		t.Errorf("ApplyIndexerCluster() ClusterMasterPhase = %s; want %s", revised.Status.ClusterMasterPhase, enterprisev1.PhaseReady)
+	}
+
+	// error if the cluster master does not exist
+	c := newMockClient()
+	c.state[getStateKey(&secret)] = &secret
+	if _, err := ApplyIndexerCluster(c, current.DeepCopy()); err == nil {
+		t.Errorf("ApplyIndexerCluster() did not return error for missing ClusterMaster")
+	}
+}
+
+func TestGetClusterMasterClientWithClusterMasterRef(t *testing.T) {
+	var gotURI, gotPassword string
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	mgr := &IndexerClusterPodManager{
+		cr:      &cr,
+		secrets: &corev1.Secret{Data: map[string][]byte{"password": []byte("peer")}},
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			gotURI, gotPassword = managementURI, password
+			return splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		},
+	}
+	test := func(wantURI, wantPassword string) {
+		mgr.getClusterMasterClient()
+		if gotURI != wantURI || gotPassword != wantPassword {
+			t.Errorf("getClusterMasterClient() = %s, %s; want %s, %s", gotURI, gotPassword, wantURI, wantPassword)
+		}
+	}
+	test("https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089", "peer")
+
+	cr.Spec.ClusterMasterRef.Name = "master1"
+	cr.Spec.ClusterMasterRef.Namespace = "other"
+	mgr.clusterMasterPassword = []byte("master")
+	test("https://splunk-master1-cluster-master-service.other.svc.cluster.local:8089", "master")
+}
+
 func indexerClusterPodManagerTester(t *testing.T, method string, mockHandlers []spltest.MockHTTPHandler,
 	desiredReplicas int32, wantPhase enterprisev1.ResourcePhase, statefulSet *appsv1.StatefulSet,
 	wantCalls map[string][]mockFuncCall, wantError error, initObjects ...runtime.Object) {
@@ -102,8 +208,8 @@ func indexerClusterPodManagerTester(t *testing.T, method string, mockHandlers []
 		log:     scopedLog,
 		cr:      &cr,
 		secrets: secrets,
-		newSplunkClient: func(managementURI, username, password string) *splclient.SplunkClient {
-			c := splclient.NewSplunkClient(managementURI, username, password)
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
 			c.Client = mockSplunkClient
 			return c
 		},
@@ -120,7 +226,8 @@ func TestIndexerClusterPodManager(t *testing.T) {
 			Namespace: "test",
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: &replicas,
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "pvc-etc"}}, {ObjectMeta: metav1.ObjectMeta{Name: "pvc-var"}}},
 		},
 		Status: appsv1.StatefulSetStatus{
 			Replicas:        replicas,
@@ -171,8 +278,14 @@ func TestIndexerClusterPodManager(t *testing.T) {
 	method := "IndexerClusterPodManager.Update(All pods ready)"
 	indexerClusterPodManagerTester(t, method, mockHandlers, 1, enterprisev1.PhaseReady, statefulSet, wantCalls, nil, statefulSet, pod)
 
-	// test pod needs update => decommission
+	// test pod needs update => enable maintenance mode and decommission
 	mockHandlers = append(mockHandlers, spltest.MockHTTPHandler{
+		Method: "POST",
+		URL:    "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/default/maintenance?mode=true",
+		Status: 200,
+		Err:    nil,
+		Body:   ``,
+	}, spltest.MockHTTPHandler{
 		Method: "POST",
 		URL:    "https://splunk-stack1-indexer-0.splunk-stack1-indexer-headless.test.svc.cluster.local:8089/services/cluster/slave/control/control/decommission?enforce_counts=0",
 		Status: 200,
@@ -183,8 +296,9 @@ func TestIndexerClusterPodManager(t *testing.T) {
 	method = "IndexerClusterPodManager.Update(Decommission Pod)"
 	indexerClusterPodManagerTester(t, method, mockHandlers, 1, enterprisev1.PhaseUpdating, statefulSet, wantCalls, nil, statefulSet, pod)
 
-	// test pod needs update => wait for decommission to complete
+	// test pod needs update => wait for decommission to complete (maintenance mode is already enabled)
 	mockHandlers = []spltest.MockHTTPHandler{mockHandlers[0], mockHandlers[1]}
+	mockHandlers[0].Body = strings.Replace(mockHandlers[0].Body, `"maintenance_mode":false`, `"maintenance_mode":true`, 1)
 	mockHandlers[1].Body = strings.Replace(mockHandlers[1].Body, `"status":"Up"`, `"status":"ReassigningPrimaries"`, 1)
 	method = "IndexerClusterPodManager.Update(ReassigningPrimaries)"
 	indexerClusterPodManagerTester(t, method, mockHandlers, 1, enterprisev1.PhaseUpdating, statefulSet, wantCalls, nil, statefulSet, pod)
@@ -233,3 +347,86 @@ func TestIndexerClusterPodManager(t *testing.T) {
 	method = "IndexerClusterPodManager.Update(Decommission)"
 	indexerClusterPodManagerTester(t, method, mockHandlers, 1, enterprisev1.PhaseScalingDown, statefulSet, wantCalls, nil, statefulSet, pod, pvcList[0], pvcList[1])
 }
+
+func TestIndexerClusterFinishMaintenanceMode(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Status: enterprisev1.IndexerClusterStatus{
+			MaintenanceMode:         true,
+			OperatorMaintenanceMode: true,
+		},
+	}
+	secrets := &corev1.Secret{
+		Data: map[string][]byte{
+			"password": []byte{'1', '2', '3'},
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mgr := &IndexerClusterPodManager{
+		log:     log.WithName("TestIndexerClusterFinishMaintenanceMode"),
+		cr:      &cr,
+		secrets: secrets,
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+			c.Client = mockSplunkClient
+			return c
+		},
+	}
+	maintenanceRequest, _ := http.NewRequest("POST", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/default/maintenance?mode=false", nil)
+	generationRequest, _ := http.NewRequest("GET", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/generation?count=0&output_mode=json", nil)
+	test := func(wantPhase enterprisev1.ResourcePhase, wantOperatorMaintenanceMode bool) {
+		phase, err := mgr.finishMaintenanceMode()
+		if err != nil {
+			t.Errorf("finishMaintenanceMode() returned error: %v", err)
+		}
+		mockSplunkClient.CheckRequests(t, "TestIndexerClusterFinishMaintenanceMode")
+		if phase != wantPhase || cr.Status.MaintenanceMode || cr.Status.OperatorMaintenanceMode != wantOperatorMaintenanceMode {
+			t.Errorf("finishMaintenanceMode() = %s, %t, %t; want %s, false, %t", phase, cr.Status.MaintenanceMode, cr.Status.OperatorMaintenanceMode, wantPhase, wantOperatorMaintenanceMode)
+		}
+	}
+
+	// maintenance mode is disabled, then waits for the replication and search factors to be met
+	mockSplunkClient.AddHandler(maintenanceRequest, 200, "", nil)
+	mockSplunkClient.AddHandler(generationRequest, 200, `{"entry":[{"content":{"replication_factor_met":"0","search_factor_met":"1"}}]}`, nil)
+	test(enterprisev1.PhaseUpdating, true)
+
+	mockSplunkClient.AddHandler(generationRequest, 200, `{"entry":[{"content":{"replication_factor_met":"1","search_factor_met":"1"}}]}`, nil)
+	test(enterprisev1.PhaseReady, false)
+}
+
+func TestIndexerClusterRollingRestart(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	secrets := &corev1.Secret{
+		Data: map[string][]byte{
+			"password": []byte{'1', '2', '3'},
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mgr := &IndexerClusterPodManager{
+		log:     log.WithName("TestIndexerClusterRollingRestart"),
+		cr:      &cr,
+		secrets: secrets,
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+			c.Client = mockSplunkClient
+			return c
+		},
+	}
+	restartRequest, _ := http.NewRequest("POST", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/control/restart?searchable=true", nil)
+	mockSplunkClient.AddHandler(restartRequest, 200, "", nil)
+	if err := mgr.RollingRestart(); err != nil {
+		t.Errorf("RollingRestart() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestIndexerClusterRollingRestart")
+	if !mgr.IsRollingRestart() {
+		t.Errorf("IsRollingRestart() = false after RollingRestart(); want true")
+	}
+}
@@ -39,9 +39,16 @@ func ApplyLicenseMaster(client ControllerClient, cr *enterprisev1.LicenseMaster)
 		return result, err
 	}
 
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, nil)
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
 	// updates status after function completes
 	cr.Status.Phase = enterprisev1.PhaseError
 	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
 		client.Status().Update(context.TODO(), cr)
 	}()
 
@@ -56,11 +63,21 @@ func ApplyLicenseMaster(client ControllerClient, cr *enterprisev1.LicenseMaster)
 		return result, err
 	}
 
-	// create or update general config resources
-	_, err = ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkLicenseMaster)
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
 	if err != nil {
 		return result, err
 	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		return result, nil
+	}
+
+	// create or update general config resources
+	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkLicenseMaster)
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
+		return result, err
+	}
 
 	// create or update a service
 	err = ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkLicenseMaster, false))
@@ -73,15 +90,35 @@ func ApplyLicenseMaster(client ControllerClient, cr *enterprisev1.LicenseMaster)
 	if err != nil {
 		return result, err
 	}
-	mgr := DefaultStatefulSetPodManager{}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the license master
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return result, err
+	}
+	mgr := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
 	phase, err := mgr.Update(client, statefulSet, 1)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
 
-	// no need to requeue if everything is ready
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
 	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// no need to requeue if everything is ready
+	if cr.Status.Phase == enterprisev1.PhaseReady && !rotationPending {
 		result.Requeue = false
 	}
 	return result, nil
@@ -27,10 +27,11 @@ func TestApplyLicenseMaster(t *testing.T) {
 	funcCalls := []mockFuncCall{
 		{metaName: "*v1.Secret-test-splunk-stack1-license-master-secrets"},
 		{metaName: "*v1.Service-test-splunk-stack1-license-master-service"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-license-master"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-license-master"},
 	}
 	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[2]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[3]}}
 	current := enterprisev1.LicenseMaster{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "LicenseMaster",
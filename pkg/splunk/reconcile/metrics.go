@@ -0,0 +1,183 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+var (
+	// clusterLabels are used by metrics that describe an entire cluster
+	clusterLabels = []string{"namespace", "name"}
+
+	// memberLabels are used by metrics that describe a single member (pod) of a cluster
+	memberLabels = []string{"namespace", "name", "pod"}
+
+	indexerPeerUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_indexer_cluster_peer_up",
+		Help: "Whether the indexer cluster peer is reported as Up by the cluster master (1) or not (0).",
+	}, memberLabels)
+
+	indexerPeerSearchable = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_indexer_cluster_peer_searchable",
+		Help: "Whether the indexer cluster peer is searchable (1) or not (0).",
+	}, memberLabels)
+
+	indexerPeerBucketCount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_indexer_cluster_peer_bucket_count",
+		Help: "Number of buckets on the indexer cluster peer, across all indexes.",
+	}, memberLabels)
+
+	indexerClusterMaintenanceMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_indexer_cluster_maintenance_mode",
+		Help: "Whether the indexer cluster is in maintenance mode (1) or not (0).",
+	}, clusterLabels)
+
+	searchHeadMemberUp = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_member_up",
+		Help: "Whether the search head cluster member is reported as Up (1) or not (0).",
+	}, memberLabels)
+
+	searchHeadMemberCaptain = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_member_captain",
+		Help: "Whether the search head cluster member is the captain (1) or not (0).",
+	}, memberLabels)
+
+	searchHeadMemberHistoricalSearches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_member_active_historical_searches",
+		Help: "Number of historical searches currently running on the search head cluster member.",
+	}, memberLabels)
+
+	searchHeadMemberRealtimeSearches = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_member_active_realtime_searches",
+		Help: "Number of realtime searches currently running on the search head cluster member.",
+	}, memberLabels)
+
+	searchHeadClusterCaptainReady = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_captain_ready",
+		Help: "Whether the search head cluster has a captain that is ready to service requests (1) or not (0).",
+	}, clusterLabels)
+
+	searchHeadClusterMaintenanceMode = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "splunk_search_head_cluster_maintenance_mode",
+		Help: "Whether the search head cluster is in maintenance mode (1) or not (0).",
+	}, clusterLabels)
+
+	// indexerPeerMetrics and searchHeadMemberMetrics are the per-member metrics of each type of cluster
+	indexerPeerMetrics      = []*prometheus.GaugeVec{indexerPeerUp, indexerPeerSearchable, indexerPeerBucketCount}
+	searchHeadMemberMetrics = []*prometheus.GaugeVec{searchHeadMemberUp, searchHeadMemberCaptain, searchHeadMemberHistoricalSearches, searchHeadMemberRealtimeSearches}
+
+	// memberPods tracks the pods that per-member metrics have been exported for, keyed by metric
+	// type, namespace and name of the custom resource, so that series for removed pods can be deleted
+	memberPods      = map[string][]string{}
+	memberPodsMutex sync.Mutex
+)
+
+func init() {
+	// metrics are served by the operator's manager, along with the controller-runtime metrics
+	metrics.Registry.MustRegister(
+		indexerPeerUp,
+		indexerPeerSearchable,
+		indexerPeerBucketCount,
+		indexerClusterMaintenanceMode,
+		searchHeadMemberUp,
+		searchHeadMemberCaptain,
+		searchHeadMemberHistoricalSearches,
+		searchHeadMemberRealtimeSearches,
+		searchHeadClusterCaptainReady,
+		searchHeadClusterMaintenanceMode,
+	)
+}
+
+// boolToGauge returns the value of a gauge used to represent a flag
+func boolToGauge(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// setMemberPods records the pods that per-member metrics are exported for, and deletes
+// the series of any pods that were previously exported but are no longer members
+func setMemberPods(kind string, cr enterprisev1.MetaObject, gauges []*prometheus.GaugeVec, pods []string) {
+	key := kind + "/" + cr.GetNamespace() + "/" + cr.GetIdentifier()
+	current := map[string]bool{}
+	for _, pod := range pods {
+		current[pod] = true
+	}
+
+	memberPodsMutex.Lock()
+	defer memberPodsMutex.Unlock()
+	for _, pod := range memberPods[key] {
+		if !current[pod] {
+			for _, gauge := range gauges {
+				gauge.DeleteLabelValues(cr.GetNamespace(), cr.GetIdentifier(), pod)
+			}
+		}
+	}
+	if len(pods) > 0 {
+		memberPods[key] = pods
+	} else {
+		delete(memberPods, key)
+	}
+}
+
+// updateIndexerClusterMetrics exports the status of an indexer cluster and its peers as Prometheus metrics,
+// or removes them if the indexer cluster is being deleted
+func updateIndexerClusterMetrics(cr *enterprisev1.IndexerCluster) {
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		indexerClusterMaintenanceMode.DeleteLabelValues(cr.GetNamespace(), cr.GetIdentifier())
+		setMemberPods("IndexerCluster", cr, indexerPeerMetrics, nil)
+		return
+	}
+
+	indexerClusterMaintenanceMode.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier()).Set(boolToGauge(cr.Status.MaintenanceMode))
+	pods := []string{}
+	for _, peer := range cr.Status.Peers {
+		indexerPeerUp.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), peer.Name).Set(boolToGauge(peer.Status == "Up"))
+		indexerPeerSearchable.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), peer.Name).Set(boolToGauge(peer.Searchable))
+		indexerPeerBucketCount.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), peer.Name).Set(float64(peer.BucketCount))
+		pods = append(pods, peer.Name)
+	}
+	setMemberPods("IndexerCluster", cr, indexerPeerMetrics, pods)
+}
+
+// updateSearchHeadClusterMetrics exports the status of a search head cluster and its members as Prometheus metrics,
+// or removes them if the search head cluster is being deleted
+func updateSearchHeadClusterMetrics(cr *enterprisev1.SearchHeadCluster) {
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		searchHeadClusterCaptainReady.DeleteLabelValues(cr.GetNamespace(), cr.GetIdentifier())
+		searchHeadClusterMaintenanceMode.DeleteLabelValues(cr.GetNamespace(), cr.GetIdentifier())
+		setMemberPods("SearchHeadCluster", cr, searchHeadMemberMetrics, nil)
+		return
+	}
+
+	searchHeadClusterCaptainReady.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier()).Set(boolToGauge(cr.Status.Captain != "" && cr.Status.CaptainReady))
+	searchHeadClusterMaintenanceMode.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier()).Set(boolToGauge(cr.Status.MaintenanceMode))
+	pods := []string{}
+	for _, member := range cr.Status.Members {
+		searchHeadMemberUp.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), member.Name).Set(boolToGauge(member.Status == "Up"))
+		searchHeadMemberCaptain.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), member.Name).Set(boolToGauge(member.Name == cr.Status.Captain))
+		searchHeadMemberHistoricalSearches.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), member.Name).Set(float64(member.ActiveHistoricalSearchCount))
+		searchHeadMemberRealtimeSearches.WithLabelValues(cr.GetNamespace(), cr.GetIdentifier(), member.Name).Set(float64(member.ActiveRealtimeSearchCount))
+		pods = append(pods, member.Name)
+	}
+	setMemberPods("SearchHeadCluster", cr, searchHeadMemberMetrics, pods)
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func checkGauge(t *testing.T, method string, gauge *prometheus.GaugeVec, want float64, labels ...string) {
+	got := testutil.ToFloat64(gauge.WithLabelValues(labels...))
+	if got != want {
+		t.Errorf("%s %v = %v; want %v", method, labels, got, want)
+	}
+}
+
+func checkGaugeDeleted(t *testing.T, method string, gauge *prometheus.GaugeVec, labels ...string) {
+	if gauge.DeleteLabelValues(labels...) {
+		t.Errorf("%s %v was not deleted", method, labels)
+	}
+}
+
+func TestUpdateIndexerClusterMetrics(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "metrics",
+			Namespace: "test",
+		},
+		Status: enterprisev1.IndexerClusterStatus{
+			MaintenanceMode: true,
+			Peers: []enterprisev1.IndexerClusterMemberStatus{
+				{Name: "splunk-metrics-indexer-0", Status: "Up", BucketCount: 42, Searchable: true},
+				{Name: "splunk-metrics-indexer-1", Status: "Down", BucketCount: 7, Searchable: false},
+			},
+		},
+	}
+	method := "updateIndexerClusterMetrics()"
+	updateIndexerClusterMetrics(&cr)
+	checkGauge(t, method, indexerClusterMaintenanceMode, 1, "test", "metrics")
+	checkGauge(t, method, indexerPeerUp, 1, "test", "metrics", "splunk-metrics-indexer-0")
+	checkGauge(t, method, indexerPeerSearchable, 1, "test", "metrics", "splunk-metrics-indexer-0")
+	checkGauge(t, method, indexerPeerBucketCount, 42, "test", "metrics", "splunk-metrics-indexer-0")
+	checkGauge(t, method, indexerPeerUp, 0, "test", "metrics", "splunk-metrics-indexer-1")
+	checkGauge(t, method, indexerPeerSearchable, 0, "test", "metrics", "splunk-metrics-indexer-1")
+	checkGauge(t, method, indexerPeerBucketCount, 7, "test", "metrics", "splunk-metrics-indexer-1")
+
+	// series of removed peers are deleted
+	cr.Status.Peers = cr.Status.Peers[:1]
+	updateIndexerClusterMetrics(&cr)
+	checkGauge(t, method, indexerPeerBucketCount, 42, "test", "metrics", "splunk-metrics-indexer-0")
+	checkGaugeDeleted(t, method, indexerPeerBucketCount, "test", "metrics", "splunk-metrics-indexer-1")
+
+	// all series are deleted with the indexer cluster
+	cr.ObjectMeta.DeletionTimestamp = &metav1.Time{}
+	updateIndexerClusterMetrics(&cr)
+	checkGaugeDeleted(t, method, indexerClusterMaintenanceMode, "test", "metrics")
+	checkGaugeDeleted(t, method, indexerPeerUp, "test", "metrics", "splunk-metrics-indexer-0")
+}
+
+func TestUpdateSearchHeadClusterMetrics(t *testing.T) {
+	cr := enterprisev1.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "metrics",
+			Namespace: "test",
+		},
+		Status: enterprisev1.SearchHeadClusterStatus{
+			Captain:      "splunk-metrics-search-head-1",
+			CaptainReady: true,
+			Members: []enterprisev1.SearchHeadClusterMemberStatus{
+				{Name: "splunk-metrics-search-head-0", Status: "Up", ActiveHistoricalSearchCount: 3, ActiveRealtimeSearchCount: 1},
+				{Name: "splunk-metrics-search-head-1", Status: "Up", ActiveHistoricalSearchCount: 5},
+			},
+		},
+	}
+	method := "updateSearchHeadClusterMetrics()"
+	updateSearchHeadClusterMetrics(&cr)
+	checkGauge(t, method, searchHeadClusterCaptainReady, 1, "test", "metrics")
+	checkGauge(t, method, searchHeadClusterMaintenanceMode, 0, "test", "metrics")
+	checkGauge(t, method, searchHeadMemberUp, 1, "test", "metrics", "splunk-metrics-search-head-0")
+	checkGauge(t, method, searchHeadMemberCaptain, 0, "test", "metrics", "splunk-metrics-search-head-0")
+	checkGauge(t, method, searchHeadMemberHistoricalSearches, 3, "test", "metrics", "splunk-metrics-search-head-0")
+	checkGauge(t, method, searchHeadMemberRealtimeSearches, 1, "test", "metrics", "splunk-metrics-search-head-0")
+	checkGauge(t, method, searchHeadMemberCaptain, 1, "test", "metrics", "splunk-metrics-search-head-1")
+	checkGauge(t, method, searchHeadMemberHistoricalSearches, 5, "test", "metrics", "splunk-metrics-search-head-1")
+
+	// captain is missing
+	cr.Status.Captain = ""
+	cr.Status.CaptainReady = false
+	updateSearchHeadClusterMetrics(&cr)
+	checkGauge(t, method, searchHeadClusterCaptainReady, 0, "test", "metrics")
+	checkGauge(t, method, searchHeadMemberCaptain, 0, "test", "metrics", "splunk-metrics-search-head-1")
+
+	// all series are deleted with the search head cluster
+	cr.ObjectMeta.DeletionTimestamp = &metav1.Time{}
+	updateSearchHeadClusterMetrics(&cr)
+	checkGaugeDeleted(t, method, searchHeadClusterCaptainReady, "test", "metrics")
+	checkGaugeDeleted(t, method, searchHeadMemberUp, "test", "metrics", "splunk-metrics-search-head-0")
+	checkGaugeDeleted(t, method, searchHeadMemberCaptain, "test", "metrics", "splunk-metrics-search-head-1")
+}
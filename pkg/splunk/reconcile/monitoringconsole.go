@@ -0,0 +1,244 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// ApplyMonitoringConsole reconciles the state of a Splunk Enterprise monitoring console.
+func ApplyMonitoringConsole(client ControllerClient, cr *enterprisev1.MonitoringConsole) (reconcile.Result, error) {
+
+	// unless modified, reconcile for this object will be requeued after 5 seconds
+	result := reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: time.Second * 5,
+	}
+	scopedLog := log.WithName("ApplyMonitoringConsole").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	// validate and updates defaults for CR
+	err := enterprise.ValidateMonitoringConsoleSpec(&cr.Spec)
+	if err != nil {
+		return result, err
+	}
+
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, nil)
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
+	// updates status after function completes
+	cr.Status.Phase = enterprisev1.PhaseError
+	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		err = client.Status().Update(context.TODO(), cr)
+		if err != nil {
+			scopedLog.Error(err, "Status update failed")
+		}
+	}()
+
+	// check if deletion has been requested
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		terminating, err := CheckSplunkDeletion(cr, client)
+		if terminating && err != nil { // don't bother if no error, since it will just be removed immmediately after
+			cr.Status.Phase = enterprisev1.PhaseTerminating
+		} else {
+			result.Requeue = false
+		}
+		return result, err
+	}
+
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
+	if err != nil {
+		return result, err
+	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		return result, nil
+	}
+
+	// create or update general config resources
+	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkMonitoringConsole)
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
+		return result, err
+	}
+
+	// create or update a service
+	err = ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkMonitoringConsole, false))
+	if err != nil {
+		return result, err
+	}
+
+	// create or update statefulset
+	statefulSet, err := enterprise.GetMonitoringConsoleStatefulSet(cr)
+	if err != nil {
+		return result, err
+	}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the monitoring console
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return result, err
+	}
+	mgr := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
+	phase, err := mgr.Update(client, statefulSet, 1)
+	if err != nil {
+		return result, err
+	}
+	cr.Status.Phase = phase
+
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// keep the search peers of the monitoring console in sync with the Splunk instances managed by the operator
+	peersErr := updateMonitoringConsolePeers(client, cr, secrets, splclient.NewSplunkClient)
+	if peersErr != nil {
+		scopedLog.Error(peersErr, "Unable to update monitoring console search peers")
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionPeersConfigured, corev1.ConditionFalse, "UpdateFailed", peersErr.Error())
+	} else if cr.Status.Phase == enterprisev1.PhaseReady {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionPeersConfigured, corev1.ConditionTrue, "Configured", "")
+	}
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// no need to requeue if everything is ready; changes to other resources will trigger another reconcile
+	if cr.Status.Phase == enterprisev1.PhaseReady && peersErr == nil && !rotationPending {
+		result.Requeue = false
+	}
+	return result, nil
+}
+
+// getMonitoringConsolePeers returns a map of host:port to admin password for all Splunk Enterprise instances
+// managed by the operator within the namespace of a monitoring console.
+func getMonitoringConsolePeers(c ControllerClient, cr *enterprisev1.MonitoringConsole) (map[string][]byte, error) {
+	scopedLog := log.WithName("getMonitoringConsolePeers").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	targets, err := listSplunkResources(c, cr.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	peers := make(map[string][]byte)
+	for _, target := range targets {
+		if _, ok := target.(*enterprisev1.MonitoringConsole); ok {
+			continue
+		}
+
+		// all instances managed by a custom resource share the same admin password
+		password, err := GetSplunkSecret(c, cr, corev1.ObjectReference{Name: target.GetIdentifier(), Namespace: target.GetNamespace()}, getSecretsInstanceType(target), "password")
+		if err != nil {
+			scopedLog.Info("Waiting for secrets", "kind", target.GetTypeMeta().Kind, "target", target.GetIdentifier())
+			return nil, err
+		}
+
+		hosts, err := enterprise.GetMonitoringConsolePeers(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, host := range hosts {
+			peers[fmt.Sprintf("%s:8089", host)] = password
+		}
+	}
+
+	return peers, nil
+}
+
+// updateMonitoringConsolePeers uses the REST API to add and remove distributed search peers of a monitoring console,
+// and rebuilds its asset table whenever they change
+func updateMonitoringConsolePeers(c ControllerClient, cr *enterprisev1.MonitoringConsole, secrets *corev1.Secret, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	if cr.Status.Phase != enterprisev1.PhaseReady {
+		return nil
+	}
+	scopedLog := log.WithName("updateMonitoringConsolePeers").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	peers, err := getMonitoringConsolePeers(c, cr)
+	if err != nil {
+		return err
+	}
+
+	tlsConfig, err := getSplunkTLSConfig(c, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if err != nil {
+		return err
+	}
+	fqdnName := resources.GetServiceFQDN(cr.GetNamespace(), enterprise.GetSplunkServiceName(enterprise.SplunkMonitoringConsole, cr.GetIdentifier(), false))
+	splunkClient := newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(secrets.Data["password"]), tlsConfig)
+	current, err := splunkClient.GetSearchPeers()
+	if err != nil {
+		return err
+	}
+
+	// add new peers and remove old ones, in a predictable order
+	wanted := make([]string, 0, len(peers))
+	for peer := range peers {
+		wanted = append(wanted, peer)
+	}
+	sort.Strings(wanted)
+	changed := false
+	for _, peer := range wanted {
+		if _, ok := current[peer]; ok {
+			continue
+		}
+		scopedLog.Info("Adding search peer", "peer", peer)
+		if err = splunkClient.AddSearchPeer(peer, "admin", string(peers[peer])); err != nil {
+			return err
+		}
+		changed = true
+	}
+	removed := []string{}
+	for peer := range current {
+		if _, ok := peers[peer]; !ok {
+			removed = append(removed, peer)
+		}
+	}
+	sort.Strings(removed)
+	for _, peer := range removed {
+		scopedLog.Info("Removing search peer", "peer", peer)
+		if err = splunkClient.RemoveSearchPeer(peer); err != nil {
+			return err
+		}
+		changed = true
+	}
+
+	if changed {
+		if err = splunkClient.UpdateMonitoringConsoleAssets(); err != nil {
+			return err
+		}
+	}
+	cr.Status.Peers = wanted
+
+	return nil
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"crypto/tls"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+func TestApplyMonitoringConsole(t *testing.T) {
+	funcCalls := []mockFuncCall{
+		{metaName: "*v1.Secret-test-splunk-stack1-monitoring-console-secrets"},
+		{metaName: "*v1.Service-test-splunk-stack1-monitoring-console-service"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-monitoring-console"},
+		{metaName: "*v1.StatefulSet-test-splunk-stack1-monitoring-console"},
+	}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[3]}}
+	current := enterprisev1.MonitoringConsole{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "MonitoringConsole",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Spec.Image = "splunk/test"
+	reconcile := func(c *mockClient, cr interface{}) error {
+		_, err := ApplyMonitoringConsole(c, cr.(*enterprisev1.MonitoringConsole))
+		return err
+	}
+	reconcileTester(t, "TestApplyMonitoringConsole", &current, revised, createCalls, updateCalls, reconcile)
+
+	// test deletion
+	currentTime := metav1.NewTime(time.Now())
+	revised.ObjectMeta.DeletionTimestamp = &currentTime
+	revised.ObjectMeta.Finalizers = []string{"enterprise.splunk.com/delete-pvc"}
+	deleteFunc := func(cr enterprisev1.MetaObject, c ControllerClient) (bool, error) {
+		_, err := ApplyMonitoringConsole(c, cr.(*enterprisev1.MonitoringConsole))
+		return true, err
+	}
+	splunkDeletionTester(t, revised, deleteFunc)
+}
+
+func TestUpdateMonitoringConsolePeers(t *testing.T) {
+	cr := enterprisev1.MonitoringConsole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "mc",
+			Namespace: "test",
+		},
+		Status: enterprisev1.MonitoringConsoleStatus{
+			Phase: enterprisev1.PhaseReady,
+		},
+	}
+	secrets := &corev1.Secret{
+		Data: map[string][]byte{
+			"password": []byte{'1', '2', '3'},
+		},
+	}
+	c := newMockClient()
+	initObjects := []ResourceObject{
+		&enterprisev1.Standalone{
+			ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "test"},
+		},
+		&enterprisev1.IndexerCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: "idxc", Namespace: "test"},
+			Spec:       enterprisev1.IndexerClusterSpec{Replicas: 2},
+		},
+		&enterprisev1.Standalone{
+			ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "splunk-s1-standalone-secrets", Namespace: "test"},
+			Data:       map[string][]byte{"password": []byte("s1pass")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "splunk-idxc-indexer-secrets", Namespace: "test"},
+			Data:       map[string][]byte{"password": []byte("idxcpass")},
+		},
+	}
+	for _, obj := range initObjects {
+		c.state[getStateKey(obj)] = obj
+	}
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+	mcURI := "https://splunk-mc-monitoring-console-service.test.svc.cluster.local:8089"
+	peersRequest, _ := http.NewRequest("GET", mcURI+"/services/search/distributed/peers?count=0&output_mode=json", nil)
+	mockSplunkClient.AddHandler(peersRequest, 200, `{"entry":[{"name":"splunk-s1-standalone-0.splunk-s1-standalone-headless.test.svc.cluster.local:8089","content":{"status":"Up"}},{"name":"splunk-old-standalone-0.splunk-old-standalone-headless.test.svc.cluster.local:8089","content":{"status":"Down"}}]}`, nil)
+	for i := 0; i < 3; i++ {
+		addRequest, _ := http.NewRequest("POST", mcURI+"/services/search/distributed/peers", nil)
+		mockSplunkClient.AddHandler(addRequest, 201, "", nil)
+	}
+	removeRequest, _ := http.NewRequest("DELETE", mcURI+"/services/search/distributed/peers/splunk-old-standalone-0.splunk-old-standalone-headless.test.svc.cluster.local:8089", nil)
+	mockSplunkClient.AddHandler(removeRequest, 200, "", nil)
+	assetsRequest, _ := http.NewRequest("POST", mcURI+"/servicesNS/nobody/splunk_monitoring_console/saved/searches/DMC%20Asset%20-%20Build%20Full/dispatch", nil)
+	mockSplunkClient.AddHandler(assetsRequest, 201, "", nil)
+
+	if err := updateMonitoringConsolePeers(c, &cr, secrets, newSplunkClient); err != nil {
+		t.Errorf("updateMonitoringConsolePeers() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestUpdateMonitoringConsolePeers")
+	want := []string{
+		"splunk-idxc-cluster-master-0.splunk-idxc-cluster-master-headless.test.svc.cluster.local:8089",
+		"splunk-idxc-indexer-0.splunk-idxc-indexer-headless.test.svc.cluster.local:8089",
+		"splunk-idxc-indexer-1.splunk-idxc-indexer-headless.test.svc.cluster.local:8089",
+		"splunk-s1-standalone-0.splunk-s1-standalone-headless.test.svc.cluster.local:8089",
+	}
+	if !reflect.DeepEqual(cr.Status.Peers, want) {
+		t.Errorf("updateMonitoringConsolePeers() peers = %v; want %v", cr.Status.Peers, want)
+	}
+	addRequest := mockSplunkClient.GotRequests[1]
+	if err := addRequest.ParseForm(); err != nil {
+		t.Fatalf("unable to parse AddSearchPeer request: %v", err)
+	}
+	if addRequest.PostForm.Get("name") != want[0] || addRequest.PostForm.Get("remotePassword") != "idxcpass" {
+		t.Errorf("AddSearchPeer form = %v; want name=%s remotePassword=%s", addRequest.PostForm, want[0], "idxcpass")
+	}
+
+	// no changes when peers are already in sync
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	body := `{"entry":[`
+	for n, peer := range want {
+		if n > 0 {
+			body += ","
+		}
+		body += `{"name":"` + peer + `","content":{"status":"Up"}}`
+	}
+	body += `]}`
+	mockSplunkClient.AddHandler(peersRequest, 200, body, nil)
+	if err := updateMonitoringConsolePeers(c, &cr, secrets, newSplunkClient); err != nil {
+		t.Errorf("updateMonitoringConsolePeers() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestUpdateMonitoringConsolePeers(no-change)")
+
+	// secrets must exist for every discovered resource
+	c.state[getStateKey(&enterprisev1.LicenseMaster{ObjectMeta: metav1.ObjectMeta{Name: "lm", Namespace: "test"}})] = &enterprisev1.LicenseMaster{ObjectMeta: metav1.ObjectMeta{Name: "lm", Namespace: "test"}}
+	if err := updateMonitoringConsolePeers(c, &cr, secrets, newSplunkClient); err == nil {
+		t.Errorf("updateMonitoringConsolePeers() returned nil; want error for missing secrets")
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// pausedAnnotation is used to suspend reconciliation of a custom resource, by setting it to "true"
+const pausedAnnotation = "enterprise.splunk.com/paused"
+
+// isPaused returns true if reconciliation of a custom resource has been suspended using the enterprise.splunk.com/paused annotation.
+// Deletion is never paused, so that finalizers of a paused custom resource still run and it can be removed.
+func isPaused(cr enterprisev1.MetaObject) bool {
+	if cr.GetObjectMeta().GetDeletionTimestamp() != nil {
+		return false
+	}
+	return cr.GetObjectMeta().GetAnnotations()[pausedAnnotation] == "true"
+}
+
+// setPausedCondition updates the Paused condition of a custom resource
+func setPausedCondition(conditions *[]enterprisev1.Condition, generation int64, paused bool) {
+	if paused {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionPaused, corev1.ConditionTrue, "AnnotationSet",
+			"Reconciliation is paused by the "+pausedAnnotation+" annotation; only status is updated")
+		return
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionPaused, corev1.ConditionFalse, "Resumed", "")
+}
+
+// applyPaused updates the status of a custom resource while its reconciliation is paused. refresh, if not nil, is used to
+// update any status that can be read without changing Kubernetes objects or Splunk Enterprise instances. The phase and all
+// other status are left as they were, so that reconciliation resumes where it left off once the annotation is removed.
+func applyPaused(c ControllerClient, cr enterprisev1.MetaObject, conditions *[]enterprisev1.Condition, refresh func() error) (reconcile.Result, error) {
+	scopedLog := log.WithName("applyPaused").WithValues("kind", cr.GetTypeMeta().Kind, "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	scopedLog.Info("Reconciliation is paused")
+
+	// keep refreshing status, since nothing else will trigger a reconcile while paused
+	result := reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: time.Second * 5,
+	}
+
+	if refresh != nil {
+		if err := refresh(); err != nil {
+			scopedLog.Error(err, "Unable to refresh status while paused")
+		}
+	}
+	setPausedCondition(conditions, cr.GetObjectMeta().GetGeneration(), true)
+	if statusErr := c.Status().Update(context.TODO(), cr); statusErr != nil {
+		scopedLog.Error(statusErr, "Status update failed")
+	}
+	return result, nil
+}
+
+// getCurrentStatefulSet returns the current state of a StatefulSet, without changing it
+func getCurrentStatefulSet(c ControllerClient, namespace, name string) (*appsv1.StatefulSet, error) {
+	var statefulSet appsv1.StatefulSet
+	err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, &statefulSet)
+	return &statefulSet, err
+}
+
+// getCurrentSplunkSecrets returns the current secrets used by the Splunk Enterprise instances of a custom resource, without changing them
+func getCurrentSplunkSecrets(c ControllerClient, cr enterprisev1.MetaObject, instanceType enterprise.InstanceType) (*corev1.Secret, error) {
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: enterprise.GetSplunkSecretsName(cr.GetIdentifier(), instanceType)}
+	var secrets corev1.Secret
+	err := c.Get(context.TODO(), namespacedName, &secrets)
+	return &secrets, err
+}
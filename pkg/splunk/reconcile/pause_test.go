@@ -0,0 +1,96 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+func TestApplyPaused(t *testing.T) {
+	cr := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "stack1",
+			Namespace:   "test",
+			Annotations: map[string]string{pausedAnnotation: "true"},
+		},
+		Status: enterprisev1.StandaloneStatus{
+			Phase: enterprisev1.PhaseUpdating,
+		},
+	}
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-standalone",
+			Namespace: "test",
+		},
+		Status: appsv1.StatefulSetStatus{
+			ReadyReplicas: 2,
+		},
+	}
+	c := newMockClient()
+	c.state[getStateKey(statefulSet)] = statefulSet
+
+	// only status is refreshed while paused
+	result, err := ApplyStandalone(c, &cr)
+	if err != nil {
+		t.Errorf("ApplyStandalone() returned error: %v", err)
+	}
+	if !result.Requeue {
+		t.Errorf("ApplyStandalone() did not requeue while paused")
+	}
+	c.checkCalls(t, "TestApplyPaused", map[string][]mockFuncCall{"Get": {{metaName: "*v1.StatefulSet-test-splunk-stack1-standalone"}}})
+	if cr.Status.Phase != enterprisev1.PhaseUpdating || cr.Status.ReadyReplicas != 2 {
+		t.Errorf("ApplyStandalone() status = %s, %d; want %s, 2", cr.Status.Phase, cr.Status.ReadyReplicas, enterprisev1.PhaseUpdating)
+	}
+	condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionPaused)
+	if condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Errorf("ApplyStandalone() Paused condition = %v; want True", condition)
+	}
+
+	// values other than "true" do not pause reconciliation
+	cr.ObjectMeta.Annotations[pausedAnnotation] = "false"
+	if isPaused(&cr) {
+		t.Errorf("isPaused() = true for %s=false; want false", pausedAnnotation)
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+	condition = resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionPaused)
+	if condition == nil || condition.Status != corev1.ConditionFalse || condition.Reason != "Resumed" {
+		t.Errorf("setPausedCondition(false) Paused condition = %v; want False (Resumed)", condition)
+	}
+
+	// deletion is handled even while paused
+	currentTime := metav1.NewTime(time.Now())
+	cr.ObjectMeta.Annotations[pausedAnnotation] = "true"
+	cr.ObjectMeta.DeletionTimestamp = &currentTime
+	cr.ObjectMeta.Finalizers = []string{"enterprise.splunk.com/delete-pvc"}
+	if isPaused(&cr) {
+		t.Errorf("isPaused() = true for a custom resource being deleted; want false")
+	}
+	deleteFunc := func(cr enterprisev1.MetaObject, c ControllerClient) (bool, error) {
+		_, err := ApplyStandalone(c, cr.(*enterprisev1.Standalone))
+		return true, err
+	}
+	splunkDeletionTester(t, &cr, deleteFunc)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"reflect"
+
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ApplyPodDisruptionBudget creates or updates a Kubernetes PodDisruptionBudget
+func ApplyPodDisruptionBudget(client ControllerClient, revised *policyv1beta1.PodDisruptionBudget) error {
+	scopedLog := log.WithName("ApplyPodDisruptionBudget").WithValues(
+		"name", revised.GetObjectMeta().GetName(),
+		"namespace", revised.GetObjectMeta().GetNamespace())
+
+	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
+	var current policyv1beta1.PodDisruptionBudget
+
+	err := client.Get(context.TODO(), namespacedName, &current)
+	if err != nil {
+		return CreateResource(client, revised)
+	}
+
+	// check for changes in the number of pods that may be disrupted, or the pods that are selected
+	hasUpdates := !reflect.DeepEqual(current.Spec.MaxUnavailable, revised.Spec.MaxUnavailable) ||
+		!reflect.DeepEqual(current.Spec.Selector, revised.Spec.Selector)
+	if hasUpdates {
+		current.Spec.MaxUnavailable = revised.Spec.MaxUnavailable
+		current.Spec.Selector = revised.Spec.Selector
+	}
+	*revised = current // caller expects that object passed represents latest state
+
+	if hasUpdates {
+		scopedLog.Info("Updating existing PodDisruptionBudget")
+		return UpdateResource(client, revised)
+	}
+
+	return nil
+}
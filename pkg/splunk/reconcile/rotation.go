@@ -0,0 +1,578 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+const (
+	// rotateSecretsAnnotation is used to request rotation of a comma-separated list of Splunk secrets for a custom resource
+	rotateSecretsAnnotation = "enterprise.splunk.com/rotate-secrets"
+
+	// rotationRestartsAnnotation records the restarts that remain to apply rotated secrets, on the resource that requested rotation
+	rotationRestartsAnnotation = "enterprise.splunk.com/rotation-restarts"
+
+	// rotationRestartHoldAnnotation is used to hold the rolling restart of an indexer or search head cluster whose secrets
+	// are being rotated, until the instances it depends upon have been restarted
+	rotationRestartHoldAnnotation = "enterprise.splunk.com/rotation-restart"
+
+	// rotationRestartHeld means that a cluster must not apply changes to its restart-only configuration
+	rotationRestartHeld = "held"
+
+	// rotationRestartReleased means that a cluster should apply changes to its restart-only configuration, and then
+	// remove the rotationRestartHoldAnnotation
+	rotationRestartReleased = "released"
+)
+
+// rotationOrder is used to sort the resources updated when secrets are rotated
+var rotationOrder = map[enterprise.InstanceType]int{
+	enterprise.SplunkLicenseMaster:     0,
+	enterprise.SplunkClusterMaster:     1,
+	enterprise.SplunkIndexer:           2,
+	enterprise.SplunkSearchHead:        3,
+	enterprise.SplunkStandalone:        4,
+	enterprise.SplunkMonitoringConsole: 5,
+}
+
+// rotationTarget is a custom resource whose Splunk instances are updated when secrets are rotated
+type rotationTarget struct {
+	cr        enterprisev1.MetaObject
+	secrets   *corev1.Secret
+	tlsConfig *tls.Config
+	hosts     []string
+}
+
+// rotationRestarts records the progress of restarting Splunk instances to apply rotated secrets
+type rotationRestarts struct {
+	// Keys of the secrets that were rotated
+	Keys []string `json:"keys"`
+
+	// Stages that remain, in the order that they are restarted
+	Stages []rotationRestartStage `json:"stages"`
+}
+
+// rotationRestartStage is a group of Splunk instances that are restarted together, once every previous stage is healthy.
+// Instances listed in Hosts are restarted using the REST API; if there are none, the stage is a rolling restart of an
+// indexer or search head cluster, which is performed by the cluster itself.
+type rotationRestartStage struct {
+	// InstanceType of the custom resource that manages the instances
+	InstanceType enterprise.InstanceType `json:"type"`
+
+	// Name of the custom resource that manages the instances
+	Name string `json:"name"`
+
+	// Hosts that are restarted using the REST API
+	Hosts []string `json:"hosts,omitempty"`
+
+	// StartupTimes of hosts that have been restarted, recorded before restarting them
+	StartupTimes map[string]int64 `json:"startupTimes,omitempty"`
+}
+
+// stagedSecret records a secret that was changed on a Splunk instance, so that the change can be rolled back
+type stagedSecret struct {
+	client   *splclient.SplunkClient
+	key      string
+	oldValue string
+}
+
+// ApplySecretsRotation rotates Splunk secrets when requested using the enterprise.splunk.com/rotate-secrets annotation.
+// It should only be called when all of the resource's instances are ready, and returns true if rotation is waiting
+// on other resources to become ready.
+func ApplySecretsRotation(c ControllerClient, cr enterprisev1.MetaObject, conditions *[]enterprisev1.Condition) (bool, error) {
+	return rotateSplunkSecrets(c, cr, conditions, splclient.NewSplunkClient)
+}
+
+// rotateSplunkSecrets changes the requested secrets of a custom resource, along with those of every resource that copies them.
+// New values are randomly generated, unless they are provided by the resource's secretRef. They are staged on every Splunk
+// instance before any of them are committed to Kubernetes Secrets, and changes are rolled back if any instance cannot be
+// updated. Instances are then restarted in the order that they depend upon each other, waiting for each stage to be healthy.
+func rotateSplunkSecrets(c ControllerClient, cr enterprisev1.MetaObject, conditions *[]enterprisev1.Condition, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) (bool, error) {
+	// finish restarting instances for a previous rotation before starting another
+	if _, ok := cr.GetObjectMeta().GetAnnotations()[rotationRestartsAnnotation]; ok {
+		return restartRotationTargets(c, cr, conditions, newSplunkClient)
+	}
+
+	value, ok := cr.GetObjectMeta().GetAnnotations()[rotateSecretsAnnotation]
+	if !ok {
+		return false, nil
+	}
+	scopedLog := log.WithName("rotateSplunkSecrets").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	generation := cr.GetObjectMeta().GetGeneration()
+
+	keys, err := enterprise.ParseSecretsRotation(value)
+	if err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "InvalidRequest", err.Error())
+		return false, err
+	}
+	newValues := enterprise.GetRotatedSplunkSecrets(keys)
+	if spec := getCommonSplunkSpec(cr); spec != nil {
+		userSecrets, err := getUserSecrets(c, cr, spec)
+		if err != nil {
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "InvalidRequest", err.Error())
+			return false, err
+		}
+		if userSecrets != nil {
+			for _, key := range keys {
+				if value, ok := userSecrets.Data[key]; ok {
+					newValues[key] = value
+				}
+			}
+		}
+	}
+	targets, waiting, err := getRotationTargets(c, cr, keys, newValues)
+	if err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "InvalidRequest", err.Error())
+		return false, err
+	}
+	if waiting != "" {
+		scopedLog.Info("Waiting to rotate secrets", "waitingFor", waiting)
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "Waiting", fmt.Sprintf("Waiting for %s to be ready", waiting))
+		return true, nil
+	}
+
+	// stage new values on every instance, rolling back if anything fails
+	scopedLog.Info("Rotating secrets", "keys", keys)
+	if err = stageRotatedSecrets(targets, keys, newValues, newSplunkClient); err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "RotationFailed", err.Error())
+		return false, err
+	}
+
+	// shared secrets are only used after instances are restarted, so hold the rolling restarts of clusters until the
+	// instances that they depend upon have been restarted
+	restarts := rotationRestarts{Keys: keys, Stages: []rotationRestartStage{}}
+	for _, key := range keys {
+		if enterprise.GetServerConfStanza(key) != "" {
+			restarts.Stages = getRotationRestartStages(targets)
+			break
+		}
+	}
+	held := []enterprisev1.MetaObject{}
+	releaseHeld := func() {
+		for _, target := range held {
+			if err := patchAnnotations(c, target, map[string]*string{rotationRestartHoldAnnotation: nil}); err != nil {
+				scopedLog.Error(err, "Unable to release rolling restart", "name", target.GetIdentifier())
+			}
+		}
+	}
+	for _, target := range targets {
+		if len(restarts.Stages) == 0 || !isRollingRestartCluster(target.cr) {
+			continue
+		}
+		hold := rotationRestartHeld
+		if err = patchAnnotations(c, target.cr, map[string]*string{rotationRestartHoldAnnotation: &hold}); err != nil {
+			releaseHeld()
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "CommitFailed", err.Error())
+			return false, err
+		}
+		held = append(held, target.cr)
+	}
+
+	// commit new values, so that they are used when instances are restarted and by any new instances
+	// note that the admin password is only changed for the resource that requested rotation (the first target)
+	for n, target := range targets {
+		values := make(map[string][]byte)
+		for _, key := range keys {
+			if enterprise.GetServerConfStanza(key) != "" || n == 0 {
+				values[key] = newValues[key]
+			}
+		}
+		enterprise.UpdateSplunkSecrets(target.secrets, values)
+		if err = UpdateResource(c, target.secrets); err != nil {
+			// let clusters apply whatever was committed, since their instances must not be left waiting
+			releaseHeld()
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "CommitFailed", err.Error())
+			return false, err
+		}
+	}
+
+	// rotation has been committed, so replace the request with the restarts that remain
+	if len(restarts.Stages) == 0 {
+		if err = patchAnnotations(c, cr, map[string]*string{rotateSecretsAnnotation: nil}); err != nil {
+			return false, err
+		}
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionTrue, "Rotated", fmt.Sprintf("Rotated %s", strings.Join(keys, ", ")))
+		return false, nil
+	}
+	value, err = getRotationRestartsValue(&restarts)
+	if err != nil {
+		return false, err
+	}
+	if err = patchAnnotations(c, cr, map[string]*string{rotateSecretsAnnotation: nil, rotationRestartsAnnotation: &value}); err != nil {
+		return false, err
+	}
+	return restartRotationTargets(c, cr, conditions, newSplunkClient)
+}
+
+// getRotationTargets returns the custom resources whose Splunk instances are updated when secrets are rotated, starting with cr
+// itself and followed by every resource that copies any of the secrets (directly or indirectly), in the order that they are updated.
+// If any of the resources are not ready, it returns a description of the resource to wait for. Resources whose secretRef
+// provides shared secrets are only updated if those match newValues.
+func getRotationTargets(c ControllerClient, cr enterprisev1.MetaObject, keys []string, newValues map[string][]byte) ([]rotationTarget, string, error) {
+	spec := getCommonSplunkSpec(cr)
+	if spec == nil {
+		return nil, "", fmt.Errorf("Secrets rotation is not supported for kind %s", cr.GetTypeMeta().Kind)
+	}
+	for key, source := range getCopiedSecrets(spec, getSecretsInstanceType(cr)) {
+		for _, k := range keys {
+			if k == key {
+				return nil, "", fmt.Errorf("Secret %s is copied from %s; rotate it there instead", key, enterprise.GetSplunkSecretsName(source.ref.Name, source.instanceType))
+			}
+		}
+	}
+
+	// find all resources that copy shared secrets from cr, or from other resources that copy them
+	all, err := listSplunkResources(c, cr.GetNamespace())
+	if err != nil {
+		return nil, "", err
+	}
+	members := []enterprisev1.MetaObject{cr}
+	secretNames := map[string]bool{enterprise.GetSplunkSecretsName(cr.GetIdentifier(), getSecretsInstanceType(cr)): true}
+	for added := true; added; {
+		added = false
+		for _, other := range all {
+			otherSecretsName := enterprise.GetSplunkSecretsName(other.GetIdentifier(), getSecretsInstanceType(other))
+			if secretNames[otherSecretsName] {
+				continue
+			}
+			copied := getCopiedSecrets(getCommonSplunkSpec(other), getSecretsInstanceType(other))
+			for _, key := range keys {
+				source, ok := copied[key]
+				if !ok || (source.ref.Namespace != "" && source.ref.Namespace != cr.GetNamespace()) {
+					continue
+				}
+				if secretNames[enterprise.GetSplunkSecretsName(source.ref.Name, source.instanceType)] {
+					members = append(members, other)
+					secretNames[otherSecretsName] = true
+					added = true
+					break
+				}
+			}
+		}
+	}
+
+	// update resources in a predictable order: license master, cluster master, peers, search heads and then others
+	dependents := members[1:]
+	sort.SliceStable(dependents, func(i, j int) bool {
+		return rotationOrder[getSecretsInstanceType(dependents[i])] < rotationOrder[getSecretsInstanceType(dependents[j])]
+	})
+
+	targets := []rotationTarget{}
+	for _, member := range members {
+		if member != cr && getResourcePhase(member) != enterprisev1.PhaseReady {
+			return nil, fmt.Sprintf("%s %s", member.GetTypeMeta().Kind, member.GetIdentifier()), nil
+		}
+
+		// values provided by the user must already be changed in their own secret store
+		memberSpec := getCommonSplunkSpec(member)
+		userSecrets, err := getUserSecrets(c, member, memberSpec)
+		if err != nil {
+			return nil, "", err
+		}
+		if userSecrets != nil {
+			for _, key := range keys {
+				if value, ok := userSecrets.Data[key]; ok && key != "password" && !bytes.Equal(value, newValues[key]) {
+					return nil, "", fmt.Errorf("Secret %s is provided by %s; change it there to the same value", key, memberSpec.SecretRef)
+				}
+			}
+		}
+
+		var secrets corev1.Secret
+		namespacedName := types.NamespacedName{Namespace: member.GetNamespace(), Name: enterprise.GetSplunkSecretsName(member.GetIdentifier(), getSecretsInstanceType(member))}
+		if err := c.Get(context.TODO(), namespacedName, &secrets); err != nil {
+			return nil, "", fmt.Errorf("Unable to get secret %s: %v", namespacedName.Name, err)
+		}
+		tlsConfig, err := getSplunkTLSConfig(c, member, memberSpec, &secrets)
+		if err != nil {
+			return nil, "", err
+		}
+		hosts, err := enterprise.GetSplunkInstanceHosts(member)
+		if err != nil {
+			return nil, "", err
+		}
+		targets = append(targets, rotationTarget{cr: member, secrets: &secrets, tlsConfig: tlsConfig, hosts: hosts})
+	}
+
+	return targets, "", nil
+}
+
+// stageRotatedSecrets changes secrets on every Splunk instance managed by the targets. Shared secrets are staged on every
+// instance before the admin password of the first target is changed. If any change fails, all previous changes are rolled back.
+func stageRotatedSecrets(targets []rotationTarget, keys []string, newValues map[string][]byte, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	scopedLog := log.WithName("stageRotatedSecrets")
+	staged := []stagedSecret{}
+	rollback := func(cause error) error {
+		for i := len(staged) - 1; i >= 0; i-- {
+			var err error
+			if stanza := enterprise.GetServerConfStanza(staged[i].key); stanza != "" {
+				err = staged[i].client.SetServerConfSecret(stanza, staged[i].oldValue)
+			} else {
+				err = staged[i].client.ChangeAdminPassword(staged[i].client.Password, staged[i].oldValue)
+			}
+			if err != nil {
+				scopedLog.Error(err, "Unable to roll back secret", "uri", staged[i].client.ManagementURI, "key", staged[i].key)
+			}
+		}
+		return cause
+	}
+
+	for _, key := range keys {
+		stanza := enterprise.GetServerConfStanza(key)
+		if stanza == "" {
+			continue
+		}
+		for _, target := range targets {
+			for _, host := range target.hosts {
+				c := newSplunkClient(fmt.Sprintf("https://%s:8089", host), "admin", string(target.secrets.Data["password"]), target.tlsConfig)
+				if err := c.SetServerConfSecret(stanza, string(newValues[key])); err != nil {
+					return rollback(fmt.Errorf("Unable to change %s on %s: %v", key, host, err))
+				}
+				staged = append(staged, stagedSecret{client: c, key: key, oldValue: string(target.secrets.Data[key])})
+			}
+		}
+	}
+
+	if newPassword, ok := newValues["password"]; ok {
+		target := targets[0]
+		for _, host := range target.hosts {
+			c := newSplunkClient(fmt.Sprintf("https://%s:8089", host), "admin", string(target.secrets.Data["password"]), target.tlsConfig)
+			if err := c.ChangeAdminPassword(c.Password, string(newPassword)); err != nil {
+				return rollback(fmt.Errorf("Unable to change password on %s: %v", host, err))
+			}
+			staged = append(staged, stagedSecret{client: c, key: "password", oldValue: string(target.secrets.Data["password"])})
+		}
+	}
+
+	return nil
+}
+
+// getRotationRestartStages returns the stages used to restart the Splunk instances managed by the targets, in the order that
+// they depend upon each other: license master, cluster master, indexer cluster peers, search head cluster members and then others.
+// The cluster master of an indexer cluster without clusterMasterRef, and the deployer of a search head cluster, are restarted
+// using the REST API before the rolling restart of the cluster's members.
+func getRotationRestartStages(targets []rotationTarget) []rotationRestartStage {
+	sorted := append([]rotationTarget{}, targets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rotationOrder[getSecretsInstanceType(sorted[i].cr)] < rotationOrder[getSecretsInstanceType(sorted[j].cr)]
+	})
+
+	stages := []rotationRestartStage{}
+	for _, target := range sorted {
+		stage := rotationRestartStage{InstanceType: getSecretsInstanceType(target.cr), Name: target.cr.GetIdentifier()}
+		if !isRollingRestartCluster(target.cr) {
+			stage.Hosts = target.hosts
+			stages = append(stages, stage)
+			continue
+		}
+		if cr, ok := target.cr.(*enterprisev1.IndexerCluster); !ok || cr.Spec.ClusterMasterRef.Name == "" {
+			stages = append(stages, rotationRestartStage{InstanceType: stage.InstanceType, Name: stage.Name, Hosts: target.hosts[:1]})
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// isRollingRestartCluster returns true if restart-only configuration changes of a custom resource are applied using a rolling restart
+func isRollingRestartCluster(cr enterprisev1.MetaObject) bool {
+	switch cr.(type) {
+	case *enterprisev1.IndexerCluster, *enterprisev1.SearchHeadCluster:
+		return true
+	}
+	return false
+}
+
+// getRotationRestartsValue returns the value of the rotationRestartsAnnotation used to record restarts
+func getRotationRestartsValue(restarts *rotationRestarts) (string, error) {
+	value, err := json.Marshal(restarts)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// restartRotationTargets continues restarting the Splunk instances that use secrets rotated by cr, one stage at a time. Each
+// stage is started once the previous one is healthy, and instances are never recreated, so that only the instances of one
+// stage are unavailable at a time. It returns true while restarts are in progress.
+func restartRotationTargets(c ControllerClient, cr enterprisev1.MetaObject, conditions *[]enterprisev1.Condition, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) (bool, error) {
+	scopedLog := log.WithName("restartRotationTargets").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	generation := cr.GetObjectMeta().GetGeneration()
+	value := cr.GetObjectMeta().GetAnnotations()[rotationRestartsAnnotation]
+	var restarts rotationRestarts
+	if err := json.Unmarshal([]byte(value), &restarts); err != nil {
+		resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "RestartFailed", err.Error())
+		return false, fmt.Errorf("Invalid %s annotation: %v", rotationRestartsAnnotation, err)
+	}
+
+	// save progress, so that no instance is restarted twice
+	save := func() error {
+		newValue, err := getRotationRestartsValue(&restarts)
+		if err != nil || newValue == value {
+			return err
+		}
+		return patchAnnotations(c, cr, map[string]*string{rotationRestartsAnnotation: &newValue})
+	}
+
+	for len(restarts.Stages) > 0 {
+		waiting, err := applyRotationRestartStage(c, cr.GetNamespace(), &restarts.Stages[0], newSplunkClient)
+		if err != nil {
+			if saveErr := save(); saveErr != nil {
+				scopedLog.Error(saveErr, "Unable to save restart progress")
+			}
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "RestartFailed", err.Error())
+			return false, err
+		}
+		if waiting != "" {
+			scopedLog.Info("Waiting for restart to apply rotated secrets", "waitingFor", waiting)
+			resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionFalse, "Restarting", fmt.Sprintf("Waiting for %s to restart", waiting))
+			return true, save()
+		}
+		restarts.Stages = restarts.Stages[1:]
+	}
+
+	if err := patchAnnotations(c, cr, map[string]*string{rotationRestartsAnnotation: nil}); err != nil {
+		return false, err
+	}
+	resources.SetCondition(conditions, generation, enterprisev1.ConditionSecretsRotated, corev1.ConditionTrue, "Rotated", fmt.Sprintf("Rotated %s", strings.Join(restarts.Keys, ", ")))
+	return false, nil
+}
+
+// applyRotationRestartStage starts restarting the instances of a stage, if they have not already been restarted, and returns
+// a description of what it is waiting for until they are healthy. Resources that no longer exist are skipped.
+func applyRotationRestartStage(c ControllerClient, namespace string, stage *rotationRestartStage, newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) (string, error) {
+	all, err := listSplunkResources(c, namespace)
+	if err != nil {
+		return "", err
+	}
+	var target enterprisev1.MetaObject
+	for _, cr := range all {
+		if getSecretsInstanceType(cr) == stage.InstanceType && cr.GetIdentifier() == stage.Name {
+			target = cr
+		}
+	}
+	if target == nil {
+		log.Info("Skipping restart of deleted resource", "type", stage.InstanceType, "name", stage.Name)
+		return "", nil
+	}
+
+	// clusters perform their own rolling restart once released, and remove the hold when it has completed
+	if len(stage.Hosts) == 0 {
+		waiting := fmt.Sprintf("%s %s", stage.InstanceType, stage.Name)
+		switch target.GetObjectMeta().GetAnnotations()[rotationRestartHoldAnnotation] {
+		case rotationRestartHeld:
+			release := rotationRestartReleased
+			return waiting, patchAnnotations(c, target, map[string]*string{rotationRestartHoldAnnotation: &release})
+		case rotationRestartReleased:
+			return waiting, nil
+		}
+		if getResourcePhase(target) != enterprisev1.PhaseReady {
+			return waiting, nil
+		}
+		return "", nil
+	}
+
+	// other instances are restarted using the REST API, recording when they were started to tell when they have restarted
+	var secrets corev1.Secret
+	namespacedName := types.NamespacedName{Namespace: namespace, Name: enterprise.GetSplunkSecretsName(target.GetIdentifier(), stage.InstanceType)}
+	if err = c.Get(context.TODO(), namespacedName, &secrets); err != nil {
+		return "", fmt.Errorf("Unable to get secret %s: %v", namespacedName.Name, err)
+	}
+	tlsConfig, err := getSplunkTLSConfig(c, target, getCommonSplunkSpec(target), &secrets)
+	if err != nil {
+		return "", err
+	}
+	if stage.StartupTimes == nil {
+		stage.StartupTimes = map[string]int64{}
+	}
+	for _, host := range stage.Hosts {
+		if _, ok := stage.StartupTimes[host]; ok {
+			continue
+		}
+		splunkClient := newSplunkClient(fmt.Sprintf("https://%s:8089", host), "admin", string(secrets.Data["password"]), tlsConfig)
+		info, err := splunkClient.GetServerInfo()
+		if err != nil {
+			return "", fmt.Errorf("Unable to get startup time of %s: %v", host, err)
+		}
+		if err = splunkClient.Restart(); err != nil {
+			return "", fmt.Errorf("Unable to restart %s: %v", host, err)
+		}
+		stage.StartupTimes[host] = info.StartupTime
+	}
+	for _, host := range stage.Hosts {
+		splunkClient := newSplunkClient(fmt.Sprintf("https://%s:8089", host), "admin", string(secrets.Data["password"]), tlsConfig)
+		info, err := splunkClient.GetServerInfo()
+		if err != nil || info.StartupTime == stage.StartupTimes[host] {
+			return host, nil
+		}
+	}
+	return "", nil
+}
+
+// patchAnnotations changes the annotations of a custom resource, removing those with nil values
+func patchAnnotations(c ControllerClient, cr enterprisev1.MetaObject, changes map[string]*string) error {
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"annotations": changes}})
+	if err != nil {
+		return err
+	}
+	if err = c.Patch(context.TODO(), cr, client.ConstantPatch(types.MergePatchType, patch)); err != nil {
+		return err
+	}
+	annotations := cr.GetObjectMeta().GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(annotations, key)
+		} else {
+			annotations[key] = *value
+		}
+	}
+	cr.GetObjectMeta().SetAnnotations(annotations)
+	return nil
+}
+
+// updateRotatedStatefulSetConfig applies changes to the restart-only configuration of a cluster using UpdateStatefulSetConfig,
+// unless its rolling restart is held while secrets are rotated. Once a held restart is released and has completed, the hold
+// is removed so that secrets rotation can continue.
+func updateRotatedStatefulSetConfig(c ControllerClient, cr enterprisev1.MetaObject, statefulSets []*appsv1.StatefulSet, mgr RollingRestartManager, checksum string) (enterprisev1.ResourcePhase, error) {
+	hold, ok := cr.GetObjectMeta().GetAnnotations()[rotationRestartHoldAnnotation]
+	if hold == rotationRestartHeld {
+		return enterprisev1.PhaseReady, nil
+	}
+	phase, err := UpdateStatefulSetConfig(c, statefulSets, mgr, checksum)
+	if err != nil || phase != enterprisev1.PhaseReady || !ok {
+		return phase, err
+	}
+	if err = patchAnnotations(c, cr, map[string]*string{rotationRestartHoldAnnotation: nil}); err != nil {
+		return enterprisev1.PhaseError, err
+	}
+	return phase, nil
+}
@@ -0,0 +1,450 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+const (
+	rotationLicenseMasterURI = "https://splunk-stack1-license-master-0.splunk-stack1-license-master-headless.test.svc.cluster.local:8089"
+	rotationStandaloneURI    = "https://splunk-s1-standalone-0.splunk-s1-standalone-headless.test.svc.cluster.local:8089"
+)
+
+// newRotationTestClient returns a mock client with a license master and a standalone instance that uses it
+func newRotationTestClient(t *testing.T) (*mockClient, *enterprisev1.LicenseMaster, *enterprisev1.Standalone) {
+	c := newMockClient()
+	lm := enterprisev1.LicenseMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "LicenseMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	lm.Status.Phase = enterprisev1.PhaseReady
+	standalone := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "s1",
+			Namespace: "test",
+		},
+	}
+	standalone.Spec.LicenseMasterRef.Name = "stack1"
+	standalone.Status.Phase = enterprisev1.PhaseReady
+	c.state[getStateKey(&lm)] = &lm
+	c.state[getStateKey(&standalone)] = &standalone
+
+	lmSecrets := enterprise.GetSplunkSecrets(&lm, enterprise.SplunkLicenseMaster, nil, nil)
+	standaloneSecrets := enterprise.GetSplunkSecrets(&standalone, enterprise.SplunkStandalone, nil, lmSecrets.Data["pass4SymmKey"])
+	c.state[getStateKey(lmSecrets)] = lmSecrets
+	c.state[getStateKey(standaloneSecrets)] = standaloneSecrets
+	return c, &lm, &standalone
+}
+
+// rotationTestRequest is a request expected by secrets rotation tests
+type rotationTestRequest struct {
+	method string
+	url    string
+	body   string
+}
+
+// newRotationTestSplunkClient returns a function that creates Splunk clients using a mock HTTP client with handlers for requests
+func newRotationTestSplunkClient(mockSplunkClient *spltest.MockHTTPClient, requests ...rotationTestRequest) func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+	for _, request := range requests {
+		wantRequest, _ := http.NewRequest(request.method, request.url, nil)
+		mockSplunkClient.AddHandler(wantRequest, 200, request.body, nil)
+	}
+	return func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+}
+
+// getRotationTestServerInfo returns the request for server info of an instance that was started at startupTime
+func getRotationTestServerInfo(uri string, startupTime int64) rotationTestRequest {
+	return rotationTestRequest{
+		method: "GET",
+		url:    uri + "/services/server/info?count=0&output_mode=json",
+		body:   fmt.Sprintf(`{"entry":[{"content":{"startup_time":%d}}]}`, startupTime),
+	}
+}
+
+// getRotationTestRestart returns the request used to restart an instance
+func getRotationTestRestart(uri string) rotationTestRequest {
+	return rotationTestRequest{method: "POST", url: uri + "/services/server/control/restart"}
+}
+
+// getRotationTestConfig returns the request used to change a secret in server.conf
+func getRotationTestConfig(uri, stanza string) rotationTestRequest {
+	return rotationTestRequest{method: "POST", url: uri + "/servicesNS/nobody/system/configs/conf-server/" + stanza}
+}
+
+func getRotationTestSecrets(t *testing.T, c *mockClient, name string) *corev1.Secret {
+	secret := corev1.Secret{}
+	secret.SetName(name)
+	secret.SetNamespace("test")
+	obj, ok := c.state[getStateKey(&secret)]
+	if !ok {
+		t.Fatalf("Secret %s not found", name)
+	}
+	return obj.(*corev1.Secret)
+}
+
+func TestRotateSplunkSecrets(t *testing.T) {
+	c, lm, _ := newRotationTestClient(t)
+	lmSecrets := getRotationTestSecrets(t, c, "splunk-stack1-license-master-secrets")
+	oldPassword := string(lmSecrets.Data["password"])
+	oldPass4SymmKey := string(lmSecrets.Data["pass4SymmKey"])
+	standaloneSecrets := getRotationTestSecrets(t, c, "splunk-s1-standalone-secrets")
+	standalonePassword := string(standaloneSecrets.Data["password"])
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+
+	// no rotation requested
+	pending, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if pending || err != nil {
+		t.Errorf("rotateSplunkSecrets() = %t, %v; want false, nil", pending, err)
+	}
+
+	// shared secrets are staged on all instances before changing the password, followed by restarting the license master
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "password, pass4SymmKey"})
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	newSplunkClient = newRotationTestSplunkClient(mockSplunkClient,
+		getRotationTestConfig(rotationLicenseMasterURI, "general"),
+		getRotationTestConfig(rotationStandaloneURI, "general"),
+		rotationTestRequest{method: "POST", url: rotationLicenseMasterURI + "/services/authentication/users/admin"},
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+		getRotationTestRestart(rotationLicenseMasterURI),
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+	)
+	c.resetCalls()
+	pending, err = rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if !pending || err != nil {
+		t.Fatalf("rotateSplunkSecrets() = %t, %v; want true, nil", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecrets")
+	lmSecrets = getRotationTestSecrets(t, c, "splunk-stack1-license-master-secrets")
+	standaloneSecrets = getRotationTestSecrets(t, c, "splunk-s1-standalone-secrets")
+	if string(lmSecrets.Data["password"]) == oldPassword || string(lmSecrets.Data["pass4SymmKey"]) == oldPass4SymmKey {
+		t.Errorf("rotateSplunkSecrets() did not change license master secrets")
+	}
+	if string(standaloneSecrets.Data["pass4SymmKey"]) != string(lmSecrets.Data["pass4SymmKey"]) {
+		t.Errorf("rotateSplunkSecrets() did not copy pass4SymmKey to standalone")
+	}
+	if string(standaloneSecrets.Data["password"]) != standalonePassword {
+		t.Errorf("rotateSplunkSecrets() changed password of standalone")
+	}
+	if _, ok := lm.GetAnnotations()[rotateSecretsAnnotation]; ok {
+		t.Errorf("rotateSplunkSecrets() did not remove %s annotation", rotateSecretsAnnotation)
+	}
+	if _, ok := lm.GetAnnotations()[rotationRestartsAnnotation]; !ok {
+		t.Errorf("rotateSplunkSecrets() did not record restarts")
+	}
+	if len(lm.Status.Conditions) != 1 || lm.Status.Conditions[0].Reason != "Restarting" {
+		t.Errorf("rotateSplunkSecrets() conditions = %v; want SecretsRotated=False with reason Restarting", lm.Status.Conditions)
+	}
+
+	// dependent instances are restarted once the license master has restarted
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	newSplunkClient = newRotationTestSplunkClient(mockSplunkClient,
+		getRotationTestServerInfo(rotationLicenseMasterURI, 2),
+		getRotationTestServerInfo(rotationStandaloneURI, 1),
+		getRotationTestRestart(rotationStandaloneURI),
+		getRotationTestServerInfo(rotationStandaloneURI, 1),
+	)
+	pending, err = rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if !pending || err != nil {
+		t.Fatalf("rotateSplunkSecrets() = %t, %v; want true, nil", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecrets(standalone)")
+
+	// instances that fail to respond are not restarted again, or recreated
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	newSplunkClient = newRotationTestSplunkClient(mockSplunkClient)
+	wantRequest, _ := http.NewRequest("GET", rotationStandaloneURI+"/services/server/info?count=0&output_mode=json", nil)
+	mockSplunkClient.AddHandler(wantRequest, 503, "", nil)
+	c.resetCalls()
+	pending, err = rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if !pending || err != nil {
+		t.Fatalf("rotateSplunkSecrets() = %t, %v; want true, nil", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecrets(unavailable)")
+	if len(c.calls["Delete"]) != 0 {
+		t.Errorf("rotateSplunkSecrets() deleted %v; want no pods deleted", c.calls["Delete"])
+	}
+
+	// rotation is complete once every instance has restarted
+	mockSplunkClient = &spltest.MockHTTPClient{}
+	newSplunkClient = newRotationTestSplunkClient(mockSplunkClient, getRotationTestServerInfo(rotationStandaloneURI, 2))
+	pending, err = rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if pending || err != nil {
+		t.Fatalf("rotateSplunkSecrets() = %t, %v; want false, nil", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecrets(done)")
+	if _, ok := lm.GetAnnotations()[rotationRestartsAnnotation]; ok {
+		t.Errorf("rotateSplunkSecrets() did not remove %s annotation", rotationRestartsAnnotation)
+	}
+	if len(lm.Status.Conditions) != 1 || lm.Status.Conditions[0].Status != corev1.ConditionTrue {
+		t.Errorf("rotateSplunkSecrets() conditions = %v; want SecretsRotated=True", lm.Status.Conditions)
+	}
+}
+
+func TestRotateSplunkSecretsClusterRestart(t *testing.T) {
+	c, lm, _ := newRotationTestClient(t)
+	shc := enterprisev1.SearchHeadCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SearchHeadCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "shc1",
+			Namespace: "test",
+		},
+	}
+	shc.Spec.Replicas = 3
+	shc.Spec.LicenseMasterRef.Name = "stack1"
+	shc.Status.Phase = enterprisev1.PhaseReady
+	c.state[getStateKey(&shc)] = &shc
+	lmSecrets := getRotationTestSecrets(t, c, "splunk-stack1-license-master-secrets")
+	shcSecrets := enterprise.GetSplunkSecrets(&shc, enterprise.SplunkSearchHead, nil, lmSecrets.Data["pass4SymmKey"])
+	c.state[getStateKey(shcSecrets)] = shcSecrets
+	deployerURI := "https://splunk-shc1-deployer-0.splunk-shc1-deployer-headless.test.svc.cluster.local:8089"
+	searchHeadURI := "https://splunk-shc1-search-head-%d.splunk-shc1-search-head-headless.test.svc.cluster.local:8089"
+	standaloneConfig := getRotationTestConfig(rotationStandaloneURI, "general")
+
+	getSearchHeadCluster := func() *enterprisev1.SearchHeadCluster {
+		return c.state[getStateKey(&shc)].(*enterprisev1.SearchHeadCluster)
+	}
+	rotate := func(method string, wantPending bool, requests ...rotationTestRequest) {
+		mockSplunkClient := &spltest.MockHTTPClient{}
+		newSplunkClient := newRotationTestSplunkClient(mockSplunkClient, requests...)
+		pending, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+		if pending != wantPending || err != nil {
+			t.Fatalf("%s rotateSplunkSecrets() = %t, %v; want %t, nil", method, pending, err, wantPending)
+		}
+		mockSplunkClient.CheckRequests(t, method)
+	}
+
+	// the rolling restart of the search head cluster is held until the license master and deployer have restarted
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	rotate("TestRotateSplunkSecretsClusterRestart(stage)", true,
+		getRotationTestConfig(rotationLicenseMasterURI, "general"),
+		getRotationTestConfig(deployerURI, "general"),
+		getRotationTestConfig(fmt.Sprintf(searchHeadURI, 0), "general"),
+		getRotationTestConfig(fmt.Sprintf(searchHeadURI, 1), "general"),
+		getRotationTestConfig(fmt.Sprintf(searchHeadURI, 2), "general"),
+		standaloneConfig,
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+		getRotationTestRestart(rotationLicenseMasterURI),
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+	)
+	if got := getSearchHeadCluster().GetAnnotations()[rotationRestartHoldAnnotation]; got != rotationRestartHeld {
+		t.Errorf("rotateSplunkSecrets() search head cluster %s = %s; want %s", rotationRestartHoldAnnotation, got, rotationRestartHeld)
+	}
+	rotate("TestRotateSplunkSecretsClusterRestart(deployer)", true,
+		getRotationTestServerInfo(rotationLicenseMasterURI, 2),
+		getRotationTestServerInfo(deployerURI, 1),
+		getRotationTestRestart(deployerURI),
+		getRotationTestServerInfo(deployerURI, 1),
+	)
+
+	// held clusters do not restart to apply changes to their configuration
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "splunk-shc1-search-head",
+			Namespace:   "test",
+			Annotations: map[string]string{configChecksumAnnotation: "one"},
+		},
+	}
+	mgr := &mockRollingRestartManager{}
+	phase, err := updateRotatedStatefulSetConfig(c, getSearchHeadCluster(), []*appsv1.StatefulSet{statefulSet}, mgr, "two")
+	if phase != enterprisev1.PhaseReady || err != nil || mgr.restarts != 0 || statefulSet.GetAnnotations()[pendingConfigChecksumAnnotation] != "" {
+		t.Errorf("updateRotatedStatefulSetConfig(held) = %s, %v, %d restarts; want %s, nil, 0", phase, err, mgr.restarts, enterprisev1.PhaseReady)
+	}
+
+	// the search head cluster is released once the deployer has restarted
+	rotate("TestRotateSplunkSecretsClusterRestart(release)", true, getRotationTestServerInfo(deployerURI, 2))
+	if got := getSearchHeadCluster().GetAnnotations()[rotationRestartHoldAnnotation]; got != rotationRestartReleased {
+		t.Errorf("rotateSplunkSecrets() search head cluster %s = %s; want %s", rotationRestartHoldAnnotation, got, rotationRestartReleased)
+	}
+
+	// released clusters remove the hold once they have applied their configuration
+	statefulSet.Annotations[configChecksumAnnotation] = "two"
+	phase, err = updateRotatedStatefulSetConfig(c, getSearchHeadCluster(), []*appsv1.StatefulSet{statefulSet}, mgr, "two")
+	if phase != enterprisev1.PhaseReady || err != nil {
+		t.Errorf("updateRotatedStatefulSetConfig(released) = %s, %v; want %s, nil", phase, err, enterprisev1.PhaseReady)
+	}
+	if _, ok := getSearchHeadCluster().GetAnnotations()[rotationRestartHoldAnnotation]; ok {
+		t.Errorf("updateRotatedStatefulSetConfig(released) did not remove %s annotation", rotationRestartHoldAnnotation)
+	}
+
+	// remaining instances are restarted after the search head cluster
+	rotate("TestRotateSplunkSecretsClusterRestart(standalone)", true,
+		getRotationTestServerInfo(rotationStandaloneURI, 1),
+		getRotationTestRestart(rotationStandaloneURI),
+		getRotationTestServerInfo(rotationStandaloneURI, 1),
+	)
+	rotate("TestRotateSplunkSecretsClusterRestart(done)", false, getRotationTestServerInfo(rotationStandaloneURI, 2))
+}
+
+func TestRotateSplunkSecretsRollback(t *testing.T) {
+	c, lm, _ := newRotationTestClient(t)
+	lmSecrets := getRotationTestSecrets(t, c, "splunk-stack1-license-master-secrets")
+	oldPass4SymmKey := string(lmSecrets.Data["pass4SymmKey"])
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+
+	// changes are rolled back if any instance fails, and nothing is committed
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	wantRequest, _ := http.NewRequest("POST", rotationLicenseMasterURI+"/servicesNS/nobody/system/configs/conf-server/general", nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, "", nil)
+	wantRequest, _ = http.NewRequest("POST", rotationStandaloneURI+"/servicesNS/nobody/system/configs/conf-server/general", nil)
+	mockSplunkClient.AddHandler(wantRequest, 500, "", nil)
+	wantRequest, _ = http.NewRequest("POST", rotationLicenseMasterURI+"/servicesNS/nobody/system/configs/conf-server/general", nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, "", nil)
+	c.resetCalls()
+	pending, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if pending || err == nil {
+		t.Errorf("rotateSplunkSecrets() = %t, %v; want false, error", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecretsRollback")
+	if string(getRotationTestSecrets(t, c, "splunk-stack1-license-master-secrets").Data["pass4SymmKey"]) != oldPass4SymmKey {
+		t.Errorf("rotateSplunkSecrets() committed pass4SymmKey after failure")
+	}
+	if len(c.calls["Update"]) != 0 || len(c.calls["Patch"]) != 0 {
+		t.Errorf("rotateSplunkSecrets() updated resources after failure: %v", c.calls)
+	}
+	if len(lm.Status.Conditions) != 1 || lm.Status.Conditions[0].Reason != "RotationFailed" {
+		t.Errorf("rotateSplunkSecrets() conditions = %v; want SecretsRotated=False with reason RotationFailed", lm.Status.Conditions)
+	}
+}
+
+func TestRotateSplunkSecretsRejected(t *testing.T) {
+	c, lm, standalone := newRotationTestClient(t)
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = &spltest.MockHTTPClient{}
+		return c
+	}
+
+	// unknown secrets
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "hec_token"})
+	if _, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient); err == nil {
+		t.Errorf("rotateSplunkSecrets() returned nil; want error for hec_token")
+	}
+
+	// secrets copied from another resource must be rotated there
+	standalone.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	if _, err := rotateSplunkSecrets(c, standalone, &standalone.Status.Conditions, newSplunkClient); err == nil {
+		t.Errorf("rotateSplunkSecrets() returned nil; want error for copied pass4SymmKey")
+	}
+
+	// shared secrets provided by the user to dependent resources must match the new values
+	userSecrets := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password":     []byte("my-password"),
+			"pass4SymmKey": []byte("my-pass4SymmKey"),
+		},
+	}
+	c.state[getStateKey(&userSecrets)] = &userSecrets
+	standalone.Spec.SecretRef = "my-secrets"
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	if _, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient); err == nil {
+		t.Errorf("rotateSplunkSecrets() returned nil; want error for user-provided pass4SymmKey of standalone")
+	}
+
+	// rotation waits for all dependent resources to be ready
+	standalone.Spec.SecretRef = ""
+	standalone.Status.Phase = enterprisev1.PhaseUpdating
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	pending, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if !pending || err != nil {
+		t.Errorf("rotateSplunkSecrets() = %t, %v; want true, nil", pending, err)
+	}
+}
+
+func TestRotateSplunkSecretsFromSecretRef(t *testing.T) {
+	c, lm, standalone := newRotationTestClient(t)
+	userSecrets := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-secrets",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"password":     []byte("my-password"),
+			"pass4SymmKey": []byte("my-pass4SymmKey"),
+		},
+	}
+	c.state[getStateKey(&userSecrets)] = &userSecrets
+	lm.Spec.SecretRef = "my-secrets"
+	standalone.Spec.SecretRef = "my-secrets"
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+
+	// values provided by secretRef are used instead of new random values
+	lm.SetAnnotations(map[string]string{rotateSecretsAnnotation: "pass4SymmKey"})
+	newSplunkClient = newRotationTestSplunkClient(mockSplunkClient,
+		getRotationTestConfig(rotationLicenseMasterURI, "general"),
+		getRotationTestConfig(rotationStandaloneURI, "general"),
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+		getRotationTestRestart(rotationLicenseMasterURI),
+		getRotationTestServerInfo(rotationLicenseMasterURI, 1),
+	)
+	pending, err := rotateSplunkSecrets(c, lm, &lm.Status.Conditions, newSplunkClient)
+	if !pending || err != nil {
+		t.Fatalf("rotateSplunkSecrets() = %t, %v; want true, nil", pending, err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestRotateSplunkSecretsFromSecretRef")
+	for _, name := range []string{"splunk-stack1-license-master-secrets", "splunk-s1-standalone-secrets"} {
+		if got := string(getRotationTestSecrets(t, c, name).Data["pass4SymmKey"]); got != "my-pass4SymmKey" {
+			t.Errorf("rotateSplunkSecrets() %s pass4SymmKey = %s; want my-pass4SymmKey", name, got)
+		}
+	}
+}
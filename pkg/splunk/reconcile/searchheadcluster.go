@@ -16,6 +16,7 @@ package reconcile
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"time"
 
@@ -42,9 +43,20 @@ func ApplySearchHeadCluster(client ControllerClient, cr *enterprisev1.SearchHead
 	// validate and updates defaults for CR
 	err := enterprise.ValidateSearchHeadClusterSpec(&cr.Spec)
 	if err != nil {
+		recordEvent(cr, corev1.EventTypeWarning, eventReasonValidationFailed, "Invalid spec: %v", err)
 		return result, err
 	}
 
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			err := updatePausedSearchHeadClusterStatus(client, cr, scopedLog)
+			updateSearchHeadClusterMetrics(cr)
+			return err
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
 	// updates status after function completes
 	cr.Status.Phase = enterprisev1.PhaseError
 	cr.Status.DeployerPhase = enterprisev1.PhaseError
@@ -54,6 +66,8 @@ func ApplySearchHeadCluster(client ControllerClient, cr *enterprisev1.SearchHead
 		cr.Status.Members = []enterprisev1.SearchHeadClusterMemberStatus{}
 	}
 	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		updateSearchHeadClusterMetrics(cr)
 		err = client.Status().Update(context.TODO(), cr)
 		if err != nil {
 			scopedLog.Error(err, "Status update failed")
@@ -72,9 +86,20 @@ func ApplySearchHeadCluster(client ControllerClient, cr *enterprisev1.SearchHead
 		return result, err
 	}
 
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
+	if err != nil {
+		return result, err
+	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		cr.Status.DeployerPhase = enterprisev1.PhasePending
+		return result, nil
+	}
+
 	// create or update general config resources
 	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkSearchHead)
-	if err != nil {
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
 		return result, err
 	}
 
@@ -96,62 +121,132 @@ func ApplySearchHeadCluster(client ControllerClient, cr *enterprisev1.SearchHead
 		return result, err
 	}
 
+	// create or update resources used by the app framework to install apps on the deployer
+	if err = ApplyAppFramework(client, cr, &cr.Spec.CommonSplunkSpec, enterprise.SplunkDeployer, cr.Spec.AppSources); err != nil {
+		return result, err
+	}
+
 	// create or update statefulset for the deployer
 	statefulSet, err := enterprise.GetDeployerStatefulSet(cr)
 	if err != nil {
 		return result, err
 	}
-	deployerManager := DefaultStatefulSetPodManager{}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the deployer
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return result, err
+	}
+	deployerManager := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
 	phase, err := deployerManager.Update(client, statefulSet, 1)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.DeployerPhase = phase
 
+	// update status of apps installed by the app framework on the deployer
+	appsInstalled := updateAppFrameworkStatus(client, cr, cr.GetGeneration(), cr.Spec.AppSources, statefulSet, &cr.Status.Apps, &cr.Status.Conditions, splclient.NewAppFrameworkClient)
+
 	// create or update statefulset for the search heads
 	statefulSet, err = enterprise.GetSearchHeadStatefulSet(cr)
 	if err != nil {
 		return result, err
 	}
-	mgr := SearchHeadClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, newSplunkClient: splclient.NewSplunkClient}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the search heads
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, enterprise.GetSearchHeadMaxUnavailable(cr.Spec.Replicas)))
+	if err != nil {
+		return result, err
+	}
+	tlsConfig, err := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if err != nil {
+		return result, err
+	}
+	mgr := SearchHeadClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, tlsConfig: tlsConfig, newSplunkClient: splclient.NewSplunkClient}
 	phase, err = mgr.Update(client, statefulSet, cr.Spec.Replicas)
+	setCaptainReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.CaptainReady, cr.Status.Captain)
 	if err != nil {
 		return result, err
 	}
 	cr.Status.Phase = phase
 
-	// no need to requeue if everything is ready
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
 	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// no need to requeue if everything is ready
+	if cr.Status.Phase == enterprisev1.PhaseReady && appsInstalled && !rotationPending {
 		result.Requeue = false
 	}
 	return result, nil
 }
 
+// updatePausedSearchHeadClusterStatus updates the status of a SearchHeadCluster resource while its reconciliation is paused
+func updatePausedSearchHeadClusterStatus(client ControllerClient, cr *enterprisev1.SearchHeadCluster, scopedLog logr.Logger) error {
+	secrets, err := getCurrentSplunkSecrets(client, cr, enterprise.SplunkSearchHead)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := getSplunkTLSConfig(client, cr, &cr.Spec.CommonSplunkSpec, secrets)
+	if err != nil {
+		return err
+	}
+	statefulSet, err := getCurrentStatefulSet(client, cr.GetNamespace(), enterprise.GetSplunkStatefulsetName(enterprise.SplunkSearchHead, cr.GetIdentifier()))
+	if err != nil {
+		return err
+	}
+	mgr := SearchHeadClusterPodManager{log: scopedLog, cr: cr, secrets: secrets, tlsConfig: tlsConfig, newSplunkClient: splclient.NewSplunkClient}
+	return mgr.updateStatus(statefulSet)
+}
+
 // SearchHeadClusterPodManager is used to manage the pods within a search head cluster
 type SearchHeadClusterPodManager struct {
 	log             logr.Logger
 	cr              *enterprisev1.SearchHeadCluster
 	secrets         *corev1.Secret
-	newSplunkClient func(managementURI, username, password string) *splclient.SplunkClient
+	tlsConfig       *tls.Config
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient
 }
 
 // Update for SearchHeadClusterPodManager handles all updates for a statefulset of search heads
 func (mgr *SearchHeadClusterPodManager) Update(c ControllerClient, statefulSet *appsv1.StatefulSet, desiredReplicas int32) (enterprisev1.ResourcePhase, error) {
 	// update statefulset, if necessary
-	_, err := ApplyStatefulSet(c, statefulSet)
+	_, err := ApplyStatefulSet(c, statefulSet, &mgr.cr.Status.Volumes)
 	if err != nil {
 		return enterprisev1.PhaseError, err
 	}
 
 	// update CR status with SHC information
 	err = mgr.updateStatus(statefulSet)
-	if err != nil || mgr.cr.Status.ReadyReplicas == 0 || !mgr.cr.Status.Initialized || !mgr.cr.Status.CaptainReady {
+	clusterReady := err == nil && mgr.cr.Status.ReadyReplicas > 0 && mgr.cr.Status.Initialized && mgr.cr.Status.CaptainReady
+	setScalingBlockedCondition(&mgr.cr.Status.Conditions, mgr.cr.GetGeneration(), statefulSet, desiredReplicas, clusterReady, "Waiting for search head cluster to become ready")
+	if !clusterReady {
 		mgr.log.Error(err, "Search head cluster is not ready")
 		return enterprisev1.PhasePending, nil
 	}
 
+	// wait for the captain to finish restarting the members
+	if mgr.cr.Status.RollingRestart {
+		return enterprisev1.PhaseUpdating, nil
+	}
+
 	// manage scaling and updates
-	return UpdateStatefulSetPods(c, statefulSet, mgr, desiredReplicas)
+	phase, err := UpdateStatefulSetPods(c, statefulSet, mgr, desiredReplicas, mgr.cr.Spec.VolumeReclaimPolicy, getRetainedVolumeGracePeriod(&mgr.cr.Spec.CommonSplunkSpec))
+	if err != nil || phase != enterprisev1.PhaseReady {
+		return phase, err
+	}
+
+	// apply changes to restart-only configuration with a rolling restart of the members
+	return updateRotatedStatefulSetConfig(c, mgr.cr, []*appsv1.StatefulSet{statefulSet}, mgr, getRestartConfigChecksum(mgr.secrets))
 }
 
 // PrepareScaleDown for SearchHeadClusterPodManager prepares search head pod to be removed via scale down event; it returns true when ready
@@ -170,6 +265,7 @@ func (mgr *SearchHeadClusterPodManager) PrepareScaleDown(n int32) (bool, error)
 	if err != nil {
 		return false, err
 	}
+	recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMemberRemoved, "Removed member %s from search head cluster", memberName)
 
 	// all done -> ok to scale down the statefulset
 	return true, nil
@@ -184,7 +280,11 @@ func (mgr *SearchHeadClusterPodManager) PrepareRecycle(n int32) (bool, error) {
 		// Detain search head
 		mgr.log.Info("Detaining search head cluster member", "memberName", memberName)
 		c := mgr.getClient(n)
-		return false, c.SetSearchHeadDetention(true)
+		if err := c.SetSearchHeadDetention(true); err != nil {
+			return false, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMemberDetained, "Detained member %s to drain active searches", memberName)
+		return false, nil
 
 	case "ManualDetention":
 		// Wait until active searches have drained
@@ -218,26 +318,52 @@ func (mgr *SearchHeadClusterPodManager) FinishRecycle(n int32) (bool, error) {
 		// release from detention
 		mgr.log.Info("Releasing search head cluster member from detention", "memberName", memberName)
 		c := mgr.getClient(n)
-		return false, c.SetSearchHeadDetention(false)
+		if err := c.SetSearchHeadDetention(false); err != nil {
+			return false, err
+		}
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonMemberReleased, "Released member %s from detention", memberName)
+		return false, nil
 	}
 
 	// unhandled status
 	return false, fmt.Errorf("Status=%s", mgr.cr.Status.Members[n].Status)
 }
 
+// RollingRestart for SearchHeadClusterPodManager starts a rolling restart of all search head cluster members
+func (mgr *SearchHeadClusterPodManager) RollingRestart() error {
+	for n, member := range mgr.cr.Status.Members {
+		if member.Name == mgr.cr.Status.Captain {
+			mgr.log.Info("Starting rolling restart of search head cluster members", "captain", member.Name)
+			if err := mgr.getClient(int32(n)).RollingRestartSearchHeadCluster(); err != nil {
+				return err
+			}
+			mgr.cr.Status.RollingRestart = true
+			return nil
+		}
+	}
+	return fmt.Errorf("Unable to find search head cluster captain %s", mgr.cr.Status.Captain)
+}
+
+// IsRollingRestart for SearchHeadClusterPodManager returns true while the captain is restarting the members
+func (mgr *SearchHeadClusterPodManager) IsRollingRestart() bool {
+	return mgr.cr.Status.RollingRestart
+}
+
 // getClient for SearchHeadClusterPodManager returns a SplunkClient for the member n
 func (mgr *SearchHeadClusterPodManager) getClient(n int32) *splclient.SplunkClient {
 	memberName := enterprise.GetSplunkStatefulsetPodName(enterprise.SplunkSearchHead, mgr.cr.GetIdentifier(), n)
 	fqdnName := resources.GetServiceFQDN(mgr.cr.GetNamespace(),
 		fmt.Sprintf("%s.%s", memberName, enterprise.GetSplunkServiceName(enterprise.SplunkSearchHead, mgr.cr.GetIdentifier(), true)))
-	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]))
+	return mgr.newSplunkClient(fmt.Sprintf("https://%s:8089", fqdnName), "admin", string(mgr.secrets.Data["password"]), mgr.tlsConfig)
 }
 
 // updateStatus for SearchHeadClusterPodManager uses the REST API to update the status for a SearcHead custom resource
 func (mgr *SearchHeadClusterPodManager) updateStatus(statefulSet *appsv1.StatefulSet) error {
 	// populate members status using REST API to get search head cluster member info
+	previousCaptain := mgr.cr.Status.Captain
 	mgr.cr.Status.Captain = ""
 	mgr.cr.Status.CaptainReady = false
+	mgr.cr.Status.RollingRestart = false
 	mgr.cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
 	if mgr.cr.Status.ReadyReplicas == 0 {
 		return nil
@@ -267,6 +393,7 @@ func (mgr *SearchHeadClusterPodManager) updateStatus(statefulSet *appsv1.Statefu
 				mgr.cr.Status.Initialized = captainInfo.Initialized
 				mgr.cr.Status.MinPeersJoined = captainInfo.MinPeersJoined
 				mgr.cr.Status.MaintenanceMode = captainInfo.MaintenanceMode
+				mgr.cr.Status.RollingRestart = captainInfo.RollingRestart
 				gotCaptainInfo = true
 			} else {
 				mgr.log.Error(err, "Unable to retrieve captain info", "memberName", memberName)
@@ -285,5 +412,12 @@ func (mgr *SearchHeadClusterPodManager) updateStatus(statefulSet *appsv1.Statefu
 		mgr.cr.Status.Members = mgr.cr.Status.Members[:statefulSet.Status.Replicas]
 	}
 
+	// report changes of captain
+	if previousCaptain != "" && mgr.cr.Status.Captain == "" {
+		recordEvent(mgr.cr, corev1.EventTypeWarning, eventReasonCaptainLost, "Unable to find search head cluster captain, previously %s", previousCaptain)
+	} else if previousCaptain != mgr.cr.Status.Captain {
+		recordEvent(mgr.cr, corev1.EventTypeNormal, eventReasonCaptainChanged, "Search head cluster captain is %s", mgr.cr.Status.Captain)
+	}
+
 	return nil
 }
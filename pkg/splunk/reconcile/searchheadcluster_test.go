@@ -15,6 +15,8 @@
 package reconcile
 
 import (
+	"crypto/tls"
+	"net/http"
 	"strings"
 	"testing"
 	"time"
@@ -35,11 +37,16 @@ func TestApplySearchHeadCluster(t *testing.T) {
 		{metaName: "*v1.Service-test-splunk-stack1-search-head-headless"},
 		{metaName: "*v1.Service-test-splunk-stack1-search-head-service"},
 		{metaName: "*v1.Service-test-splunk-stack1-deployer-service"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-deployer-app-framework"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-deployer-app-repository"},
+		{metaName: "*v1.Service-test-splunk-stack1-deployer-app-repository"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-deployer"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-deployer"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-search-head"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-search-head"},
 	}
-	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[4], funcCalls[5]}}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[0], funcCalls[1], funcCalls[2], funcCalls[3], funcCalls[4], funcCalls[7], funcCalls[8], funcCalls[9], funcCalls[10]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[8], funcCalls[10]}}
 	statefulSet := enterprisev1.SearchHeadCluster{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "SearchHeadCluster",
@@ -98,8 +105,8 @@ func searchHeadClusterPodManagerTester(t *testing.T, method string, mockHandlers
 		log:     scopedLog,
 		cr:      &cr,
 		secrets: secrets,
-		newSplunkClient: func(managementURI, username, password string) *splclient.SplunkClient {
-			c := splclient.NewSplunkClient(managementURI, username, password)
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
 			c.Client = mockSplunkClient
 			return c
 		},
@@ -112,11 +119,13 @@ func TestSearchHeadClusterPodManager(t *testing.T) {
 	var replicas int32 = 1
 	statefulSet := &appsv1.StatefulSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "splunk-stack1",
-			Namespace: "test",
+			Name:        "splunk-stack1",
+			Namespace:   "test",
+			Annotations: map[string]string{configChecksumAnnotation: getRestartConfigChecksum(&corev1.Secret{})},
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: &replicas,
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "pvc-etc"}}, {ObjectMeta: metav1.ObjectMeta{Name: "pvc-var"}}},
 		},
 		Status: appsv1.StatefulSetStatus{
 			Replicas:        replicas,
@@ -251,3 +260,52 @@ func TestSearchHeadClusterPodManager(t *testing.T) {
 	method = "SearchHeadClusterPodManager.Update(Remove Member)"
 	searchHeadClusterPodManagerTester(t, method, mockHandlers, 1, enterprisev1.PhaseScalingDown, statefulSet, wantCalls, nil, statefulSet, pod, pvcList[0], pvcList[1])
 }
+
+func TestSearchHeadClusterRollingRestart(t *testing.T) {
+	cr := enterprisev1.SearchHeadCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Status: enterprisev1.SearchHeadClusterStatus{
+			Captain: "splunk-stack1-search-head-1",
+			Members: []enterprisev1.SearchHeadClusterMemberStatus{
+				{Name: "splunk-stack1-search-head-0"},
+				{Name: "splunk-stack1-search-head-1"},
+			},
+		},
+	}
+	secrets := &corev1.Secret{
+		Data: map[string][]byte{
+			"password": []byte{'1', '2', '3'},
+		},
+	}
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	mgr := &SearchHeadClusterPodManager{
+		log:     log.WithName("TestSearchHeadClusterRollingRestart"),
+		cr:      &cr,
+		secrets: secrets,
+		newSplunkClient: func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+			c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+			c.Client = mockSplunkClient
+			return c
+		},
+	}
+
+	// rolling restart is started on the captain
+	restartRequest, _ := http.NewRequest("POST", "https://splunk-stack1-search-head-1.splunk-stack1-search-head-headless.test.svc.cluster.local:8089/services/shcluster/captain/control/control/restart", nil)
+	mockSplunkClient.AddHandler(restartRequest, 200, "", nil)
+	if err := mgr.RollingRestart(); err != nil {
+		t.Errorf("RollingRestart() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestSearchHeadClusterRollingRestart")
+	if !mgr.IsRollingRestart() {
+		t.Errorf("IsRollingRestart() = false after RollingRestart(); want true")
+	}
+
+	// unknown captain
+	cr.Status.Captain = "splunk-stack1-search-head-2"
+	if err := mgr.RollingRestart(); err == nil {
+		t.Errorf("RollingRestart() with unknown captain returned nil; want error")
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// ApplySmartStoreConfig creates or updates the generated defaults used to configure SmartStore for a Splunk Enterprise resource.
+func ApplySmartStoreConfig(client ControllerClient, cr enterprisev1.MetaObject, spec *enterprisev1.SmartStoreSpec, instanceType enterprise.InstanceType) error {
+	if len(spec.Volumes) == 0 {
+		return nil
+	}
+
+	// retrieve credentials for remote storage volumes
+	volumeSecrets := make(map[string]*corev1.Secret)
+	for _, volume := range spec.Volumes {
+		if volume.SecretRef == "" || volumeSecrets[volume.SecretRef] != nil {
+			continue
+		}
+		namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: volume.SecretRef}
+		var secret corev1.Secret
+		err := client.Get(context.TODO(), namespacedName, &secret)
+		if err != nil {
+			return fmt.Errorf("Unable to get secret %s for SmartStore volume %s: %v", volume.SecretRef, volume.Name, err)
+		}
+		volumeSecrets[volume.SecretRef] = &secret
+	}
+
+	defaults, err := enterprise.GetSplunkSmartStoreDefaults(cr, instanceType, spec, volumeSecrets)
+	if err != nil {
+		return err
+	}
+	defaults.SetOwnerReferences(append(defaults.GetOwnerReferences(), resources.AsOwner(cr)))
+	return ApplyGeneratedSecret(client, defaults)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+)
+
+func TestApplySmartStoreConfig(t *testing.T) {
+	funcCalls := []mockFuncCall{
+		{metaName: "*v1.Secret-test-s3-creds"},
+		{metaName: "*v1.Secret-test-splunk-stack1-standalone-smartstore"},
+	}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": {funcCalls[1]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": {funcCalls[1]}}
+	current := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.StandaloneSpec{
+			SmartStore: enterprisev1.SmartStoreSpec{
+				Volumes: []enterprisev1.SmartStoreVolume{
+					{Name: "s3", Endpoint: "http://minio:9000", Path: "smartstore", SecretRef: "s3-creds"},
+				},
+				Indexes: []enterprisev1.SmartStoreIndex{
+					{Name: "main", VolumeName: "s3", RemotePath: "main"},
+				},
+			},
+		},
+	}
+	revised := current.DeepCopy()
+	revised.Spec.SmartStore.Volumes[0].Endpoint = "http://minio.minio:9000"
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "s3-creds",
+			Namespace: "test",
+		},
+		Data: map[string][]byte{
+			"s3_access_key": []byte("access"),
+			"s3_secret_key": []byte("secret"),
+		},
+	}
+	reconcile := func(c *mockClient, cr interface{}) error {
+		obj := cr.(*enterprisev1.Standalone)
+		return ApplySmartStoreConfig(c, obj, &obj.Spec.SmartStore, enterprise.SplunkStandalone)
+	}
+	reconcileTester(t, "TestApplySmartStoreConfig", &current, revised, createCalls, updateCalls, reconcile, &secret)
+
+	// missing secret returns an error
+	c := newMockClient()
+	if err := ApplySmartStoreConfig(c, &current, &current.Spec.SmartStore, enterprise.SplunkStandalone); err == nil {
+		t.Errorf("ApplySmartStoreConfig() did not return error for missing secret")
+	}
+
+	// nothing to do without volumes
+	c = newMockClient()
+	current.Spec.SmartStore = enterprisev1.SmartStoreSpec{}
+	if err := ApplySmartStoreConfig(c, &current, &current.Spec.SmartStore, enterprise.SplunkStandalone); err != nil {
+		t.Errorf("ApplySmartStoreConfig() returned error: %v", err)
+	}
+	c.checkCalls(t, "TestApplySmartStoreConfig(none)", map[string][]mockFuncCall{})
+}
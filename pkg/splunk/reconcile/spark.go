@@ -17,14 +17,30 @@ package reconcile
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
 	"github.com/splunk/splunk-operator/pkg/splunk/spark"
 )
 
+const (
+	// drainStartedAnnotation records when the operator started to drain a spark worker pod, before removing or recycling it
+	drainStartedAnnotation = "enterprise.splunk.com/drain-started-at"
+
+	// defaultSparkWorkerDrainTimeout is how long to wait for executors to finish, unless drainTimeoutSeconds is specified
+	defaultSparkWorkerDrainTimeout = 10 * time.Minute
+)
+
 // ApplySpark reconciles the Deployments and Services for a Spark cluster.
 func ApplySpark(client ControllerClient, cr *enterprisev1.Spark) (reconcile.Result, error) {
 
@@ -40,11 +56,20 @@ func ApplySpark(client ControllerClient, cr *enterprisev1.Spark) (reconcile.Resu
 		return result, err
 	}
 
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			return updatePausedSparkStatus(client, cr, splclient.NewSparkClient)
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
 	// updates status after function completes
 	cr.Status.Phase = enterprisev1.PhaseError
 	cr.Status.Replicas = cr.Spec.Replicas
 	cr.Status.Selector = fmt.Sprintf("app.kubernetes.io/instance=splunk-%s-spark-worker", cr.GetIdentifier())
 	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
 		client.Status().Update(context.TODO(), cr)
 	}()
 
@@ -79,16 +104,34 @@ func ApplySpark(client ControllerClient, cr *enterprisev1.Spark) (reconcile.Resu
 	cr.Status.MasterPhase, err = ApplyDeployment(client, deployment)
 	if err != nil {
 		cr.Status.MasterPhase = enterprisev1.PhaseError
+	}
+	setPhaseCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionSparkMasterReady, cr.Status.MasterPhase)
+	if err != nil {
 		return result, err
 	}
 
-	// create or update deployment for spark worker
-	deployment, err = spark.GetSparkDeployment(cr, spark.SparkWorker)
+	// create or update statefulset for spark workers
+	statefulSet, err := spark.GetSparkStatefulSet(cr, spark.SparkWorker)
 	if err != nil {
 		return result, err
 	}
-	cr.Status.Phase, err = ApplyDeployment(client, deployment)
-	cr.Status.ReadyReplicas = deployment.Status.ReadyReplicas
+	mgr := SparkWorkerPodManager{
+		log:            log.WithName("ApplySpark").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace()),
+		cr:             cr,
+		client:         client,
+		newSparkClient: splclient.NewSparkClient,
+	}
+	cr.Status.Phase, err = mgr.Update(client, statefulSet, int32(cr.Spec.Replicas))
+	cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+
+	// remove the deployment used for spark workers by previous versions of the operator, once the workers of the
+	// statefulset are ready to replace them
+	if err == nil && cr.Status.Phase == enterprisev1.PhaseReady {
+		var removed bool
+		if removed, err = mgr.removeWorkerDeployment(client); err == nil && !removed {
+			cr.Status.Phase = enterprisev1.PhaseUpdating
+		}
+	}
 	if err != nil {
 		cr.Status.Phase = enterprisev1.PhaseError
 	} else if cr.Status.Phase == enterprisev1.PhaseReady {
@@ -96,3 +139,216 @@ func ApplySpark(client ControllerClient, cr *enterprisev1.Spark) (reconcile.Resu
 	}
 	return result, err
 }
+
+// updatePausedSparkStatus updates the status of a Spark resource while its reconciliation is paused
+func updatePausedSparkStatus(client ControllerClient, cr *enterprisev1.Spark, newSparkClient func(masterURI string) *splclient.SparkClient) error {
+	statefulSet, err := getCurrentStatefulSet(client, cr.GetNamespace(), spark.GetSparkStatefulsetName(spark.SparkWorker, cr.GetIdentifier()))
+	if err != nil {
+		return err
+	}
+	cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	if _, err = updateSparkMasterStatus(cr, newSparkClient); err != nil {
+		log.Error(err, "Unable to retrieve spark master status", "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	}
+	return nil
+}
+
+// getSparkMasterClient returns a client for the web interface of the spark master of a Spark resource
+func getSparkMasterClient(cr *enterprisev1.Spark, newSparkClient func(masterURI string) *splclient.SparkClient) *splclient.SparkClient {
+	fqdnName := resources.GetServiceFQDN(cr.GetNamespace(), spark.GetSparkServiceName(spark.SparkMaster, cr.GetIdentifier(), false))
+	return newSparkClient(fmt.Sprintf("http://%s:8009", fqdnName))
+}
+
+// updateSparkMasterStatus updates the status of a Spark resource with the workers and applications reported by
+// the spark master, and returns the status reported by the master. The previous status is kept if the spark master
+// cannot be reached.
+func updateSparkMasterStatus(cr *enterprisev1.Spark, newSparkClient func(masterURI string) *splclient.SparkClient) (*splclient.SparkMasterStatus, error) {
+	c := getSparkMasterClient(cr, newSparkClient)
+	masterStatus, err := c.GetMasterStatus()
+	if err != nil {
+		return nil, err
+	}
+	cr.Status.AliveWorkers = int32(masterStatus.AliveWorkers)
+	cr.Status.Cores = int32(masterStatus.Cores)
+	cr.Status.CoresUsed = int32(masterStatus.CoresUsed)
+	cr.Status.Memory = masterStatus.Memory
+	cr.Status.MemoryUsed = masterStatus.MemoryUsed
+	cr.Status.ActiveApps = int32(len(masterStatus.ActiveApps))
+	cr.Status.CompletedApps = int32(len(masterStatus.CompletedApps))
+	return masterStatus, nil
+}
+
+// SparkWorkerPodManager is used to manage the pods of spark workers
+type SparkWorkerPodManager struct {
+	log            logr.Logger
+	cr             *enterprisev1.Spark
+	client         ControllerClient
+	newSparkClient func(masterURI string) *splclient.SparkClient
+
+	// name of the statefulset used for the spark workers
+	statefulSetName string
+
+	// status of the workers and applications reported by the spark master
+	masterStatus *splclient.SparkMasterStatus
+}
+
+// Update for SparkWorkerPodManager handles all updates for a statefulset of spark workers
+func (mgr *SparkWorkerPodManager) Update(c ControllerClient, statefulSet *appsv1.StatefulSet, desiredReplicas int32) (enterprisev1.ResourcePhase, error) {
+	// update statefulset, if necessary
+	phase, err := ApplyStatefulSet(c, statefulSet, nil)
+	if err != nil || phase != enterprisev1.PhaseReady {
+		return phase, err
+	}
+	mgr.statefulSetName = statefulSet.GetName()
+
+	// update CR status with workers and applications registered with the master
+	if mgr.cr.Status.MasterPhase == enterprisev1.PhaseReady {
+		mgr.masterStatus, err = updateSparkMasterStatus(mgr.cr, mgr.newSparkClient)
+	} else {
+		err = fmt.Errorf("Waiting for spark master to become ready")
+	}
+	masterReady := err == nil
+	setScalingBlockedCondition(&mgr.cr.Status.Conditions, mgr.cr.GetGeneration(), statefulSet, desiredReplicas, masterReady, "Waiting for spark master to become ready")
+	if !masterReady {
+		mgr.log.Info("Unable to retrieve spark master status", "error", err.Error())
+		return enterprisev1.PhasePending, nil
+	}
+
+	// manage scaling and updates
+	return UpdateStatefulSetPods(c, statefulSet, mgr, desiredReplicas, enterprisev1.VolumeReclaimDelete, defaultRetainedVolumeGracePeriod)
+}
+
+// PrepareScaleDown for SparkWorkerPodManager drains a spark worker before it is removed via scale down event;
+// it returns true when ready
+func (mgr *SparkWorkerPodManager) PrepareScaleDown(n int32) (bool, error) {
+	return mgr.drain(n)
+}
+
+// PrepareRecycle for SparkWorkerPodManager drains a spark worker before it is recycled for updates; it returns
+// true when ready
+func (mgr *SparkWorkerPodManager) PrepareRecycle(n int32) (bool, error) {
+	return mgr.drain(n)
+}
+
+// FinishRecycle for SparkWorkerPodManager does nothing and returns true, since workers register with the master
+// again when they start
+func (mgr *SparkWorkerPodManager) FinishRecycle(n int32) (bool, error) {
+	return true, nil
+}
+
+// drain for SparkWorkerPodManager decommissions a spark worker, so that no new executors are launched on it, and
+// returns true once no executors are running on it. Workers that are still busy after the drain timeout are removed
+// anyway, which kills their executors.
+func (mgr *SparkWorkerPodManager) drain(n int32) (bool, error) {
+	var pod corev1.Pod
+	namespacedName := types.NamespacedName{Namespace: mgr.cr.GetNamespace(), Name: fmt.Sprintf("%s-%d", mgr.statefulSetName, n)}
+	if err := mgr.client.Get(context.TODO(), namespacedName, &pod); err != nil {
+		return false, err
+	}
+	return mgr.drainPod(&pod)
+}
+
+// drainPod for SparkWorkerPodManager drains the spark worker running in a pod; it returns true when ready
+func (mgr *SparkWorkerPodManager) drainPod(pod *corev1.Pod) (bool, error) {
+	podName := pod.GetName()
+	worker := mgr.getWorker(pod)
+	if worker == nil || (worker.State != "ALIVE" && worker.State != "DECOMMISSIONED") {
+		// worker is not registered with the master, so it cannot be running any executors
+		return true, nil
+	}
+	if worker.CoresUsed == 0 {
+		mgr.log.Info("No executors are running on spark worker", "podName", podName)
+		return true, nil
+	}
+
+	// decommission the worker when draining starts, and record when that happened
+	started, ok := pod.GetAnnotations()[drainStartedAnnotation]
+	if !ok {
+		if worker.State == "ALIVE" {
+			if err := getSparkMasterClient(mgr.cr, mgr.newSparkClient).DecommissionWorker(worker.Host); err != nil {
+				// older versions of spark, or masters that do not allow it, cannot decommission workers
+				mgr.log.Info("Unable to decommission spark worker", "podName", podName, "error", err.Error())
+			}
+		}
+		annotations := pod.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[drainStartedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+		pod.SetAnnotations(annotations)
+		mgr.log.Info("Draining spark worker", "podName", podName, "coresUsed", worker.CoresUsed)
+		return false, mgr.client.Update(context.TODO(), pod)
+	}
+
+	// stop waiting after the drain timeout
+	timeout := defaultSparkWorkerDrainTimeout
+	if mgr.cr.Spec.Worker.DrainTimeoutSeconds > 0 {
+		timeout = time.Duration(mgr.cr.Spec.Worker.DrainTimeoutSeconds) * time.Second
+	}
+	startedAt, err := time.Parse(time.RFC3339, started)
+	if err != nil || time.Since(startedAt) > timeout {
+		mgr.log.Info("Timed out waiting for executors running on spark worker to finish", "podName", podName, "coresUsed", worker.CoresUsed)
+		recordEvent(mgr.cr, corev1.EventTypeWarning, eventReasonDrainTimedOut, "Removing spark worker %s with %d cores still in use after waiting %s for its executors to finish", podName, worker.CoresUsed, timeout)
+		return true, nil
+	}
+	mgr.log.Info("Waiting for executors running on spark worker to finish", "podName", podName, "coresUsed", worker.CoresUsed)
+	return false, nil
+}
+
+// removeWorkerDeployment for SparkWorkerPodManager removes the Deployment that was used for spark workers before they
+// were managed using a StatefulSet, if it exists. Its workers are decommissioned and drained first, in the same way as
+// the workers of the StatefulSet, so that their executors can finish. It returns true once the Deployment is removed.
+func (mgr *SparkWorkerPodManager) removeWorkerDeployment(c ControllerClient) (bool, error) {
+	var deployment appsv1.Deployment
+	namespacedName := types.NamespacedName{Namespace: mgr.cr.GetNamespace(), Name: spark.GetSparkDeploymentName(spark.SparkWorker, mgr.cr.GetIdentifier())}
+	if err := c.Get(context.TODO(), namespacedName, &deployment); err != nil {
+		// no deployment to remove
+		return true, nil
+	}
+
+	// pods of the deployment share the labels of the statefulset, but are owned by its replica sets
+	var pods corev1.PodList
+	if err := c.List(context.TODO(), &pods, client.InNamespace(deployment.GetNamespace())); err != nil {
+		return false, err
+	}
+	drained := true
+	for idx := range pods.Items {
+		if !isOwnedByDeployment(&pods.Items[idx], deployment.GetName()) {
+			continue
+		}
+		ready, err := mgr.drainPod(&pods.Items[idx])
+		if err != nil {
+			return false, err
+		}
+		drained = drained && ready
+	}
+	if !drained {
+		return false, nil
+	}
+
+	mgr.log.Info("Removing spark worker Deployment", "name", deployment.GetName())
+	return true, c.Delete(context.TODO(), &deployment)
+}
+
+// isOwnedByDeployment returns true if a pod belongs to a replica set of the named Deployment
+func isOwnedByDeployment(pod *corev1.Pod, deploymentName string) bool {
+	for _, owner := range pod.GetOwnerReferences() {
+		if owner.Kind == "ReplicaSet" && strings.HasPrefix(owner.Name, deploymentName+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// getWorker for SparkWorkerPodManager returns the spark worker registered with the master for a pod, or nil
+// if it is not registered
+func (mgr *SparkWorkerPodManager) getWorker(pod *corev1.Pod) *splclient.SparkWorkerInfo {
+	// workers register using either the address or the host name of their pod
+	podName := pod.GetName()
+	for idx, worker := range mgr.masterStatus.Workers {
+		if (pod.Status.PodIP != "" && worker.Host == pod.Status.PodIP) || worker.Host == podName || strings.HasPrefix(worker.Host, podName+".") {
+			return &mgr.masterStatus.Workers[idx]
+		}
+	}
+	return nil
+}
@@ -15,12 +15,17 @@
 package reconcile
 
 import (
+	"net/http"
 	"testing"
 	"time"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
 )
 
 func TestApplySpark(t *testing.T) {
@@ -28,7 +33,7 @@ func TestApplySpark(t *testing.T) {
 		{metaName: "*v1.Service-test-splunk-stack1-spark-master-service"},
 		{metaName: "*v1.Service-test-splunk-stack1-spark-worker-headless"},
 		{metaName: "*v1.Deployment-test-splunk-stack1-spark-master"},
-		{metaName: "*v1.Deployment-test-splunk-stack1-spark-worker"},
+		{metaName: "*v1.StatefulSet-test-splunk-stack1-spark-worker"},
 	}
 	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
 	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[2], funcCalls[3]}}
@@ -59,3 +64,213 @@ func TestApplySpark(t *testing.T) {
 	}
 	splunkDeletionTester(t, revised, deleteFunc)
 }
+
+func TestUpdateSparkMasterStatus(t *testing.T) {
+	cr := enterprisev1.Spark{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+
+	test := func(status int, body string, want enterprisev1.SparkStatus) {
+		mockHTTPClient := &spltest.MockHTTPClient{}
+		wantRequest, _ := http.NewRequest("GET", "http://splunk-stack1-spark-master-service.test.svc.cluster.local:8009/json/", nil)
+		mockHTTPClient.AddHandler(wantRequest, status, body, nil)
+		newClient := func(masterURI string) *splclient.SparkClient {
+			sparkClient := splclient.NewSparkClient(masterURI)
+			sparkClient.Client = mockHTTPClient
+			return sparkClient
+		}
+		_, err := updateSparkMasterStatus(&cr, newClient)
+		if (err != nil) != (status != 200) {
+			t.Errorf("updateSparkMasterStatus() err = %v; want error %t", err, status != 200)
+		}
+		mockHTTPClient.CheckRequests(t, "TestUpdateSparkMasterStatus")
+		got := cr.Status
+		if got.AliveWorkers != want.AliveWorkers || got.Cores != want.Cores || got.CoresUsed != want.CoresUsed ||
+			got.Memory != want.Memory || got.MemoryUsed != want.MemoryUsed || got.ActiveApps != want.ActiveApps || got.CompletedApps != want.CompletedApps {
+			t.Errorf("updateSparkMasterStatus() status = %v; want %v", got, want)
+		}
+	}
+
+	body := `{"url":"spark://splunk-stack1-spark-master-service:7777","workers":[{"id":"worker-1","host":"10.0.0.1","port":7777,"cores":4,"coresused":2,"memory":6144,"memoryused":1024,"state":"ALIVE"}],` +
+		`"aliveworkers":1,"cores":4,"coresused":2,"memory":6144,"memoryused":1024,"activeapps":[{"id":"app-1","name":"dsp-job","state":"RUNNING"}],` +
+		`"completedapps":[{"id":"app-0","name":"dsp-test","state":"FINISHED"},{"id":"app-2","name":"dsp-test","state":"FAILED"}],"status":"ALIVE"}`
+	want := enterprisev1.SparkStatus{AliveWorkers: 1, Cores: 4, CoresUsed: 2, Memory: 6144, MemoryUsed: 1024, ActiveApps: 1, CompletedApps: 2}
+	test(200, body, want)
+
+	// previous status is kept if the spark master cannot be reached
+	test(500, "", want)
+}
+
+func TestSparkWorkerPodManagerDrain(t *testing.T) {
+	cr := enterprisev1.Spark{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-spark-worker-1",
+			Namespace: "test",
+		},
+		Status: corev1.PodStatus{PodIP: "10.0.0.2"},
+	}
+	c := newMockClient()
+	c.state[getStateKey(pod)] = pod
+
+	decommissionRequest, _ := http.NewRequest("POST", "http://splunk-stack1-spark-master-service.test.svc.cluster.local:8009/workers/kill/", nil)
+	test := func(prepare string, workers []splclient.SparkWorkerInfo, want bool, wantDecommission bool) {
+		mockHTTPClient := &spltest.MockHTTPClient{}
+		if wantDecommission {
+			mockHTTPClient.AddHandler(decommissionRequest, 200, "", nil)
+		}
+		mgr := SparkWorkerPodManager{
+			log:    log.WithName("TestSparkWorkerPodManagerDrain"),
+			cr:     &cr,
+			client: c,
+			newSparkClient: func(masterURI string) *splclient.SparkClient {
+				sparkClient := splclient.NewSparkClient(masterURI)
+				sparkClient.Client = mockHTTPClient
+				return sparkClient
+			},
+			statefulSetName: "splunk-stack1-spark-worker",
+			masterStatus:    &splclient.SparkMasterStatus{Workers: workers},
+		}
+		var got bool
+		var err error
+		if prepare == "PrepareScaleDown" {
+			got, err = mgr.PrepareScaleDown(1)
+		} else {
+			got, err = mgr.PrepareRecycle(1)
+		}
+		if err != nil {
+			t.Errorf("SparkWorkerPodManager %s() returned %v; want nil", prepare, err)
+		}
+		if got != want {
+			t.Errorf("SparkWorkerPodManager %s() = %t; want %t", prepare, got, want)
+		}
+		mockHTTPClient.CheckRequests(t, "TestSparkWorkerPodManagerDrain")
+	}
+
+	other := splclient.SparkWorkerInfo{ID: "worker-0", Host: "10.0.0.1", State: "ALIVE", CoresUsed: 2}
+	idle := splclient.SparkWorkerInfo{ID: "worker-1", Host: "10.0.0.2", State: "ALIVE", CoresUsed: 0}
+	busy := splclient.SparkWorkerInfo{ID: "worker-1", Host: "10.0.0.2", State: "ALIVE", CoresUsed: 2}
+	decommissioned := splclient.SparkWorkerInfo{ID: "worker-1", Host: "10.0.0.2", State: "DECOMMISSIONED", CoresUsed: 2}
+	for _, prepare := range []string{"PrepareScaleDown", "PrepareRecycle"} {
+		test(prepare, []splclient.SparkWorkerInfo{other}, true, false)
+		test(prepare, []splclient.SparkWorkerInfo{other, idle}, true, false)
+		test(prepare, []splclient.SparkWorkerInfo{other, {ID: "worker-1", Host: "10.0.0.2", State: "DEAD", CoresUsed: 2}}, true, false)
+	}
+
+	// busy workers are decommissioned, and then waited for
+	test("PrepareScaleDown", []splclient.SparkWorkerInfo{other, busy}, false, true)
+	drained := c.state[getStateKey(pod)].(*corev1.Pod)
+	if _, ok := drained.GetAnnotations()[drainStartedAnnotation]; !ok {
+		t.Errorf("SparkWorkerPodManager drain() did not add annotation %s", drainStartedAnnotation)
+	}
+	test("PrepareScaleDown", []splclient.SparkWorkerInfo{other, decommissioned}, false, false)
+	test("PrepareScaleDown", []splclient.SparkWorkerInfo{other, idle}, true, false)
+
+	// workers that stay busy are removed after the drain timeout
+	cr.Spec.Worker.DrainTimeoutSeconds = 60
+	test("PrepareRecycle", []splclient.SparkWorkerInfo{other, decommissioned}, false, false)
+	drained.ObjectMeta.Annotations[drainStartedAnnotation] = time.Now().Add(-2 * time.Minute).UTC().Format(time.RFC3339)
+	test("PrepareRecycle", []splclient.SparkWorkerInfo{other, decommissioned}, true, false)
+
+	// workers registered using their host name are also drained
+	c.state[getStateKey(pod)] = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: pod.GetName(), Namespace: "test"}}
+	test("PrepareScaleDown", []splclient.SparkWorkerInfo{other, {ID: "worker-1", Host: "splunk-stack1-spark-worker-1.splunk-stack1-spark-worker-headless", State: "ALIVE", CoresUsed: 2}}, false, true)
+}
+
+func TestSparkWorkerDeploymentMigration(t *testing.T) {
+	cr := enterprisev1.Spark{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Spark",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-spark-worker",
+			Namespace: "test",
+		},
+	}
+	oldPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "splunk-stack1-spark-worker-7d9f8-x2k4p",
+			Namespace:       "test",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "splunk-stack1-spark-worker-7d9f8"}},
+		},
+		Status: corev1.PodStatus{PodIP: "10.0.0.5"},
+	}
+	newPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "splunk-stack1-spark-worker-0",
+			Namespace:       "test",
+			OwnerReferences: []metav1.OwnerReference{{Kind: "StatefulSet", Name: "splunk-stack1-spark-worker"}},
+		},
+		Status: corev1.PodStatus{PodIP: "10.0.0.1"},
+	}
+	c := newMockClient()
+	c.state[getStateKey(deployment)] = deployment
+	c.state[getStateKey(oldPod)] = oldPod
+	c.state[getStateKey(newPod)] = newPod
+
+	// the deployment is kept until the workers of the statefulset are ready
+	if _, err := ApplySpark(c, &cr); err != nil {
+		t.Errorf("ApplySpark() returned %v; want nil", err)
+	}
+	if cr.Status.Phase == enterprisev1.PhaseReady || len(c.calls["Delete"]) != 0 {
+		t.Errorf("ApplySpark() removed spark worker Deployment before the StatefulSet was ready")
+	}
+
+	decommissionRequest, _ := http.NewRequest("POST", "http://splunk-stack1-spark-master-service.test.svc.cluster.local:8009/workers/kill/", nil)
+	test := func(workers []splclient.SparkWorkerInfo, want bool, wantDecommission bool) {
+		mockHTTPClient := &spltest.MockHTTPClient{}
+		if wantDecommission {
+			mockHTTPClient.AddHandler(decommissionRequest, 200, "", nil)
+		}
+		mgr := SparkWorkerPodManager{
+			log:    log.WithName("TestSparkWorkerDeploymentMigration"),
+			cr:     &cr,
+			client: c,
+			newSparkClient: func(masterURI string) *splclient.SparkClient {
+				sparkClient := splclient.NewSparkClient(masterURI)
+				sparkClient.Client = mockHTTPClient
+				return sparkClient
+			},
+			statefulSetName: "splunk-stack1-spark-worker",
+			masterStatus:    &splclient.SparkMasterStatus{Workers: workers},
+		}
+		c.resetCalls()
+		got, err := mgr.removeWorkerDeployment(c)
+		if err != nil {
+			t.Errorf("SparkWorkerPodManager removeWorkerDeployment() returned %v; want nil", err)
+		}
+		if got != want || (len(c.calls["Delete"]) == 1) != want {
+			t.Errorf("SparkWorkerPodManager removeWorkerDeployment() = %t, %d deletes; want %t", got, len(c.calls["Delete"]), want)
+		}
+		mockHTTPClient.CheckRequests(t, "TestSparkWorkerDeploymentMigration")
+	}
+
+	// workers of the deployment are decommissioned and drained before it is removed, leaving the statefulset alone
+	current := splclient.SparkWorkerInfo{ID: "worker-0", Host: "10.0.0.1", State: "ALIVE", CoresUsed: 2}
+	test([]splclient.SparkWorkerInfo{current, {ID: "worker-1", Host: "10.0.0.5", State: "ALIVE", CoresUsed: 2}}, false, true)
+	if _, ok := c.state[getStateKey(oldPod)].(*corev1.Pod).GetAnnotations()[drainStartedAnnotation]; !ok {
+		t.Errorf("SparkWorkerPodManager removeWorkerDeployment() did not drain worker of Deployment")
+	}
+	if _, ok := c.state[getStateKey(newPod)].(*corev1.Pod).GetAnnotations()[drainStartedAnnotation]; ok {
+		t.Errorf("SparkWorkerPodManager removeWorkerDeployment() drained worker of StatefulSet")
+	}
+	test([]splclient.SparkWorkerInfo{current, {ID: "worker-1", Host: "10.0.0.5", State: "DECOMMISSIONED", CoresUsed: 2}}, false, false)
+	test([]splclient.SparkWorkerInfo{current, {ID: "worker-1", Host: "10.0.0.5", State: "DECOMMISSIONED", CoresUsed: 0}}, true, false)
+	if c.state[getStateKey(deployment)] != nil {
+		t.Errorf("SparkWorkerPodManager removeWorkerDeployment() did not remove Deployment")
+	}
+}
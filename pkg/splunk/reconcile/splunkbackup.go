@@ -0,0 +1,336 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+// splunkFinalizerEndQuiesce is added to SplunkBackup resources while their target is quiesced, so that it is resumed
+// if they are removed before the backup completes
+const splunkFinalizerEndQuiesce = "enterprise.splunk.com/end-quiesce"
+
+// ApplySplunkBackup reconciles the state of backups of a Splunk Enterprise instance's volumes.
+func ApplySplunkBackup(client ControllerClient, cr *enterprisev1.SplunkBackup) (reconcile.Result, error) {
+
+	// unless modified, reconcile for this object will be requeued after 5 seconds
+	result := reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: time.Second * 5,
+	}
+	scopedLog := log.WithName("ApplySplunkBackup").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	// validate and updates defaults for CR
+	err := enterprise.ValidateSplunkBackup(cr)
+	if err != nil {
+		return result, err
+	}
+
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			_, err := updateSplunkBackupStatus(client, cr)
+			return err
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
+	// updates status after function completes
+	cr.Status.Phase = enterprisev1.PhaseError
+	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		err = client.Status().Update(context.TODO(), cr)
+		if err != nil {
+			scopedLog.Error(err, "Status update failed")
+		}
+	}()
+
+	// VolumeSnapshots are kept when a SplunkBackup is removed, but its target must not be left quiesced
+	if cr.ObjectMeta.DeletionTimestamp != nil {
+		cr.Status.Phase = enterprisev1.PhaseTerminating
+		if hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) {
+			err = endSplunkBackupQuiesce(client, cr, splclient.NewSplunkClient)
+		}
+		result.Requeue = err != nil
+		return result, err
+	}
+
+	snapshots, err := updateSplunkBackupStatus(client, cr)
+	if err != nil {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionBackupCompleted, corev1.ConditionFalse, "SnapshotFailed", err.Error())
+		return result, err
+	}
+
+	// wait for the VolumeSnapshots of the most recent backup to be ready, before resuming the target
+	if n := len(cr.Status.Backups); n > 0 && !cr.Status.Backups[n-1].ReadyToUse {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionBackupCompleted, corev1.ConditionFalse, "InProgress",
+			fmt.Sprintf("Waiting for the VolumeSnapshots of backup %s to be ready", cr.Status.Backups[n-1].Name))
+		cr.Status.Phase = enterprisev1.PhaseUpdating
+		return result, nil
+	}
+	if hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) {
+		if err = endSplunkBackupQuiesce(client, cr, splclient.NewSplunkClient); err != nil {
+			return result, err
+		}
+	}
+	if cr.Status.LatestBackup != "" {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionBackupCompleted, corev1.ConditionTrue, "Completed",
+			fmt.Sprintf("Backup %s is ready to use", cr.Status.LatestBackup))
+	}
+
+	if err = pruneSplunkBackups(client, cr, snapshots); err != nil {
+		return result, err
+	}
+
+	// wait until the next backup is due
+	next := getNextSplunkBackupTime(cr)
+	if next.IsZero() {
+		cr.Status.Phase = enterprisev1.PhaseReady
+		result.Requeue = false
+		return result, nil
+	}
+	if wait := time.Until(next); wait > 0 {
+		cr.Status.Phase = enterprisev1.PhaseReady
+		result.RequeueAfter = wait
+		return result, nil
+	}
+
+	// backups are only started while the target is ready, so that they do not interfere with updates
+	target, instanceType, err := getSplunkBackupTarget(client, cr)
+	if err != nil {
+		return result, err
+	}
+	if getSplunkBackupTargetPhase(target) != enterprisev1.PhaseReady {
+		scopedLog.Info("Waiting for target to be ready", "kind", cr.Spec.TargetRef.Kind, "target", cr.Spec.TargetRef.Name)
+		cr.Status.Phase = enterprisev1.PhasePending
+		return result, nil
+	}
+
+	err = startSplunkBackup(client, cr, target, instanceType, splclient.NewSplunkClient)
+	if err != nil {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionBackupCompleted, corev1.ConditionFalse, "SnapshotFailed", err.Error())
+		return result, err
+	}
+	cr.Status.Phase = enterprisev1.PhaseUpdating
+	return result, nil
+}
+
+// getSplunkBackupTarget returns the custom resource that a SplunkBackup backs up, and the type of Splunk instance whose
+// volumes are snapshotted. Indexer clusters that use a ClusterMaster resource must be backed up using that resource.
+func getSplunkBackupTarget(c ControllerClient, cr *enterprisev1.SplunkBackup) (enterprisev1.MetaObject, enterprise.InstanceType, error) {
+	var target enterprisev1.MetaObject
+	switch cr.Spec.TargetRef.Kind {
+	case "Standalone":
+		target = &enterprisev1.Standalone{}
+	case "LicenseMaster":
+		target = &enterprisev1.LicenseMaster{}
+	case "ClusterMaster":
+		target = &enterprisev1.ClusterMaster{}
+	case "IndexerCluster":
+		target = &enterprisev1.IndexerCluster{}
+	case "SearchHeadCluster":
+		target = &enterprisev1.SearchHeadCluster{}
+	}
+	instanceType := enterprise.GetSplunkBackupInstanceType(cr.Spec.TargetRef.Kind)
+
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.Spec.TargetRef.Name}
+	if err := c.Get(context.TODO(), namespacedName, target); err != nil {
+		return nil, instanceType, err
+	}
+	target.GetObjectKind().SetGroupVersionKind(enterprisev1.SchemeGroupVersion.WithKind(cr.Spec.TargetRef.Kind))
+
+	if indexerCluster, ok := target.(*enterprisev1.IndexerCluster); ok && indexerCluster.Spec.ClusterMasterRef.Name != "" {
+		return nil, instanceType, fmt.Errorf("IndexerCluster %s uses ClusterMaster %s; set targetRef to back up the ClusterMaster instead",
+			indexerCluster.GetName(), indexerCluster.Spec.ClusterMasterRef.Name)
+	}
+	return target, instanceType, nil
+}
+
+// getSplunkBackupTargetPhase returns the current phase of the Splunk instance that is backed up for a target resource
+func getSplunkBackupTargetPhase(target enterprisev1.MetaObject) enterprisev1.ResourcePhase {
+	switch target := target.(type) {
+	case *enterprisev1.IndexerCluster:
+		return target.Status.ClusterMasterPhase
+	case *enterprisev1.SearchHeadCluster:
+		return target.Status.DeployerPhase
+	}
+	return getResourcePhase(target)
+}
+
+// updateSplunkBackupStatus updates the status of each backup taken by a SplunkBackup, using the current state of its
+// VolumeSnapshots, and returns the VolumeSnapshots
+func updateSplunkBackupStatus(c ControllerClient, cr *enterprisev1.SplunkBackup) (*unstructured.UnstructuredList, error) {
+	snapshots := enterprise.NewVolumeSnapshotList()
+	listOpts := []client.ListOption{
+		client.InNamespace(cr.GetNamespace()),
+		client.MatchingLabels(enterprise.GetSplunkBackupLabels(cr)),
+	}
+	if err := c.List(context.TODO(), snapshots, listOpts...); err != nil {
+		return nil, err
+	}
+
+	backups, err := enterprise.GetSplunkBackups(snapshots.Items)
+	if err != nil {
+		return nil, err
+	}
+	cr.Status.Backups = backups
+	cr.Status.Quiesced = hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce)
+	cr.Status.LatestBackup = ""
+	for i := len(backups) - 1; i >= 0; i-- {
+		if backups[i].ReadyToUse {
+			cr.Status.LatestBackup = backups[i].Name
+			break
+		}
+	}
+	return snapshots, nil
+}
+
+// getNextSplunkBackupTime returns the time that the next backup is due, which is zero if a SplunkBackup only takes a
+// single backup and it has already been taken
+func getNextSplunkBackupTime(cr *enterprisev1.SplunkBackup) time.Time {
+	n := len(cr.Status.Backups)
+	if n == 0 {
+		return time.Now()
+	}
+	interval := enterprise.GetSplunkBackupInterval(cr)
+	if interval == 0 {
+		return time.Time{}
+	}
+	return cr.Status.Backups[n-1].Time.Add(interval)
+}
+
+// pruneSplunkBackups deletes the VolumeSnapshots of the oldest backups taken by a SplunkBackup, so that only the number
+// of backups given by retain are kept. It is only called when no backup is in progress.
+func pruneSplunkBackups(c ControllerClient, cr *enterprisev1.SplunkBackup, snapshots *unstructured.UnstructuredList) error {
+	excess := len(cr.Status.Backups) - int(cr.Spec.Retain)
+	if excess <= 0 {
+		return nil
+	}
+	scopedLog := log.WithName("pruneSplunkBackups").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	pruned := make(map[string]bool)
+	for _, backup := range cr.Status.Backups[:excess] {
+		pruned[backup.Name] = true
+	}
+	for i := range snapshots.Items {
+		snapshot := &snapshots.Items[i]
+		if !pruned[enterprise.GetVolumeSnapshotBackupName(snapshot)] {
+			continue
+		}
+		scopedLog.Info("Deleting VolumeSnapshot", "volumeSnapshot", snapshot.GetName())
+		if err := c.Delete(context.TODO(), snapshot); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	cr.Status.Backups = cr.Status.Backups[excess:]
+	return nil
+}
+
+// startSplunkBackup quiesces the target of a SplunkBackup, if needed, and creates VolumeSnapshots of its volumes
+func startSplunkBackup(c ControllerClient, cr *enterprisev1.SplunkBackup, target enterprisev1.MetaObject, instanceType enterprise.InstanceType,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	started := time.Now()
+	backupName := enterprise.GetSplunkBackupName(cr.GetName(), started.Unix())
+	scopedLog := log.WithName("startSplunkBackup").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace(), "backup", backupName)
+
+	// bucket fixup continually changes the state of a cluster master, so it is paused using maintenance mode
+	if instanceType == enterprise.SplunkClusterMaster {
+		if err := quiesceSplunkBackupTarget(c, cr, target, newSplunkClient); err != nil {
+			return err
+		}
+	}
+
+	for _, snapshot := range enterprise.GetSplunkVolumeSnapshots(cr, target, instanceType, backupName, started) {
+		scopedLog.Info("Creating VolumeSnapshot", "volumeSnapshot", snapshot.GetName())
+		if err := c.Create(context.TODO(), snapshot); err != nil && !errors.IsAlreadyExists(err) {
+			return err
+		}
+	}
+	cr.Status.Backups = append(cr.Status.Backups, enterprisev1.SplunkBackupSnapshotStatus{
+		Name: backupName,
+		Time: metav1.NewTime(started),
+	})
+	return nil
+}
+
+// quiesceSplunkBackupTarget enables maintenance mode on the cluster master backed up by a SplunkBackup. The end-quiesce
+// finalizer is added before maintenance mode is enabled, and tracks that it must be disabled once the backup completes;
+// maintenance mode that was already enabled for other reasons is left alone.
+func quiesceSplunkBackupTarget(c ControllerClient, cr *enterprisev1.SplunkBackup, target enterprisev1.MetaObject,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	clients, err := getSplunkIndexTargetClients(c, target, enterprise.SplunkClusterMaster, newSplunkClient)
+	if err != nil {
+		return err
+	}
+	clusterInfo, err := clients[0].GetClusterMasterInfo()
+	if err != nil {
+		return err
+	}
+	if clusterInfo.MaintenanceMode {
+		return nil
+	}
+
+	if !hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) {
+		cr.ObjectMeta.Finalizers = append(cr.ObjectMeta.Finalizers, splunkFinalizerEndQuiesce)
+		if err = c.Update(context.TODO(), cr); err != nil {
+			return err
+		}
+	}
+	log.Info("Enabling maintenance mode for backup", "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+	if err = clients[0].SetClusterMaintenanceMode(true); err != nil {
+		return err
+	}
+	cr.Status.Quiesced = true
+	return nil
+}
+
+// endSplunkBackupQuiesce disables the maintenance mode enabled by quiesceSplunkBackupTarget, and removes the end-quiesce finalizer
+func endSplunkBackupQuiesce(c ControllerClient, cr *enterprisev1.SplunkBackup,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	target, _, err := getSplunkBackupTarget(c, cr)
+	if err == nil {
+		var clients []*splclient.SplunkClient
+		clients, err = getSplunkIndexTargetClients(c, target, enterprise.SplunkClusterMaster, newSplunkClient)
+		if err != nil {
+			return err
+		}
+		log.Info("Disabling maintenance mode after backup", "name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+		if err = clients[0].SetClusterMaintenanceMode(false); err != nil {
+			return err
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	// the target no longer needs to be resumed if it was removed
+	cr.Status.Quiesced = false
+	return RemoveSplunkFinalizer(cr, c, splunkFinalizerEndQuiesce)
+}
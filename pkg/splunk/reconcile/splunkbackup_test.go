@@ -0,0 +1,241 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// newSplunkBackupTestClient returns a mock client with a standalone instance, and a SplunkBackup that targets it
+func newSplunkBackupTestClient() (*mockClient, *enterprisev1.Standalone, *enterprisev1.SplunkBackup) {
+	c := newMockClient()
+	c.notFoundError = k8serrors.NewNotFound(schema.GroupResource{Group: "enterprise.splunk.com", Resource: "test"}, "")
+	standalone := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	c.state[getStateKey(&standalone)] = &standalone
+
+	cr := enterprisev1.SplunkBackup{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SplunkBackup",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "daily",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "Standalone", Name: "stack1"}
+	return c, &standalone, &cr
+}
+
+// getSplunkBackupTestSnapshots returns the names of all VolumeSnapshots in the mock client, after updating their status
+func getSplunkBackupTestSnapshots(c *mockClient, readyToUse bool) map[string]*unstructured.Unstructured {
+	snapshots := make(map[string]*unstructured.Unstructured)
+	for _, obj := range c.state {
+		if snapshot, ok := obj.(*unstructured.Unstructured); ok && snapshot.GetKind() == "VolumeSnapshot" {
+			unstructured.SetNestedField(snapshot.Object, readyToUse, "status", "readyToUse")
+			snapshots[snapshot.GetName()] = snapshot
+		}
+	}
+	return snapshots
+}
+
+func TestApplySplunkBackup(t *testing.T) {
+	c, standalone, cr := newSplunkBackupTestClient()
+	test := func(wantPhase enterprisev1.ResourcePhase, wantBackups int, wantLatest string) {
+		_, err := ApplySplunkBackup(c, cr)
+		if err != nil {
+			t.Errorf("ApplySplunkBackup() returned error: %v", err)
+		}
+		if cr.Status.Phase != wantPhase || len(cr.Status.Backups) != wantBackups || cr.Status.LatestBackup != wantLatest {
+			t.Errorf("ApplySplunkBackup() status = %s, %d, %q; want %s, %d, %q", cr.Status.Phase, len(cr.Status.Backups), cr.Status.LatestBackup, wantPhase, wantBackups, wantLatest)
+		}
+	}
+
+	// waits for the target to be ready
+	standalone.Status.Phase = enterprisev1.PhaseUpdating
+	test(enterprisev1.PhasePending, 0, "")
+	if snapshots := getSplunkBackupTestSnapshots(c, false); len(snapshots) != 0 {
+		t.Errorf("ApplySplunkBackup() created %d VolumeSnapshots while target is not ready; want 0", len(snapshots))
+	}
+
+	// snapshots the etc and var volumes, then waits for them to be ready
+	standalone.Status.Phase = enterprisev1.PhaseReady
+	test(enterprisev1.PhaseUpdating, 1, "")
+	backupName := cr.Status.Backups[0].Name
+	test(enterprisev1.PhaseUpdating, 1, "")
+	snapshots := getSplunkBackupTestSnapshots(c, false)
+	if len(snapshots) != 2 || snapshots[backupName+"-etc"] == nil || snapshots[backupName+"-var"] == nil {
+		t.Errorf("ApplySplunkBackup() VolumeSnapshots = %v; want %s-etc and %s-var", snapshots, backupName, backupName)
+	}
+	if condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionBackupCompleted); condition == nil || condition.Reason != "InProgress" {
+		t.Errorf("ApplySplunkBackup() BackupCompleted condition = %v; want InProgress", condition)
+	}
+
+	// a single backup is taken when no interval is set
+	getSplunkBackupTestSnapshots(c, true)
+	result, err := ApplySplunkBackup(c, cr)
+	if err != nil || result.Requeue {
+		t.Errorf("ApplySplunkBackup() = %v, %v; want no requeue", result, err)
+	}
+	if cr.Status.Phase != enterprisev1.PhaseReady || cr.Status.LatestBackup != backupName {
+		t.Errorf("ApplySplunkBackup() status = %s, %q; want Ready, %q", cr.Status.Phase, cr.Status.LatestBackup, backupName)
+	}
+	if condition := resources.GetCondition(cr.Status.Conditions, enterprisev1.ConditionBackupCompleted); condition == nil || condition.Status != corev1.ConditionTrue {
+		t.Errorf("ApplySplunkBackup() BackupCompleted condition = %v; want True", condition)
+	}
+
+	// waits for the next backup to be due
+	cr.Spec.Interval = "1h"
+	cr.Spec.Retain = 1
+	result, err = ApplySplunkBackup(c, cr)
+	if err != nil || result.RequeueAfter <= 59*time.Minute || result.RequeueAfter > time.Hour {
+		t.Errorf("ApplySplunkBackup() = %v, %v; want requeue after 1h", result, err)
+	}
+
+	// takes another backup once it is due, and deletes the oldest backup once the new one is ready
+	for key, obj := range c.state {
+		if _, ok := obj.(*unstructured.Unstructured); ok {
+			delete(c.state, key)
+		}
+	}
+	backupName = "daily-1590000000"
+	for _, snapshot := range enterprise.GetSplunkVolumeSnapshots(cr, standalone, enterprise.SplunkStandalone, backupName, time.Now().Add(-2*time.Hour)) {
+		unstructured.SetNestedField(snapshot.Object, true, "status", "readyToUse")
+		c.state[getStateKey(snapshot)] = snapshot
+	}
+	test(enterprisev1.PhaseUpdating, 2, backupName)
+	newBackupName := cr.Status.Backups[1].Name
+	getSplunkBackupTestSnapshots(c, true)
+	test(enterprisev1.PhaseReady, 1, newBackupName)
+	snapshots = getSplunkBackupTestSnapshots(c, true)
+	if len(snapshots) != 2 || snapshots[newBackupName+"-etc"] == nil {
+		t.Errorf("ApplySplunkBackup() VolumeSnapshots = %v; want only those of %s", snapshots, newBackupName)
+	}
+
+	// VolumeSnapshots are kept when the SplunkBackup is deleted
+	now := metav1.Now()
+	cr.ObjectMeta.DeletionTimestamp = &now
+	result, err = ApplySplunkBackup(c, cr)
+	if err != nil || result.Requeue || cr.Status.Phase != enterprisev1.PhaseTerminating {
+		t.Errorf("ApplySplunkBackup() = %v, %v, %s; want no requeue, Terminating", result, err, cr.Status.Phase)
+	}
+	if snapshots = getSplunkBackupTestSnapshots(c, true); len(snapshots) != 2 {
+		t.Errorf("ApplySplunkBackup() deleted VolumeSnapshots of a removed SplunkBackup")
+	}
+}
+
+func TestSplunkBackupQuiesce(t *testing.T) {
+	c, _, cr := newSplunkBackupTestClient()
+	cm := enterprisev1.ClusterMaster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "ClusterMaster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	c.state[getStateKey(&cm)] = &cm
+	secrets := enterprise.GetSplunkSecrets(&cm, enterprise.SplunkClusterMaster, nil, nil)
+	c.state[getStateKey(secrets)] = secrets
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "ClusterMaster", Name: "stack1"}
+	if err := enterprise.ValidateSplunkBackup(cr); err != nil {
+		t.Fatalf("ValidateSplunkBackup() returned error: %v", err)
+	}
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+	infoRequest, _ := http.NewRequest("GET", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/info?count=0&output_mode=json", nil)
+	enableRequest, _ := http.NewRequest("POST", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/default/maintenance?mode=true", nil)
+	disableRequest, _ := http.NewRequest("POST", "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/control/default/maintenance?mode=false", nil)
+
+	// maintenance mode is enabled before the volumes are snapshotted
+	target, instanceType, err := getSplunkBackupTarget(c, cr)
+	if err != nil || instanceType != enterprise.SplunkClusterMaster {
+		t.Fatalf("getSplunkBackupTarget() = %s, %v; want cluster-master", instanceType, err)
+	}
+	mockSplunkClient.AddHandler(infoRequest, 200, `{"entry":[{"content":{"maintenance_mode":false}}]}`, nil)
+	mockSplunkClient.AddHandler(enableRequest, 200, "", nil)
+	if err = startSplunkBackup(c, cr, target, instanceType, newSplunkClient); err != nil {
+		t.Errorf("startSplunkBackup() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestSplunkBackupQuiesce")
+	if !cr.Status.Quiesced || !hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) || len(cr.Status.Backups) != 1 {
+		t.Errorf("startSplunkBackup() = %t, %v, %d backups; want quiesced with finalizer and 1 backup", cr.Status.Quiesced, cr.GetFinalizers(), len(cr.Status.Backups))
+	}
+	if snapshots := getSplunkBackupTestSnapshots(c, false); snapshots[cr.Status.Backups[0].Name+"-etc"] == nil {
+		t.Errorf("startSplunkBackup() did not create VolumeSnapshot %s-etc", cr.Status.Backups[0].Name)
+	}
+
+	// maintenance mode is disabled once the backup completes
+	mockSplunkClient.AddHandler(disableRequest, 200, "", nil)
+	if err = endSplunkBackupQuiesce(c, cr, newSplunkClient); err != nil {
+		t.Errorf("endSplunkBackupQuiesce() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestSplunkBackupQuiesce")
+	if cr.Status.Quiesced || hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) {
+		t.Errorf("endSplunkBackupQuiesce() = %t, %v; want not quiesced without finalizer", cr.Status.Quiesced, cr.GetFinalizers())
+	}
+
+	// maintenance mode that is already enabled is left alone
+	mockSplunkClient.AddHandler(infoRequest, 200, `{"entry":[{"content":{"maintenance_mode":true}}]}`, nil)
+	if err = startSplunkBackup(c, cr, target, instanceType, newSplunkClient); err != nil {
+		t.Errorf("startSplunkBackup() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestSplunkBackupQuiesce")
+	if cr.Status.Quiesced || hasSplunkFinalizer(cr, splunkFinalizerEndQuiesce) {
+		t.Errorf("startSplunkBackup() quiesced a cluster master that was already in maintenance mode")
+	}
+
+	// indexer clusters using a ClusterMaster resource must be backed up using that resource
+	idxc := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "idxc",
+			Namespace: "test",
+		},
+	}
+	idxc.Spec.ClusterMasterRef.Name = "stack1"
+	c.state[getStateKey(&idxc)] = &idxc
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "IndexerCluster", Name: "idxc"}
+	if _, _, err = getSplunkBackupTarget(c, cr); err == nil {
+		t.Errorf("getSplunkBackupTarget() returned nil; want error for IndexerCluster with clusterMasterRef")
+	}
+}
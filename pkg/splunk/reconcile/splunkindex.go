@@ -0,0 +1,353 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/resources"
+)
+
+const (
+	// splunkFinalizerDisableIndex is added to SplunkIndex resources, so that their indexes are disabled before they are removed
+	splunkFinalizerDisableIndex = "enterprise.splunk.com/disable-index"
+
+	// indexesUpdatedAnnotation records when the indexes.conf within an indexes ConfigMap last changed
+	indexesUpdatedAnnotation = "enterprise.splunk.com/updated-at"
+
+	// indexesPushedAnnotation records the checksum of the last indexes.conf that was pushed from an indexes ConfigMap
+	indexesPushedAnnotation = "enterprise.splunk.com/pushed-checksum"
+
+	// indexesRejectedAnnotation records the checksum of the last indexes.conf that failed cluster bundle validation
+	indexesRejectedAnnotation = "enterprise.splunk.com/rejected-checksum"
+
+	// indexesSyncDelay is the time allowed for the kubelet to update the ConfigMap volumes of running pods,
+	// after which changes to indexes.conf are pushed to Splunk Enterprise
+	indexesSyncDelay = 2 * time.Minute
+)
+
+// ApplySplunkIndex reconciles the state of an index defined by a SplunkIndex resource.
+func ApplySplunkIndex(client ControllerClient, cr *enterprisev1.SplunkIndex) (reconcile.Result, error) {
+
+	// unless modified, reconcile for this object will be requeued after 5 seconds
+	result := reconcile.Result{
+		Requeue:      true,
+		RequeueAfter: time.Second * 5,
+	}
+	scopedLog := log.WithName("ApplySplunkIndex").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace())
+
+	// validate and updates defaults for CR
+	err := enterprise.ValidateSplunkIndex(cr)
+	if err != nil {
+		return result, err
+	}
+
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			return updatePausedSplunkIndexStatus(client, cr)
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
+	// updates status after function completes
+	cr.Status.Phase = enterprisev1.PhaseError
+	cr.Status.IndexName = cr.Spec.IndexName
+	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
+		err = client.Status().Update(context.TODO(), cr)
+		if err != nil {
+			scopedLog.Error(err, "Status update failed")
+		}
+	}()
+
+	// indexes are disabled before their resources are removed, so that their data is kept
+	deleting := cr.ObjectMeta.DeletionTimestamp != nil
+	if deleting {
+		if !hasSplunkFinalizer(cr, splunkFinalizerDisableIndex) {
+			result.Requeue = false
+			return result, nil
+		}
+		cr.Status.Phase = enterprisev1.PhaseTerminating
+	} else if !hasSplunkFinalizer(cr, splunkFinalizerDisableIndex) {
+		cr.ObjectMeta.Finalizers = append(cr.ObjectMeta.Finalizers, splunkFinalizerDisableIndex)
+		if err = client.Update(context.TODO(), cr); err != nil {
+			return result, err
+		}
+	}
+
+	target, instanceType, err := getSplunkIndexTarget(client, cr)
+	if deleting && (errors.IsNotFound(err) || (err == nil && target.GetObjectMeta().GetDeletionTimestamp() != nil)) {
+		// the index is being removed along with its target
+		err = RemoveSplunkFinalizer(cr, client, splunkFinalizerDisableIndex)
+		result.Requeue = false
+		return result, err
+	}
+	if err != nil {
+		return result, err
+	}
+
+	// wait for the target to be ready, so that changes can be pushed to it
+	if getResourcePhase(target) != enterprisev1.PhaseReady {
+		scopedLog.Info("Waiting for target to be ready", "kind", cr.Spec.TargetRef.Kind, "target", cr.Spec.TargetRef.Name)
+		if !deleting {
+			cr.Status.Phase = enterprisev1.PhasePending
+		}
+		return result, nil
+	}
+
+	pushed, err := applySplunkIndexes(client, cr, target, instanceType, deleting, splclient.NewSplunkClient)
+	if err != nil {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionIndexPushed, corev1.ConditionFalse, "PushFailed", err.Error())
+		return result, err
+	}
+	if !pushed {
+		resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionIndexPushed, corev1.ConditionFalse, "WaitingForSync",
+			fmt.Sprintf("Waiting for ConfigMap %s to be updated in pods and pushed", cr.Status.ConfigMap))
+		if !deleting {
+			cr.Status.Phase = enterprisev1.PhaseUpdating
+		}
+		return result, nil
+	}
+	resources.SetCondition(&cr.Status.Conditions, cr.GetGeneration(), enterprisev1.ConditionIndexPushed, corev1.ConditionTrue, "Pushed", "")
+
+	if deleting {
+		err = RemoveSplunkFinalizer(cr, client, splunkFinalizerDisableIndex)
+		result.Requeue = false
+		return result, err
+	}
+
+	// bucket counts are reported by indexer cluster peers; refresh them periodically
+	cr.Status.Phase = enterprisev1.PhaseReady
+	if instanceType == enterprise.SplunkClusterMaster {
+		err = updateSplunkIndexBucketCount(client, cr, target, splclient.NewSplunkClient)
+		if err != nil {
+			cr.Status.Phase = enterprisev1.PhaseError
+			return result, err
+		}
+		result.RequeueAfter = time.Minute * 5
+	} else {
+		result.Requeue = false
+	}
+	return result, nil
+}
+
+// updatePausedSplunkIndexStatus updates the status of a SplunkIndex resource while its reconciliation is paused
+func updatePausedSplunkIndexStatus(client ControllerClient, cr *enterprisev1.SplunkIndex) error {
+	target, instanceType, err := getSplunkIndexTarget(client, cr)
+	if err != nil || instanceType != enterprise.SplunkClusterMaster || getResourcePhase(target) != enterprisev1.PhaseReady {
+		return err
+	}
+	return updateSplunkIndexBucketCount(client, cr, target, splclient.NewSplunkClient)
+}
+
+// hasSplunkFinalizer returns true if a custom resource has a finalizer
+func hasSplunkFinalizer(cr enterprisev1.MetaObject, finalizer string) bool {
+	for _, f := range cr.GetObjectMeta().GetFinalizers() {
+		if f == finalizer {
+			return true
+		}
+	}
+	return false
+}
+
+// getSplunkIndexTarget returns the custom resource that manages the Splunk instances that a SplunkIndex is configured on,
+// and their type. Indexes for an indexer cluster are configured on its cluster master, which may be a ClusterMaster resource.
+func getSplunkIndexTarget(c ControllerClient, cr *enterprisev1.SplunkIndex) (enterprisev1.MetaObject, enterprise.InstanceType, error) {
+	namespacedName := types.NamespacedName{Namespace: cr.GetNamespace(), Name: cr.Spec.TargetRef.Name}
+	if cr.Spec.TargetRef.Kind == "Standalone" {
+		var standalone enterprisev1.Standalone
+		if err := c.Get(context.TODO(), namespacedName, &standalone); err != nil {
+			return nil, enterprise.SplunkStandalone, err
+		}
+		standalone.TypeMeta.APIVersion = "enterprise.splunk.com/v1alpha2"
+		standalone.TypeMeta.Kind = "Standalone"
+		return &standalone, enterprise.SplunkStandalone, nil
+	}
+
+	var indexerCluster enterprisev1.IndexerCluster
+	if err := c.Get(context.TODO(), namespacedName, &indexerCluster); err != nil {
+		return nil, enterprise.SplunkClusterMaster, err
+	}
+	indexerCluster.TypeMeta.APIVersion = "enterprise.splunk.com/v1alpha2"
+	indexerCluster.TypeMeta.Kind = "IndexerCluster"
+	ref := indexerCluster.Spec.ClusterMasterRef
+	if ref.Name == "" {
+		return &indexerCluster, enterprise.SplunkClusterMaster, nil
+	}
+
+	if ref.Namespace != "" {
+		namespacedName.Namespace = ref.Namespace
+	}
+	namespacedName.Name = ref.Name
+	var clusterMaster enterprisev1.ClusterMaster
+	if err := c.Get(context.TODO(), namespacedName, &clusterMaster); err != nil {
+		return nil, enterprise.SplunkClusterMaster, err
+	}
+	clusterMaster.TypeMeta.APIVersion = "enterprise.splunk.com/v1alpha2"
+	clusterMaster.TypeMeta.Kind = "ClusterMaster"
+	return &clusterMaster, enterprise.SplunkClusterMaster, nil
+}
+
+// getSplunkIndexTargetClients returns Splunk REST API clients for each of the instances that indexes are pushed to:
+// the cluster master of an indexer cluster, or all standalone instances.
+func getSplunkIndexTargetClients(c ControllerClient, target enterprisev1.MetaObject, instanceType enterprise.InstanceType,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) ([]*splclient.SplunkClient, error) {
+	namespacedName := types.NamespacedName{Namespace: target.GetNamespace(), Name: enterprise.GetSplunkSecretsName(target.GetIdentifier(), getSecretsInstanceType(target))}
+	var secrets corev1.Secret
+	if err := c.Get(context.TODO(), namespacedName, &secrets); err != nil {
+		return nil, fmt.Errorf("Unable to get secret %s: %v", namespacedName.Name, err)
+	}
+	tlsConfig, err := getSplunkTLSConfig(c, target, getCommonSplunkSpec(target), &secrets)
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := []string{resources.GetServiceFQDN(target.GetNamespace(), enterprise.GetSplunkServiceName(enterprise.SplunkClusterMaster, target.GetIdentifier(), false))}
+	if instanceType == enterprise.SplunkStandalone {
+		if hosts, err = enterprise.GetSplunkInstanceHosts(target); err != nil {
+			return nil, err
+		}
+	}
+	clients := make([]*splclient.SplunkClient, len(hosts))
+	for i, host := range hosts {
+		clients[i] = newSplunkClient(fmt.Sprintf("https://%s:8089", host), "admin", string(secrets.Data["password"]), tlsConfig)
+	}
+	return clients, nil
+}
+
+// applySplunkIndexes renders the index defined by a SplunkIndex into the indexes ConfigMap of its target, and reloads
+// the indexes of standalone instances once the kubelet has had time to update the ConfigMap in their pods. Indexes for
+// indexer clusters are pushed by the reconciler of the cluster master, using a validated cluster bundle. It returns
+// true once the current configuration has been pushed.
+func applySplunkIndexes(c ControllerClient, cr *enterprisev1.SplunkIndex, target enterprisev1.MetaObject, instanceType enterprise.InstanceType, disabled bool,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) (bool, error) {
+	scopedLog := log.WithName("applySplunkIndexes").WithValues("name", cr.GetIdentifier(), "namespace", cr.GetNamespace(), "index", cr.Spec.IndexName)
+
+	namespacedName := types.NamespacedName{Namespace: target.GetNamespace(), Name: enterprise.GetSplunkIndexesName(target.GetIdentifier(), instanceType)}
+	var current *corev1.ConfigMap
+	var existing corev1.ConfigMap
+	err := c.Get(context.TODO(), namespacedName, &existing)
+	if err == nil {
+		current = &existing
+	} else if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	revised, err := enterprise.GetSplunkIndexesConfigMap(target, instanceType, current, cr, disabled)
+	if err != nil {
+		return false, err
+	}
+	if revised == nil {
+		// nothing to disable
+		return true, nil
+	}
+	cr.Status.ConfigMap = revised.GetName()
+
+	// record when indexes.conf changes, so that it is only pushed after pods have been updated
+	conf := enterprise.GetSplunkIndexesConf(revised)
+	annotations := revised.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if current == nil || enterprise.GetSplunkIndexesConf(current) != conf {
+		annotations[indexesUpdatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	}
+	revised.SetAnnotations(annotations)
+	if current == nil {
+		scopedLog.Info("Creating indexes ConfigMap", "configMap", revised.GetName())
+		return false, CreateResource(c, revised)
+	}
+	if !reflect.DeepEqual(current.Data, revised.Data) || !reflect.DeepEqual(current.GetAnnotations(), annotations) {
+		scopedLog.Info("Updating indexes ConfigMap", "configMap", revised.GetName())
+		if err = UpdateResource(c, revised); err != nil {
+			return false, err
+		}
+	}
+
+	checksum := getSplunkIndexesChecksum(revised)
+	if annotations[indexesPushedAnnotation] == checksum {
+		return true, nil
+	}
+	if instanceType == enterprise.SplunkClusterMaster {
+		if annotations[indexesRejectedAnnotation] == checksum {
+			return false, fmt.Errorf("Cluster bundle validation failed; see the BundlePushed condition of %s %s", target.GetTypeMeta().Kind, target.GetIdentifier())
+		}
+		return false, nil
+	}
+	if !isSplunkIndexesSynced(revised) {
+		return false, nil
+	}
+
+	// reload indexes on all of the standalone instances
+	clients, err := getSplunkIndexTargetClients(c, target, instanceType, newSplunkClient)
+	if err != nil {
+		return false, err
+	}
+	for _, splunkClient := range clients {
+		scopedLog.Info("Reloading indexes", "uri", splunkClient.ManagementURI)
+		if err = splunkClient.ReloadIndexes(); err != nil {
+			return false, err
+		}
+	}
+	annotations[indexesPushedAnnotation] = checksum
+	revised.SetAnnotations(annotations)
+	return true, UpdateResource(c, revised)
+}
+
+// getSplunkIndexesChecksum returns the checksum of the indexes.conf in an indexes ConfigMap, used to track which
+// version has been pushed to Splunk Enterprise
+func getSplunkIndexesChecksum(configMap *corev1.ConfigMap) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(enterprise.GetSplunkIndexesConf(configMap))))
+}
+
+// isSplunkIndexesSynced returns true once the kubelet has had time to update an indexes ConfigMap in running pods
+func isSplunkIndexesSynced(configMap *corev1.ConfigMap) bool {
+	updated, err := time.Parse(time.RFC3339, configMap.GetAnnotations()[indexesUpdatedAnnotation])
+	return err != nil || time.Since(updated) >= indexesSyncDelay
+}
+
+// updateSplunkIndexBucketCount updates the status of a SplunkIndex with the total number of buckets reported for its
+// index by all of the indexer cluster peers
+func updateSplunkIndexBucketCount(c ControllerClient, cr *enterprisev1.SplunkIndex, target enterprisev1.MetaObject,
+	newSplunkClient func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient) error {
+	clients, err := getSplunkIndexTargetClients(c, target, enterprise.SplunkClusterMaster, newSplunkClient)
+	if err != nil {
+		return err
+	}
+	peers, err := clients[0].GetClusterMasterPeers()
+	if err != nil {
+		return err
+	}
+	var bucketCount int64
+	for _, peer := range peers {
+		bucketCount += peer.BucketCountByIndex[cr.Spec.IndexName]
+	}
+	cr.Status.BucketCount = bucketCount
+	return nil
+}
@@ -0,0 +1,242 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reconcile
+
+import (
+	"crypto/tls"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	spltest "github.com/splunk/splunk-operator/pkg/splunk/test"
+)
+
+// newSplunkIndexTestClient returns a mock client with an indexer cluster, and a SplunkIndex that targets it
+func newSplunkIndexTestClient() (*mockClient, *enterprisev1.IndexerCluster, *enterprisev1.SplunkIndex) {
+	c := newMockClient()
+	c.notFoundError = k8serrors.NewNotFound(schema.GroupResource{Group: "enterprise.splunk.com", Resource: "test"}, "")
+	idxc := enterprisev1.IndexerCluster{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "IndexerCluster",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	c.state[getStateKey(&idxc)] = &idxc
+	secrets := enterprise.GetSplunkSecrets(&idxc, enterprise.SplunkIndexer, nil, nil)
+	c.state[getStateKey(secrets)] = secrets
+
+	cr := enterprisev1.SplunkIndex{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "SplunkIndex",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "web",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.TargetRef = corev1.ObjectReference{Kind: "IndexerCluster", Name: "stack1"}
+	cr.Spec.FrozenTimePeriodInSecs = 86400
+	return c, &idxc, &cr
+}
+
+func getSplunkIndexTestConfigMap(t *testing.T, c *mockClient) *corev1.ConfigMap {
+	configMap := corev1.ConfigMap{}
+	configMap.SetName("splunk-stack1-cluster-master-indexes")
+	configMap.SetNamespace("test")
+	obj, ok := c.state[getStateKey(&configMap)]
+	if !ok {
+		t.Fatalf("ConfigMap %s not found", configMap.GetName())
+	}
+	return obj.(*corev1.ConfigMap)
+}
+
+func TestApplySplunkIndex(t *testing.T) {
+	c, idxc, cr := newSplunkIndexTestClient()
+
+	// waits for the target to be ready
+	idxc.Status.Phase = enterprisev1.PhaseUpdating
+	_, err := ApplySplunkIndex(c, cr)
+	if err != nil {
+		t.Errorf("ApplySplunkIndex() returned error: %v", err)
+	}
+	if cr.Status.Phase != enterprisev1.PhasePending || cr.Status.IndexName != "web" {
+		t.Errorf("ApplySplunkIndex() status = %s, %s; want Pending, web", cr.Status.Phase, cr.Status.IndexName)
+	}
+	if !hasSplunkFinalizer(cr, splunkFinalizerDisableIndex) {
+		t.Errorf("ApplySplunkIndex() did not add finalizer %s", splunkFinalizerDisableIndex)
+	}
+
+	// renders the index into the cluster master's ConfigMap, then waits for it to be updated in pods
+	idxc.Status.Phase = enterprisev1.PhaseReady
+	_, err = ApplySplunkIndex(c, cr)
+	if err != nil {
+		t.Errorf("ApplySplunkIndex() returned error: %v", err)
+	}
+	if cr.Status.Phase != enterprisev1.PhaseUpdating || cr.Status.ConfigMap != "splunk-stack1-cluster-master-indexes" {
+		t.Errorf("ApplySplunkIndex() status = %s, %s; want Updating, splunk-stack1-cluster-master-indexes", cr.Status.Phase, cr.Status.ConfigMap)
+	}
+	configMap := getSplunkIndexTestConfigMap(t, c)
+	if conf := enterprise.GetSplunkIndexesConf(configMap); !strings.Contains(conf, "[web]\n") || !strings.Contains(conf, "frozenTimePeriodInSecs = 86400\n") {
+		t.Errorf("ApplySplunkIndex() indexes.conf = %s; want index web", conf)
+	}
+	if configMap.GetAnnotations()[indexesUpdatedAnnotation] == "" {
+		t.Errorf("ApplySplunkIndex() did not set %s", indexesUpdatedAnnotation)
+	}
+
+	// deleting the index while its target is being removed just removes the finalizer
+	delete(c.state, getStateKey(idxc))
+	now := metav1.Now()
+	cr.ObjectMeta.DeletionTimestamp = &now
+	_, err = ApplySplunkIndex(c, cr)
+	if err != nil {
+		t.Errorf("ApplySplunkIndex() returned error: %v", err)
+	}
+	if hasSplunkFinalizer(cr, splunkFinalizerDisableIndex) {
+		t.Errorf("ApplySplunkIndex() did not remove finalizer %s", splunkFinalizerDisableIndex)
+	}
+}
+
+func TestApplySplunkIndexes(t *testing.T) {
+	c, idxc, cr := newSplunkIndexTestClient()
+	if err := enterprise.ValidateSplunkIndex(cr); err != nil {
+		t.Fatalf("ValidateSplunkIndex() returned error: %v", err)
+	}
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+	var target enterprisev1.MetaObject = idxc
+	instanceType := enterprise.SplunkClusterMaster
+	configMapName := "splunk-stack1-cluster-master-indexes"
+	getConfigMap := func() *corev1.ConfigMap {
+		configMap := corev1.ConfigMap{}
+		configMap.SetName(configMapName)
+		configMap.SetNamespace("test")
+		obj, ok := c.state[getStateKey(&configMap)]
+		if !ok {
+			t.Fatalf("ConfigMap %s not found", configMapName)
+		}
+		return obj.(*corev1.ConfigMap)
+	}
+	test := func(disabled bool, want bool) {
+		got, err := applySplunkIndexes(c, cr, target, instanceType, disabled, newSplunkClient)
+		if err != nil {
+			t.Errorf("applySplunkIndexes() returned error: %v", err)
+		}
+		if got != want {
+			t.Errorf("applySplunkIndexes() = %t; want %t", got, want)
+		}
+	}
+
+	// indexes for indexer clusters are pushed by the cluster master's reconciler
+	test(false, false)
+	test(false, false)
+	configMap := getConfigMap()
+	configMap.Annotations[indexesPushedAnnotation] = getSplunkIndexesChecksum(configMap)
+	test(false, true)
+	mockSplunkClient.CheckRequests(t, "TestApplySplunkIndexes")
+
+	// deleted indexes are disabled
+	test(true, false)
+	if conf := enterprise.GetSplunkIndexesConf(getConfigMap()); !strings.Contains(conf, "disabled = true\n") {
+		t.Errorf("applySplunkIndexes() indexes.conf = %s; want web disabled", conf)
+	}
+
+	// bundle validation failures are returned
+	configMap = getConfigMap()
+	configMap.Annotations[indexesRejectedAnnotation] = getSplunkIndexesChecksum(configMap)
+	if _, err := applySplunkIndexes(c, cr, target, instanceType, true, newSplunkClient); err == nil {
+		t.Errorf("applySplunkIndexes() returned nil; want error for rejected bundle")
+	}
+
+	// indexes are reloaded on standalone instances once pods have had time to update
+	standalone := enterprisev1.Standalone{
+		TypeMeta: metav1.TypeMeta{
+			Kind: "Standalone",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "s1",
+			Namespace: "test",
+		},
+	}
+	standalone.Spec.Replicas = 1
+	secrets := enterprise.GetSplunkSecrets(&standalone, enterprise.SplunkStandalone, nil, nil)
+	c.state[getStateKey(secrets)] = secrets
+	target, instanceType, configMapName = &standalone, enterprise.SplunkStandalone, "splunk-s1-standalone-indexes"
+	test(false, false)
+	getConfigMap().Annotations[indexesUpdatedAnnotation] = time.Now().Add(-indexesSyncDelay).UTC().Format(time.RFC3339)
+	wantRequest, _ := http.NewRequest("POST", "https://splunk-s1-standalone-0.splunk-s1-standalone-headless.test.svc.cluster.local:8089/services/data/indexes/_reload", nil)
+	mockSplunkClient.AddHandler(wantRequest, 200, "", nil)
+	test(false, true)
+	mockSplunkClient.CheckRequests(t, "TestApplySplunkIndexes")
+	if getConfigMap().GetAnnotations()[indexesPushedAnnotation] == "" {
+		t.Errorf("applySplunkIndexes() did not set %s", indexesPushedAnnotation)
+	}
+
+	// nothing is reloaded again until the index changes
+	test(false, true)
+	mockSplunkClient.CheckRequests(t, "TestApplySplunkIndexes")
+
+	// reload failures are returned
+	cr.Spec.FrozenTimePeriodInSecs = 3600
+	test(false, false)
+	getConfigMap().Annotations[indexesUpdatedAnnotation] = time.Now().Add(-indexesSyncDelay).UTC().Format(time.RFC3339)
+	mockSplunkClient.AddHandler(wantRequest, 500, "", nil)
+	if _, err := applySplunkIndexes(c, cr, target, instanceType, false, newSplunkClient); err == nil {
+		t.Errorf("applySplunkIndexes() returned nil; want error")
+	}
+}
+
+func TestUpdateSplunkIndexBucketCount(t *testing.T) {
+	c, idxc, cr := newSplunkIndexTestClient()
+	if err := enterprise.ValidateSplunkIndex(cr); err != nil {
+		t.Fatalf("ValidateSplunkIndex() returned error: %v", err)
+	}
+
+	mockSplunkClient := &spltest.MockHTTPClient{}
+	newSplunkClient := func(managementURI, username, password string, tlsConfig *tls.Config) *splclient.SplunkClient {
+		c := splclient.NewSplunkClient(managementURI, username, password, tlsConfig)
+		c.Client = mockSplunkClient
+		return c
+	}
+	mockSplunkClient.AddHandlers(spltest.MockHTTPHandler{
+		Method: "GET",
+		URL:    "https://splunk-stack1-cluster-master-service.test.svc.cluster.local:8089/services/cluster/master/peers?count=0&output_mode=json",
+		Status: 200,
+		Body:   `{"entry":[{"name":"A","content":{"label":"splunk-stack1-indexer-0","bucket_count_by_index":{"_internal":45,"web":12}}},{"name":"B","content":{"label":"splunk-stack1-indexer-1","bucket_count_by_index":{"web":10}}},{"name":"C","content":{"label":"splunk-stack1-indexer-2","bucket_count_by_index":{"_internal":40}}}]}`,
+	})
+	if err := updateSplunkIndexBucketCount(c, cr, idxc, newSplunkClient); err != nil {
+		t.Errorf("updateSplunkIndexBucketCount() returned error: %v", err)
+	}
+	mockSplunkClient.CheckRequests(t, "TestUpdateSplunkIndexBucketCount")
+	if cr.Status.BucketCount != 22 {
+		t.Errorf("updateSplunkIndexBucketCount() bucketCount = %d; want 22", cr.Status.BucketCount)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	splclient "github.com/splunk/splunk-operator/pkg/splunk/client"
 	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 )
 
@@ -40,11 +41,20 @@ func ApplyStandalone(client ControllerClient, cr *enterprisev1.Standalone) (reco
 		return result, err
 	}
 
+	// only update status while reconciliation is paused
+	if isPaused(cr) {
+		return applyPaused(client, cr, &cr.Status.Conditions, func() error {
+			return updatePausedStandaloneStatus(client, cr)
+		})
+	}
+	setPausedCondition(&cr.Status.Conditions, cr.GetGeneration(), false)
+
 	// updates status after function completes
 	cr.Status.Phase = enterprisev1.PhaseError
 	cr.Status.Replicas = cr.Spec.Replicas
 	cr.Status.Selector = fmt.Sprintf("app.kubernetes.io/instance=splunk-%s-standalone", cr.GetIdentifier())
 	defer func() {
+		setReadyCondition(&cr.Status.Conditions, cr.GetGeneration(), cr.Status.Phase, err)
 		client.Status().Update(context.TODO(), cr)
 	}()
 
@@ -59,11 +69,31 @@ func ApplyStandalone(client ControllerClient, cr *enterprisev1.Standalone) (reco
 		return result, err
 	}
 
-	// create or update general config resources
-	_, err = ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone)
+	// wait for the custom resources that this one references to be ready
+	dependenciesReady, err := applyDependencies(client, cr, &cr.Status.Conditions)
 	if err != nil {
 		return result, err
 	}
+	if !dependenciesReady {
+		cr.Status.Phase = enterprisev1.PhasePending
+		return result, nil
+	}
+
+	// create or update general config resources
+	secrets, err := ApplySplunkConfig(client, cr, cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone)
+	if err = setSecretsAppliedCondition(&cr.Status.Conditions, cr.GetGeneration(), err); err != nil {
+		return result, err
+	}
+
+	// create or update generated defaults for SmartStore
+	if err = ApplySmartStoreConfig(client, cr, &cr.Spec.SmartStore, enterprise.SplunkStandalone); err != nil {
+		return result, err
+	}
+
+	// create or update resources used by the app framework to install apps
+	if err = ApplyAppFramework(client, cr, &cr.Spec.CommonSplunkSpec, enterprise.SplunkStandalone, cr.Spec.AppSources); err != nil {
+		return result, err
+	}
 
 	// create or update a headless service (this is required by DFS for Spark->standalone comms, possibly other things)
 	err = ApplyService(client, enterprise.GetSplunkService(cr, cr.Spec.CommonSpec, enterprise.SplunkStandalone, true))
@@ -76,7 +106,12 @@ func ApplyStandalone(client ControllerClient, cr *enterprisev1.Standalone) (reco
 	if err != nil {
 		return result, err
 	}
-	mgr := DefaultStatefulSetPodManager{}
+	// create or update a PodDisruptionBudget that limits voluntary disruptions of the standalone instances
+	err = ApplyPodDisruptionBudget(client, enterprise.GetSplunkPodDisruptionBudget(cr, &cr.Spec.CommonSplunkSpec, statefulSet, 1))
+	if err != nil {
+		return result, err
+	}
+	mgr := DefaultStatefulSetPodManager{volumes: &cr.Status.Volumes, volumeReclaimPolicy: cr.Spec.VolumeReclaimPolicy, retainedVolumeGracePeriod: getRetainedVolumeGracePeriod(&cr.Spec.CommonSplunkSpec)}
 	phase, err := mgr.Update(client, statefulSet, cr.Spec.Replicas)
 	cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
 	if err != nil {
@@ -84,9 +119,37 @@ func ApplyStandalone(client ControllerClient, cr *enterprisev1.Standalone) (reco
 	}
 	cr.Status.Phase = phase
 
-	// no need to requeue if everything is ready
+	// verify the certificates added to secrets created by older versions of the operator, once all pods use them
+	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if err = updateSplunkTLSPending(client, secrets); err != nil {
+			return result, err
+		}
+	}
+
+	// update status of apps installed by the app framework
+	appsInstalled := updateAppFrameworkStatus(client, cr, cr.GetGeneration(), cr.Spec.AppSources, statefulSet, &cr.Status.Apps, &cr.Status.Conditions, splclient.NewAppFrameworkClient)
+
+	// rotate secrets when requested, once all instances are ready
+	rotationPending := false
 	if cr.Status.Phase == enterprisev1.PhaseReady {
+		if rotationPending, err = ApplySecretsRotation(client, cr, &cr.Status.Conditions); err != nil {
+			return result, err
+		}
+	}
+
+	// no need to requeue if everything is ready
+	if cr.Status.Phase == enterprisev1.PhaseReady && appsInstalled && !rotationPending {
 		result.Requeue = false
 	}
 	return result, nil
 }
+
+// updatePausedStandaloneStatus updates the status of a Standalone resource while its reconciliation is paused
+func updatePausedStandaloneStatus(client ControllerClient, cr *enterprisev1.Standalone) error {
+	statefulSet, err := getCurrentStatefulSet(client, cr.GetNamespace(), enterprise.GetSplunkStatefulsetName(enterprise.SplunkStandalone, cr.GetIdentifier()))
+	if err != nil {
+		return err
+	}
+	cr.Status.ReadyReplicas = statefulSet.Status.ReadyReplicas
+	return nil
+}
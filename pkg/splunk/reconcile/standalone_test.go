@@ -26,11 +26,15 @@ import (
 func TestApplyStandalone(t *testing.T) {
 	funcCalls := []mockFuncCall{
 		{metaName: "*v1.Secret-test-splunk-stack1-standalone-secrets"},
+		{metaName: "*v1.ConfigMap-test-splunk-stack1-standalone-app-framework"},
+		{metaName: "*v1.Deployment-test-splunk-stack1-standalone-app-repository"},
+		{metaName: "*v1.Service-test-splunk-stack1-standalone-app-repository"},
 		{metaName: "*v1.Service-test-splunk-stack1-standalone-headless"},
+		{metaName: "*v1beta1.PodDisruptionBudget-test-splunk-stack1-standalone"},
 		{metaName: "*v1.StatefulSet-test-splunk-stack1-standalone"},
 	}
-	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": funcCalls}
-	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[2]}}
+	createCalls := map[string][]mockFuncCall{"Get": funcCalls, "Create": []mockFuncCall{funcCalls[0], funcCalls[1], funcCalls[4], funcCalls[5], funcCalls[6]}}
+	updateCalls := map[string][]mockFuncCall{"Get": funcCalls, "Update": []mockFuncCall{funcCalls[6]}}
 	current := enterprisev1.Standalone{
 		TypeMeta: metav1.TypeMeta{
 			Kind: "Standalone",
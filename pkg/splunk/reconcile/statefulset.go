@@ -17,15 +17,46 @@ package reconcile
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
 )
 
+const (
+	// configChecksumAnnotation records the checksum of the restart-only configuration used by the pods of a StatefulSet
+	configChecksumAnnotation = "enterprise.splunk.com/config-checksum"
+
+	// pendingConfigChecksumAnnotation records the checksum of restart-only configuration that has not yet been applied
+	pendingConfigChecksumAnnotation = "enterprise.splunk.com/pending-config-checksum"
+
+	// configUpdatedAnnotation records when a change to restart-only configuration was first seen
+	configUpdatedAnnotation = "enterprise.splunk.com/config-updated-at"
+
+	// volumeResizing is the state of a volume that is being expanded
+	volumeResizing = "Resizing"
+
+	// volumeFileSystemResizePending is the state of a volume that is waiting for its file system to be expanded
+	volumeFileSystemResizePending = "FileSystemResizePending"
+
+	// volumeResized is the state of a volume that has been expanded
+	volumeResized = "Resized"
+
+	// configSyncDelay is the time allowed for the kubelet to update the Secret volumes of running pods,
+	// after which splunkd is restarted to apply changes to restart-only configuration
+	configSyncDelay = 2 * time.Minute
+)
+
 // StatefulSetPodManager is used to manage the pods within a StatefulSet
 type StatefulSetPodManager interface {
 	// Update handles all updates for a statefulset and all of its pods
@@ -41,14 +72,33 @@ type StatefulSetPodManager interface {
 	FinishRecycle(int32) (bool, error)
 }
 
+// RollingRestartManager is used to restart splunkd within all of the pods of one or more StatefulSets,
+// without recreating the pods
+type RollingRestartManager interface {
+	// RollingRestart starts a rolling restart of splunkd on all pods
+	RollingRestart() error
+
+	// IsRollingRestart returns true while a rolling restart is in progress
+	IsRollingRestart() bool
+}
+
 // DefaultStatefulSetPodManager is a simple StatefulSetPodManager that does nothing
-type DefaultStatefulSetPodManager struct{}
+type DefaultStatefulSetPodManager struct {
+	// volumes is used to report the progress of resizing volumes, if not nil
+	volumes *[]enterprisev1.VolumeStatus
+
+	// volumeReclaimPolicy determines what happens to the volumes of pods that are removed by scaling down
+	volumeReclaimPolicy enterprisev1.VolumeReclaimPolicy
+
+	// retainedVolumeGracePeriod is how long volumes retained by scaling down may be re-attached by scaling back up
+	retainedVolumeGracePeriod time.Duration
+}
 
 // Update for DefaultStatefulSetPodManager handles all updates for a statefulset of standard pods
 func (mgr *DefaultStatefulSetPodManager) Update(client ControllerClient, statefulSet *appsv1.StatefulSet, desiredReplicas int32) (enterprisev1.ResourcePhase, error) {
-	phase, err := ApplyStatefulSet(client, statefulSet)
+	phase, err := ApplyStatefulSet(client, statefulSet, mgr.volumes)
 	if err == nil && phase == enterprisev1.PhaseReady {
-		phase, err = UpdateStatefulSetPods(client, statefulSet, mgr, desiredReplicas)
+		phase, err = UpdateStatefulSetPods(client, statefulSet, mgr, desiredReplicas, mgr.volumeReclaimPolicy, mgr.retainedVolumeGracePeriod)
 	}
 	return phase, err
 }
@@ -68,20 +118,35 @@ func (mgr *DefaultStatefulSetPodManager) FinishRecycle(n int32) (bool, error) {
 	return true, nil
 }
 
-// ApplyStatefulSet creates or updates a Kubernetes StatefulSet
-func ApplyStatefulSet(c ControllerClient, revised *appsv1.StatefulSet) (enterprisev1.ResourcePhase, error) {
+// ApplyStatefulSet creates or updates a Kubernetes StatefulSet. Volumes are resized when the storage requested by
+// its volume claim templates increases, and the progress of resizing is reported using volumes, if not nil.
+func ApplyStatefulSet(c ControllerClient, revised *appsv1.StatefulSet, volumes *[]enterprisev1.VolumeStatus) (enterprisev1.ResourcePhase, error) {
 	namespacedName := types.NamespacedName{Namespace: revised.GetNamespace(), Name: revised.GetName()}
 	var current appsv1.StatefulSet
 
 	err := c.Get(context.TODO(), namespacedName, &current)
 	if err != nil {
 		// no StatefulSet exists -> just create a new one
+		setStatefulSetVolumeStatus(volumes, revised, nil)
 		err = CreateResource(c, revised)
 		return enterprisev1.PhasePending, err
 	}
 
 	// found an existing StatefulSet
 
+	// wait for a StatefulSet that is being recreated with resized volumes to be removed
+	if current.GetObjectMeta().GetDeletionTimestamp() != nil {
+		*revised = current
+		return enterprisev1.PhasePending, nil
+	}
+
+	// check for changes in the size of volumes
+	phase, err := resizeStatefulSetVolumes(c, &current, revised, volumes)
+	if err != nil || phase != enterprisev1.PhaseReady {
+		*revised = current
+		return phase, err
+	}
+
 	// check for changes in Pod template
 	hasUpdates := MergePodUpdates(&current.Spec.Template, &revised.Spec.Template, current.GetObjectMeta().GetName())
 	*revised = current // caller expects that object passed represents latest state
@@ -98,13 +163,207 @@ func ApplyStatefulSet(c ControllerClient, revised *appsv1.StatefulSet) (enterpri
 	return enterprisev1.PhaseReady, nil
 }
 
-// UpdateStatefulSetPods manages scaling and config updates for StatefulSets
-func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet, mgr StatefulSetPodManager, desiredReplicas int32) (enterprisev1.ResourcePhase, error) {
+// resizeStatefulSetVolumes expands the PersistentVolumeClaims used by the pods of a StatefulSet when the storage
+// requested by its volume claim templates increases. Claims are only expanded if allowed by their StorageClass;
+// otherwise, an error is returned before any claims are changed, and the StatefulSet is left as it is. Since volume
+// claim templates cannot be changed, the StatefulSet is deleted without deleting its pods once all claims have been
+// resized, so that it is recreated using the revised templates. It returns PhaseReady if there is nothing to resize.
+func resizeStatefulSetVolumes(c ControllerClient, current, revised *appsv1.StatefulSet, volumes *[]enterprisev1.VolumeStatus) (enterprisev1.ResourcePhase, error) {
+	scopedLog := log.WithName("resizeStatefulSetVolumes").WithValues(
+		"name", current.GetObjectMeta().GetName(),
+		"namespace", current.GetObjectMeta().GetNamespace())
+
+	// find volume claim templates that request more storage
+	requests := make(map[string]resource.Quantity)
+	for _, tmpl := range revised.Spec.VolumeClaimTemplates {
+		for _, currentTmpl := range current.Spec.VolumeClaimTemplates {
+			if currentTmpl.GetName() != tmpl.GetName() {
+				continue
+			}
+			size := tmpl.Spec.Resources.Requests[corev1.ResourceStorage]
+			currentSize := currentTmpl.Spec.Resources.Requests[corev1.ResourceStorage]
+			switch size.Cmp(currentSize) {
+			case -1:
+				return enterprisev1.PhaseError, fmt.Errorf("Size of volume %s used by StatefulSet %s cannot be reduced from %s to %s", tmpl.GetName(), current.GetName(), currentSize.String(), size.String())
+			case 1:
+				requests[tmpl.GetName()] = size
+			}
+		}
+	}
+	if len(requests) == 0 {
+		setStatefulSetVolumeStatus(volumes, current, nil)
+		return enterprisev1.PhaseReady, nil
+	}
+
+	// request more storage for each claim, and wait for all of them to be resized
+	claims, err := getStatefulSetVolumeClaims(c, current)
+	if err != nil {
+		return enterprisev1.PhaseError, err
+	}
+	for i := range claims {
+		if _, ok := requests[getVolumeClaimTemplateName(current, claims[i].GetName())]; !ok {
+			continue
+		}
+		if err = checkVolumeExpansion(c, &claims[i]); err != nil {
+			recordEvent(getEventOwner(current), corev1.EventTypeWarning, eventReasonVolumeExpansionFailed, "%v", err)
+			return enterprisev1.PhaseError, err
+		}
+	}
+	resized := true
+	resizing := []corev1.PersistentVolumeClaim{}
+	for i := range claims {
+		pvc := &claims[i]
+		size, ok := requests[getVolumeClaimTemplateName(current, pvc.GetName())]
+		if !ok {
+			continue
+		}
+		currentSize := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+		if currentSize.Cmp(size) < 0 {
+			scopedLog.Info("Resizing PVC", "pvcName", pvc.GetName(), "from", currentSize.String(), "to", size.String())
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+			if err = UpdateResource(c, pvc); err != nil {
+				return enterprisev1.PhaseError, err
+			}
+		}
+		if getVolumeStatus(pvc).State != volumeResized {
+			resized = false
+		}
+		resizing = append(resizing, *pvc)
+	}
+	setStatefulSetVolumeStatus(volumes, current, resizing)
+	if !resized {
+		return enterprisev1.PhaseUpdating, nil
+	}
+
+	// recreate the StatefulSet, leaving its pods to be adopted by the new one
+	scopedLog.Info("Recreating StatefulSet with resized volumes")
+	err = c.Delete(context.TODO(), current, client.PropagationPolicy(metav1.DeletePropagationOrphan))
+	if err != nil {
+		scopedLog.Error(err, "Unable to delete StatefulSet")
+		return enterprisev1.PhaseError, err
+	}
+	return enterprisev1.PhaseUpdating, nil
+}
+
+// checkVolumeExpansion returns an error if the StorageClass of a PersistentVolumeClaim does not allow it to be expanded.
+// If the operator is not allowed to read StorageClasses, this is left to the API server to enforce.
+func checkVolumeExpansion(c ControllerClient, pvc *corev1.PersistentVolumeClaim) error {
+	className := ""
+	if pvc.Spec.StorageClassName != nil {
+		className = *pvc.Spec.StorageClassName
+	}
+	if className == "" {
+		return fmt.Errorf("Volume %s cannot be expanded because it does not have a StorageClass", pvc.GetName())
+	}
+	var storageClass storagev1.StorageClass
+	err := c.Get(context.TODO(), types.NamespacedName{Name: className}, &storageClass)
+	if errors.IsForbidden(err) {
+		log.WithName("checkVolumeExpansion").Info("Unable to read StorageClass", "storageClass", className, "error", err.Error())
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("Volume %s cannot be expanded because its StorageClass %s was not found: %v", pvc.GetName(), className, err)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return fmt.Errorf("Volume %s cannot be expanded because its StorageClass %s does not set allowVolumeExpansion", pvc.GetName(), className)
+	}
+	return nil
+}
+
+// getStatefulSetVolumeClaims returns the PersistentVolumeClaims used by the pods of a StatefulSet
+func getStatefulSetVolumeClaims(c ControllerClient, statefulSet *appsv1.StatefulSet) ([]corev1.PersistentVolumeClaim, error) {
+	pvcList := corev1.PersistentVolumeClaimList{}
+	listOpts := []client.ListOption{
+		client.InNamespace(statefulSet.GetNamespace()),
+		client.MatchingLabels(statefulSet.Spec.Selector.MatchLabels),
+	}
+	err := c.List(context.TODO(), &pvcList, listOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := []corev1.PersistentVolumeClaim{}
+	for _, pvc := range pvcList.Items {
+		if getVolumeClaimTemplateName(statefulSet, pvc.GetName()) != "" {
+			claims = append(claims, pvc)
+		}
+	}
+	return claims, nil
+}
+
+// getVolumeClaimTemplateName returns the name of the volume claim template that a PersistentVolumeClaim was created from,
+// or an empty string if it was not created for one of the pods of a StatefulSet
+func getVolumeClaimTemplateName(statefulSet *appsv1.StatefulSet, claimName string) string {
+	for _, tmpl := range statefulSet.Spec.VolumeClaimTemplates {
+		prefix := fmt.Sprintf("%s-%s-", tmpl.GetName(), statefulSet.GetName())
+		if !strings.HasPrefix(claimName, prefix) {
+			continue
+		}
+		// claims are named using the ordinal index of a pod
+		if _, err := strconv.Atoi(strings.TrimPrefix(claimName, prefix)); err == nil {
+			return tmpl.GetName()
+		}
+	}
+	return ""
+}
+
+// getVolumeStatus returns the resize status of a PersistentVolumeClaim
+func getVolumeStatus(pvc *corev1.PersistentVolumeClaim) enterprisev1.VolumeStatus {
+	requested := pvc.Spec.Resources.Requests[corev1.ResourceStorage]
+	capacity := pvc.Status.Capacity[corev1.ResourceStorage]
+	status := enterprisev1.VolumeStatus{
+		Name:      pvc.GetName(),
+		Requested: requested.String(),
+		State:     volumeResizing,
+	}
+	if pvc.Status.Phase != corev1.ClaimBound {
+		// claim is waiting to be bound
+		return status
+	}
+	status.Capacity = capacity.String()
+	if capacity.Cmp(requested) >= 0 {
+		status.State = volumeResized
+		return status
+	}
+	for _, cond := range pvc.Status.Conditions {
+		if cond.Type == corev1.PersistentVolumeClaimFileSystemResizePending && cond.Status == corev1.ConditionTrue {
+			status.State = volumeFileSystemResizePending
+		}
+	}
+	return status
+}
+
+// setStatefulSetVolumeStatus replaces the status of volumes used by the pods of a StatefulSet
+func setStatefulSetVolumeStatus(volumes *[]enterprisev1.VolumeStatus, statefulSet *appsv1.StatefulSet, claims []corev1.PersistentVolumeClaim) {
+	if volumes == nil {
+		return
+	}
+	result := []enterprisev1.VolumeStatus{}
+	for _, vol := range *volumes {
+		if getVolumeClaimTemplateName(statefulSet, vol.Name) == "" {
+			result = append(result, vol)
+		}
+	}
+	for i := range claims {
+		result = append(result, getVolumeStatus(&claims[i]))
+	}
+	if len(result) == 0 {
+		result = nil
+	}
+	*volumes = result
+}
+
+// UpdateStatefulSetPods manages scaling and config updates for StatefulSets. The volumes of pods that are removed by
+// scaling down are reclaimed using reclaimPolicy, and retained volumes are re-attached by scaling back up within gracePeriod.
+func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet, mgr StatefulSetPodManager, desiredReplicas int32, reclaimPolicy enterprisev1.VolumeReclaimPolicy, gracePeriod time.Duration) (enterprisev1.ResourcePhase, error) {
 
 	scopedLog := log.WithName("UpdateStatefulSetPods").WithValues(
 		"name", statefulSet.GetObjectMeta().GetName(),
 		"namespace", statefulSet.GetObjectMeta().GetNamespace())
 
+	// events are recorded on the custom resource that owns the statefulset
+	owner := getEventOwner(statefulSet)
+
 	// wait for all replicas ready
 	replicas := *statefulSet.Spec.Replicas
 	readyReplicas := statefulSet.Status.ReadyReplicas
@@ -123,8 +382,33 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 
 	// check for scaling up
 	if readyReplicas < desiredReplicas {
+		// delete volumes that were retained for too long, and wait for them to be removed before scaling up
+		removed := true
+		for n := readyReplicas; n < desiredReplicas; n++ {
+			podName := fmt.Sprintf("%s-%d", statefulSet.GetName(), n)
+			podRemoved, err := deleteExpiredPodVolumes(c, owner, statefulSet, podName, gracePeriod)
+			if err != nil {
+				scopedLog.Error(err, "Unable to delete expired PVCs", "podName", podName)
+				return enterprisev1.PhaseError, err
+			}
+			removed = removed && podRemoved
+		}
+		if !removed {
+			return enterprisev1.PhaseScalingUp, nil
+		}
+
+		// re-attach volumes that were retained when scaling down
+		for n := readyReplicas; n < desiredReplicas; n++ {
+			podName := fmt.Sprintf("%s-%d", statefulSet.GetName(), n)
+			if err := reattachPodVolumes(c, owner, statefulSet, podName); err != nil {
+				scopedLog.Error(err, "Unable to re-attach retained PVCs", "podName", podName)
+				return enterprisev1.PhaseError, err
+			}
+		}
+
 		// scale up StatefulSet to match desiredReplicas
 		scopedLog.Info("Scaling replicas up", "replicas", desiredReplicas)
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonScalingUp, "Scaling up StatefulSet %s from %d to %d replicas", statefulSet.GetName(), readyReplicas, desiredReplicas)
 		*statefulSet.Spec.Replicas = desiredReplicas
 		return enterprisev1.PhaseScalingUp, UpdateResource(c, statefulSet)
 	}
@@ -137,6 +421,7 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 		ready, err := mgr.PrepareScaleDown(n)
 		if err != nil {
 			scopedLog.Error(err, "Unable to decommission Pod", "podName", podName)
+			recordEvent(owner, corev1.EventTypeWarning, eventReasonScaleDownFailed, "Unable to prepare pod %s for removal: %v", podName, err)
 			return enterprisev1.PhaseError, err
 		}
 		if !ready {
@@ -146,6 +431,7 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 
 		// scale down statefulset to terminate pod
 		scopedLog.Info("Scaling replicas down", "replicas", n)
+		recordEvent(owner, corev1.EventTypeNormal, eventReasonScalingDown, "Scaling down StatefulSet %s to %d replicas, removing pod %s", statefulSet.GetName(), n, podName)
 		*statefulSet.Spec.Replicas = n
 		err = UpdateResource(c, statefulSet)
 		if err != nil {
@@ -153,11 +439,11 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 			return enterprisev1.PhaseError, err
 		}
 
-		// delete PVCs used by the pod so that a future scale up will have clean state
-		for _, vol := range []string{"pvc-etc", "pvc-var"} {
+		// reclaim PVCs used by the pod so that a future scale up will have clean state
+		for _, tmpl := range statefulSet.Spec.VolumeClaimTemplates {
 			namespacedName := types.NamespacedName{
 				Namespace: statefulSet.GetNamespace(),
-				Name:      fmt.Sprintf("%s-%s", vol, podName),
+				Name:      fmt.Sprintf("%s-%s", tmpl.GetName(), podName),
 			}
 			var pvc corev1.PersistentVolumeClaim
 			err := c.Get(context.TODO(), namespacedName, &pvc)
@@ -165,10 +451,9 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 				scopedLog.Error(err, "Unable to find PVC for deletion", "pvcName", pvc.ObjectMeta.Name)
 				return enterprisev1.PhaseError, err
 			}
-			log.Info("Deleting PVC", "pvcName", pvc.ObjectMeta.Name)
-			err = c.Delete(context.Background(), &pvc)
+			err = reclaimVolume(c, owner, &pvc, reclaimPolicy)
 			if err != nil {
-				scopedLog.Error(err, "Unable to delete PVC", "pvcName", pvc.ObjectMeta.Name)
+				scopedLog.Error(err, "Unable to reclaim PVC", "pvcName", pvc.ObjectMeta.Name)
 				return enterprisev1.PhaseError, err
 			}
 		}
@@ -201,6 +486,7 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 			ready, err := mgr.PrepareRecycle(n)
 			if err != nil {
 				scopedLog.Error(err, "Unable to prepare Pod for recycling", "podName", podName)
+				recordEvent(owner, corev1.EventTypeWarning, eventReasonRecycleFailed, "Unable to prepare pod %s for recycling: %v", podName, err)
 				return enterprisev1.PhaseError, err
 			}
 			if !ready {
@@ -218,6 +504,7 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 				scopedLog.Error(err, "Unable to delete Pod", "podName", podName)
 				return enterprisev1.PhaseError, err
 			}
+			recordEvent(owner, corev1.EventTypeNormal, eventReasonPodRecycled, "Recycled pod %s to update it to revision %s", podName, statefulSet.Status.UpdateRevision)
 
 			// only delete one at a time
 			return enterprisev1.PhaseUpdating, nil
@@ -239,3 +526,77 @@ func UpdateStatefulSetPods(c ControllerClient, statefulSet *appsv1.StatefulSet,
 	scopedLog.Info("All pods are ready")
 	return enterprisev1.PhaseReady, nil
 }
+
+// UpdateStatefulSetConfig applies changes to restart-only configuration, which splunkd reads directly from files mounted
+// in its pods, using a rolling restart rather than recycling the pods. checksum is the checksum of the current restart-only
+// configuration. This should only be called once all pods have been updated by UpdateStatefulSetPods. Note that pods
+// recycled for other updates already use the latest configuration, and so they are restarted at most one more time.
+func UpdateStatefulSetConfig(c ControllerClient, statefulSets []*appsv1.StatefulSet, mgr RollingRestartManager, checksum string) (enterprisev1.ResourcePhase, error) {
+	// wait for any rolling restart in progress to complete
+	if mgr.IsRollingRestart() {
+		return enterprisev1.PhaseUpdating, nil
+	}
+
+	pending := []*appsv1.StatefulSet{}
+	synced := true
+	for _, statefulSet := range statefulSets {
+		annotations := statefulSet.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		applied, ok := annotations[configChecksumAnnotation]
+		if applied == checksum {
+			continue
+		}
+		if !ok {
+			// pods created before the checksum was recorded already use the current configuration. note that pods
+			// created by older versions of the operator, before TLS certificates were added to their secrets, have
+			// already been recycled by UpdateStatefulSetPods, since their template changes
+			annotations[configChecksumAnnotation] = checksum
+			statefulSet.SetAnnotations(annotations)
+			if err := UpdateResource(c, statefulSet); err != nil {
+				return enterprisev1.PhaseError, err
+			}
+			continue
+		}
+		if annotations[pendingConfigChecksumAnnotation] != checksum {
+			// record when the change was first seen, to allow time for the kubelet to update the files in running pods
+			annotations[pendingConfigChecksumAnnotation] = checksum
+			annotations[configUpdatedAnnotation] = time.Now().UTC().Format(time.RFC3339)
+			statefulSet.SetAnnotations(annotations)
+			if err := UpdateResource(c, statefulSet); err != nil {
+				return enterprisev1.PhaseError, err
+			}
+		}
+		updated, err := time.Parse(time.RFC3339, annotations[configUpdatedAnnotation])
+		if err == nil && time.Since(updated) < configSyncDelay {
+			synced = false
+		}
+		pending = append(pending, statefulSet)
+	}
+
+	if len(pending) == 0 {
+		return enterprisev1.PhaseReady, nil
+	}
+	if !synced {
+		log.Info("Waiting for configuration to be updated in pods", "checksum", checksum)
+		return enterprisev1.PhaseUpdating, nil
+	}
+
+	// restart all pods together, since a rolling restart includes every member of the cluster
+	log.Info("Starting rolling restart to apply configuration", "checksum", checksum)
+	if err := mgr.RollingRestart(); err != nil {
+		return enterprisev1.PhaseError, err
+	}
+	for _, statefulSet := range pending {
+		annotations := statefulSet.GetAnnotations()
+		annotations[configChecksumAnnotation] = checksum
+		delete(annotations, pendingConfigChecksumAnnotation)
+		delete(annotations, configUpdatedAnnotation)
+		statefulSet.SetAnnotations(annotations)
+		if err := UpdateResource(c, statefulSet); err != nil {
+			return enterprisev1.PhaseError, err
+		}
+	}
+	return enterprisev1.PhaseUpdating, nil
+}
@@ -18,10 +18,14 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -43,12 +47,143 @@ func TestApplyStatefulSet(t *testing.T) {
 	revised := current.DeepCopy()
 	revised.Spec.Template.ObjectMeta.Labels = map[string]string{"one": "two"}
 	reconcile := func(c *mockClient, cr interface{}) error {
-		_, err := ApplyStatefulSet(c, cr.(*appsv1.StatefulSet))
+		_, err := ApplyStatefulSet(c, cr.(*appsv1.StatefulSet), nil)
 		return err
 	}
 	reconcileTester(t, "TestApplyStatefulSet", current, revised, createCalls, updateCalls, reconcile)
 }
 
+func TestResizeStatefulSetVolumes(t *testing.T) {
+	var replicas int32 = 2
+	labels := map[string]string{"app.kubernetes.io/instance": "splunk-stack1-standalone"}
+	storageClassName := "expandable"
+	volumeClaim := func(name, size string) corev1.PersistentVolumeClaim {
+		return corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: labels},
+			Spec: corev1.PersistentVolumeClaimSpec{
+				StorageClassName: &storageClassName,
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+				},
+			},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:    corev1.ClaimBound,
+				Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		}
+	}
+	current := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "splunk-stack1-standalone", Namespace: "test"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas:             &replicas,
+			Selector:             &metav1.LabelSelector{MatchLabels: labels},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{volumeClaim("pvc-etc", "10Gi"), volumeClaim("pvc-var", "100Gi")},
+		},
+	}
+	allowExpansion := true
+	storageClass := &storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: storageClassName},
+		AllowVolumeExpansion: &allowExpansion,
+	}
+	c := newMockClient()
+	c.state[getStateKey(current)] = current
+	c.state[getStateKey(storageClass)] = storageClass
+	claimNames := []string{}
+	for _, tmpl := range current.Spec.VolumeClaimTemplates {
+		for n := 0; n < 2; n++ {
+			size := tmpl.Spec.Resources.Requests[corev1.ResourceStorage]
+			pvc := volumeClaim(fmt.Sprintf("%s-splunk-stack1-standalone-%d", tmpl.GetName(), n), size.String())
+			claimNames = append(claimNames, pvc.GetName())
+			c.state[getStateKey(&pvc)] = &pvc
+		}
+	}
+	// unrelated claim of another StatefulSet
+	other := volumeClaim("pvc-var-splunk-stack1-standalone-backup-0", "100Gi")
+	c.state[getStateKey(&other)] = &other
+	getClaim := func(name string) *corev1.PersistentVolumeClaim {
+		return c.state[getStateKey(&corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"}})].(*corev1.PersistentVolumeClaim)
+	}
+
+	var volumes []enterprisev1.VolumeStatus
+	test := func(size string, wantPhase enterprisev1.ResourcePhase, wantStates []string) {
+		revised := current.DeepCopy()
+		revised.Spec.VolumeClaimTemplates[1] = volumeClaim("pvc-var", size)
+		phase, err := ApplyStatefulSet(c, revised, &volumes)
+		if err != nil {
+			t.Errorf("ApplyStatefulSet(%s) returned error: %v", size, err)
+		}
+		if phase != wantPhase {
+			t.Errorf("ApplyStatefulSet(%s) phase = %s; want %s", size, phase, wantPhase)
+		}
+		gotStates := []string{}
+		for _, vol := range volumes {
+			gotStates = append(gotStates, vol.State)
+		}
+		if fmt.Sprint(gotStates) != fmt.Sprint(wantStates) {
+			t.Errorf("ApplyStatefulSet(%s) volume states = %v; want %v", size, gotStates, wantStates)
+		}
+	}
+
+	// no change in size
+	test("100Gi", enterprisev1.PhaseReady, []string{})
+
+	// volumes are not expanded if their StorageClass does not allow it
+	allowExpansion = false
+	revised := current.DeepCopy()
+	revised.Spec.VolumeClaimTemplates[1] = volumeClaim("pvc-var", "200Gi")
+	if phase, err := ApplyStatefulSet(c, revised, &volumes); err == nil || phase != enterprisev1.PhaseError {
+		t.Errorf("ApplyStatefulSet() = %s, %v; want error when StorageClass does not allow volume expansion", phase, err)
+	}
+	if len(c.calls["Update"]) != 0 || len(c.calls["Delete"]) != 0 {
+		t.Errorf("ApplyStatefulSet() changed claims or StatefulSet when StorageClass does not allow volume expansion: %v", c.calls)
+	}
+	if got := getClaim(claimNames[2]).Spec.Resources.Requests[corev1.ResourceStorage]; got.String() != "100Gi" {
+		t.Errorf("PVC %s requests %s; want 100Gi", claimNames[2], got.String())
+	}
+	allowExpansion = true
+
+	// volumes are expanded
+	test("200Gi", enterprisev1.PhaseUpdating, []string{"Resizing", "Resizing"})
+	for _, name := range claimNames[2:] {
+		got := getClaim(name).Spec.Resources.Requests[corev1.ResourceStorage]
+		if got.String() != "200Gi" {
+			t.Errorf("PVC %s requests %s; want 200Gi", name, got.String())
+		}
+	}
+	if got := getClaim(other.GetName()).Spec.Resources.Requests[corev1.ResourceStorage]; got.String() != "100Gi" {
+		t.Errorf("PVC %s requests %s; want 100Gi", other.GetName(), got.String())
+	}
+	if volumes[0].Name != "pvc-var-splunk-stack1-standalone-0" || volumes[0].Requested != "200Gi" || volumes[0].Capacity != "100Gi" {
+		t.Errorf("ApplyStatefulSet() volume status = %v", volumes[0])
+	}
+
+	// waiting for resize to complete
+	getClaim(claimNames[2]).Status.Capacity[corev1.ResourceStorage] = resource.MustParse("200Gi")
+	getClaim(claimNames[3]).Status.Conditions = []corev1.PersistentVolumeClaimCondition{
+		{Type: corev1.PersistentVolumeClaimFileSystemResizePending, Status: corev1.ConditionTrue},
+	}
+	test("200Gi", enterprisev1.PhaseUpdating, []string{"Resized", "FileSystemResizePending"})
+
+	// StatefulSet is recreated once all volumes are resized
+	getClaim(claimNames[3]).Status.Capacity[corev1.ResourceStorage] = resource.MustParse("200Gi")
+	test("200Gi", enterprisev1.PhaseUpdating, []string{"Resized", "Resized"})
+	if len(c.calls["Delete"]) != 1 || c.state[getStateKey(current)] != nil {
+		t.Errorf("ApplyStatefulSet() did not delete StatefulSet %s", current.GetName())
+	}
+	test("200Gi", enterprisev1.PhasePending, []string{})
+	if len(volumes) != 0 {
+		t.Errorf("ApplyStatefulSet() volume status = %v; want none", volumes)
+	}
+
+	// volumes cannot be shrunk
+	revised = current.DeepCopy()
+	revised.Spec.VolumeClaimTemplates[1] = volumeClaim("pvc-var", "50Gi")
+	c.state[getStateKey(current)] = current
+	if _, err := ApplyStatefulSet(c, revised, &volumes); err == nil {
+		t.Errorf("ApplyStatefulSet() did not return error when reducing size of volumes")
+	}
+}
+
 func podManagerUpdateTester(t *testing.T, method string, mgr StatefulSetPodManager,
 	desiredReplicas int32, wantPhase enterprisev1.ResourcePhase, statefulSet *appsv1.StatefulSet,
 	wantCalls map[string][]mockFuncCall, wantError error, initObjects ...runtime.Object) {
@@ -85,7 +220,8 @@ func podManagerTester(t *testing.T, method string, mgr StatefulSetPodManager) {
 			Namespace: "test",
 		},
 		Spec: appsv1.StatefulSetSpec{
-			Replicas: &replicas,
+			Replicas:             &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "pvc-etc"}}, {ObjectMeta: metav1.ObjectMeta{Name: "pvc-var"}}},
 		},
 		Status: appsv1.StatefulSetStatus{
 			Replicas:        replicas,
@@ -121,8 +257,13 @@ func podManagerTester(t *testing.T, method string, mgr StatefulSetPodManager) {
 	replicas = 1
 	current.Status.Replicas = 1
 	current.Status.ReadyReplicas = 1
+	scaleUpPvcCalls := []mockFuncCall{
+		{metaName: "*v1.PersistentVolumeClaim-test-pvc-etc-splunk-stack1-1"},
+		{metaName: "*v1.PersistentVolumeClaim-test-pvc-var-splunk-stack1-1"},
+	}
+	scaleUpUpdateCalls := map[string][]mockFuncCall{"Get": {funcCalls[0], scaleUpPvcCalls[0], scaleUpPvcCalls[1], scaleUpPvcCalls[0], scaleUpPvcCalls[1]}, "Update": funcCalls}
 	methodPlus = fmt.Sprintf("%s(%s)", method, "ScalingUp, Update Replicas 1=>2")
-	podManagerUpdateTester(t, methodPlus, mgr, 2, enterprisev1.PhaseScalingUp, revised, updateCalls, nil, current)
+	podManagerUpdateTester(t, methodPlus, mgr, 2, enterprisev1.PhaseScalingUp, revised, scaleUpUpdateCalls, nil, current)
 
 	// test scale down (2 ready, 1 desired)
 	replicas = 1
@@ -197,3 +338,122 @@ func TestDefaultStatefulSetPodManager(t *testing.T) {
 	method := "DefaultStatefulSetPodManager.Update"
 	podManagerTester(t, method, &mgr)
 }
+
+// mockRollingRestartManager is a RollingRestartManager that counts rolling restarts
+type mockRollingRestartManager struct {
+	restarts       int
+	rollingRestart bool
+}
+
+func (mgr *mockRollingRestartManager) RollingRestart() error {
+	mgr.restarts++
+	mgr.rollingRestart = true
+	return nil
+}
+
+func (mgr *mockRollingRestartManager) IsRollingRestart() bool {
+	return mgr.rollingRestart
+}
+
+func TestUpdateStatefulSetConfig(t *testing.T) {
+	statefulSet := &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-indexer",
+			Namespace: "test",
+		},
+	}
+	c := newMockClient()
+	mgr := &mockRollingRestartManager{}
+	test := func(checksum string, wantPhase enterprisev1.ResourcePhase, wantRestarts int, wantChecksum string) {
+		phase, err := UpdateStatefulSetConfig(c, []*appsv1.StatefulSet{statefulSet}, mgr, checksum)
+		if err != nil {
+			t.Errorf("UpdateStatefulSetConfig(%s) returned error: %v", checksum, err)
+		}
+		if phase != wantPhase || mgr.restarts != wantRestarts || statefulSet.GetAnnotations()[configChecksumAnnotation] != wantChecksum {
+			t.Errorf("UpdateStatefulSetConfig(%s) = %s, %d restarts, checksum %s; want %s, %d, %s", checksum, phase, mgr.restarts,
+				statefulSet.GetAnnotations()[configChecksumAnnotation], wantPhase, wantRestarts, wantChecksum)
+		}
+	}
+
+	// checksum is recorded without restarting pods that already use the configuration
+	test("one", enterprisev1.PhaseReady, 0, "one")
+	test("one", enterprisev1.PhaseReady, 0, "one")
+
+	// changes wait for the kubelet to update pods before restarting them
+	test("two", enterprisev1.PhaseUpdating, 0, "one")
+	if statefulSet.GetAnnotations()[pendingConfigChecksumAnnotation] != "two" {
+		t.Errorf("UpdateStatefulSetConfig(two) pending checksum = %s; want two", statefulSet.GetAnnotations()[pendingConfigChecksumAnnotation])
+	}
+	test("two", enterprisev1.PhaseUpdating, 0, "one")
+	statefulSet.Annotations[configUpdatedAnnotation] = time.Now().Add(-configSyncDelay).UTC().Format(time.RFC3339)
+	test("two", enterprisev1.PhaseUpdating, 1, "two")
+	if _, ok := statefulSet.GetAnnotations()[pendingConfigChecksumAnnotation]; ok {
+		t.Errorf("UpdateStatefulSetConfig(two) did not remove pending checksum")
+	}
+
+	// wait for rolling restart to complete
+	test("two", enterprisev1.PhaseUpdating, 1, "two")
+	mgr.rollingRestart = false
+	test("two", enterprisev1.PhaseReady, 1, "two")
+}
+
+// restartingPodManager is a StatefulSetPodManager that applies restart-only configuration using rolling restarts
+type restartingPodManager struct {
+	DefaultStatefulSetPodManager
+	mockRollingRestartManager
+}
+
+func TestDefaultsStatefulSetUpdates(t *testing.T) {
+	cr := enterprisev1.IndexerCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+	}
+	cr.Spec.Replicas = 1
+	cr.Spec.Defaults = "splunk:\n  conf: {}\n"
+	current, err := enterprise.GetIndexerStatefulSet(&cr)
+	if err != nil {
+		t.Fatalf("GetIndexerStatefulSet() returned error: %v", err)
+	}
+	current.Status = appsv1.StatefulSetStatus{Replicas: 1, ReadyReplicas: 1, UpdatedReplicas: 1, UpdateRevision: "v1"}
+	c := newMockClient()
+	c.state[getStateKey(current)] = current
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "splunk-stack1-indexer-0",
+			Namespace: "test",
+			Labels:    map[string]string{"controller-revision-hash": "v1"},
+		},
+		Status: corev1.PodStatus{
+			Phase:             corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{Ready: true}},
+		},
+	}
+
+	// defaults are only applied by splunk-ansible when pods start, so pods are recycled even by managers that
+	// apply restart-only configuration using rolling restarts
+	test := func(update func()) {
+		update()
+		revised, err := enterprise.GetIndexerStatefulSet(&cr)
+		if err != nil {
+			t.Fatalf("GetIndexerStatefulSet() returned error: %v", err)
+		}
+		phase, err := ApplyStatefulSet(c, revised, nil)
+		if err != nil || phase != enterprisev1.PhaseUpdating {
+			t.Fatalf("ApplyStatefulSet() = %s, %v; want %s, nil", phase, err, enterprisev1.PhaseUpdating)
+		}
+		revised.Status.UpdateRevision = "v2"
+		c.state[getStateKey(pod)] = pod
+		c.resetCalls()
+		if _, err = UpdateStatefulSetPods(c, revised, &restartingPodManager{}, 1, "", 0); err != nil {
+			t.Errorf("UpdateStatefulSetPods() returned error: %v", err)
+		}
+		if len(c.calls["Delete"]) != 1 {
+			t.Errorf("UpdateStatefulSetPods() did not recycle pod for changes to defaults")
+		}
+		revised.Status.UpdateRevision = "v1"
+	}
+	test(func() { cr.Spec.Defaults = "splunk:\n  conf:\n    server: {}\n" })
+	test(func() { cr.Spec.DefaultsURL = "/mnt/defaults/default.yml" })
+}
@@ -28,6 +28,8 @@ import (
 	//stdlog "log"
 	//"github.com/go-logr/stdr"
 
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
 	"github.com/splunk/splunk-operator/pkg/splunk/resources"
 )
 
@@ -262,3 +264,134 @@ func MergeServiceSpecUpdates(current *corev1.ServiceSpec, revised *corev1.Servic
 
 	return result
 }
+
+// listSplunkResources returns all of the Splunk Enterprise custom resources within a namespace, except for those
+// that are being deleted. Their specs are validated, so that defaults have been applied; invalid resources are skipped.
+func listSplunkResources(c ControllerClient, namespace string) ([]enterprisev1.MetaObject, error) {
+	listOpts := []client.ListOption{client.InNamespace(namespace)}
+	result := []enterprisev1.MetaObject{}
+
+	var standalones enterprisev1.StandaloneList
+	if err := c.List(context.TODO(), &standalones, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range standalones.Items {
+		if err := enterprise.ValidateStandaloneSpec(&standalones.Items[i].Spec); err == nil {
+			result = append(result, &standalones.Items[i])
+		}
+	}
+
+	var licenseMasters enterprisev1.LicenseMasterList
+	if err := c.List(context.TODO(), &licenseMasters, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range licenseMasters.Items {
+		if err := enterprise.ValidateLicenseMasterSpec(&licenseMasters.Items[i].Spec); err == nil {
+			result = append(result, &licenseMasters.Items[i])
+		}
+	}
+
+	var clusterMasters enterprisev1.ClusterMasterList
+	if err := c.List(context.TODO(), &clusterMasters, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range clusterMasters.Items {
+		if err := enterprise.ValidateClusterMasterSpec(&clusterMasters.Items[i].Spec); err == nil {
+			result = append(result, &clusterMasters.Items[i])
+		}
+	}
+
+	var searchHeadClusters enterprisev1.SearchHeadClusterList
+	if err := c.List(context.TODO(), &searchHeadClusters, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range searchHeadClusters.Items {
+		if err := enterprise.ValidateSearchHeadClusterSpec(&searchHeadClusters.Items[i].Spec); err == nil {
+			result = append(result, &searchHeadClusters.Items[i])
+		}
+	}
+
+	var indexerClusters enterprisev1.IndexerClusterList
+	if err := c.List(context.TODO(), &indexerClusters, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range indexerClusters.Items {
+		if err := enterprise.ValidateIndexerClusterSpec(&indexerClusters.Items[i].Spec); err == nil {
+			result = append(result, &indexerClusters.Items[i])
+		}
+	}
+
+	var monitoringConsoles enterprisev1.MonitoringConsoleList
+	if err := c.List(context.TODO(), &monitoringConsoles, listOpts...); err != nil {
+		return nil, err
+	}
+	for i := range monitoringConsoles.Items {
+		if err := enterprise.ValidateMonitoringConsoleSpec(&monitoringConsoles.Items[i].Spec); err == nil {
+			result = append(result, &monitoringConsoles.Items[i])
+		}
+	}
+
+	// skip resources that are being deleted
+	active := result[:0]
+	for _, cr := range result {
+		if cr.GetObjectMeta().GetDeletionTimestamp() == nil {
+			active = append(active, cr)
+		}
+	}
+	return active, nil
+}
+
+// getSecretsInstanceType returns the type of Splunk instance used to name the secrets of a Splunk Enterprise custom resource
+func getSecretsInstanceType(cr enterprisev1.MetaObject) enterprise.InstanceType {
+	switch cr.(type) {
+	case *enterprisev1.Standalone:
+		return enterprise.SplunkStandalone
+	case *enterprisev1.LicenseMaster:
+		return enterprise.SplunkLicenseMaster
+	case *enterprisev1.SearchHeadCluster:
+		return enterprise.SplunkSearchHead
+	case *enterprisev1.MonitoringConsole:
+		return enterprise.SplunkMonitoringConsole
+	case *enterprisev1.ClusterMaster:
+		return enterprise.SplunkClusterMaster
+	}
+	return enterprise.SplunkIndexer
+}
+
+// getCommonSplunkSpec returns the spec parameters shared by all Splunk Enterprise custom resources
+func getCommonSplunkSpec(cr enterprisev1.MetaObject) *enterprisev1.CommonSplunkSpec {
+	switch cr := cr.(type) {
+	case *enterprisev1.Standalone:
+		return &cr.Spec.CommonSplunkSpec
+	case *enterprisev1.LicenseMaster:
+		return &cr.Spec.CommonSplunkSpec
+	case *enterprisev1.SearchHeadCluster:
+		return &cr.Spec.CommonSplunkSpec
+	case *enterprisev1.IndexerCluster:
+		return &cr.Spec.CommonSplunkSpec
+	case *enterprisev1.ClusterMaster:
+		return &cr.Spec.CommonSplunkSpec
+	case *enterprisev1.MonitoringConsole:
+		return &cr.Spec.CommonSplunkSpec
+	}
+	return nil
+}
+
+// getResourcePhase returns the current phase of a Splunk Enterprise custom resource
+func getResourcePhase(cr enterprisev1.MetaObject) enterprisev1.ResourcePhase {
+	switch cr := cr.(type) {
+	case *enterprisev1.Standalone:
+		return cr.Status.Phase
+	case *enterprisev1.LicenseMaster:
+		return cr.Status.Phase
+	case *enterprisev1.SearchHeadCluster:
+		return cr.Status.Phase
+	case *enterprisev1.IndexerCluster:
+		return cr.Status.Phase
+	case *enterprisev1.ClusterMaster:
+		return cr.Status.Phase
+	case *enterprisev1.MonitoringConsole:
+		return cr.Status.Phase
+	}
+	return enterprisev1.PhasePending
+}
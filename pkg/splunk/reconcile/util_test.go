@@ -19,13 +19,18 @@ import (
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"testing"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -45,20 +50,30 @@ func copyResource(dst runtime.Object, src runtime.Object) {
 		*dst.(*corev1.Service) = *src.(*corev1.Service)
 	case *corev1.Pod:
 		*dst.(*corev1.Pod) = *src.(*corev1.Pod)
+	case *policyv1beta1.PodDisruptionBudget:
+		*dst.(*policyv1beta1.PodDisruptionBudget) = *src.(*policyv1beta1.PodDisruptionBudget)
 	case *appsv1.Deployment:
 		*dst.(*appsv1.Deployment) = *src.(*appsv1.Deployment)
 	case *appsv1.StatefulSet:
 		*dst.(*appsv1.StatefulSet) = *src.(*appsv1.StatefulSet)
+	case *storagev1.StorageClass:
+		*dst.(*storagev1.StorageClass) = *src.(*storagev1.StorageClass)
+	case *enterprisev1.ClusterMaster:
+		*dst.(*enterprisev1.ClusterMaster) = *src.(*enterprisev1.ClusterMaster)
 	case *enterprisev1.IndexerCluster:
 		*dst.(*enterprisev1.IndexerCluster) = *src.(*enterprisev1.IndexerCluster)
 	case *enterprisev1.LicenseMaster:
 		*dst.(*enterprisev1.LicenseMaster) = *src.(*enterprisev1.LicenseMaster)
+	case *enterprisev1.MonitoringConsole:
+		*dst.(*enterprisev1.MonitoringConsole) = *src.(*enterprisev1.MonitoringConsole)
 	case *enterprisev1.SearchHeadCluster:
 		*dst.(*enterprisev1.SearchHeadCluster) = *src.(*enterprisev1.SearchHeadCluster)
 	case *enterprisev1.Spark:
 		*dst.(*enterprisev1.Spark) = *src.(*enterprisev1.Spark)
 	case *enterprisev1.Standalone:
 		*dst.(*enterprisev1.Standalone) = *src.(*enterprisev1.Standalone)
+	case *unstructured.Unstructured:
+		src.(*unstructured.Unstructured).DeepCopyInto(dst.(*unstructured.Unstructured))
 	default:
 		dst = src
 	}
@@ -66,9 +81,10 @@ func copyResource(dst runtime.Object, src runtime.Object) {
 
 // getStateKeyFromObject returns a lookup key for the mockClient's state map
 func getStateKey(obj runtime.Object) string {
+	objMeta, _ := meta.Accessor(obj)
 	key := client.ObjectKey{
-		Name:      obj.(ResourceObject).GetObjectMeta().GetName(),
-		Namespace: obj.(ResourceObject).GetObjectMeta().GetNamespace(),
+		Name:      objMeta.GetName(),
+		Namespace: objMeta.GetNamespace(),
 	}
 	return getStateKeyWithKey(key, obj)
 }
@@ -77,6 +93,10 @@ func getStateKey(obj runtime.Object) string {
 func getStateKeyWithKey(key client.ObjectKey, obj runtime.Object) string {
 	kind := reflect.TypeOf(obj).String()
 	//_, kind := obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		// unstructured objects are distinguished by their kind
+		kind = u.GetKind()
+	}
 	return fmt.Sprintf("%s-%s-%s", kind, key.Namespace, key.Name)
 }
 
@@ -161,7 +181,35 @@ func (c mockClient) List(ctx context.Context, obj runtime.Object, opts ...client
 		copyResource(obj, listObj.(runtime.Object))
 		return nil
 	}
-	return c.notFoundError
+
+	// otherwise, return all objects in the mock state that have the list's item type
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	keys := []string{}
+	for key := range c.state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	items := []runtime.Object{}
+	for _, key := range keys {
+		item, ok := c.state[key].(runtime.Object)
+		if !ok {
+			continue // deleted
+		}
+		if u, ok := item.(*unstructured.Unstructured); ok {
+			if list, ok := obj.(*unstructured.UnstructuredList); !ok || u.GetKind()+"List" != list.GetKind() {
+				continue
+			}
+		} else if reflect.TypeOf(item).String()+"List" != reflect.TypeOf(obj).String() {
+			continue
+		}
+		itemMeta, _ := meta.Accessor(item)
+		if listOpts.Namespace != "" && itemMeta.GetNamespace() != listOpts.Namespace {
+			continue
+		}
+		items = append(items, item)
+	}
+	return meta.SetList(obj, items)
 }
 
 // Create returns mock client's err field
@@ -194,12 +242,13 @@ func (c mockClient) Update(ctx context.Context, obj runtime.Object, opts ...clie
 	return nil
 }
 
-// Patch returns mock client's err field
+// Patch returns mock client's err field; obj is expected to have been changed by the caller to match the patch
 func (c mockClient) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
 	c.calls["Patch"] = append(c.calls["Patch"], mockFuncCall{
 		ctx: ctx,
 		obj: obj,
 	})
+	c.state[getStateKey(obj)] = obj
 	return nil
 }
 
@@ -0,0 +1,74 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+// GetCondition returns the condition of the given type, or nil if it does not exist.
+func GetCondition(conditions []enterprisev1.Condition, conditionType enterprisev1.ConditionType) *enterprisev1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// IsConditionTrue returns true if the condition of the given type exists and has a status of True.
+func IsConditionTrue(conditions []enterprisev1.Condition, conditionType enterprisev1.ConditionType) bool {
+	condition := GetCondition(conditions, conditionType)
+	return condition != nil && condition.Status == corev1.ConditionTrue
+}
+
+// SetCondition adds or updates a condition of the given type. LastTransitionTime is only
+// changed when the status of an existing condition changes.
+func SetCondition(conditions *[]enterprisev1.Condition, generation int64, conditionType enterprisev1.ConditionType, status corev1.ConditionStatus, reason, message string) {
+	condition := GetCondition(*conditions, conditionType)
+	if condition == nil {
+		*conditions = append(*conditions, enterprisev1.Condition{Type: conditionType})
+		condition = &(*conditions)[len(*conditions)-1]
+	}
+	if condition.Status != status {
+		condition.Status = status
+		condition.LastTransitionTime = metav1.Now()
+	}
+	condition.ObservedGeneration = generation
+	condition.Reason = reason
+	condition.Message = message
+}
+
+// SetConditionFromBool is a convenience wrapper for SetCondition that converts a bool into a condition status.
+func SetConditionFromBool(conditions *[]enterprisev1.Condition, generation int64, conditionType enterprisev1.ConditionType, value bool, reason, message string) {
+	status := corev1.ConditionFalse
+	if value {
+		status = corev1.ConditionTrue
+	}
+	SetCondition(conditions, generation, conditionType, status, reason, message)
+}
+
+// RemoveCondition removes the condition of the given type, if it exists.
+func RemoveCondition(conditions *[]enterprisev1.Condition, conditionType enterprisev1.ConditionType) {
+	for i := range *conditions {
+		if (*conditions)[i].Type == conditionType {
+			*conditions = append((*conditions)[:i], (*conditions)[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package resources
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+)
+
+func TestSetCondition(t *testing.T) {
+	conditions := []enterprisev1.Condition{}
+
+	SetCondition(&conditions, 1, enterprisev1.ConditionReady, corev1.ConditionFalse, "Pending", "waiting")
+	if len(conditions) != 1 {
+		t.Fatalf("SetCondition() len = %d; want %d", len(conditions), 1)
+	}
+	got := GetCondition(conditions, enterprisev1.ConditionReady)
+	if got == nil || got.Status != corev1.ConditionFalse || got.Reason != "Pending" || got.Message != "waiting" || got.ObservedGeneration != 1 {
+		t.Errorf("SetCondition() = %v; want Ready=False", got)
+	}
+	transitionTime := got.LastTransitionTime
+	if transitionTime.IsZero() {
+		t.Errorf("SetCondition() LastTransitionTime was not set")
+	}
+
+	// same status should not change transition time
+	SetCondition(&conditions, 2, enterprisev1.ConditionReady, corev1.ConditionFalse, "ScalingUp", "")
+	got = GetCondition(conditions, enterprisev1.ConditionReady)
+	if got.LastTransitionTime != transitionTime || got.Reason != "ScalingUp" || got.ObservedGeneration != 2 {
+		t.Errorf("SetCondition() = %v; want unchanged transition time and updated reason", got)
+	}
+
+	SetConditionFromBool(&conditions, 2, enterprisev1.ConditionSecretsApplied, true, "SecretsApplied", "")
+	if len(conditions) != 2 {
+		t.Errorf("SetConditionFromBool() len = %d; want %d", len(conditions), 2)
+	}
+	if !IsConditionTrue(conditions, enterprisev1.ConditionSecretsApplied) {
+		t.Errorf("IsConditionTrue(SecretsApplied) = false; want true")
+	}
+	if IsConditionTrue(conditions, enterprisev1.ConditionReady) {
+		t.Errorf("IsConditionTrue(Ready) = true; want false")
+	}
+	if IsConditionTrue(conditions, enterprisev1.ConditionCaptainReady) {
+		t.Errorf("IsConditionTrue(CaptainReady) = true; want false")
+	}
+
+	RemoveCondition(&conditions, enterprisev1.ConditionReady)
+	if len(conditions) != 1 || GetCondition(conditions, enterprisev1.ConditionReady) != nil {
+		t.Errorf("RemoveCondition() = %v; want only SecretsApplied", conditions)
+	}
+}
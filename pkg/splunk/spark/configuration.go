@@ -15,6 +15,11 @@
 package spark
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -112,15 +117,153 @@ func ValidateSparkSpec(spec *enterprisev1.SparkSpec) error {
 			corev1.ResourceMemory: resource.MustParse("8Gi"),
 		},
 	}
-	return resources.ValidateCommonSpec(&spec.CommonSpec, defaultResources)
+	err := resources.ValidateCommonSpec(&spec.CommonSpec, defaultResources)
+	if err != nil {
+		return err
+	}
+
+	// resources of the master and workers default to those shared by both
+	for _, instanceSpec := range []*enterprisev1.SparkInstanceSpec{&spec.Master, &spec.Worker.SparkInstanceSpec} {
+		if instanceSpec.Resources != nil {
+			resources.ValidateResources(instanceSpec.Resources, spec.Resources)
+		}
+		err = validateSparkConf(instanceSpec.SparkConf)
+		if err != nil {
+			return err
+		}
+	}
+
+	if spec.Worker.Cores < 0 {
+		return fmt.Errorf("Spark worker cores must not be negative: %d", spec.Worker.Cores)
+	}
+	if spec.Worker.Memory != "" && !sparkMemoryRegex.MatchString(spec.Worker.Memory) {
+		return fmt.Errorf("Spark worker memory must be a number optionally followed by k, m, g or t: %s", spec.Worker.Memory)
+	}
+
+	return nil
 }
 
-// GetSparkDeployment returns a Kubernetes Deployment object for the Spark master configured for a Spark resource.
+// sparkMemoryRegex matches amounts of memory in the format used by Spark, for example "4g"
+var sparkMemoryRegex = regexp.MustCompile(`^[0-9]+[kmgtKMGT]?$`)
+
+// validateSparkConf returns an error if Spark configuration properties cannot be passed as java system properties.
+func validateSparkConf(sparkConf map[string]string) error {
+	for key, value := range sparkConf {
+		if key == "" || strings.ContainsAny(key, " \t\n=") {
+			return fmt.Errorf("Invalid Spark configuration property name: %q", key)
+		}
+		if strings.ContainsAny(value, " \t\n") {
+			return fmt.Errorf("Spark configuration property %s must not contain whitespace: %q", key, value)
+		}
+	}
+	return nil
+}
+
+// getSparkInstanceSpec returns the settings of a Spark resource specific to the master or workers.
+func getSparkInstanceSpec(cr *enterprisev1.Spark, instanceType InstanceType) *enterprisev1.SparkInstanceSpec {
+	if instanceType == SparkMaster {
+		return &cr.Spec.Master
+	}
+	return &cr.Spec.Worker.SparkInstanceSpec
+}
+
+// getSparkOpts returns the value of the SPARK_MASTER_OPTS or SPARK_WORKER_OPTS environment variable used to pass
+// additional configuration properties (note that order is important for tests).
+func getSparkOpts(sparkConf map[string]string) string {
+	opts := make([]string, 0, len(sparkConf))
+	for key, value := range sparkConf {
+		opts = append(opts, fmt.Sprintf("-D%s=%s", key, value))
+	}
+	sort.Strings(opts)
+	return strings.Join(opts, " ")
+}
+
+// GetSparkDeployment returns a Kubernetes Deployment object for Spark instances configured for a Spark resource.
 func GetSparkDeployment(cr *enterprisev1.Spark, instanceType InstanceType) (*appsv1.Deployment, error) {
+	replicas := getSparkReplicas(cr, instanceType)
+	podTemplate, selectLabels, err := getSparkPodTemplate(cr, instanceType)
+	if err != nil {
+		return nil, err
+	}
+	podTemplate.Spec.Hostname = GetSparkServiceName(instanceType, cr.GetIdentifier(), false)
+
+	// create deployment configuration
+	deployment := &appsv1.Deployment{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "Deployment",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSparkDeploymentName(instanceType, cr.GetIdentifier()),
+			Namespace: cr.GetNamespace(),
+		},
+		Spec: appsv1.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectLabels,
+			},
+			Replicas: &replicas,
+			Template: *podTemplate,
+		},
+	}
+
+	// make Spark object the owner
+	deployment.SetOwnerReferences(append(deployment.GetOwnerReferences(), resources.AsOwner(cr)))
+
+	return deployment, nil
+}
+
+// GetSparkStatefulSet returns a Kubernetes StatefulSet object for Spark instances configured for a Spark resource.
+// Pods are updated by the operator (OnDelete), so that each worker can be drained before it is recycled.
+func GetSparkStatefulSet(cr *enterprisev1.Spark, instanceType InstanceType) (*appsv1.StatefulSet, error) {
+	replicas := getSparkReplicas(cr, instanceType)
+	podTemplate, selectLabels, err := getSparkPodTemplate(cr, instanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	// create statefulset configuration
+	statefulSet := &appsv1.StatefulSet{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "StatefulSet",
+			APIVersion: "apps/v1",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      GetSparkStatefulsetName(instanceType, cr.GetIdentifier()),
+			Namespace: cr.GetNamespace(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: selectLabels,
+			},
+			ServiceName:         GetSparkServiceName(instanceType, cr.GetIdentifier(), true),
+			Replicas:            &replicas,
+			PodManagementPolicy: appsv1.ParallelPodManagement,
+			UpdateStrategy: appsv1.StatefulSetUpdateStrategy{
+				Type: appsv1.OnDeleteStatefulSetStrategyType,
+			},
+			Template: *podTemplate,
+		},
+	}
+
+	// make Spark object the owner
+	statefulSet.SetOwnerReferences(append(statefulSet.GetOwnerReferences(), resources.AsOwner(cr)))
+
+	return statefulSet, nil
+}
+
+// getSparkReplicas returns the number of replicas to use for Spark instances.
+func getSparkReplicas(cr *enterprisev1.Spark, instanceType InstanceType) int32 {
+	if instanceType == SparkWorker {
+		return int32(cr.Spec.Replicas)
+	}
+	return 1
+}
+
+// getSparkPodTemplate returns the pod template and selector labels to use for Spark instances.
+func getSparkPodTemplate(cr *enterprisev1.Spark, instanceType InstanceType) (*corev1.PodTemplateSpec, map[string]string, error) {
 	// prepare type specific variables (note that port order is important for tests)
 	var ports []corev1.ContainerPort
 	var envVariables []corev1.EnvVar
-	var replicas int32
 	switch instanceType {
 	case SparkMaster:
 		ports = resources.SortContainerPorts(getSparkMasterContainerPorts())
@@ -130,7 +273,6 @@ func GetSparkDeployment(cr *enterprisev1.Spark, instanceType InstanceType) (*app
 				Value: "splunk_spark_master",
 			},
 		}
-		replicas = 1
 	case SparkWorker:
 		ports = resources.SortContainerPorts(getSparkWorkerContainerPorts())
 		envVariables = []corev1.EnvVar{
@@ -145,70 +287,75 @@ func GetSparkDeployment(cr *enterprisev1.Spark, instanceType InstanceType) (*app
 				Value: "7777",
 			},
 		}
-		replicas = int32(cr.Spec.Replicas)
+	}
+
+	// append settings specific to the master or workers
+	instanceSpec := getSparkInstanceSpec(cr, instanceType)
+	if instanceType == SparkWorker {
+		if cr.Spec.Worker.Cores > 0 {
+			envVariables = append(envVariables, corev1.EnvVar{Name: "SPARK_WORKER_CORES", Value: fmt.Sprintf("%d", cr.Spec.Worker.Cores)})
+		}
+		if cr.Spec.Worker.Memory != "" {
+			envVariables = append(envVariables, corev1.EnvVar{Name: "SPARK_WORKER_MEMORY", Value: cr.Spec.Worker.Memory})
+		}
+	}
+	if len(instanceSpec.SparkConf) > 0 {
+		optsName := "SPARK_WORKER_OPTS"
+		if instanceType == SparkMaster {
+			optsName = "SPARK_MASTER_OPTS"
+		}
+		envVariables = append(envVariables, corev1.EnvVar{Name: optsName, Value: getSparkOpts(instanceSpec.SparkConf)})
 	}
 
 	// prepare labels, annotations and affinity
 	annotations := resources.GetIstioAnnotations(ports)
-	affinity := resources.AppendPodAntiAffinity(&cr.Spec.Affinity, cr.GetIdentifier(), instanceType.ToString())
+	affinity := &cr.Spec.Affinity
+	if instanceSpec.Affinity != nil {
+		affinity = instanceSpec.Affinity
+	}
+	affinity = resources.AppendPodAntiAffinity(affinity, cr.GetIdentifier(), instanceType.ToString())
+	tolerations := cr.Spec.Tolerations
+	if instanceSpec.Tolerations != nil {
+		tolerations = instanceSpec.Tolerations
+	}
 	selectLabels := getSparkLabels(cr.GetIdentifier(), instanceType)
 	labels := make(map[string]string)
 	for k, v := range selectLabels {
 		labels[k] = v
 	}
 
-	// create deployment configuration
-	deployment := &appsv1.Deployment{
-		TypeMeta: metav1.TypeMeta{
-			Kind:       "Deployment",
-			APIVersion: "apps/v1",
-		},
+	podTemplate := &corev1.PodTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      GetSparkDeploymentName(instanceType, cr.GetIdentifier()),
-			Namespace: cr.GetNamespace(),
+			Labels:      labels,
+			Annotations: annotations,
 		},
-		Spec: appsv1.DeploymentSpec{
-			Selector: &metav1.LabelSelector{
-				MatchLabels: selectLabels,
-			},
-			Replicas: &replicas,
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels:      labels,
-					Annotations: annotations,
-				},
-				Spec: corev1.PodSpec{
-					Affinity:      affinity,
-					Tolerations:   cr.Spec.Tolerations,
-					SchedulerName: cr.Spec.SchedulerName,
-					Hostname:      GetSparkServiceName(instanceType, cr.GetIdentifier(), false),
-					Containers: []corev1.Container{
-						{
-							Image:           cr.Spec.Image,
-							ImagePullPolicy: corev1.PullPolicy(cr.Spec.ImagePullPolicy),
-							Name:            "spark",
-							Ports:           ports,
-							Env:             envVariables,
-						},
-					},
+		Spec: corev1.PodSpec{
+			Affinity:      affinity,
+			Tolerations:   tolerations,
+			NodeSelector:  instanceSpec.NodeSelector,
+			SchedulerName: cr.Spec.SchedulerName,
+			Containers: []corev1.Container{
+				{
+					Image:           cr.Spec.Image,
+					ImagePullPolicy: corev1.PullPolicy(cr.Spec.ImagePullPolicy),
+					Name:            "spark",
+					Ports:           ports,
+					Env:             envVariables,
 				},
 			},
 		},
 	}
 
 	// append labels and annotations from parent
-	resources.AppendParentMeta(deployment.Spec.Template.GetObjectMeta(), cr.GetObjectMeta())
-
-	// make Spark object the owner
-	deployment.SetOwnerReferences(append(deployment.GetOwnerReferences(), resources.AsOwner(cr)))
+	resources.AppendParentMeta(podTemplate.GetObjectMeta(), cr.GetObjectMeta())
 
 	// update with common spark pod config
-	err := updateSparkPodTemplateWithConfig(&deployment.Spec.Template, cr, instanceType)
+	err := updateSparkPodTemplateWithConfig(podTemplate, cr, instanceType)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return deployment, nil
+	return podTemplate, selectLabels, nil
 }
 
 // GetSparkService returns a Kubernetes Service object for Spark instances configured for a Spark resource.
@@ -304,9 +451,15 @@ func updateSparkPodTemplateWithConfig(podTemplateSpec *corev1.PodTemplateSpec, c
 		PeriodSeconds:       10,
 	}
 
+	// use resources of the master or workers, if provided
+	containerResources := cr.Spec.Resources
+	if instanceSpec := getSparkInstanceSpec(cr, instanceType); instanceSpec.Resources != nil {
+		containerResources = *instanceSpec.Resources
+	}
+
 	// update each container in pod
 	for idx := range podTemplateSpec.Spec.Containers {
-		podTemplateSpec.Spec.Containers[idx].Resources = cr.Spec.Resources
+		podTemplateSpec.Spec.Containers[idx].Resources = containerResources
 		podTemplateSpec.Spec.Containers[idx].LivenessProbe = livenessProbe
 		podTemplateSpec.Spec.Containers[idx].ReadinessProbe = readinessProbe
 	}
@@ -18,6 +18,8 @@ import (
 	"encoding/json"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
@@ -58,6 +60,125 @@ func TestGetSparkDeployment(t *testing.T) {
 	test(SparkWorker, `{"kind":"Deployment","apiVersion":"apps/v1","metadata":{"name":"splunk-stack1-spark-worker","namespace":"test","creationTimestamp":null,"ownerReferences":[{"apiVersion":"","kind":"","name":"stack1","uid":"","controller":true}]},"spec":{"replicas":3,"selector":{"matchLabels":{"app.kubernetes.io/component":"spark","app.kubernetes.io/instance":"splunk-stack1-spark-worker","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"spark-worker","app.kubernetes.io/part-of":"splunk-stack1-spark"}},"template":{"metadata":{"creationTimestamp":null,"labels":{"app.kubernetes.io/component":"spark","app.kubernetes.io/instance":"splunk-stack1-spark-worker","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"spark-worker","app.kubernetes.io/part-of":"splunk-stack1-spark"},"annotations":{"traffic.sidecar.istio.io/excludeOutboundPorts":"8089,8191,9997,7777,9000,17000,17500,19000","traffic.sidecar.istio.io/includeInboundPorts":"7000"}},"spec":{"containers":[{"name":"spark","image":"splunk/spark","ports":[{"name":"workerwebui","containerPort":7000,"protocol":"TCP"},{"name":"dfwreceivedata","containerPort":17500,"protocol":"TCP"}],"env":[{"name":"SPLUNK_ROLE","value":"splunk_spark_worker"},{"name":"SPARK_MASTER_HOSTNAME","value":"splunk-stack1-spark-master-service"},{"name":"SPARK_WORKER_PORT","value":"7777"}],"resources":{"limits":{"cpu":"4","memory":"8Gi"},"requests":{"cpu":"100m","memory":"512Mi"}},"livenessProbe":{"httpGet":{"path":"/","port":7000},"initialDelaySeconds":30,"timeoutSeconds":10,"periodSeconds":10},"readinessProbe":{"httpGet":{"path":"/","port":7000},"initialDelaySeconds":5,"timeoutSeconds":10,"periodSeconds":10},"imagePullPolicy":"IfNotPresent"}],"securityContext":{"runAsUser":41812,"fsGroup":41812},"hostname":"splunk-stack1-spark-worker-service","affinity":{"podAntiAffinity":{"preferredDuringSchedulingIgnoredDuringExecution":[{"weight":100,"podAffinityTerm":{"labelSelector":{"matchExpressions":[{"key":"app.kubernetes.io/instance","operator":"In","values":["splunk-stack1-spark-worker"]}]},"topologyKey":"kubernetes.io/hostname"}}]}},"schedulerName":"default-scheduler"}},"strategy":{}},"status":{}}`)
 }
 
+func TestGetSparkDeploymentInstanceSpec(t *testing.T) {
+	masterResources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("0.5")},
+	}
+	masterAffinity := corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{Weight: 1}},
+		},
+	}
+	workerTolerations := []corev1.Toleration{{Key: "spark", Operator: corev1.TolerationOpExists}}
+	cr := enterprisev1.Spark{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.SparkSpec{
+			CommonSpec: enterprisev1.CommonSpec{
+				Tolerations: []corev1.Toleration{{Key: "splunk", Operator: corev1.TolerationOpExists}},
+			},
+			Master: enterprisev1.SparkInstanceSpec{
+				Resources: &masterResources,
+				Affinity:  &masterAffinity,
+				SparkConf: map[string]string{"spark.deploy.retainedApplications": "10"},
+			},
+			Worker: enterprisev1.SparkWorkerSpec{
+				SparkInstanceSpec: enterprisev1.SparkInstanceSpec{
+					Tolerations:  workerTolerations,
+					NodeSelector: map[string]string{"node-type": "spark"},
+					SparkConf:    map[string]string{"spark.worker.cleanup.enabled": "true", "spark.worker.cleanup.appDataTtl": "3600"},
+				},
+				Cores:  4,
+				Memory: "6g",
+			},
+		},
+	}
+	err := ValidateSparkSpec(&cr.Spec)
+	if err != nil {
+		t.Errorf("ValidateSparkSpec() returned error: %v", err)
+	}
+
+	test := func(instanceType InstanceType, wantResources, wantAffinity, wantTolerations, wantNodeSelector, wantEnv string) {
+		deployment, err := GetSparkDeployment(&cr, instanceType)
+		if err != nil {
+			t.Errorf("GetSparkDeployment() returned error: %v", err)
+		}
+		podSpec := deployment.Spec.Template.Spec
+		check := func(field string, obj interface{}, want string) {
+			got, _ := json.Marshal(obj)
+			if string(got) != want {
+				t.Errorf("GetSparkDeployment(\"%s\") %s = %s; want %s", instanceType, field, got, want)
+			}
+		}
+		check("resources", podSpec.Containers[0].Resources, wantResources)
+		check("affinity", podSpec.Affinity.NodeAffinity, wantAffinity)
+		check("tolerations", podSpec.Tolerations, wantTolerations)
+		check("nodeSelector", podSpec.NodeSelector, wantNodeSelector)
+		check("env", podSpec.Containers[0].Env, wantEnv)
+	}
+
+	test(SparkMaster,
+		`{"limits":{"cpu":"4","memory":"8Gi"},"requests":{"cpu":"500m","memory":"512Mi"}}`,
+		`{"preferredDuringSchedulingIgnoredDuringExecution":[{"weight":1,"preference":{}}]}`,
+		`[{"key":"splunk","operator":"Exists"}]`,
+		`null`,
+		`[{"name":"SPLUNK_ROLE","value":"splunk_spark_master"},{"name":"SPARK_MASTER_OPTS","value":"-Dspark.deploy.retainedApplications=10"}]`)
+	test(SparkWorker,
+		`{"limits":{"cpu":"4","memory":"8Gi"},"requests":{"cpu":"100m","memory":"512Mi"}}`,
+		`null`,
+		`[{"key":"spark","operator":"Exists"}]`,
+		`{"node-type":"spark"}`,
+		`[{"name":"SPLUNK_ROLE","value":"splunk_spark_worker"},{"name":"SPARK_MASTER_HOSTNAME","value":"splunk-stack1-spark-master-service"},{"name":"SPARK_WORKER_PORT","value":"7777"},`+
+			`{"name":"SPARK_WORKER_CORES","value":"4"},{"name":"SPARK_WORKER_MEMORY","value":"6g"},{"name":"SPARK_WORKER_OPTS","value":"-Dspark.worker.cleanup.appDataTtl=3600 -Dspark.worker.cleanup.enabled=true"}]`)
+}
+
+func TestValidateSparkSpec(t *testing.T) {
+	test := func(spec enterprisev1.SparkSpec, wantErr bool) {
+		err := ValidateSparkSpec(&spec)
+		if (err != nil) != wantErr {
+			t.Errorf("ValidateSparkSpec(%v) returned %v; want error %t", spec, err, wantErr)
+		}
+	}
+
+	test(enterprisev1.SparkSpec{}, false)
+	test(enterprisev1.SparkSpec{Worker: enterprisev1.SparkWorkerSpec{Cores: 2, Memory: "512m"}}, false)
+	test(enterprisev1.SparkSpec{Worker: enterprisev1.SparkWorkerSpec{Cores: -1}}, true)
+	test(enterprisev1.SparkSpec{Worker: enterprisev1.SparkWorkerSpec{Memory: "4 GB"}}, true)
+	test(enterprisev1.SparkSpec{Master: enterprisev1.SparkInstanceSpec{SparkConf: map[string]string{"spark.ui.port=8080": "true"}}}, true)
+	test(enterprisev1.SparkSpec{Master: enterprisev1.SparkInstanceSpec{SparkConf: map[string]string{"spark.ui.title": "my title"}}}, true)
+}
+
+func TestGetSparkStatefulSet(t *testing.T) {
+	cr := enterprisev1.Spark{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "stack1",
+			Namespace: "test",
+		},
+		Spec: enterprisev1.SparkSpec{
+			Replicas: 3,
+		},
+	}
+	err := ValidateSparkSpec(&cr.Spec)
+	if err != nil {
+		t.Errorf("ValidateSparkSpec() returned error: %v", err)
+	}
+
+	statefulSet, err := GetSparkStatefulSet(&cr, SparkWorker)
+	if err != nil {
+		t.Errorf("GetSparkStatefulSet() returned error: %v", err)
+	}
+	got, err := json.Marshal(statefulSet)
+	if err != nil {
+		t.Errorf("GetSparkStatefulSet(\"%s\",%d) failed to marshall: %v", SparkWorker, cr.Spec.Replicas, err)
+	}
+	want := `{"kind":"StatefulSet","apiVersion":"apps/v1","metadata":{"name":"splunk-stack1-spark-worker","namespace":"test","creationTimestamp":null,"ownerReferences":[{"apiVersion":"","kind":"","name":"stack1","uid":"","controller":true}]},"spec":{"replicas":3,"selector":{"matchLabels":{"app.kubernetes.io/component":"spark","app.kubernetes.io/instance":"splunk-stack1-spark-worker","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"spark-worker","app.kubernetes.io/part-of":"splunk-stack1-spark"}},"template":{"metadata":{"creationTimestamp":null,"labels":{"app.kubernetes.io/component":"spark","app.kubernetes.io/instance":"splunk-stack1-spark-worker","app.kubernetes.io/managed-by":"splunk-operator","app.kubernetes.io/name":"spark-worker","app.kubernetes.io/part-of":"splunk-stack1-spark"},"annotations":{"traffic.sidecar.istio.io/excludeOutboundPorts":"8089,8191,9997,7777,9000,17000,17500,19000","traffic.sidecar.istio.io/includeInboundPorts":"7000"}},"spec":{"containers":[{"name":"spark","image":"splunk/spark","ports":[{"name":"workerwebui","containerPort":7000,"protocol":"TCP"},{"name":"dfwreceivedata","containerPort":17500,"protocol":"TCP"}],"env":[{"name":"SPLUNK_ROLE","value":"splunk_spark_worker"},{"name":"SPARK_MASTER_HOSTNAME","value":"splunk-stack1-spark-master-service"},{"name":"SPARK_WORKER_PORT","value":"7777"}],"resources":{"limits":{"cpu":"4","memory":"8Gi"},"requests":{"cpu":"100m","memory":"512Mi"}},"livenessProbe":{"httpGet":{"path":"/","port":7000},"initialDelaySeconds":30,"timeoutSeconds":10,"periodSeconds":10},"readinessProbe":{"httpGet":{"path":"/","port":7000},"initialDelaySeconds":5,"timeoutSeconds":10,"periodSeconds":10},"imagePullPolicy":"IfNotPresent"}],"securityContext":{"runAsUser":41812,"fsGroup":41812},"affinity":{"podAntiAffinity":{"preferredDuringSchedulingIgnoredDuringExecution":[{"weight":100,"podAffinityTerm":{"labelSelector":{"matchExpressions":[{"key":"app.kubernetes.io/instance","operator":"In","values":["splunk-stack1-spark-worker"]}]},"topologyKey":"kubernetes.io/hostname"}}]}},"schedulerName":"default-scheduler"}},"serviceName":"splunk-stack1-spark-worker-headless","podManagementPolicy":"Parallel","updateStrategy":{"type":"OnDelete"}},"status":{"replicas":0}}`
+	if string(got) != want {
+		t.Errorf("GetSparkStatefulSet(\"%s\",%d) = %s; want %s", SparkWorker, cr.Spec.Replicas, got, want)
+	}
+}
+
 func TestGetSparkService(t *testing.T) {
 	cr := enterprisev1.Spark{
 		TypeMeta: metav1.TypeMeta{
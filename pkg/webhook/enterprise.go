@@ -0,0 +1,114 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	enterprisev1 "github.com/splunk/splunk-operator/pkg/apis/enterprise/v1alpha2"
+	"github.com/splunk/splunk-operator/pkg/splunk/enterprise"
+	"github.com/splunk/splunk-operator/pkg/splunk/spark"
+)
+
+// resourceWebhooks is the list of custom resources that admission webhooks are registered for
+var resourceWebhooks = []resourceWebhook{
+	{
+		name:      "standalone",
+		newObject: func() runtime.Object { return &enterprisev1.Standalone{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateStandaloneSpec(&obj.(*enterprisev1.Standalone).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.Standalone).Spec.CommonSplunkSpec, &revised.(*enterprisev1.Standalone).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "searchheadcluster",
+		newObject: func() runtime.Object { return &enterprisev1.SearchHeadCluster{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateSearchHeadClusterSpec(&obj.(*enterprisev1.SearchHeadCluster).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.SearchHeadCluster).Spec.CommonSplunkSpec, &revised.(*enterprisev1.SearchHeadCluster).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "indexercluster",
+		newObject: func() runtime.Object { return &enterprisev1.IndexerCluster{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateIndexerClusterSpec(&obj.(*enterprisev1.IndexerCluster).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.IndexerCluster).Spec.CommonSplunkSpec, &revised.(*enterprisev1.IndexerCluster).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "clustermaster",
+		newObject: func() runtime.Object { return &enterprisev1.ClusterMaster{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateClusterMasterSpec(&obj.(*enterprisev1.ClusterMaster).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.ClusterMaster).Spec.CommonSplunkSpec, &revised.(*enterprisev1.ClusterMaster).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "licensemaster",
+		newObject: func() runtime.Object { return &enterprisev1.LicenseMaster{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateLicenseMasterSpec(&obj.(*enterprisev1.LicenseMaster).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.LicenseMaster).Spec.CommonSplunkSpec, &revised.(*enterprisev1.LicenseMaster).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "monitoringconsole",
+		newObject: func() runtime.Object { return &enterprisev1.MonitoringConsole{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateMonitoringConsoleSpec(&obj.(*enterprisev1.MonitoringConsole).Spec)
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateCommonSplunkSpecUpdate(&current.(*enterprisev1.MonitoringConsole).Spec.CommonSplunkSpec, &revised.(*enterprisev1.MonitoringConsole).Spec.CommonSplunkSpec)
+		},
+	},
+	{
+		name:      "splunkindex",
+		newObject: func() runtime.Object { return &enterprisev1.SplunkIndex{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateSplunkIndex(obj.(*enterprisev1.SplunkIndex))
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateSplunkIndexUpdate(current.(*enterprisev1.SplunkIndex), revised.(*enterprisev1.SplunkIndex))
+		},
+	},
+	{
+		name:      "splunkbackup",
+		newObject: func() runtime.Object { return &enterprisev1.SplunkBackup{} },
+		validate: func(obj runtime.Object) error {
+			return enterprise.ValidateSplunkBackup(obj.(*enterprisev1.SplunkBackup))
+		},
+		validateUpdate: func(current, revised runtime.Object) error {
+			return enterprise.ValidateSplunkBackupUpdate(current.(*enterprisev1.SplunkBackup), revised.(*enterprisev1.SplunkBackup))
+		},
+	},
+	{
+		name:      "spark",
+		newObject: func() runtime.Object { return &enterprisev1.Spark{} },
+		validate: func(obj runtime.Object) error {
+			return spark.ValidateSparkSpec(&obj.(*enterprisev1.Spark).Spec)
+		},
+	},
+}
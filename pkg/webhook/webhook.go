@@ -0,0 +1,156 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+/*
+Package webhook implements admission webhooks that set defaults for and validate
+Splunk Enterprise custom resources, using the same logic that is used during
+reconciliation.
+*/
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+var log = logf.Log.WithName("webhook")
+
+// unpersistedDefaults are paths of defaults that depend on the version of the operator, such as images. These are left
+// out of patches, so that resources continue to use the current defaults when the operator is upgraded.
+var unpersistedDefaults = map[string]bool{
+	"/spec/image":      true,
+	"/spec/sparkImage": true,
+}
+
+// resourceWebhook defines how to set defaults for and validate a kind of custom resource
+type resourceWebhook struct {
+	// name of the resource kind, in lowercase (used for webhook paths)
+	name string
+
+	// newObject returns a new, empty custom resource
+	newObject func() runtime.Object
+
+	// validate checks validity and makes default updates to the spec of a custom resource
+	validate func(obj runtime.Object) error
+
+	// validateUpdate checks that changes made to a custom resource are allowed (may be nil)
+	validateUpdate func(current, revised runtime.Object) error
+}
+
+// getMutatePath returns the path used by the defaulting webhook for a kind of custom resource
+func (w *resourceWebhook) getMutatePath() string {
+	return fmt.Sprintf("/mutate-enterprise-splunk-com-v1alpha2-%s", w.name)
+}
+
+// getValidatePath returns the path used by the validating webhook for a kind of custom resource
+func (w *resourceWebhook) getValidatePath() string {
+	return fmt.Sprintf("/validate-enterprise-splunk-com-v1alpha2-%s", w.name)
+}
+
+// AddToManager registers defaulting and validating webhooks for all custom resources with the manager's webhook server
+func AddToManager(mgr manager.Manager) error {
+	decoder, err := admission.NewDecoder(mgr.GetScheme())
+	if err != nil {
+		return err
+	}
+	server := mgr.GetWebhookServer()
+	for idx := range resourceWebhooks {
+		w := &resourceWebhooks[idx]
+		server.Register(w.getMutatePath(), &admission.Webhook{Handler: &defaultingHandler{webhook: w, decoder: decoder}})
+		server.Register(w.getValidatePath(), &admission.Webhook{Handler: &validatingHandler{webhook: w, decoder: decoder}})
+	}
+	return nil
+}
+
+// defaultingHandler sets defaults for the spec of a custom resource
+type defaultingHandler struct {
+	webhook *resourceWebhook
+	decoder *admission.Decoder
+}
+
+// Handle for defaultingHandler returns a patch that updates a custom resource with default values
+func (h *defaultingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	obj := h.webhook.newObject()
+	if err := h.decoder.Decode(req, obj); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// invalid resources are left unchanged, so that they are rejected by the validating webhook
+	if err := h.webhook.validate(obj); err != nil {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(obj)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	resp := admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+	patches := resp.Patches[:0]
+	for _, p := range resp.Patches {
+		if !unpersistedDefaults[p.Path] {
+			patches = append(patches, p)
+		}
+	}
+	resp.Patches = patches
+	return resp
+}
+
+// validatingHandler validates a custom resource and any changes made to it
+type validatingHandler struct {
+	webhook *resourceWebhook
+	decoder *admission.Decoder
+}
+
+// Handle for validatingHandler denies requests to create or update invalid custom resources
+func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	revised := h.webhook.newObject()
+	if err := h.decoder.Decode(req, revised); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	// never block updates for resources that are being deleted (e.g. removal of finalizers)
+	if revised.(metav1.Object).GetDeletionTimestamp() != nil {
+		return admission.Allowed("")
+	}
+
+	if err := h.webhook.validate(revised); err != nil {
+		log.Info("Denied invalid resource", "kind", req.Kind.Kind, "name", req.Name, "namespace", req.Namespace, "reason", err.Error())
+		return admission.Denied(err.Error())
+	}
+
+	if req.Operation == admissionv1beta1.Update && h.webhook.validateUpdate != nil {
+		current := h.webhook.newObject()
+		if err := h.decoder.DecodeRaw(req.OldObject, current); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+
+		// compare effective specs, since the current resource may have been created before defaults were set
+		h.webhook.validate(current)
+		if err := h.webhook.validateUpdate(current, revised); err != nil {
+			log.Info("Denied invalid update", "kind", req.Kind.Kind, "name", req.Name, "namespace", req.Namespace, "reason", err.Error())
+			return admission.Denied(err.Error())
+		}
+	}
+
+	return admission.Allowed("")
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2018-2020 Splunk Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// 	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	admissionv1beta1 "k8s.io/api/admission/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/splunk/splunk-operator/pkg/apis"
+)
+
+func getResourceWebhook(t *testing.T, name string) *resourceWebhook {
+	for idx := range resourceWebhooks {
+		if resourceWebhooks[idx].name == name {
+			return &resourceWebhooks[idx]
+		}
+	}
+	t.Fatalf("no resourceWebhook found for %s", name)
+	return nil
+}
+
+func getDecoder(t *testing.T) *admission.Decoder {
+	scheme := runtime.NewScheme()
+	if err := apis.AddToScheme(scheme); err != nil {
+		t.Fatalf("AddToScheme() returned error: %v", err)
+	}
+	decoder, err := admission.NewDecoder(scheme)
+	if err != nil {
+		t.Fatalf("NewDecoder() returned error: %v", err)
+	}
+	return decoder
+}
+
+func newRequest(op admissionv1beta1.Operation, current, revised string) admission.Request {
+	req := admission.Request{
+		AdmissionRequest: admissionv1beta1.AdmissionRequest{
+			Operation: op,
+			Object:    runtime.RawExtension{Raw: []byte(revised)},
+		},
+	}
+	if current != "" {
+		req.OldObject = runtime.RawExtension{Raw: []byte(current)}
+	}
+	return req
+}
+
+func TestDefaultingHandler(t *testing.T) {
+	h := &defaultingHandler{webhook: getResourceWebhook(t, "searchheadcluster"), decoder: getDecoder(t)}
+
+	req := newRequest(admissionv1beta1.Create, "", `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"SearchHeadCluster","metadata":{"name":"stack1","namespace":"test"},"spec":{}}`)
+	resp := h.Handle(context.TODO(), req)
+	if !resp.Allowed {
+		t.Errorf("defaultingHandler.Handle() denied valid resource: %v", resp.Result)
+	}
+	wantPaths := map[string]bool{"/spec/replicas": false, "/spec/etcStorage": false, "/spec/varStorage": false, "/spec/imagePullPolicy": false}
+	for _, p := range resp.Patches {
+		if _, ok := wantPaths[p.Path]; ok {
+			wantPaths[p.Path] = true
+		}
+	}
+	for path, found := range wantPaths {
+		if !found {
+			t.Errorf("defaultingHandler.Handle() did not patch %s: %v", path, resp.Patches)
+		}
+	}
+
+	// images depend on the version of the operator, and are not persisted
+	for _, p := range resp.Patches {
+		if unpersistedDefaults[p.Path] {
+			t.Errorf("defaultingHandler.Handle() patched %s: %v", p.Path, resp.Patches)
+		}
+	}
+
+	// invalid resources are not patched
+	req = newRequest(admissionv1beta1.Create, "", `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"SearchHeadCluster","metadata":{"name":"stack1","namespace":"test"},"spec":{"replicas":2}}`)
+	resp = h.Handle(context.TODO(), req)
+	if !resp.Allowed || len(resp.Patches) != 0 {
+		t.Errorf("defaultingHandler.Handle() patched invalid resource: %v", resp.Patches)
+	}
+}
+
+func TestValidatingHandler(t *testing.T) {
+	decoder := getDecoder(t)
+	test := func(name string, op admissionv1beta1.Operation, current, revised string, want bool) {
+		h := &validatingHandler{webhook: getResourceWebhook(t, name), decoder: decoder}
+		resp := h.Handle(context.TODO(), newRequest(op, current, revised))
+		if resp.Allowed != want {
+			t.Errorf("validatingHandler.Handle(%s) allowed = %t; want %t: %v", revised, resp.Allowed, want, resp.Result)
+		}
+	}
+
+	shc := `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"SearchHeadCluster","metadata":{"name":"stack1","namespace":"test"},"spec":%s}`
+	spec := func(s string) string { return fmt.Sprintf(shc, s) }
+	test("searchheadcluster", admissionv1beta1.Create, "", spec(`{}`), true)
+	test("searchheadcluster", admissionv1beta1.Create, "", spec(`{"replicas":2}`), false)
+	test("searchheadcluster", admissionv1beta1.Create, "", spec(`{"imagePullPolicy":"Sometimes"}`), false)
+	test("searchheadcluster", admissionv1beta1.Create, "", spec(`{"etcStorage":"lots"}`), false)
+	test("searchheadcluster", admissionv1beta1.Update, spec(`{}`), spec(`{"etcStorage":"20Gi"}`), true)
+	test("searchheadcluster", admissionv1beta1.Update, spec(`{}`), spec(`{"etcStorage":"5Gi"}`), false)
+	test("searchheadcluster", admissionv1beta1.Update, spec(`{"storageClassName":"gp2"}`), spec(`{"storageClassName":"local"}`), false)
+
+	// resources being deleted are always allowed
+	deleted := `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"SearchHeadCluster","metadata":{"name":"stack1","namespace":"test","deletionTimestamp":"2020-01-01T00:00:00Z"},"spec":{"replicas":2}}`
+	test("searchheadcluster", admissionv1beta1.Update, spec(`{}`), deleted, true)
+
+	sa := `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"Standalone","metadata":{"name":"stack1","namespace":"test"},"spec":{"varStorage":"50Gi"}}`
+	test("standalone", admissionv1beta1.Create, "", sa, true)
+	test("standalone", admissionv1beta1.Update, `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"Standalone","metadata":{"name":"stack1","namespace":"test"},"spec":{}}`, sa, false)
+
+	spark := `{"apiVersion":"enterprise.splunk.com/v1alpha2","kind":"Spark","metadata":{"name":"stack1","namespace":"test"},"spec":{"imagePullPolicy":"Always"}}`
+	test("spark", admissionv1beta1.Update, spark, spark, true)
+}
@@ -63,6 +63,34 @@ var _ = Describe("Smoke test", func() {
 		})
 	})
 
+	Context("Standalone deployment with SmartStore (S1 with MinIO remote storage)", func() {
+		It("can deploy a standalone instance using SmartStore", func() {
+
+			endpoint, secretName, err := deployment.DeployMinio(deployment.GetName() + "-minio")
+			Expect(err).To(Succeed(), "Unable to deploy MinIO")
+
+			standalone, err := deployment.DeployStandaloneWithSmartStore(deployment.GetName(), endpoint, secretName)
+			Expect(err).To(Succeed(), "Unable to deploy standalone instance with SmartStore")
+
+			Eventually(func() enterprisev1.ResourcePhase {
+				err = deployment.GetInstance(deployment.GetName(), standalone)
+				if err != nil {
+					return enterprisev1.PhaseError
+				}
+				testenvInstance.Log.Info("Waiting for standalone instance status to be ready", "instance", standalone.ObjectMeta.Name, "Phase", standalone.Status.Phase)
+				dumpGetPods(testenvInstance.GetName())
+
+				return standalone.Status.Phase
+			}, deployment.GetTimeout(), PollInterval).Should(Equal(enterprisev1.PhaseReady))
+
+			// In a steady state, we should stay in Ready and not flip-flop around
+			Consistently(func() enterprisev1.ResourcePhase {
+				_ = deployment.GetInstance(deployment.GetName(), standalone)
+				return standalone.Status.Phase
+			}, ConsistentDuration, ConsistentPollInterval).Should(Equal(enterprisev1.PhaseReady))
+		})
+	})
+
 	Context("Clustered deployment (C3 - clustered indexer, search head cluster)", func() {
 		It("can deploy indexers and search head cluster", func() {
 
@@ -76,6 +76,32 @@ func (d *Deployment) DeployStandalone(name string) (*enterprisev1.Standalone, er
 	return deployed.(*enterprisev1.Standalone), err
 }
 
+// DeployMinio deploys a MinIO server, which may be used as an S3 compatible stand-in for remote storage.
+// It returns the endpoint of the server and the name of a secret containing its credentials.
+func (d *Deployment) DeployMinio(name string) (string, string, error) {
+	secret := newMinioSecret(name+"-creds", d.testenv.namespace)
+	if _, err := d.deployCR(secret.GetName(), secret); err != nil {
+		return "", "", err
+	}
+	if _, err := d.deployCR(name, newMinio(name, d.testenv.namespace, secret.GetName())); err != nil {
+		return "", "", err
+	}
+	if _, err := d.deployCR(name, newMinioService(name, d.testenv.namespace)); err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("http://%s.%s.svc.cluster.local:9000", name, d.testenv.namespace), secret.GetName(), nil
+}
+
+// DeployStandaloneWithSmartStore deploys a standalone splunk enterprise instance that uses SmartStore to store indexed data
+func (d *Deployment) DeployStandaloneWithSmartStore(name, endpoint, secretName string) (*enterprisev1.Standalone, error) {
+	standalone := newStandaloneWithSmartStore(name, d.testenv.namespace, endpoint, secretName)
+	deployed, err := d.deployCR(name, standalone)
+	if err != nil {
+		return nil, err
+	}
+	return deployed.(*enterprisev1.Standalone), err
+}
+
 // GetInstance retrieves the standalone, indexer, searchhead, licensemaster instance
 func (d *Deployment) GetInstance(name string, instance runtime.Object) error {
 	key := client.ObjectKey{Name: name, Namespace: d.testenv.namespace}
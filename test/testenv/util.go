@@ -21,6 +21,12 @@ import (
 
 const (
 	letterBytes = "abcdefghijklmnopqrstuvwxyz0123456789"
+
+	// minioImage is the docker image used for MinIO, an S3 compatible stand-in for remote storage
+	minioImage = "minio/minio"
+
+	// minioBucket is the bucket created in MinIO for SmartStore tests
+	minioBucket = "smartstore"
 )
 
 func init() {
@@ -166,6 +172,127 @@ func newSearchHeadCluster(name, ns, indexerClusterName, licenseMasterName string
 	return &new
 }
 
+// newStandaloneWithSmartStore creates and initializes CR for Standalone Kind, using SmartStore to store indexed data
+func newStandaloneWithSmartStore(name, ns, endpoint, secretName string) *enterprisev1.Standalone {
+	new := newStandalone(name, ns)
+	new.Spec.SmartStore = enterprisev1.SmartStoreSpec{
+		Volumes: []enterprisev1.SmartStoreVolume{
+			{
+				Name:      "minio",
+				Endpoint:  endpoint,
+				Path:      minioBucket,
+				SecretRef: secretName,
+			},
+		},
+		Indexes: []enterprisev1.SmartStoreIndex{
+			{Name: "main"},
+			{Name: "smartstore-test"},
+		},
+	}
+	return new
+}
+
+// newMinioSecret creates a secret with credentials used to access MinIO, in the format expected by SmartStore volumes
+func newMinioSecret(name, ns string) *corev1.Secret {
+	new := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Data: map[string][]byte{
+			"s3_access_key": []byte(RandomDNSName(20)),
+			"s3_secret_key": []byte(RandomDNSName(40)),
+		},
+	}
+
+	return &new
+}
+
+// newMinio creates a single MinIO server, which is used as an S3 compatible stand-in for remote storage
+func newMinio(name, ns, secretName string) *appsv1.Deployment {
+	var replicas int32 = 1
+	labels := map[string]string{
+		"app": name,
+	}
+	secretKeyRef := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+				Key:                  key,
+			},
+		}
+	}
+
+	new := appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:            "minio",
+							Image:           minioImage,
+							ImagePullPolicy: "IfNotPresent",
+							Command:         []string{"sh", "-c", "mkdir -p /data/" + minioBucket + " && minio server /data"},
+							Env: []corev1.EnvVar{
+								{Name: "MINIO_ACCESS_KEY", ValueFrom: secretKeyRef("s3_access_key")},
+								{Name: "MINIO_SECRET_KEY", ValueFrom: secretKeyRef("s3_secret_key")},
+							},
+							Ports: []corev1.ContainerPort{
+								{Name: "s3", ContainerPort: 9000},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "data", MountPath: "/data"},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	return &new
+}
+
+// newMinioService creates a service used to access a MinIO server
+func newMinioService(name, ns string) *corev1.Service {
+	new := corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: map[string]string{
+				"app": name,
+			},
+			Ports: []corev1.ServicePort{
+				{Name: "s3", Port: 9000},
+			},
+		},
+	}
+
+	return &new
+}
+
 func newRole(name, ns string) *rbacv1.Role {
 	new := rbacv1.Role{
 		ObjectMeta: metav1.ObjectMeta{